@@ -0,0 +1,17 @@
+// Package events has the JSON schemas of the domain events published by the
+// service. A schema is never changed in a breaking way: a new version is added
+// next to it, as <type>.v<version>.json
+package events
+
+import (
+	"embed"
+	"fmt"
+)
+
+//go:embed *.json
+var schemasFS embed.FS
+
+// Schema returns the JSON schema of the version of the event type
+func Schema(eventType string, version int) ([]byte, error) {
+	return schemasFS.ReadFile(fmt.Sprintf("%s.v%d.json", eventType, version))
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/environment"
+	"gorm.io/gorm"
+)
+
+// databaseDialectors returns the dialectors of the primary and of the read
+// replicas of the configured driver. The sqlite driver has no replicas
+func databaseDialectors(dsn string) (gorm.Dialector, []gorm.Dialector, error) {
+	switch environment.GetDBDriver() {
+	case "postgres":
+		primary, err := database.PostgresDialector(dsn, environment.GetDBStatementTimeout())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		replicas := make([]gorm.Dialector, 0, len(environment.GetDBReadReplicaDSNs()))
+
+		for i, replicaDSN := range environment.GetDBReadReplicaDSNs() {
+			replica, err := database.PostgresDialector(replicaDSN, environment.GetDBStatementTimeout())
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("read replica %v: %w", i, err)
+			}
+
+			replicas = append(replicas, replica)
+		}
+
+		return primary, replicas, nil
+	case "sqlite":
+		if len(environment.GetDBReadReplicaDSNs()) > 0 {
+			return nil, nil, errors.New("the sqlite driver has no read replicas")
+		}
+
+		return database.SQLiteDialector(environment.GetDBSQLitePath()), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown database driver %q, it must be postgres or sqlite", environment.GetDBDriver())
+	}
+}
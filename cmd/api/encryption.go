@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"github.com/thiagoluis88git/tech1-customer/pkg/environment"
+)
+
+const encryptionUsage = "usage: encryption backfill"
+
+// newEncryptor creates the Encryptor of the personal data with the configured
+// key provider
+func newEncryptor() (*encryption.Encryptor, error) {
+	var provider encryption.KeyProvider
+
+	switch environment.GetEncryptionProvider() {
+	case "local":
+		if environment.GetEncryptionKeyFile() == "" {
+			return nil, fmt.Errorf("%v is required by the local key provider", environment.EncryptionKeyFile)
+		}
+
+		localProvider, err := encryption.NewLocalKeyProvider(environment.GetEncryptionKeyFile())
+
+		if err != nil {
+			return nil, err
+		}
+
+		provider = localProvider
+	case "kms":
+		if environment.GetEncryptionKMSKeyID() == "" {
+			return nil, fmt.Errorf("%v is required by the kms key provider", environment.EncryptionKMSKeyID)
+		}
+
+		sess, err := session.NewSession(&aws.Config{
+			Region: aws.String(environment.GetRegion()),
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		provider = encryption.NewKMSKeyProvider(kms.New(sess), environment.GetEncryptionKMSKeyID())
+	default:
+		return nil, fmt.Errorf("unknown key provider %q, it must be local or kms", environment.GetEncryptionProvider())
+	}
+
+	if environment.GetEncryptionIndexKey() == "" {
+		return nil, fmt.Errorf("%v is required", environment.EncryptionIndexKey)
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(environment.GetEncryptionIndexKey())
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid %v: %w", environment.EncryptionIndexKey, err)
+	}
+
+	return encryption.New(provider, indexKey)
+}
+
+// runEncryption runs the encryption subcommand. The backfill encrypts the rows
+// written before the encryption and the values of a rotated master key
+func runEncryption(ctx context.Context, backfill *repositories.EncryptionBackfill, args []string, out io.Writer) error {
+	if len(args) != 1 || args[0] != "backfill" {
+		return errors.New(encryptionUsage)
+	}
+
+	written, err := backfill.Run(ctx)
+
+	tables := make([]string, 0, len(written))
+
+	for table := range written {
+		tables = append(tables, table)
+	}
+
+	sort.Strings(tables)
+
+	for _, table := range tables {
+		fmt.Fprintf(out, "%v: %v rows encrypted\n", table, written[table])
+	}
+
+	return err
+}
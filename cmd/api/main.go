@@ -1,24 +1,40 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"time"
 
+	customerv1 "github.com/thiagoluis88git/tech1-customer/api/proto/customer/v1"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/handler"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/rpc"
 	"github.com/thiagoluis88git/tech1-customer/internal/integrations/remote"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+	"github.com/thiagoluis88git/tech1-customer/pkg/cache"
 	"github.com/thiagoluis88git/tech1-customer/pkg/database"
 	"github.com/thiagoluis88git/tech1-customer/pkg/environment"
+	"github.com/thiagoluis88git/tech1-customer/pkg/grpcserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/health"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/idempotency"
+	"github.com/thiagoluis88git/tech1-customer/pkg/lifecycle"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+	"github.com/thiagoluis88git/tech1-customer/pkg/outbox"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
-	"gorm.io/driver/postgres"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
 
 	"github.com/mvrilo/go-redoc"
 
 	"github.com/go-chi/chi/v5"
 
-	_ "github.com/thiagoluis88git/tech1-customer/docs"
+	docsV1 "github.com/thiagoluis88git/tech1-customer/docs/v1"
 
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
@@ -37,16 +53,27 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 
 // @host localshot:3210
-// @BasePath /
+// @BasePath /v1
 func main() {
 	environment.LoadEnvironmentVariables()
 
-	doc := redoc.Redoc{
-		Title:       "Example API",
-		Description: "Example API Description",
+	appLogger, err := logger.New(
+		logger.Level(environment.GetLogLevel()),
+		logger.Format(environment.GetLogFormat()),
+	)
+
+	if err != nil {
+		log.Fatalf("could not configure the logger: %v", err)
+	}
+
+	slog.SetDefault(appLogger)
+
+	docV1 := redoc.Redoc{
+		Title:       "Tech1 Customer API v1",
+		Description: "Tech1 Customer API v1",
 		SpecFile:    *environment.RedocFolderPath,
-		SpecPath:    "/docs/swagger.json",
-		DocsPath:    "/docs",
+		SpecPath:    "/docs/v1/swagger.json",
+		DocsPath:    "/docs/v1",
 	}
 
 	dsn := fmt.Sprintf("host=%v user=%v password=%v dbname=%v port=%v",
@@ -57,18 +84,135 @@ func main() {
 		environment.GetDBPort(),
 	)
 
-	db, err := database.ConfigDatabase(postgres.Open(dsn))
+	shutdownTracing, err := tracing.Setup(
+		context.Background(),
+		tracing.Exporter(environment.GetTracingExporter()),
+		tracing.ServiceName(environment.GetServiceName()),
+		tracing.SampleRatio(environment.GetTracingSampleRatio()),
+	)
+
+	if err != nil {
+		log.Fatalf("could not configure tracing: %v", err)
+	}
+
+	primary, replicas, err := databaseDialectors(dsn)
+
+	if err != nil {
+		log.Fatalf("invalid database configuration: %v", err)
+	}
+
+	db, err := database.ConfigDatabase(
+		primary,
+		database.MaxOpenConns(environment.GetDBMaxOpenConns()),
+		database.MaxIdleConns(environment.GetDBMaxIdleConns()),
+		database.ConnMaxLifetime(environment.GetDBConnMaxLifetime()),
+		database.ConnMaxIdleTime(environment.GetDBConnMaxIdleTime()),
+		database.ReadReplicas(replicas...),
+		database.ReplicaCheckInterval(environment.GetDBReplicaCheckInterval()),
+		database.Logger(appLogger),
+	)
 
 	if err != nil {
 		panic(fmt.Sprintf("could not open database: %v", err.Error()))
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err = runMigrate(context.Background(), db, os.Args[2:], os.Stdout)
+
+		db.Close(context.Background())
+		shutdownTracing(context.Background())
+
+		if err != nil {
+			log.Fatalf("migrate: %v", err)
+		}
+
+		return
+	}
+
+	encryptor, err := newEncryptor()
+
+	if err != nil {
+		log.Fatalf("invalid encryption configuration: %v", err)
+	}
+
+	encryptionBackfill := repositories.NewEncryptionBackfill(db, encryptor)
+
+	if len(os.Args) > 1 && os.Args[1] == "encryption" {
+		err = runEncryption(context.Background(), encryptionBackfill, os.Args[2:], os.Stdout)
+
+		db.Close(context.Background())
+		shutdownTracing(context.Background())
+
+		if err != nil {
+			log.Fatalf("encryption: %v", err)
+		}
+
+		return
+	}
+
+	// the schema is changed only by the migrate command, so a replica started
+	// before the migrations were applied does not serve with a missing table
+	err = db.CheckMigrations(context.Background())
+
+	if err != nil {
+		log.Fatalf("refusing to start, run the migrate command: %v", err)
+	}
+
+	// the lookups use the blind indexes, so the rows not backfilled yet would
+	// not be found
+	pending, err := encryptionBackfill.Pending(context.Background())
+
+	if err != nil {
+		log.Fatalf("could not check the encryption backfill: %v", err)
+	}
+
+	if pending > 0 {
+		log.Fatalf("refusing to start, run the encryption backfill command: %v rows are not encrypted", pending)
+	}
+
+	outboxPublisher, closeOutboxPublisher, err := newOutboxPublisher()
+
+	if err != nil {
+		log.Fatalf("invalid outbox configuration: %v", err)
+	}
+
+	// the components are stopped in the reverse order they are added, so the
+	// database is closed after everything that uses it
+	app := lifecycle.New(
+		lifecycle.ShutdownTimeout(environment.GetShutdownTimeout()),
+		lifecycle.Logger(appLogger),
+	)
+	app.OnShutdown("database", db.Close)
+	app.OnShutdown("tracing", shutdownTracing)
+
+	err = db.Use(tracing.NewGormPlugin())
+
+	if err != nil {
+		log.Fatalf("could not register the database tracing: %v", err)
+	}
+
 	router := chi.NewRouter()
+	router.Use(tracing.Middleware)
+
+	if environment.IsMetricsEnabled() {
+		err = db.Use(metrics.NewGormPlugin())
+
+		if err != nil {
+			log.Fatalf("could not register the database metrics: %v", err)
+		}
+
+		router.Use(metrics.Middleware)
+	}
+
 	router.Use(chiMiddleware.RequestID)
 	router.Use(chiMiddleware.RealIP)
+	router.Use(logger.Middleware(appLogger))
 	router.Use(chiMiddleware.Recoverer)
+	router.Use(database.NoCacheReads)
 
-	// httpClient := httpserver.NewHTTPClient()
+	if environment.IsIfMatchRequired() {
+		router.Use(httpserver.RequireIfMatch)
+	}
 
 	cognitoRemote := remote.NewCognitoRemoteDataSource(
 		environment.GetRegion(),
@@ -77,20 +221,57 @@ func main() {
 		environment.GetCognitoGroupUser(),
 		environment.GetCognitoGroupAdmin(),
 	)
-	customerRepo := repositories.NewCustomerRepository(db, cognitoRemote)
-	userRepo := repositories.NewUserAdminRepository(db, cognitoRemote)
+	customerRepo := repositories.NewCustomerRepository(db, cognitoRemote, encryptor)
+
+	if environment.GetCustomerCacheSize() > 0 {
+		customerRepo = repositories.NewCachedCustomerRepository(
+			customerRepo,
+			cache.NewLRU(environment.GetCustomerCacheSize()),
+			encryptor,
+			repositories.CustomerCacheTTL(environment.GetCustomerCacheTTL()),
+			repositories.CustomerCacheNotFoundTTL(environment.GetCustomerCacheNotFoundTTL()),
+		)
+	}
+
+	userRepo := repositories.NewUserAdminRepository(db, cognitoRemote, encryptor)
+	auditRepo := repositories.NewAuditRepository(db)
+	unitOfWork := repositories.NewUnitOfWork(db)
 	validateCPFUseCase := usecases.NewValidateCPFUseCase()
 	loginCustomerUseCase := usecases.NewLoginCustomerUseCase(customerRepo)
 	loginUnknownCustomerUseCase := usecases.NewLoginUnknownCustomerUseCase(customerRepo)
-	createCustomerUseCase := usecases.NewCreateCustomerUseCase(validateCPFUseCase, customerRepo)
-	updateCustomerUseCase := usecases.NewUpdateCustomerUseCase(validateCPFUseCase, customerRepo)
+	createCustomerUseCase := usecases.NewCreateCustomerUseCase(validateCPFUseCase, customerRepo, unitOfWork)
+	updateCustomerUseCase := usecases.NewUpdateCustomerUseCase(validateCPFUseCase, customerRepo, unitOfWork)
+	patchCustomerUseCase := usecases.NewPatchCustomerUseCase(customerRepo, unitOfWork)
 	getCustomerByCPFUseCase := usecases.NewGetCustomerByCPFUseCase(validateCPFUseCase, customerRepo)
+	getCustomerByIdUseCase := usecases.NewGetCustomerByIdUseCase(customerRepo)
+	batchGetCustomersUseCase := usecases.NewBatchGetCustomersUseCase(validateCPFUseCase, customerRepo, environment.GetBatchGetMaxItems())
+	deleteCustomerUseCase := usecases.NewDeleteCustomerUseCase(customerRepo, unitOfWork)
+	restoreCustomerUseCase := usecases.NewRestoreCustomerUseCase(customerRepo, unitOfWork)
+	getCustomerHistoryUseCase := usecases.NewGetCustomerHistoryUseCase(customerRepo)
+	getCustomerAsOfUseCase := usecases.NewGetCustomerAsOfUseCase(customerRepo)
+	validateTokenUseCase := usecases.NewValidateTokenUseCase(customerRepo)
 
 	loginUserUseCase := usecases.NewLoginUserUseCase(userRepo)
 	createUserUseCase := usecases.NewCreateUserUseCase(validateCPFUseCase, userRepo)
 	updateUserUseCase := usecases.NewUpdateUserUseCase(validateCPFUseCase, userRepo)
+	patchUserUseCase := usecases.NewPatchUserUseCase(userRepo)
 	getUserByIdUseCase := usecases.NewGetUserByIdUseCase(userRepo)
 	getUserByCPFUseCase := usecases.NewGetUserByCPFUseCase(validateCPFUseCase, userRepo)
+	deleteUserUseCase := usecases.NewDeleteUserUseCase(userRepo)
+	restoreUserUseCase := usecases.NewRestoreUserUseCase(userRepo)
+	getUserHistoryUseCase := usecases.NewGetUserHistoryUseCase(userRepo)
+	getUserAsOfUseCase := usecases.NewGetUserAsOfUseCase(userRepo)
+
+	listAuditLogsUseCase := usecases.NewListAuditLogsUseCase(auditRepo)
+	verifyAuditChainUseCase := usecases.NewVerifyAuditChainUseCase(auditRepo)
+
+	healthChecker := health.NewChecker(
+		health.Timeout(environment.GetHealthCheckTimeout()),
+		health.CacheTTL(environment.GetHealthCacheTTL()),
+	)
+	healthChecker.Register("database", db.Ping)
+	healthChecker.Register("migrations", db.CheckMigrations)
+	healthChecker.Register("identity_provider", cognitoRemote.Ping)
 
 	router.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		httpserver.SendResponseSuccess(w, &responses.BusinessResponse{
@@ -98,26 +279,213 @@ func main() {
 			Message:    "ok",
 		})
 	})
+	router.Get("/health/live", health.LiveHandler())
+	router.Get("/health/ready", health.ReadyHandler(healthChecker))
+
+	useCases := handler.UseCases{
+		LoginCustomer:        loginCustomerUseCase,
+		LoginUnknownCustomer: loginUnknownCustomerUseCase,
+		CreateCustomer:       createCustomerUseCase,
+		UpdateCustomer:       updateCustomerUseCase,
+		PatchCustomer:        patchCustomerUseCase,
+		GetCustomerByCPF:     getCustomerByCPFUseCase,
+		BatchGetCustomers:    batchGetCustomersUseCase,
+		DeleteCustomer:       deleteCustomerUseCase,
+		RestoreCustomer:      restoreCustomerUseCase,
+		GetCustomerHistory:   getCustomerHistoryUseCase,
+		GetCustomerAsOf:      getCustomerAsOfUseCase,
+		LoginUser:            loginUserUseCase,
+		CreateUser:           createUserUseCase,
+		UpdateUser:           updateUserUseCase,
+		PatchUser:            patchUserUseCase,
+		GetUserById:          getUserByIdUseCase,
+		GetUserByCPF:         getUserByCPFUseCase,
+		DeleteUser:           deleteUserUseCase,
+		RestoreUser:          restoreUserUseCase,
+		GetUserHistory:       getUserHistoryUseCase,
+		GetUserAsOf:          getUserAsOfUseCase,
+		ListAuditLogs:        listAuditLogsUseCase,
+		VerifyAuditChain:     verifyAuditChainUseCase,
+	}
+
+	idempotencyStore := repositories.NewIdempotencyRepository(db)
+	idempotencyMiddleware := idempotency.Middleware(
+		idempotencyStore,
+		idempotency.TTL(environment.GetIdempotencyTTL()),
+		idempotency.Lease(environment.GetIdempotencyLease()),
+		idempotency.WaitTimeout(environment.GetIdempotencyWaitTimeout()),
+	)
+
+	// the audit actor is the Cognito username of the access token owner
+	auditMiddleware := audit.Middleware(func(ctx context.Context, accessToken string) (string, error) {
+		tokenInfo, err := validateTokenUseCase.Execute(ctx, accessToken)
+
+		if err != nil || tokenInfo.Anonymous {
+			return audit.AnonymousActor, err
+		}
+
+		return tokenInfo.Username, nil
+	})
+
+	app.Job("read replica monitor", db.MonitorReplicas)
+	app.Job("idempotency purge", func(ctx context.Context) error {
+		idempotency.Purge(ctx, idempotencyStore, time.Hour)
+		return nil
+	})
 
-	router.Post("/auth/login", handler.LoginCustomerHandler(loginCustomerUseCase))
-	router.Post("/auth/login/unknown", handler.LoginUnknownCustomerHandler(loginUnknownCustomerUseCase))
-	router.Post("/auth/admin/login", handler.LoginUserHandler(loginUserUseCase))
-	router.Post("/auth/signup", handler.CreateCustomerHandler(createCustomerUseCase))
-	router.Post("/auth/admin/signup", handler.CreateUserHandler(createUserUseCase))
+	// the events are written with the changes even without a publisher, so a
+	// relay started later publishes them
+	outboxStore := repositories.NewOutboxRepository(db, encryptor)
 
-	router.Put("/api/admin/customers/{id}", handler.UpdateCustomerHandler(updateCustomerUseCase))
-	router.Get("/api/customers/{cpf}", handler.GetCustomerByCPFHandler(getCustomerByCPFUseCase))
+	if outboxPublisher != nil {
+		if closeOutboxPublisher != nil {
+			app.OnShutdown("outbox publisher", closeOutboxPublisher)
+		}
 
-	router.Put("/api/users/{id}", handler.UpdateUserHandler(updateUserUseCase))
-	router.Get("/api/users/{id}", handler.GetUserByIdHandler(getUserByIdUseCase))
-	router.Post("/api/users/login", handler.GetUserByCPFHandler(getUserByCPFUseCase))
+		relay := outbox.NewRelay(
+			outboxStore,
+			outboxPublisher,
+			outbox.BatchSize(environment.GetOutboxBatchSize()),
+			outbox.PollInterval(environment.GetOutboxPollInterval()),
+		)
+
+		app.Job("outbox relay", relay.Run)
+	}
+
+	app.Job("outbox purge", func(ctx context.Context) error {
+		outbox.Purge(ctx, outboxStore, time.Hour, environment.GetOutboxRetention())
+		return nil
+	})
 
-	router.Get("/swagger/*", httpSwagger.Handler(
-		httpSwagger.URL("http://localhost:3210/swagger/doc.json"),
+	routeOptions := []handler.RouteOption{
+		handler.Idempotency(idempotencyMiddleware),
+		handler.Audit(auditMiddleware),
+		handler.PrimaryReads(database.PrimaryReads),
+	}
+
+	router.Route("/v1", func(r chi.Router) {
+		handler.RegisterV1Routes(r, useCases, routeOptions...)
+	})
+
+	if environment.IsLegacyRoutesEnabled() {
+		router.Group(func(r chi.Router) {
+			r.Use(httpserver.Deprecated(environment.GetLegacyDeprecatedAt(), environment.GetLegacySunsetAt(), "/v1"))
+			handler.RegisterV1Routes(r, useCases, routeOptions...)
+		})
+	}
+
+	if environment.IsMetricsEnabled() && environment.GetMetricsPort() == "" {
+		router.Handle("/metrics", metrics.Handler())
+	}
+
+	router.Get("/swagger/v1/*", httpSwagger.Handler(
+		httpSwagger.URL(environment.GetSwaggerDocURL()),
+		httpSwagger.InstanceName(docsV1.SwaggerInfov1.InstanceName()),
 	))
 
-	go http.ListenAndServe(":3211", doc.Handler())
+	grpcServer := grpcserver.New(
+		grpcserver.Address(environment.GetHTTPHost(), environment.GetGRPCPort()),
+		grpcserver.ShutdownTimeout(environment.GetHTTPShutdownTimeout()),
+		grpcserver.Reflection(environment.IsGRPCReflectionEnabled()),
+		grpcserver.Logger(appLogger),
+	)
+	grpcServer.RegisterService(&customerv1.CustomerService_ServiceDesc, rpc.NewCustomerServer(rpc.UseCases{
+		GetCustomerByCPF:  getCustomerByCPFUseCase,
+		GetCustomerById:   getCustomerByIdUseCase,
+		BatchGetCustomers: batchGetCustomersUseCase,
+		ValidateToken:     validateTokenUseCase,
+	}))
+
+	err = grpcServer.Validate()
 
-	server := httpserver.New(router)
-	server.Start()
+	if err != nil {
+		log.Fatalf("invalid gRPC server configuration: %v", err)
+	}
+
+	if environment.GetGRPCPort() == environment.GetHTTPPort() || environment.GetGRPCPort() == environment.GetDocsPort() {
+		log.Fatalf("invalid gRPC port %v: it must be different from the API and docs ports", environment.GetGRPCPort())
+	}
+
+	server := httpserver.New(
+		router,
+		httpserver.Address(environment.GetHTTPHost(), environment.GetHTTPPort()),
+		httpserver.ReadTimeout(environment.GetHTTPReadTimeout()),
+		httpserver.WriteTimeout(environment.GetHTTPWriteTimeout()),
+		httpserver.IdleTimeout(environment.GetHTTPIdleTimeout()),
+		httpserver.ShutdownTimeout(environment.GetHTTPShutdownTimeout()),
+		httpserver.MaxHeaderBytes(environment.GetHTTPMaxHeaderBytes()),
+		httpserver.TLS(environment.GetHTTPTLSCertFile(), environment.GetHTTPTLSKeyFile()),
+		httpserver.H2C(environment.IsHTTPH2CEnabled()),
+		httpserver.Logger(appLogger),
+	)
+
+	err = server.Validate()
+
+	if err != nil {
+		log.Fatalf("invalid http server configuration: %v", err)
+	}
+
+	if environment.GetDocsPort() == environment.GetHTTPPort() {
+		log.Fatalf("invalid docs port %v: it must be different from the API port", environment.GetDocsPort())
+	}
+
+	docsRouter := http.NewServeMux()
+	docsRouter.Handle("/docs/v1", docV1.Handler())
+	docsRouter.Handle("/docs/v1/swagger.json", docV1.Handler())
+	docsRouter.Handle("/docs", http.RedirectHandler("/docs/v1", http.StatusMovedPermanently))
+
+	docsServer := httpserver.New(
+		docsRouter,
+		httpserver.Address(environment.GetHTTPHost(), environment.GetDocsPort()),
+		httpserver.ShutdownTimeout(environment.GetHTTPShutdownTimeout()),
+		httpserver.Logger(appLogger),
+	)
+
+	err = docsServer.Validate()
+
+	if err != nil {
+		log.Fatalf("invalid docs server configuration: %v", err)
+	}
+
+	app.Server("docs", docsServer)
+
+	if environment.IsMetricsEnabled() && environment.GetMetricsPort() != "" {
+		if environment.GetMetricsPort() == environment.GetHTTPPort() || environment.GetMetricsPort() == environment.GetDocsPort() {
+			log.Fatalf("invalid metrics port %v: it must be different from the API and docs ports", environment.GetMetricsPort())
+		}
+
+		metricsRouter := http.NewServeMux()
+		metricsRouter.Handle("/metrics", metrics.Handler())
+
+		metricsServer := httpserver.New(
+			metricsRouter,
+			httpserver.Address(environment.GetHTTPHost(), environment.GetMetricsPort()),
+			httpserver.ShutdownTimeout(environment.GetHTTPShutdownTimeout()),
+			httpserver.Logger(appLogger),
+		)
+
+		err = metricsServer.Validate()
+
+		if err != nil {
+			log.Fatalf("invalid metrics server configuration: %v", err)
+		}
+
+		app.Server("metrics", metricsServer)
+	}
+
+	app.Server("grpc", grpcServer)
+	app.Server("api", server)
+
+	// the readiness probe fails first and the listeners keep serving for the
+	// drain delay, so the pod leaves the endpoints before connections are
+	// refused
+	app.OnShutdown("readiness", func(ctx context.Context) error {
+		return healthChecker.Drain(ctx, environment.GetShutdownDrainDelay())
+	})
+
+	err = app.Run(context.Background())
+
+	if err != nil {
+		log.Fatalf("application stopped: %v", err)
+	}
 }
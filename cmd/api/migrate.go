@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+)
+
+const migrateUsage = "usage: migrate up | down | status | to <version>"
+
+// runMigrate runs the migrate subcommand. The migrations are applied only by
+// this command, never by the API on startup
+func runMigrate(ctx context.Context, db *database.Database, args []string, out io.Writer) error {
+	migrator, err := database.NewMigrator(db)
+
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		err = migrator.Up(ctx)
+	case args[0] == "down" && len(args) == 1:
+		err = migrator.Down(ctx)
+	case args[0] == "to" && len(args) == 2:
+		var version uint64
+		version, err = strconv.ParseUint(args[1], 10, 0)
+
+		if err != nil {
+			return fmt.Errorf("invalid version %v: %w", args[1], err)
+		}
+
+		err = migrator.To(ctx, uint(version))
+	case args[0] == "status" && len(args) == 1:
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	status, err := migrator.Status(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, migration := range status {
+		appliedAt := "pending"
+
+		if migration.AppliedAt != nil {
+			appliedAt = migration.AppliedAt.UTC().Format(time.RFC3339)
+		}
+
+		fmt.Fprintf(writer, "%v\t%v\t%v\n", migration.Version, migration.Name, appliedAt)
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/thiagoluis88git/tech1-customer/pkg/environment"
+	"github.com/thiagoluis88git/tech1-customer/pkg/outbox"
+)
+
+// newOutboxPublisher creates the publisher of the domain events and the
+// function that closes it. The none publisher returns a nil publisher: the
+// events are kept in the outbox until a relay with a publisher runs
+func newOutboxPublisher() (outbox.Publisher, func(ctx context.Context) error, error) {
+	switch environment.GetOutboxPublisher() {
+	case "none":
+		return nil, nil, nil
+	case "file":
+		publisher, err := outbox.NewFilePublisher(environment.GetOutboxFilePath())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return publisher, publisher.Close, nil
+	case "sns":
+		if environment.GetOutboxSNSTopicARN() == "" {
+			return nil, nil, fmt.Errorf("%v is required by the sns publisher", environment.OutboxSNSTopicARN)
+		}
+
+		sess, err := session.NewSession(&aws.Config{
+			Region: aws.String(environment.GetRegion()),
+		})
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return outbox.NewSNSPublisher(sns.New(sess), environment.GetOutboxSNSTopicARN()), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown outbox publisher %q, it must be none, file or sns", environment.GetOutboxPublisher())
+	}
+}
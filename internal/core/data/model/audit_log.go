@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AuditLog is an append-only record of a change. The personal data in Diff is
+// masked. Hash is the SHA-256 of the record together with the hash of the
+// previous record (PrevHash), so changing or removing a record breaks the chain
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey"`
+	Sequence   uint64    `gorm:"uniqueIndex;not null"`
+	Actor      string    `gorm:"index;size:255;not null"`
+	Action     string    `gorm:"size:32;not null"`
+	TargetType string    `gorm:"index:idx_audit_logs_target;size:32;not null"`
+	TargetID   uint      `gorm:"index:idx_audit_logs_target;not null"`
+	Diff       string    `gorm:"not null"`
+	RequestID  string    `gorm:"size:255"`
+	IP         string    `gorm:"size:64"`
+	CreatedAt  time.Time `gorm:"index;not null"`
+	PrevHash   string    `gorm:"size:64;not null"`
+	Hash       string    `gorm:"size:64;not null"`
+}
+
+// AuditChainHead is the single row with the last record of the audit chain.
+// It is locked to append a record, so the records are chained one at a time
+type AuditChainHead struct {
+	ID       uint `gorm:"primaryKey;autoIncrement:false"`
+	Sequence uint64
+	Hash     string `gorm:"size:64"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// IdempotencyKey is a request made with the Idempotency-Key header. Only the
+// fingerprint of the request is stored, never its body. StatusCode is 0 while
+// the request is being processed. Owner is the token of the request holding
+// the lease
+type IdempotencyKey struct {
+	Key         string `gorm:"column:idempotency_key;primaryKey;size:255"`
+	Fingerprint string `gorm:"size:64;not null"`
+	Owner       string `gorm:"size:64;not null;default:''"`
+	StatusCode  int    `gorm:"not null;default:0"`
+	ContentType string
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"index;not null"`
+	CreatedAt   time.Time
+}
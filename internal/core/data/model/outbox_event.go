@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// OutboxEvent is a domain event written in the transaction of the change, to
+// be published by the outbox relay. Payload is the encrypted JSON of the event
+// data. PublishedAt is nil while the event is pending
+type OutboxEvent struct {
+	ID            uint64     `gorm:"primaryKey"`
+	EventID       string     `gorm:"uniqueIndex;size:36;not null"`
+	EventType     string     `gorm:"size:64;not null"`
+	SchemaVersion int        `gorm:"not null"`
+	AggregateKey  string     `gorm:"size:255;not null"`
+	Payload       string     `gorm:"not null"`
+	OccurredAt    time.Time  `gorm:"not null"`
+	PublishedAt   *time.Time `gorm:"index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	LastError     string
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// RecordVersion is a version of a customer or an admin user, written in the
+// transaction of each change. CPF and Email are encrypted as in the row and
+// Deleted is true when the change soft deleted the row. ValidFrom is when the
+// version became the current one, until the next version
+type RecordVersion struct {
+	ID         uint   `gorm:"primaryKey"`
+	TargetType string `gorm:"uniqueIndex:idx_record_versions_target;size:32;not null"`
+	TargetID   uint   `gorm:"uniqueIndex:idx_record_versions_target;not null"`
+	Version    uint   `gorm:"uniqueIndex:idx_record_versions_target;not null"`
+	Action     string `gorm:"size:32;not null"`
+	Name       string
+	CPF        string
+	Email      string
+	CPFIndex   *string   `gorm:"size:64"`
+	EmailIndex *string   `gorm:"size:64"`
+	Deleted    bool      `gorm:"not null;default:false"`
+	Actor      string    `gorm:"size:255;not null"`
+	RequestID  string    `gorm:"size:255"`
+	ValidFrom  time.Time `gorm:"not null"`
+}
@@ -4,7 +4,14 @@ import "gorm.io/gorm"
 
 type UserAdmin struct {
 	gorm.Model
-	Name  string
-	CPF   string `gorm:"index;unique"`
-	Email string `gorm:"unique"`
+	Name string
+	// CPF and Email are encrypted, see pkg/encryption. CPFIndex and EmailIndex
+	// are their blind indexes, used by the lookups and the unique constraints
+	CPF        string
+	Email      string
+	CPFIndex   string `gorm:"size:64;uniqueIndex"`
+	EmailIndex string `gorm:"size:64;uniqueIndex"`
+	// Version is incremented on every update and is used as the ETag of the
+	// resource. Updates are conditional on it (optimistic concurrency)
+	Version uint `gorm:"not null;default:1"`
 }
@@ -0,0 +1,362 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	_auditChainHeadID     = 1
+	_auditVerifyBatchSize = 500
+
+	auditTargetCustomer  = "customer"
+	auditTargetUserAdmin = "user_admin"
+)
+
+// auditedFields are the audited columns of the customers and the users
+type auditedFields struct {
+	Name  string
+	CPF   string
+	Email string
+}
+
+func (fields auditedFields) toMap() map[string]string {
+	return map[string]string{
+		"name":  fields.Name,
+		"cpf":   fields.CPF,
+		"email": fields.Email,
+	}
+}
+
+// decrypt decrypts the CPF and the email read from the database
+func (fields *auditedFields) decrypt(ctx context.Context, encryptor *encryption.Encryptor) error {
+	var err error
+
+	fields.CPF, err = encryptor.Decrypt(ctx, fields.CPF)
+
+	if err != nil {
+		return err
+	}
+
+	fields.Email, err = encryptor.Decrypt(ctx, fields.Email)
+
+	return err
+}
+
+type AuditRepository struct {
+	db *database.Database
+}
+
+func NewAuditRepository(db *database.Database) repository.AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (repository *AuditRepository) ListAuditLogs(ctx context.Context, filter dto.AuditLogFilter) ([]dto.AuditLog, error) {
+	query := repository.db.Reader(ctx).Order("sequence DESC").Limit(filter.Limit)
+
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	if filter.Before != 0 {
+		query = query.Where("sequence < ?", filter.Before)
+	}
+
+	var auditLogEntities []model.AuditLog
+
+	err := query.Find(&auditLogEntities).Error
+
+	if err != nil {
+		return nil, responses.GetDatabaseError(err)
+	}
+
+	auditLogs := make([]dto.AuditLog, 0, len(auditLogEntities))
+
+	for _, auditLogEntity := range auditLogEntities {
+		var diff map[string]dto.AuditChange
+
+		err = json.Unmarshal([]byte(auditLogEntity.Diff), &diff)
+
+		if err != nil {
+			return nil, responses.GetDatabaseError(err)
+		}
+
+		auditLogs = append(auditLogs, dto.AuditLog{
+			Sequence:   auditLogEntity.Sequence,
+			Actor:      auditLogEntity.Actor,
+			Action:     auditLogEntity.Action,
+			TargetType: auditLogEntity.TargetType,
+			TargetID:   auditLogEntity.TargetID,
+			Diff:       diff,
+			RequestID:  auditLogEntity.RequestID,
+			IP:         auditLogEntity.IP,
+			CreatedAt:  auditLogEntity.CreatedAt,
+			PrevHash:   auditLogEntity.PrevHash,
+			Hash:       auditLogEntity.Hash,
+		})
+	}
+
+	return auditLogs, nil
+}
+
+// VerifyAuditChain recomputes the hash of every record, in batches, and checks
+// that each one points to the previous record and that the last one is the
+// chain head. It reads from the primary, since a lagging replica would report
+// the last records as missing
+func (repository *AuditRepository) VerifyAuditChain(ctx context.Context) (dto.AuditChainVerification, error) {
+	db := repository.db.Conn(ctx)
+	verification := dto.AuditChainVerification{Valid: true}
+	prevHash := ""
+
+	broken := func(sequence uint64, reason string) (dto.AuditChainVerification, error) {
+		verification.Valid = false
+		verification.BrokenAt = &sequence
+		verification.Reason = reason
+
+		return verification, nil
+	}
+
+	for {
+		var auditLogEntities []model.AuditLog
+
+		err := db.Where("sequence > ?", verification.Records).
+			Order("sequence").
+			Limit(_auditVerifyBatchSize).
+			Find(&auditLogEntities).
+			Error
+
+		if err != nil {
+			return dto.AuditChainVerification{}, responses.GetDatabaseError(err)
+		}
+
+		for _, auditLogEntity := range auditLogEntities {
+			expected := verification.Records + 1
+
+			switch {
+			case auditLogEntity.Sequence != expected:
+				return broken(expected, "record is missing")
+			case auditLogEntity.PrevHash != prevHash:
+				return broken(expected, "previous hash does not match")
+			case hashAuditLog(auditLogEntity) != auditLogEntity.Hash:
+				return broken(expected, "record was changed")
+			}
+
+			prevHash = auditLogEntity.Hash
+			verification.Records++
+		}
+
+		if len(auditLogEntities) < _auditVerifyBatchSize {
+			break
+		}
+	}
+
+	var head model.AuditChainHead
+
+	err := db.Limit(1).Find(&head, _auditChainHeadID).Error
+
+	if err != nil {
+		return dto.AuditChainVerification{}, responses.GetDatabaseError(err)
+	}
+
+	if head.Sequence != verification.Records || head.Hash != prevHash {
+		return broken(verification.Records+1, "last records are missing")
+	}
+
+	return verification, nil
+}
+
+// appendAuditLog appends the record of a change to the audit chain. It must be
+// called with the transaction of the change, so both are committed together.
+// The chain head is locked until the commit, so concurrent changes are
+// chained one after the other
+func appendAuditLog(ctx context.Context, tx *gorm.DB, action string, targetType string, targetID uint, changes map[string]audit.Change) error {
+	metadata := audit.FromContext(ctx)
+
+	diff, err := json.Marshal(changes)
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	head, err := lockAuditChainHead(tx)
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	auditLogEntity := model.AuditLog{
+		Sequence:   head.Sequence + 1,
+		Actor:      metadata.Actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Diff:       string(diff),
+		RequestID:  metadata.RequestID,
+		IP:         metadata.IP,
+		// the database keeps microseconds, and the hash must be computed
+		// with the stored value
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		PrevHash:  head.Hash,
+	}
+	auditLogEntity.Hash = hashAuditLog(auditLogEntity)
+
+	err = tx.Create(&auditLogEntity).Error
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	err = tx.Model(&model.AuditChainHead{}).
+		Where("id = ?", _auditChainHeadID).
+		Updates(map[string]any{
+			"sequence": auditLogEntity.Sequence,
+			"hash":     auditLogEntity.Hash,
+		}).
+		Error
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	return nil
+}
+
+// lockAuditChainHead locks the chain head row, creating it on the first change
+func lockAuditChainHead(tx *gorm.DB) (model.AuditChainHead, error) {
+	var head model.AuditChainHead
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, _auditChainHeadID).Error
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return head, err
+	}
+
+	err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.AuditChainHead{ID: _auditChainHeadID}).Error
+
+	if err != nil {
+		return head, err
+	}
+
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&head, _auditChainHeadID).Error
+
+	return head, err
+}
+
+// updateAudited runs a versioned update of the plaintext changes and appends
+// its version, its audit record and its domain event in a single transaction.
+// It joins the unit-of-work transaction when db comes from Conn. The row is
+// locked to read the values before the change. The personal data is encrypted
+// before the update
+func updateAudited(ctx context.Context, db *gorm.DB, encryptor *encryption.Encryptor, entity any, targetType string, action string, id uint, version uint, changes map[string]string) error {
+	values, err := encryptValues(ctx, encryptor, changes)
+
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var before auditedFields
+
+		err := tx.Model(entity).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("name", "cpf", "email").
+			Where("id = ?", id).
+			Take(&before).
+			Error
+
+		if err != nil {
+			return responses.GetDatabaseError(err)
+		}
+
+		err = before.decrypt(ctx, encryptor)
+
+		if err != nil {
+			return err
+		}
+
+		after := before.toMap()
+
+		for field, value := range changes {
+			after[field] = value
+		}
+
+		err = updateVersioned(ctx, tx, entity, id, version, values)
+
+		if err != nil {
+			return err
+		}
+
+		version, err := appendRecordVersion(ctx, tx, entity, targetType, action, id)
+
+		if err != nil {
+			return err
+		}
+
+		err = appendAuditLog(ctx, tx, action, targetType, id, audit.Diff(before.toMap(), after))
+
+		if err != nil {
+			return err
+		}
+
+		fields := auditedFields{
+			Name:  after["name"],
+			CPF:   after["cpf"],
+			Email: after["email"],
+		}
+
+		return appendChangeEvent(ctx, tx, encryptor, targetType, action, id, version, fields, changedFields(before.toMap(), after))
+	})
+}
+
+// hashAuditLog returns the SHA-256 of the record fields and the previous hash.
+// The fields are encoded as a JSON array, so the encoding is not ambiguous
+func hashAuditLog(auditLogEntity model.AuditLog) string {
+	content, _ := json.Marshal([]any{
+		auditLogEntity.Sequence,
+		auditLogEntity.Actor,
+		auditLogEntity.Action,
+		auditLogEntity.TargetType,
+		auditLogEntity.TargetID,
+		auditLogEntity.Diff,
+		auditLogEntity.RequestID,
+		auditLogEntity.IP,
+		auditLogEntity.CreatedAt.UTC().Format(time.RFC3339Nano),
+		auditLogEntity.PrevHash,
+	})
+
+	hash := sha256.Sum256(content)
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,159 @@
+package repositories_test
+
+import (
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+)
+
+func (suite *RepositoryTestSuite) TestAuditLogWrittenWithChanges() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+	auditRepo := repositories.NewAuditRepository(suite.db)
+
+	ctx := audit.WithMetadata(suite.ctx, audit.Metadata{
+		Actor:     "admin",
+		RequestID: "REQUEST-1",
+		IP:        "10.0.0.7",
+	})
+
+	mockCognito.On("SignUp", ctx, &model.Customer{
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "teste@teste.com",
+	}).Return(nil)
+
+	newId, err := repo.CreateCustomer(ctx, dto.Customer{
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "teste@teste.com",
+	})
+	suite.NoError(err)
+
+	err = repo.UpdateCustomer(ctx, dto.Customer{
+		ID:    newId,
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "novo@teste.com",
+	})
+	suite.NoError(err)
+
+	auditLogs, err := auditRepo.ListAuditLogs(suite.ctx, dto.AuditLogFilter{
+		TargetType: "customer",
+		TargetID:   newId,
+		Limit:      10,
+	})
+	suite.NoError(err)
+	suite.Len(auditLogs, 2)
+
+	suite.Equal(uint64(2), auditLogs[0].Sequence)
+	suite.Equal(audit.ActionUpdate, auditLogs[0].Action)
+	suite.Equal("admin", auditLogs[0].Actor)
+	suite.Equal("REQUEST-1", auditLogs[0].RequestID)
+	suite.Equal("10.0.0.7", auditLogs[0].IP)
+	suite.Equal(map[string]dto.AuditChange{
+		"email": {Before: "t***@teste.com", After: "n***@teste.com"},
+	}, auditLogs[0].Diff)
+	suite.Equal(auditLogs[1].Hash, auditLogs[0].PrevHash)
+
+	suite.Equal(audit.ActionCreate, auditLogs[1].Action)
+	suite.Equal("***.***.***-93", auditLogs[1].Diff["cpf"].After)
+	suite.Empty(auditLogs[1].PrevHash)
+
+	auditLogs, err = auditRepo.ListAuditLogs(suite.ctx, dto.AuditLogFilter{
+		Actor:  "admin",
+		Before: 2,
+		Limit:  10,
+	})
+	suite.NoError(err)
+	suite.Len(auditLogs, 1)
+	suite.Equal(uint64(1), auditLogs[0].Sequence)
+}
+
+func (suite *RepositoryTestSuite) TestAuditLogRolledBackWithFailedChange() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewUserAdminRepository(suite.db, mockCognito, testEncryptor)
+	auditRepo := repositories.NewAuditRepository(suite.db)
+
+	mockCognito.On("SignUpAdmin", suite.ctx, &model.UserAdmin{
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "teste@teste.com",
+	}).Return(nil)
+
+	newId, err := repo.CreateUser(suite.ctx, dto.UserAdmin{
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "teste@teste.com",
+	})
+	suite.NoError(err)
+
+	// the version 5 does not exist, so the update fails with its audit record
+	err = repo.UpdateUser(suite.ctx, dto.UserAdmin{
+		ID:      newId,
+		Name:    "Teste 2",
+		CPF:     "83212446293",
+		Email:   "teste@teste.com",
+		Version: 5,
+	})
+	suite.Error(err)
+
+	auditLogs, err := auditRepo.ListAuditLogs(suite.ctx, dto.AuditLogFilter{Limit: 10})
+	suite.NoError(err)
+	suite.Len(auditLogs, 1)
+	suite.Equal(audit.AnonymousActor, auditLogs[0].Actor)
+	suite.Equal(audit.ActionCreate, auditLogs[0].Action)
+}
+
+func (suite *RepositoryTestSuite) TestVerifyAuditChainWithTamperedRecord() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewUserAdminRepository(suite.db, mockCognito, testEncryptor)
+	auditRepo := repositories.NewAuditRepository(suite.db)
+
+	mockCognito.On("SignUpAdmin", suite.ctx, &model.UserAdmin{
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "teste@teste.com",
+	}).Return(nil)
+
+	newId, err := repo.CreateUser(suite.ctx, dto.UserAdmin{
+		Name:  "Teste",
+		CPF:   "83212446293",
+		Email: "teste@teste.com",
+	})
+	suite.NoError(err)
+
+	for _, name := range []string{"Teste 2", "Teste 3"} {
+		err = repo.UpdateUser(suite.ctx, dto.UserAdmin{
+			ID:    newId,
+			Name:  name,
+			CPF:   "83212446293",
+			Email: "teste@teste.com",
+		})
+		suite.NoError(err)
+	}
+
+	verification, err := auditRepo.VerifyAuditChain(suite.ctx)
+	suite.NoError(err)
+	suite.True(verification.Valid)
+	suite.Equal(uint64(3), verification.Records)
+
+	err = suite.db.Connection.Exec("UPDATE audit_logs SET actor = ? WHERE sequence = ?", "someone", 2).Error
+	suite.NoError(err)
+
+	verification, err = auditRepo.VerifyAuditChain(suite.ctx)
+	suite.NoError(err)
+	suite.False(verification.Valid)
+	suite.Equal(uint64(2), *verification.BrokenAt)
+	suite.Equal("record was changed", verification.Reason)
+
+	err = suite.db.Connection.Exec("DELETE FROM audit_logs WHERE sequence >= ?", 2).Error
+	suite.NoError(err)
+
+	verification, err = auditRepo.VerifyAuditChain(suite.ctx)
+	suite.NoError(err)
+	suite.False(verification.Valid)
+	suite.Equal(uint64(2), *verification.BrokenAt)
+	suite.Equal("last records are missing", verification.Reason)
+}
@@ -2,26 +2,30 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
 	"github.com/thiagoluis88git/tech1-customer/internal/integrations/remote"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
 	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
-
 	"gorm.io/gorm"
 )
 
 type CustomerRepository struct {
 	db            *database.Database
 	cognitoRemote remote.CognitoRemoteDataSource
+	encryptor     *encryption.Encryptor
 }
 
-func NewCustomerRepository(db *database.Database, cognitoRemote remote.CognitoRemoteDataSource) repository.CustomerRepository {
+func NewCustomerRepository(db *database.Database, cognitoRemote remote.CognitoRemoteDataSource, encryptor *encryption.Encryptor) repository.CustomerRepository {
 	return &CustomerRepository{
 		db:            db,
 		cognitoRemote: cognitoRemote,
+		encryptor:     encryptor,
 	}
 }
 
@@ -32,43 +36,86 @@ func (repository *CustomerRepository) CreateCustomer(ctx context.Context, custom
 		Email: customer.Email,
 	}
 
-	err := repository.cognitoRemote.SignUp(customerEntity)
+	err := repository.cognitoRemote.SignUp(ctx, customerEntity)
 
 	if err != nil {
 		return 0, responses.GetCognitoError(err)
 	}
 
-	err = repository.db.Connection.WithContext(ctx).Create(customerEntity).Error
+	created := auditedFields{
+		Name:  customerEntity.Name,
+		CPF:   customerEntity.CPF,
+		Email: customerEntity.Email,
+	}
+
+	customerEntity.CPF, customerEntity.CPFIndex, err = encryptField(ctx, repository.encryptor, created.CPF)
 
 	if err != nil {
-		return 0, responses.GetDatabaseError(err)
+		return 0, err
+	}
+
+	customerEntity.Email, customerEntity.EmailIndex, err = encryptField(ctx, repository.encryptor, created.Email)
+
+	if err != nil {
+		return 0, err
+	}
+
+	err = repository.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(customerEntity).Error
+
+		if err != nil {
+			return responses.GetDatabaseError(err)
+		}
+
+		version, err := appendRecordVersion(ctx, tx, &model.Customer{}, auditTargetCustomer, audit.ActionCreate, customerEntity.ID)
+
+		if err != nil {
+			return err
+		}
+
+		err = appendAuditLog(ctx, tx, audit.ActionCreate, auditTargetCustomer, customerEntity.ID, audit.Diff(nil, created.toMap()))
+
+		if err != nil {
+			return err
+		}
+
+		return appendChangeEvent(ctx, tx, repository.encryptor, auditTargetCustomer, audit.ActionCreate, customerEntity.ID, version, created, nil)
+	})
+
+	if err != nil {
+		return 0, err
 	}
 
 	return customerEntity.ID, nil
 }
 
 func (repository *CustomerRepository) UpdateCustomer(ctx context.Context, customer dto.Customer) error {
-	customerEntity := &model.Customer{
-		Model: gorm.Model{ID: customer.ID},
-		Name:  customer.Name,
-		CPF:   customer.CPF,
-		Email: customer.Email,
-	}
+	return updateAudited(ctx, repository.db.Conn(ctx), repository.encryptor, &model.Customer{}, auditTargetCustomer, audit.ActionUpdate, customer.ID, customer.Version, map[string]string{
+		"name":  customer.Name,
+		"cpf":   customer.CPF,
+		"email": customer.Email,
+	})
+}
 
-	err := repository.db.Connection.WithContext(ctx).Save(&customerEntity).Error
+func (repository *CustomerRepository) PatchCustomer(ctx context.Context, patch dto.CustomerPatch) error {
+	values := map[string]string{}
 
-	if err != nil {
-		return responses.GetDatabaseError(err)
+	if patch.Name != nil {
+		values["name"] = *patch.Name
+	}
+
+	if patch.Email != nil {
+		values["email"] = *patch.Email
 	}
 
-	return nil
+	return updateAudited(ctx, repository.db.Conn(ctx), repository.encryptor, &model.Customer{}, auditTargetCustomer, audit.ActionPatch, patch.ID, patch.Version, values)
 }
 
 func (repository *CustomerRepository) GetCustomerById(ctx context.Context, id uint) (dto.Customer, error) {
 	var customerEntity model.Customer
 
 	err := repository.
-		db.Connection.WithContext(ctx).
+		db.Reader(ctx).
 		First(&customerEntity, id).
 		Error
 
@@ -76,15 +123,15 @@ func (repository *CustomerRepository) GetCustomerById(ctx context.Context, id ui
 		return dto.Customer{}, responses.GetDatabaseError(err)
 	}
 
-	return repository.populateCustomer(customerEntity), nil
+	return repository.populateCustomer(ctx, customerEntity)
 }
 
 func (repository *CustomerRepository) GetCustomerByCPF(ctx context.Context, cpf string) (dto.Customer, error) {
 	var customerEntity model.Customer
 
 	err := repository.
-		db.Connection.WithContext(ctx).
-		Where("cpf = ?", cpf).
+		db.Reader(ctx).
+		Where("cpf_index = ?", repository.encryptor.BlindIndex(cpf)).
 		First(&customerEntity).
 		Error
 
@@ -92,20 +139,88 @@ func (repository *CustomerRepository) GetCustomerByCPF(ctx context.Context, cpf
 		return dto.Customer{}, responses.GetDatabaseError(err)
 	}
 
-	return repository.populateCustomer(customerEntity), nil
+	return repository.populateCustomer(ctx, customerEntity)
 }
 
-func (repository *CustomerRepository) populateCustomer(customerEntity model.Customer) dto.Customer {
-	return dto.Customer{
-		ID:    customerEntity.ID,
-		Name:  customerEntity.Name,
-		CPF:   customerEntity.CPF,
-		Email: customerEntity.Email,
+// GetCustomersByIDsOrCPFs returns the customers of the ids and the CPFs with a
+// single query. The missing ones are not in the result
+func (repository *CustomerRepository) GetCustomersByIDsOrCPFs(ctx context.Context, ids []uint, cpfs []string) ([]dto.Customer, error) {
+	var customerEntities []model.Customer
+
+	query := repository.db.Reader(ctx)
+
+	switch {
+	case len(ids) > 0 && len(cpfs) > 0:
+		query = query.Where("id IN ? OR cpf_index IN ?", ids, blindIndexes(repository.encryptor, cpfs))
+	case len(ids) > 0:
+		query = query.Where("id IN ?", ids)
+	case len(cpfs) > 0:
+		query = query.Where("cpf_index IN ?", blindIndexes(repository.encryptor, cpfs))
+	default:
+		return []dto.Customer{}, nil
 	}
+
+	err := query.Find(&customerEntities).Error
+
+	if err != nil {
+		return nil, responses.GetDatabaseError(err)
+	}
+
+	customers := make([]dto.Customer, 0, len(customerEntities))
+
+	for _, customerEntity := range customerEntities {
+		customer, err := repository.populateCustomer(ctx, customerEntity)
+
+		if err != nil {
+			return nil, err
+		}
+
+		customers = append(customers, customer)
+	}
+
+	return customers, nil
+}
+
+// DeleteCustomer soft deletes the customer and disables its identity provider
+// account. Its CPF and email stay taken until it is restored
+func (repository *CustomerRepository) DeleteCustomer(ctx context.Context, id uint) error {
+	return setDeleted(ctx, repository.db.Conn(ctx), repository.encryptor, &model.Customer{}, auditTargetCustomer, id, true, repository.cognitoRemote.DisableUser)
+}
+
+// RestoreCustomer restores a deleted customer and enables its identity provider
+// account again
+func (repository *CustomerRepository) RestoreCustomer(ctx context.Context, id uint) error {
+	return setDeleted(ctx, repository.db.Conn(ctx), repository.encryptor, &model.Customer{}, auditTargetCustomer, id, false, repository.cognitoRemote.EnableUser)
+}
+
+func (repository *CustomerRepository) GetCustomerHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	return listRecordVersions(ctx, repository.db.Reader(ctx), repository.encryptor, auditTargetCustomer, id)
+}
+
+func (repository *CustomerRepository) GetCustomerAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	return getRecordVersionAt(ctx, repository.db.Reader(ctx), repository.encryptor, auditTargetCustomer, id, at)
+}
+
+func (repository *CustomerRepository) populateCustomer(ctx context.Context, customerEntity model.Customer) (dto.Customer, error) {
+	fields := auditedFields{CPF: customerEntity.CPF, Email: customerEntity.Email}
+
+	err := fields.decrypt(ctx, repository.encryptor)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	return dto.Customer{
+		ID:      customerEntity.ID,
+		Name:    customerEntity.Name,
+		CPF:     fields.CPF,
+		Email:   fields.Email,
+		Version: customerEntity.Version,
+	}, nil
 }
 
 func (repository *CustomerRepository) Login(ctx context.Context, cpf string) (string, error) {
-	token, err := repository.cognitoRemote.Login(cpf)
+	token, err := repository.cognitoRemote.Login(ctx, cpf)
 
 	if err != nil {
 		return "", responses.GetDatabaseError(err)
@@ -114,8 +229,8 @@ func (repository *CustomerRepository) Login(ctx context.Context, cpf string) (st
 	return token, nil
 }
 
-func (repository *CustomerRepository) LoginUnknown() (string, error) {
-	token, err := repository.cognitoRemote.LoginUnknown()
+func (repository *CustomerRepository) LoginUnknown(ctx context.Context) (string, error) {
+	token, err := repository.cognitoRemote.LoginUnknown(ctx)
 
 	if err != nil {
 		return "", responses.GetDatabaseError(err)
@@ -123,3 +238,16 @@ func (repository *CustomerRepository) LoginUnknown() (string, error) {
 
 	return token, nil
 }
+
+func (repository *CustomerRepository) ValidateToken(ctx context.Context, accessToken string) (dto.TokenInfo, error) {
+	username, err := repository.cognitoRemote.GetUsername(ctx, accessToken)
+
+	if err != nil {
+		return dto.TokenInfo{}, responses.GetCognitoError(err)
+	}
+
+	return dto.TokenInfo{
+		Username:  username,
+		Anonymous: username == remote.UnknownUsername,
+	}, nil
+}
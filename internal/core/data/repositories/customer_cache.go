@@ -0,0 +1,440 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
+	"github.com/thiagoluis88git/tech1-customer/pkg/cache"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	_customerCacheName   = "customer_by_cpf"
+	_customerCachePrefix = "customer:v1:cpf:"
+
+	_defaultCustomerCacheTTL         = 5 * time.Minute
+	_defaultCustomerCacheNotFoundTTL = 30 * time.Second
+)
+
+// cachedCustomer is the cache entry of a CPF lookup. Found is false for a CPF
+// without customer. CPF and Email are encrypted as in the database, so a shared
+// cache never holds the personal data in plaintext
+type cachedCustomer struct {
+	Found   bool   `json:"found"`
+	ID      uint   `json:"id,omitempty"`
+	Name    string `json:"name,omitempty"`
+	CPF     string `json:"cpf,omitempty"`
+	Email   string `json:"email,omitempty"`
+	Version uint   `json:"version,omitempty"`
+}
+
+// CustomerCacheStats are the lookups of the CachedCustomerRepository since it
+// was created. Coalesced are the misses that waited for a concurrent one
+// instead of querying the database
+type CustomerCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Coalesced uint64
+	Errors    uint64
+}
+
+// CachedCustomerRepository is a read-through cache of GetCustomerByCPF around
+// a CustomerRepository. The keys are the blind index of the CPF, never the CPF
+// itself, and the entries have the CPF and the email encrypted. The CPFs
+// without customer are cached for a shorter TTL and the concurrent misses of a
+// CPF make a single query.
+//
+// The writes invalidate the entries of the old and the new CPF after the
+// transaction is committed. The reads that must see the latest writes (inside
+// a transaction or with the primary required) bypass the cache
+type CachedCustomerRepository struct {
+	next        repository.CustomerRepository
+	store       cache.Cache
+	encryptor   *encryption.Encryptor
+	ttl         time.Duration
+	notFoundTTL time.Duration
+
+	group singleflight.Group
+
+	// invalidations changes on every write, so a miss that read the
+	// database before a write finished does not cache the old customer
+	invalidations atomic.Uint64
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+	errors    atomic.Uint64
+}
+
+// CustomerCacheOption -.
+type CustomerCacheOption func(*CachedCustomerRepository)
+
+// CustomerCacheTTL is how long a found customer is cached
+func CustomerCacheTTL(ttl time.Duration) CustomerCacheOption {
+	return func(r *CachedCustomerRepository) {
+		r.ttl = ttl
+	}
+}
+
+// CustomerCacheNotFoundTTL is how long a CPF without customer is cached
+func CustomerCacheNotFoundTTL(ttl time.Duration) CustomerCacheOption {
+	return func(r *CachedCustomerRepository) {
+		r.notFoundTTL = ttl
+	}
+}
+
+func NewCachedCustomerRepository(
+	next repository.CustomerRepository,
+	store cache.Cache,
+	encryptor *encryption.Encryptor,
+	opts ...CustomerCacheOption,
+) *CachedCustomerRepository {
+	cached := &CachedCustomerRepository{
+		next:        next,
+		store:       store,
+		encryptor:   encryptor,
+		ttl:         _defaultCustomerCacheTTL,
+		notFoundTTL: _defaultCustomerCacheNotFoundTTL,
+	}
+
+	for _, opt := range opts {
+		opt(cached)
+	}
+
+	return cached
+}
+
+func (repository *CachedCustomerRepository) CreateCustomer(ctx context.Context, customer dto.Customer) (uint, error) {
+	id, err := repository.next.CreateCustomer(ctx, customer)
+
+	if err != nil {
+		return id, err
+	}
+
+	// the CPF may be cached as not found
+	repository.invalidate(ctx, customer.CPF)
+
+	return id, nil
+}
+
+func (repository *CachedCustomerRepository) UpdateCustomer(ctx context.Context, customer dto.Customer) error {
+	cpfs := repository.currentCPFs(ctx, customer.ID)
+
+	err := repository.next.UpdateCustomer(ctx, customer)
+
+	if err != nil {
+		return err
+	}
+
+	repository.invalidate(ctx, append(cpfs, customer.CPF)...)
+
+	return nil
+}
+
+func (repository *CachedCustomerRepository) PatchCustomer(ctx context.Context, patch dto.CustomerPatch) error {
+	cpfs := repository.currentCPFs(ctx, patch.ID)
+
+	err := repository.next.PatchCustomer(ctx, patch)
+
+	if err != nil {
+		return err
+	}
+
+	repository.invalidate(ctx, cpfs...)
+
+	return nil
+}
+
+func (repository *CachedCustomerRepository) GetCustomerById(ctx context.Context, id uint) (dto.Customer, error) {
+	return repository.next.GetCustomerById(ctx, id)
+}
+
+func (repository *CachedCustomerRepository) GetCustomerByCPF(ctx context.Context, cpf string) (dto.Customer, error) {
+	if database.IsPrimaryRequired(ctx) {
+		return repository.next.GetCustomerByCPF(ctx, cpf)
+	}
+
+	key := repository.key(cpf)
+	value, found, err := repository.store.Get(ctx, key)
+
+	if err != nil {
+		repository.observe("error")
+	}
+
+	if err == nil && found {
+		var entry cachedCustomer
+
+		err = json.Unmarshal(value, &entry)
+
+		if err == nil {
+			var customer dto.Customer
+
+			customer, err = repository.result(ctx, entry)
+
+			if err == nil || isNotFound(err) {
+				repository.observe("hit")
+				return customer, err
+			}
+		}
+
+		repository.observe("error")
+	}
+
+	loaded := false
+
+	result := repository.group.DoChan(key, func() (any, error) {
+		loaded = true
+		repository.observe("miss")
+
+		// the query is shared by the concurrent misses, so it must not be
+		// canceled with the request that started it
+		return repository.load(context.WithoutCancel(ctx), key, cpf)
+	})
+
+	select {
+	case <-ctx.Done():
+		return dto.Customer{}, responses.GetDatabaseError(ctx.Err())
+	case loadResult := <-result:
+		if !loaded {
+			repository.observe("coalesced")
+		}
+
+		customer, _ := loadResult.Val.(dto.Customer)
+
+		return customer, loadResult.Err
+	}
+}
+
+func (repository *CachedCustomerRepository) GetCustomersByIDsOrCPFs(ctx context.Context, ids []uint, cpfs []string) ([]dto.Customer, error) {
+	return repository.next.GetCustomersByIDsOrCPFs(ctx, ids, cpfs)
+}
+
+func (repository *CachedCustomerRepository) DeleteCustomer(ctx context.Context, id uint) error {
+	cpfs := repository.currentCPFs(ctx, id)
+
+	err := repository.next.DeleteCustomer(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	repository.invalidate(ctx, cpfs...)
+
+	return nil
+}
+
+func (repository *CachedCustomerRepository) RestoreCustomer(ctx context.Context, id uint) error {
+	err := repository.next.RestoreCustomer(ctx, id)
+
+	if err != nil {
+		return err
+	}
+
+	// the CPF may be cached as not found, and it is known only once the
+	// customer is restored
+	repository.invalidate(ctx, repository.currentCPFs(database.WithPrimary(ctx), id)...)
+
+	return nil
+}
+
+func (repository *CachedCustomerRepository) GetCustomerHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	return repository.next.GetCustomerHistory(ctx, id)
+}
+
+func (repository *CachedCustomerRepository) GetCustomerAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	return repository.next.GetCustomerAsOf(ctx, id, at)
+}
+
+func (repository *CachedCustomerRepository) Login(ctx context.Context, cpf string) (string, error) {
+	return repository.next.Login(ctx, cpf)
+}
+
+func (repository *CachedCustomerRepository) LoginUnknown(ctx context.Context) (string, error) {
+	return repository.next.LoginUnknown(ctx)
+}
+
+func (repository *CachedCustomerRepository) ValidateToken(ctx context.Context, accessToken string) (dto.TokenInfo, error) {
+	return repository.next.ValidateToken(ctx, accessToken)
+}
+
+// Stats returns the lookups since the repository was created. The same numbers
+// are in the tech1_customer_cache_requests_total metric
+func (repository *CachedCustomerRepository) Stats() CustomerCacheStats {
+	return CustomerCacheStats{
+		Hits:      repository.hits.Load(),
+		Misses:    repository.misses.Load(),
+		Coalesced: repository.coalesced.Load(),
+		Errors:    repository.errors.Load(),
+	}
+}
+
+// load queries the customer and caches the result. The errors other than not
+// found are not cached. The query reads from the primary: a lagging replica
+// would cache the customer as it was before the last invalidation
+func (repository *CachedCustomerRepository) load(ctx context.Context, key string, cpf string) (dto.Customer, error) {
+	invalidations := repository.invalidations.Load()
+
+	customer, err := repository.next.GetCustomerByCPF(database.WithPrimary(ctx), cpf)
+
+	entry := cachedCustomer{}
+	ttl := repository.notFoundTTL
+
+	if err != nil && !isNotFound(err) {
+		return dto.Customer{}, err
+	}
+
+	if err == nil {
+		var encryptErr error
+
+		entry, encryptErr = repository.entry(ctx, customer)
+
+		if encryptErr != nil {
+			repository.observe("error")
+			return customer, nil
+		}
+
+		ttl = repository.ttl
+	}
+
+	if repository.invalidations.Load() != invalidations {
+		return customer, err
+	}
+
+	value, cacheErr := json.Marshal(entry)
+
+	if cacheErr == nil {
+		cacheErr = repository.store.Set(ctx, key, value, ttl)
+	}
+
+	if cacheErr != nil {
+		repository.observe("error")
+	}
+
+	return customer, err
+}
+
+// entry returns the cache entry of a found customer, with the CPF and the
+// email encrypted
+func (repository *CachedCustomerRepository) entry(ctx context.Context, customer dto.Customer) (cachedCustomer, error) {
+	cpf, err := repository.encryptor.Encrypt(ctx, customer.CPF)
+
+	if err != nil {
+		return cachedCustomer{}, err
+	}
+
+	email, err := repository.encryptor.Encrypt(ctx, customer.Email)
+
+	if err != nil {
+		return cachedCustomer{}, err
+	}
+
+	return cachedCustomer{
+		Found:   true,
+		ID:      customer.ID,
+		Name:    customer.Name,
+		CPF:     cpf,
+		Email:   email,
+		Version: customer.Version,
+	}, nil
+}
+
+// currentCPFs returns the stored CPF of the customer, whose entry must be
+// invalidated when it changes. When the customer can not be read the write
+// fails too, or the old entry expires with the TTL
+func (repository *CachedCustomerRepository) currentCPFs(ctx context.Context, id uint) []string {
+	current, err := repository.next.GetCustomerById(ctx, id)
+
+	if err != nil {
+		return nil
+	}
+
+	return []string{current.CPF}
+}
+
+// invalidate removes the entries of the CPFs once the write is committed. The
+// invalidations counter changes both now and after the commit, so the misses
+// running in between do not cache the old customer
+func (repository *CachedCustomerRepository) invalidate(ctx context.Context, cpfs ...string) {
+	keys := make([]string, 0, len(cpfs))
+
+	for _, cpf := range cpfs {
+		keys = append(keys, repository.key(cpf))
+	}
+
+	repository.invalidations.Add(1)
+
+	database.AfterCommit(ctx, func() {
+		repository.invalidations.Add(1)
+
+		err := repository.store.Delete(context.WithoutCancel(ctx), keys...)
+
+		if err != nil {
+			repository.observe("error")
+		}
+	})
+}
+
+func (repository *CachedCustomerRepository) key(cpf string) string {
+	return _customerCachePrefix + repository.encryptor.BlindIndex(cpf)
+}
+
+func (repository *CachedCustomerRepository) observe(result string) {
+	switch result {
+	case "hit":
+		repository.hits.Add(1)
+	case "miss":
+		repository.misses.Add(1)
+	case "coalesced":
+		repository.coalesced.Add(1)
+	case "error":
+		repository.errors.Add(1)
+	}
+
+	metrics.ObserveCache(_customerCacheName, result)
+}
+
+// result returns the customer of a cache entry, with the CPF and the email
+// decrypted, or the not found error of a CPF without customer
+func (repository *CachedCustomerRepository) result(ctx context.Context, entry cachedCustomer) (dto.Customer, error) {
+	if !entry.Found {
+		return dto.Customer{}, &responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		}
+	}
+
+	cpf, err := repository.encryptor.Decrypt(ctx, entry.CPF)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	email, err := repository.encryptor.Decrypt(ctx, entry.Email)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	return dto.Customer{
+		ID:      entry.ID,
+		Name:    entry.Name,
+		CPF:     cpf,
+		Email:   email,
+		Version: entry.Version,
+	}, nil
+}
+
+func isNotFound(err error) bool {
+	var localError *responses.LocalError
+
+	return errors.As(err, &localError) && localError.Code == responses.NOT_FOUND_ERROR
+}
@@ -0,0 +1,322 @@
+package repositories_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/cache"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+// recordingCache is an LRU that keeps every key it was given
+type recordingCache struct {
+	*cache.LRU
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	c.keys = append(c.keys, key)
+	c.mu.Unlock()
+
+	return c.LRU.Set(ctx, key, value, ttl)
+}
+
+var (
+	cachedCustomer = dto.Customer{ID: 1, Name: "Teste", CPF: "83212446293", Email: "teste@teste.com", Version: 2}
+	notFoundError  = &responses.LocalError{Code: responses.NOT_FOUND_ERROR, Message: "record not found"}
+)
+
+func TestCachedCustomerRepositoryLocal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got customer from cache when calling GetCustomerByCPF twice local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(cachedCustomer, nil).Once()
+
+		store := &recordingCache{LRU: cache.NewLRU(10)}
+		repository := repositories.NewCachedCustomerRepository(next, store, testEncryptor)
+
+		for range 2 {
+			customer, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+			assert.NoError(t, err)
+			assert.Equal(t, cachedCustomer, customer)
+		}
+
+		assert.Equal(t, repositories.CustomerCacheStats{Hits: 1, Misses: 1}, repository.Stats())
+		assert.Equal(t, []string{"customer:v1:cpf:" + testEncryptor.BlindIndex("83212446293")}, store.keys)
+		assert.NotContains(t, store.keys[0], "83212446293")
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got CPF and email encrypted in cache when calling GetCustomerByCPF local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.MatchedBy(database.IsPrimaryRequired), "83212446293").Return(cachedCustomer, nil).Once()
+
+		store := cache.NewLRU(10)
+		repository := repositories.NewCachedCustomerRepository(next, store, testEncryptor)
+
+		_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+		assert.NoError(t, err)
+
+		value, found, err := store.Get(context.TODO(), "customer:v1:cpf:"+testEncryptor.BlindIndex("83212446293"))
+
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.NotContains(t, string(value), "83212446293")
+		assert.NotContains(t, string(value), "teste@teste.com")
+
+		customer, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+		assert.NoError(t, err)
+		assert.Equal(t, cachedCustomer, customer)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got not found from cache until not found TTL when calling GetCustomerByCPF local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(dto.Customer{}, notFoundError).Twice()
+
+		repository := repositories.NewCachedCustomerRepository(
+			next,
+			cache.NewLRU(10),
+			testEncryptor,
+			repositories.CustomerCacheNotFoundTTL(20*time.Millisecond),
+		)
+
+		for range 2 {
+			_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+			assert.Equal(t, responses.NOT_FOUND_ERROR, err.(*responses.LocalError).Code)
+		}
+
+		next.AssertNumberOfCalls(t, "GetCustomerByCPF", 1)
+
+		time.Sleep(30 * time.Millisecond)
+
+		_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+		assert.Error(t, err)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got database error not cached when calling GetCustomerByCPF local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").
+			Return(dto.Customer{}, &responses.LocalError{Code: responses.DATABASE_ERROR, Message: "service unavailable"}).
+			Twice()
+
+		repository := repositories.NewCachedCustomerRepository(next, cache.NewLRU(10), testEncryptor)
+
+		for range 2 {
+			_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+			assert.Error(t, err)
+		}
+
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got a single query when calling GetCustomerByCPF concurrently local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").
+			After(50*time.Millisecond).
+			Return(cachedCustomer, nil).
+			Once()
+
+		repository := repositories.NewCachedCustomerRepository(next, cache.NewLRU(10), testEncryptor)
+
+		var wg sync.WaitGroup
+
+		for range 10 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				customer, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+				assert.NoError(t, err)
+				assert.Equal(t, cachedCustomer, customer)
+			}()
+		}
+
+		wg.Wait()
+
+		stats := repository.Stats()
+
+		assert.Equal(t, uint64(1), stats.Misses)
+		assert.Equal(t, uint64(9), stats.Hits+stats.Coalesced)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got database read when calling GetCustomerByCPF with primary required local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(cachedCustomer, nil).Twice()
+
+		store := cache.NewLRU(10)
+		repository := repositories.NewCachedCustomerRepository(next, store, testEncryptor)
+
+		for range 2 {
+			_, err := repository.GetCustomerByCPF(database.WithPrimary(context.TODO()), "83212446293")
+
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 0, store.Len())
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got old and new CPF invalidated when calling UpdateCustomer local", func(t *testing.T) {
+		t.Parallel()
+
+		updated := cachedCustomer
+		updated.CPF = "11144477735"
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(cachedCustomer, nil).Twice()
+		next.On("GetCustomerByCPF", mock.Anything, "11144477735").Return(dto.Customer{}, notFoundError).Once()
+		next.On("GetCustomerById", mock.Anything, uint(1)).Return(cachedCustomer, nil).Once()
+		next.On("UpdateCustomer", mock.Anything, updated).Return(nil).Once()
+		next.On("GetCustomerByCPF", mock.Anything, "11144477735").Return(updated, nil).Once()
+
+		store := cache.NewLRU(10)
+		repository := repositories.NewCachedCustomerRepository(next, store, testEncryptor)
+
+		_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+		assert.NoError(t, err)
+
+		_, err = repository.GetCustomerByCPF(context.TODO(), "11144477735")
+		assert.Error(t, err)
+
+		assert.Equal(t, 2, store.Len())
+
+		err = repository.UpdateCustomer(context.TODO(), updated)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, store.Len())
+
+		customer, err := repository.GetCustomerByCPF(context.TODO(), "11144477735")
+
+		assert.NoError(t, err)
+		assert.Equal(t, updated, customer)
+
+		_, err = repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+		assert.NoError(t, err)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got not found entry invalidated when calling CreateCustomer local", func(t *testing.T) {
+		t.Parallel()
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(dto.Customer{}, notFoundError).Once()
+		next.On("CreateCustomer", mock.Anything, cachedCustomer).Return(uint(1), nil).Once()
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(cachedCustomer, nil).Once()
+
+		repository := repositories.NewCachedCustomerRepository(next, cache.NewLRU(10), testEncryptor)
+
+		_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+		assert.Error(t, err)
+
+		id, err := repository.CreateCustomer(context.TODO(), cachedCustomer)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), id)
+
+		customer, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+		assert.NoError(t, err)
+		assert.Equal(t, cachedCustomer, customer)
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got entry kept when calling PatchCustomer with error local", func(t *testing.T) {
+		t.Parallel()
+
+		name := "Novo"
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(cachedCustomer, nil).Once()
+		next.On("GetCustomerById", mock.Anything, uint(1)).Return(cachedCustomer, nil).Once()
+		next.On("PatchCustomer", mock.Anything, dto.CustomerPatch{ID: 1, Name: &name, Version: 2}).
+			Return(&responses.LocalError{Code: responses.PRECONDITION_FAILED_ERROR}).
+			Once()
+
+		store := cache.NewLRU(10)
+		repository := repositories.NewCachedCustomerRepository(next, store, testEncryptor)
+
+		_, err := repository.GetCustomerByCPF(context.TODO(), "83212446293")
+		assert.NoError(t, err)
+
+		err = repository.PatchCustomer(context.TODO(), dto.CustomerPatch{ID: 1, Name: &name, Version: 2})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, store.Len())
+		next.AssertExpectations(t)
+	})
+
+	t.Run("got entry invalidated only after commit when calling PatchCustomer in unit of work local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectCommit()
+
+		name := "Novo"
+
+		next := new(MockCustomerRepository)
+		next.On("GetCustomerByCPF", mock.Anything, "83212446293").Return(cachedCustomer, nil).Twice()
+		next.On("GetCustomerById", mock.Anything, uint(1)).Return(cachedCustomer, nil).Once()
+		next.On("PatchCustomer", mock.Anything, dto.CustomerPatch{ID: 1, Name: &name, Version: 2}).Return(nil).Once()
+
+		store := cache.NewLRU(10)
+		repository := repositories.NewCachedCustomerRepository(next, store, testEncryptor)
+		unitOfWork := repositories.NewUnitOfWork(&database.Database{Connection: db})
+
+		_, err = repository.GetCustomerByCPF(context.TODO(), "83212446293")
+		assert.NoError(t, err)
+
+		err = unitOfWork.Do(context.TODO(), func(ctx context.Context) error {
+			err := repository.PatchCustomer(ctx, dto.CustomerPatch{ID: 1, Name: &name, Version: 2})
+
+			assert.Equal(t, 1, store.Len())
+
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, store.Len())
+
+		_, err = repository.GetCustomerByCPF(context.TODO(), "83212446293")
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+		next.AssertExpectations(t)
+	})
+}
@@ -2,8 +2,11 @@ package repositories_test
 
 import (
 	"context"
+	"errors"
+	"net/http"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
@@ -15,6 +18,12 @@ func TestCustomerRepository(t *testing.T) {
 	suite.Run(t, new(RepositoryTestSuite))
 }
 
+// TestRepositorySQLiteLocal runs the repository suite on SQLite, without
+// external services
+func TestRepositorySQLiteLocal(t *testing.T) {
+	suite.Run(t, &RepositoryTestSuite{dialect: "sqlite"})
+}
+
 func (suite *RepositoryTestSuite) TestCreateCustomerWithSuccess() {
 	// ensure that the postgres database is empty
 	var customers []model.Customer
@@ -23,7 +32,7 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithSuccess() {
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	newCustomer := dto.Customer{
 		Name:  "Teste",
@@ -37,7 +46,7 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithSuccess() {
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(nil)
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
 
 	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
 
@@ -57,7 +66,7 @@ func (suite *RepositoryTestSuite) TestGetCustomerByIDError() {
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	newCustomer := dto.Customer{
 		Name:  "Teste",
@@ -71,7 +80,7 @@ func (suite *RepositoryTestSuite) TestGetCustomerByIDError() {
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(nil)
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
 
 	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
 
@@ -91,7 +100,7 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithConflictError() {
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	newCustomer := dto.Customer{
 		Name:  "Teste",
@@ -105,7 +114,7 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithConflictError() {
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(nil)
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
 
 	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
 
@@ -116,6 +125,12 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithConflictError() {
 
 	suite.Error(err)
 	suite.Equal(uint(0), newIdError)
+
+	var localError *responses.LocalError
+
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.DATABASE_CONFLICT_ERROR, localError.Code)
+	suite.NotEmpty(localError.Constraint)
 }
 
 func (suite *RepositoryTestSuite) TestCreateCustomerWithSignupError() {
@@ -126,7 +141,7 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithSignupError() {
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	newCustomer := dto.Customer{
 		Name:  "Teste",
@@ -140,7 +155,7 @@ func (suite *RepositoryTestSuite) TestCreateCustomerWithSignupError() {
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(&responses.NetworkError{
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(&responses.NetworkError{
 		Code: 419,
 	})
 
@@ -158,7 +173,7 @@ func (suite *RepositoryTestSuite) TestUpdateCustomerWithSuccess() {
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	newCustomer := dto.Customer{
 		Name:  "Teste",
@@ -172,7 +187,7 @@ func (suite *RepositoryTestSuite) TestUpdateCustomerWithSuccess() {
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(nil)
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
 
 	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
 
@@ -193,6 +208,62 @@ func (suite *RepositoryTestSuite) TestUpdateCustomerWithSuccess() {
 	customer, err := repo.GetCustomerById(suite.ctx, uint(1))
 	suite.NoError(err)
 	suite.Equal("Teste 2", customer.Name)
+	suite.Equal(uint(2), customer.Version)
+}
+
+func (suite *RepositoryTestSuite) TestUpdateCustomerWithOldVersion() {
+	// ensure that the postgres database is empty
+	var customers []model.Customer
+	result := suite.db.Connection.Find(&customers)
+	suite.NoError(result.Error)
+	suite.Empty(customers)
+
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	newCustomer := dto.Customer{
+		Name:  "Teste",
+		CPF:   "12312312312",
+		Email: "teste@teste.com",
+	}
+
+	newCustomerModel := &model.Customer{
+		Name:  "Teste",
+		CPF:   "12312312312",
+		Email: "teste@teste.com",
+	}
+
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
+
+	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
+	suite.NoError(err)
+
+	customer, err := repo.GetCustomerById(suite.ctx, newId)
+	suite.NoError(err)
+	suite.Equal(uint(1), customer.Version)
+
+	customer.Name = "Teste 2"
+	err = repo.UpdateCustomer(suite.ctx, customer)
+	suite.NoError(err)
+
+	// same version read before the first update
+	customer.Name = "Teste 3"
+	err = repo.UpdateCustomer(suite.ctx, customer)
+
+	var localError *responses.LocalError
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.PRECONDITION_FAILED_ERROR, localError.Code)
+
+	customer.ID = 999
+	err = repo.UpdateCustomer(suite.ctx, customer)
+
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.NOT_FOUND_ERROR, localError.Code)
+
+	customer, err = repo.GetCustomerById(suite.ctx, newId)
+	suite.NoError(err)
+	suite.Equal("Teste 2", customer.Name)
+	suite.Equal(uint(2), customer.Version)
 }
 
 func (suite *RepositoryTestSuite) TestGetCustomerByCPFWithSuccess() {
@@ -203,7 +274,7 @@ func (suite *RepositoryTestSuite) TestGetCustomerByCPFWithSuccess() {
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	// Product 1
 	newCustomer := dto.Customer{
@@ -218,7 +289,7 @@ func (suite *RepositoryTestSuite) TestGetCustomerByCPFWithSuccess() {
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(nil)
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
 
 	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
 
@@ -238,7 +309,7 @@ func (suite *RepositoryTestSuite) TestGetCustomerByCPFWithUnknownCustomerError()
 	suite.Empty(customers)
 
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
 	// Product 1
 	newCustomer := dto.Customer{
@@ -253,7 +324,7 @@ func (suite *RepositoryTestSuite) TestGetCustomerByCPFWithUnknownCustomerError()
 		Email: "teste@teste.com",
 	}
 
-	mockCognito.On("SignUp", newCustomerModel).Return(nil)
+	mockCognito.On("SignUp", suite.ctx, newCustomerModel).Return(nil)
 
 	newId, err := repo.CreateCustomer(suite.ctx, newCustomer)
 
@@ -267,9 +338,9 @@ func (suite *RepositoryTestSuite) TestGetCustomerByCPFWithUnknownCustomerError()
 
 func (suite *RepositoryTestSuite) TestLoginWithSuccess() {
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
-	mockCognito.On("Login", "123456").Return("TOKEN", nil)
+	mockCognito.On("Login", context.TODO(), "123456").Return("TOKEN", nil)
 
 	token, err := repo.Login(context.TODO(), "123456")
 
@@ -279,9 +350,9 @@ func (suite *RepositoryTestSuite) TestLoginWithSuccess() {
 
 func (suite *RepositoryTestSuite) TestLoginWithCognitoError() {
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
-	mockCognito.On("Login", "123456").Return("", &responses.NetworkError{
+	mockCognito.On("Login", context.TODO(), "123456").Return("", &responses.NetworkError{
 		Code: 401,
 	})
 
@@ -293,11 +364,11 @@ func (suite *RepositoryTestSuite) TestLoginWithCognitoError() {
 
 func (suite *RepositoryTestSuite) TestLoginUnknownWithSuccess() {
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
-	mockCognito.On("LoginUnknown").Return("TOKEN", nil)
+	mockCognito.On("LoginUnknown", context.TODO()).Return("TOKEN", nil)
 
-	token, err := repo.LoginUnknown()
+	token, err := repo.LoginUnknown(context.TODO())
 
 	suite.NoError(err)
 	suite.Equal("TOKEN", token)
@@ -305,14 +376,76 @@ func (suite *RepositoryTestSuite) TestLoginUnknownWithSuccess() {
 
 func (suite *RepositoryTestSuite) TestLoginUnknownWithCognitoError() {
 	mockCognito := new(MockCognitoRemoteDataSource)
-	repo := repositories.NewCustomerRepository(suite.db, mockCognito)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
 
-	mockCognito.On("LoginUnknown").Return("", &responses.NetworkError{
+	mockCognito.On("LoginUnknown", context.TODO()).Return("", &responses.NetworkError{
 		Code: 401,
 	})
 
-	token, err := repo.LoginUnknown()
+	token, err := repo.LoginUnknown(context.TODO())
 
 	suite.Error(err)
 	suite.Empty(token)
 }
+
+func (suite *RepositoryTestSuite) TestGetCustomersByIDsOrCPFsWithSuccess() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+
+	firstId, err := repo.CreateCustomer(suite.ctx, dto.Customer{
+		Name:  "Teste",
+		CPF:   "12312312312",
+		Email: "teste@teste.com",
+	})
+	suite.NoError(err)
+
+	secondId, err := repo.CreateCustomer(suite.ctx, dto.Customer{
+		Name:  "Teste 2",
+		CPF:   "32132132132",
+		Email: "teste2@teste.com",
+	})
+	suite.NoError(err)
+
+	customers, err := repo.GetCustomersByIDsOrCPFs(suite.ctx, []uint{firstId, 999}, []string{"32132132132", "99999999999"})
+	suite.NoError(err)
+	suite.Len(customers, 2)
+	suite.ElementsMatch([]uint{firstId, secondId}, []uint{customers[0].ID, customers[1].ID})
+
+	customers, err = repo.GetCustomersByIDsOrCPFs(suite.ctx, nil, []string{"12312312312"})
+	suite.NoError(err)
+	suite.Len(customers, 1)
+	suite.Equal(firstId, customers[0].ID)
+
+	customers, err = repo.GetCustomersByIDsOrCPFs(suite.ctx, nil, nil)
+	suite.NoError(err)
+	suite.Empty(customers)
+}
+
+func (suite *RepositoryTestSuite) TestValidateTokenWithSuccess() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("GetUsername", context.TODO(), "TOKEN").Return("unknown-user", nil)
+
+	tokenInfo, err := repo.ValidateToken(context.TODO(), "TOKEN")
+
+	suite.NoError(err)
+	suite.Equal("unknown-user", tokenInfo.Username)
+	suite.True(tokenInfo.Anonymous)
+}
+
+func (suite *RepositoryTestSuite) TestValidateTokenWithCognitoError() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("GetUsername", context.TODO(), "TOKEN").Return("", errors.New("NotAuthorizedException: Access Token has expired"))
+
+	tokenInfo, err := repo.ValidateToken(context.TODO(), "TOKEN")
+
+	var networkError *responses.NetworkError
+	suite.ErrorAs(err, &networkError)
+	suite.Equal(http.StatusUnauthorized, networkError.Code)
+	suite.Empty(tokenInfo)
+}
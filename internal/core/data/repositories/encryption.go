@@ -0,0 +1,308 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const _encryptionBackfillBatchSize = 500
+
+// encryptedTables are the tables with encrypted personal data. The versions are
+// encrypted again after a key rotation too, so the history stays readable
+var encryptedTables = []string{"user_admins", "customers", "record_versions"}
+
+// _outboxEventsTable has the encrypted payloads of the domain events. The ones
+// not published yet are encrypted again after a key rotation, so the relay can
+// still read them once the old master key is removed
+const _outboxEventsTable = "outbox_events"
+
+// encryptedColumns are the personal data columns stored encrypted, with the
+// column of their blind index
+var encryptedColumns = map[string]string{
+	"cpf":   "cpf_index",
+	"email": "email_index",
+}
+
+// encryptField returns the encrypted value and its blind index
+func encryptField(ctx context.Context, encryptor *encryption.Encryptor, value string) (string, string, error) {
+	encrypted, err := encryptor.Encrypt(ctx, value)
+
+	if err != nil {
+		return "", "", err
+	}
+
+	return encrypted, encryptor.BlindIndex(value), nil
+}
+
+// encryptValues returns the columns to update for the plaintext values. The
+// personal data ones are encrypted and have their blind index added
+func encryptValues(ctx context.Context, encryptor *encryption.Encryptor, values map[string]string) (map[string]any, error) {
+	columns := make(map[string]any, len(values)+len(encryptedColumns))
+
+	for column, value := range values {
+		indexColumn, ok := encryptedColumns[column]
+
+		if !ok {
+			columns[column] = value
+			continue
+		}
+
+		encrypted, index, err := encryptField(ctx, encryptor, value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		columns[column] = encrypted
+		columns[indexColumn] = index
+	}
+
+	return columns, nil
+}
+
+// blindIndexes returns the blind indexes of the values, in the same order
+func blindIndexes(encryptor *encryption.Encryptor, values []string) []string {
+	indexes := make([]string, 0, len(values))
+
+	for _, value := range values {
+		indexes = append(indexes, encryptor.BlindIndex(value))
+	}
+
+	return indexes
+}
+
+// EncryptionBackfill encrypts the personal data of the rows written before the
+// encryption, and encrypts again the values of a master key that is not the
+// active one anymore, after a key rotation
+type EncryptionBackfill struct {
+	db        *database.Database
+	encryptor *encryption.Encryptor
+}
+
+// encryptedPayload is the payload of an outbox event
+type encryptedPayload struct {
+	ID      uint64
+	Payload string
+}
+
+// encryptedRow is the personal data of a row of an encrypted table
+type encryptedRow struct {
+	ID         uint
+	CPF        string
+	Email      string
+	CPFIndex   *string
+	EmailIndex *string
+}
+
+func NewEncryptionBackfill(db *database.Database, encryptor *encryption.Encryptor) *EncryptionBackfill {
+	return &EncryptionBackfill{
+		db:        db,
+		encryptor: encryptor,
+	}
+}
+
+// Run backfills the tables in batches, each one in a transaction with its rows
+// locked, so a concurrent update is not overwritten. The soft deleted rows are
+// included. It returns the number of rows written by table and can run again
+// after a failure
+func (backfill *EncryptionBackfill) Run(ctx context.Context) (map[string]int, error) {
+	written := make(map[string]int, len(encryptedTables))
+
+	for _, table := range encryptedTables {
+		var err error
+
+		written[table], err = backfill.runTable(ctx, table)
+
+		if err != nil {
+			return written, fmt.Errorf("backfilling %v: %w", table, err)
+		}
+	}
+
+	var err error
+
+	written[_outboxEventsTable], err = backfill.runOutbox(ctx)
+
+	if err != nil {
+		return written, fmt.Errorf("backfilling %v: %w", _outboxEventsTable, err)
+	}
+
+	return written, nil
+}
+
+// Pending returns the number of rows not encrypted yet. The rows of an old
+// master key are not counted, since they can still be decrypted
+func (backfill *EncryptionBackfill) Pending(ctx context.Context) (int64, error) {
+	var pending int64
+
+	for _, table := range encryptedTables {
+		var count int64
+
+		err := backfill.db.Connection.WithContext(ctx).
+			Table(table).
+			Where("cpf_index IS NULL OR email_index IS NULL").
+			Count(&count).
+			Error
+
+		if err != nil {
+			return 0, err
+		}
+
+		pending += count
+	}
+
+	return pending, nil
+}
+
+func (backfill *EncryptionBackfill) runTable(ctx context.Context, table string) (int, error) {
+	var lastID uint
+	written := 0
+
+	for {
+		var rows []encryptedRow
+		batchWritten := 0
+
+		err := backfill.db.Connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			err := tx.Table(table).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "cpf", "email", "cpf_index", "email_index").
+				Where("id > ?", lastID).
+				Order("id").
+				Limit(_encryptionBackfillBatchSize).
+				Find(&rows).
+				Error
+
+			if err != nil {
+				return err
+			}
+
+			for _, row := range rows {
+				if !backfill.needsBackfill(row) {
+					continue
+				}
+
+				values, err := backfill.encryptRow(ctx, row)
+
+				if err != nil {
+					return fmt.Errorf("row %v: %w", row.ID, err)
+				}
+
+				err = tx.Table(table).Where("id = ?", row.ID).UpdateColumns(values).Error
+
+				if err != nil {
+					return fmt.Errorf("row %v: %w", row.ID, err)
+				}
+
+				batchWritten++
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return written, err
+		}
+
+		written += batchWritten
+
+		if len(rows) < _encryptionBackfillBatchSize {
+			return written, nil
+		}
+
+		lastID = rows[len(rows)-1].ID
+	}
+}
+
+// runOutbox encrypts again the payloads of the pending events written with a
+// master key that is not the active one. The published events are never read
+// again, so they are left as they are until they are purged
+func (backfill *EncryptionBackfill) runOutbox(ctx context.Context) (int, error) {
+	var lastID uint64
+	written := 0
+
+	for {
+		var events []encryptedPayload
+		batchWritten := 0
+
+		err := backfill.db.Connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			err := tx.Table(_outboxEventsTable).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "payload").
+				Where("published_at IS NULL AND id > ?", lastID).
+				Order("id").
+				Limit(_encryptionBackfillBatchSize).
+				Find(&events).
+				Error
+
+			if err != nil {
+				return err
+			}
+
+			for _, event := range events {
+				if !backfill.encryptor.NeedsEncryption(event.Payload) {
+					continue
+				}
+
+				data, err := backfill.encryptor.Decrypt(ctx, event.Payload)
+
+				if err != nil {
+					return fmt.Errorf("event %v: %w", event.ID, err)
+				}
+
+				payload, err := backfill.encryptor.Encrypt(ctx, data)
+
+				if err != nil {
+					return fmt.Errorf("event %v: %w", event.ID, err)
+				}
+
+				err = tx.Table(_outboxEventsTable).Where("id = ?", event.ID).UpdateColumn("payload", payload).Error
+
+				if err != nil {
+					return fmt.Errorf("event %v: %w", event.ID, err)
+				}
+
+				batchWritten++
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return written, err
+		}
+
+		written += batchWritten
+
+		if len(events) < _encryptionBackfillBatchSize {
+			return written, nil
+		}
+
+		lastID = events[len(events)-1].ID
+	}
+}
+
+func (backfill *EncryptionBackfill) needsBackfill(row encryptedRow) bool {
+	return row.CPFIndex == nil ||
+		row.EmailIndex == nil ||
+		backfill.encryptor.NeedsEncryption(row.CPF) ||
+		backfill.encryptor.NeedsEncryption(row.Email)
+}
+
+func (backfill *EncryptionBackfill) encryptRow(ctx context.Context, row encryptedRow) (map[string]any, error) {
+	fields := auditedFields{CPF: row.CPF, Email: row.Email}
+
+	err := fields.decrypt(ctx, backfill.encryptor)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return encryptValues(ctx, backfill.encryptor, map[string]string{
+		"cpf":   fields.CPF,
+		"email": fields.Email,
+	})
+}
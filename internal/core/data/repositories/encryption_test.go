@@ -0,0 +1,189 @@
+package repositories_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+)
+
+const (
+	selectBackfillUsersQuery     = "SELECT `id`,`cpf`,`email`,`cpf_index`,`email_index` FROM `user_admins` WHERE id > ? ORDER BY id LIMIT ? FOR UPDATE"
+	selectBackfillCustomersQuery = "SELECT `id`,`cpf`,`email`,`cpf_index`,`email_index` FROM `customers` WHERE id > ? ORDER BY id LIMIT ? FOR UPDATE"
+	updateBackfillUserQuery      = "UPDATE `user_admins` SET `cpf`=?,`cpf_index`=?,`email`=?,`email_index`=? WHERE id = ?"
+	countPendingUsersQuery       = "SELECT count(*) FROM `user_admins` WHERE cpf_index IS NULL OR email_index IS NULL"
+	countPendingCustomersQuery   = "SELECT count(*) FROM `customers` WHERE cpf_index IS NULL OR email_index IS NULL"
+	selectBackfillVersionsQuery  = "SELECT `id`,`cpf`,`email`,`cpf_index`,`email_index` FROM `record_versions` WHERE id > ? ORDER BY id LIMIT ? FOR UPDATE"
+	updateBackfillVersionQuery   = "UPDATE `record_versions` SET `cpf`=?,`cpf_index`=?,`email`=?,`email_index`=? WHERE id = ?"
+	countPendingVersionsQuery    = "SELECT count(*) FROM `record_versions` WHERE cpf_index IS NULL OR email_index IS NULL"
+	selectBackfillOutboxQuery    = "SELECT `id`,`payload` FROM `outbox_events` WHERE published_at IS NULL AND id > ? ORDER BY id LIMIT ? FOR UPDATE"
+	updateBackfillOutboxQuery    = "UPDATE `outbox_events` SET `payload`=? WHERE id = ?"
+)
+
+var backfillColumns = []string{"id", "cpf", "email", "cpf_index", "email_index"}
+
+func TestEncryptionBackfillLocal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got plaintext rows encrypted when running backfill local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		alreadyEncrypted, err := testEncryptor.Encrypt(context.TODO(), "22222222222")
+		assert.NoError(t, err)
+
+		alreadyEncryptedEmail, err := testEncryptor.Encrypt(context.TODO(), "done@teste.com")
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectBackfillUsersQuery).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows(backfillColumns).
+				AddRow(1, "11111111111", "user@teste.com", nil, nil).
+				AddRow(2, alreadyEncrypted, alreadyEncryptedEmail, testEncryptor.BlindIndex("22222222222"), testEncryptor.BlindIndex("done@teste.com")))
+		sqlMock.ExpectExec(updateBackfillUserQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("11111111111"), encrypted{}, testEncryptor.BlindIndex("user@teste.com"), 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectBackfillCustomersQuery).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows(backfillColumns))
+		sqlMock.ExpectCommit()
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectBackfillVersionsQuery).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows(backfillColumns).AddRow(7, "11111111111", "user@teste.com", nil, nil))
+		sqlMock.ExpectExec(updateBackfillVersionQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("11111111111"), encrypted{}, testEncryptor.BlindIndex("user@teste.com"), 7).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectBackfillOutboxQuery).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}))
+		sqlMock.ExpectCommit()
+
+		backfill := repositories.NewEncryptionBackfill(&database.Database{Connection: db}, testEncryptor)
+
+		written, err := backfill.Run(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]int{"user_admins": 1, "customers": 0, "record_versions": 1, "outbox_events": 0}, written)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("got pending outbox payloads of old key encrypted again when running backfill local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		oldProvider, err := encryption.NewLocalKeyProviderFromKeys("old", map[string][]byte{
+			"old": bytes.Repeat([]byte("o"), 32),
+		})
+		assert.NoError(t, err)
+
+		oldEncryptor, err := encryption.New(oldProvider, bytes.Repeat([]byte("i"), 32))
+		assert.NoError(t, err)
+
+		rotatedProvider, err := encryption.NewLocalKeyProviderFromKeys("test", map[string][]byte{
+			"test": bytes.Repeat([]byte("k"), 32),
+			"old":  bytes.Repeat([]byte("o"), 32),
+		})
+		assert.NoError(t, err)
+
+		rotatedEncryptor, err := encryption.New(rotatedProvider, bytes.Repeat([]byte("i"), 32))
+		assert.NoError(t, err)
+
+		oldPayload, err := oldEncryptor.Encrypt(context.TODO(), `{"cpf":"11111111111"}`)
+		assert.NoError(t, err)
+
+		currentPayload, err := rotatedEncryptor.Encrypt(context.TODO(), `{"cpf":"22222222222"}`)
+		assert.NoError(t, err)
+
+		for _, query := range []string{selectBackfillUsersQuery, selectBackfillCustomersQuery, selectBackfillVersionsQuery} {
+			sqlMock.ExpectBegin()
+			sqlMock.ExpectQuery(query).
+				WithArgs(0, 500).
+				WillReturnRows(sqlmock.NewRows(backfillColumns))
+			sqlMock.ExpectCommit()
+		}
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectBackfillOutboxQuery).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "payload"}).
+				AddRow(3, oldPayload).
+				AddRow(4, currentPayload))
+		sqlMock.ExpectExec(updateBackfillOutboxQuery).
+			WithArgs(encrypted{}, 3).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		sqlMock.ExpectCommit()
+
+		backfill := repositories.NewEncryptionBackfill(&database.Database{Connection: db}, rotatedEncryptor)
+
+		written, err := backfill.Run(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, written["outbox_events"])
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("got error and rollback when update fails running backfill local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectBackfillUsersQuery).
+			WithArgs(0, 500).
+			WillReturnRows(sqlmock.NewRows(backfillColumns).AddRow(1, "11111111111", "user@teste.com", nil, nil))
+		sqlMock.ExpectExec(updateBackfillUserQuery).
+			WillReturnError(assert.AnError)
+		sqlMock.ExpectRollback()
+
+		backfill := repositories.NewEncryptionBackfill(&database.Database{Connection: db}, testEncryptor)
+
+		written, err := backfill.Run(context.TODO())
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.ErrorContains(t, err, "backfilling user_admins: row 1")
+		assert.Equal(t, 0, written["user_admins"])
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("got pending rows when calling Pending local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		sqlMock.ExpectQuery(countPendingUsersQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		sqlMock.ExpectQuery(countPendingCustomersQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+		sqlMock.ExpectQuery(countPendingVersionsQuery).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+		backfill := repositories.NewEncryptionBackfill(&database.Database{Connection: db}, testEncryptor)
+
+		pending, err := backfill.Pending(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), pending)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recordRow is the state of a customer or an admin user row that is kept in
+// its history
+type recordRow struct {
+	Version    uint
+	Name       string
+	CPF        string
+	Email      string
+	CPFIndex   *string
+	EmailIndex *string
+	DeletedAt  *time.Time
+}
+
+// appendRecordVersion copies the row, as it is after a change, to a new version
+// of its history, and returns that version. It must be called with the
+// transaction of the change, so both are committed together
+func appendRecordVersion(ctx context.Context, tx *gorm.DB, entity any, targetType string, action string, id uint) (uint, error) {
+	var row recordRow
+
+	err := tx.Model(entity).
+		Unscoped().
+		Select("version", "name", "cpf", "email", "cpf_index", "email_index", "deleted_at").
+		Where("id = ?", id).
+		Take(&row).
+		Error
+
+	if err != nil {
+		return 0, responses.GetDatabaseError(err)
+	}
+
+	metadata := audit.FromContext(ctx)
+
+	err = tx.Create(&model.RecordVersion{
+		TargetType: targetType,
+		TargetID:   id,
+		Version:    row.Version,
+		Action:     action,
+		Name:       row.Name,
+		CPF:        row.CPF,
+		Email:      row.Email,
+		CPFIndex:   row.CPFIndex,
+		EmailIndex: row.EmailIndex,
+		Deleted:    row.DeletedAt != nil,
+		Actor:      metadata.Actor,
+		RequestID:  metadata.RequestID,
+		// UTC, so SQLite compares the stored text in the as of lookups
+		ValidFrom: time.Now().UTC().Truncate(time.Microsecond),
+	}).Error
+
+	if err != nil {
+		return 0, responses.GetDatabaseError(err)
+	}
+
+	return row.Version, nil
+}
+
+// listRecordVersions returns the versions of a record, from the newest. A
+// record without versions is not found
+func listRecordVersions(ctx context.Context, db *gorm.DB, encryptor *encryption.Encryptor, targetType string, id uint) ([]dto.RecordVersion, error) {
+	var versionEntities []model.RecordVersion
+
+	err := db.Where("target_type = ? AND target_id = ?", targetType, id).
+		Order("version DESC").
+		Find(&versionEntities).
+		Error
+
+	if err != nil {
+		return nil, responses.GetDatabaseError(err)
+	}
+
+	if len(versionEntities) == 0 {
+		return nil, responses.GetDatabaseError(gorm.ErrRecordNotFound)
+	}
+
+	versions := make([]dto.RecordVersion, 0, len(versionEntities))
+
+	for _, versionEntity := range versionEntities {
+		version, err := populateRecordVersion(ctx, encryptor, versionEntity)
+
+		if err != nil {
+			return nil, err
+		}
+
+		versions = append(versions, version)
+	}
+
+	return versions, nil
+}
+
+// getRecordVersionAt returns the version of a record that was the current one
+// at the given time. A record created after it is not found
+func getRecordVersionAt(ctx context.Context, db *gorm.DB, encryptor *encryption.Encryptor, targetType string, id uint, at time.Time) (dto.RecordVersion, error) {
+	var versionEntity model.RecordVersion
+
+	err := db.Where("target_type = ? AND target_id = ? AND valid_from <= ?", targetType, id, at.UTC()).
+		Order("version DESC").
+		Take(&versionEntity).
+		Error
+
+	if err != nil {
+		return dto.RecordVersion{}, responses.GetDatabaseError(err)
+	}
+
+	return populateRecordVersion(ctx, encryptor, versionEntity)
+}
+
+// setDeleted soft deletes or restores a row as a new version, like
+// updateAudited. The row is locked and identity runs with its CPF before the
+// version and the audit record are appended: a failed call rolls the change
+// back, and a slow identity provider holds only the lock of this row, not the
+// audit chain head that every write waits for. Deleting a deleted row is a not
+// found error and restoring a row that is not deleted is a conflict
+func setDeleted(
+	ctx context.Context,
+	db *gorm.DB,
+	encryptor *encryption.Encryptor,
+	entity any,
+	targetType string,
+	id uint,
+	deleted bool,
+	identity func(ctx context.Context, cpf string) error,
+) error {
+	action := audit.ActionRestore
+	var deletedAt any
+
+	if deleted {
+		action = audit.ActionDelete
+		deletedAt = time.Now().UTC()
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var row recordRow
+
+		err := tx.Model(entity).
+			Unscoped().
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("name", "cpf", "email", "deleted_at").
+			Where("id = ?", id).
+			Take(&row).
+			Error
+
+		if err != nil {
+			return responses.GetDatabaseError(err)
+		}
+
+		switch {
+		case deleted && row.DeletedAt != nil:
+			return responses.GetDatabaseError(gorm.ErrRecordNotFound)
+		case !deleted && row.DeletedAt == nil:
+			return &responses.LocalError{
+				Code:    responses.DATABASE_CONFLICT_ERROR,
+				Message: "record is not deleted",
+			}
+		}
+
+		fields := auditedFields{
+			Name:  row.Name,
+			CPF:   row.CPF,
+			Email: row.Email,
+		}
+
+		err = fields.decrypt(ctx, encryptor)
+
+		if err != nil {
+			return err
+		}
+
+		err = identity(ctx, fields.CPF)
+
+		if err != nil {
+			return responses.GetCognitoError(err)
+		}
+
+		err = tx.Model(entity).
+			Unscoped().
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"deleted_at": deletedAt,
+				"version":    gorm.Expr("version + 1"),
+			}).
+			Error
+
+		if err != nil {
+			return responses.GetDatabaseError(err)
+		}
+
+		version, err := appendRecordVersion(ctx, tx, entity, targetType, action, id)
+
+		if err != nil {
+			return err
+		}
+
+		err = appendAuditLog(ctx, tx, action, targetType, id, audit.Diff(
+			map[string]string{"deleted": strconv.FormatBool(!deleted)},
+			map[string]string{"deleted": strconv.FormatBool(deleted)},
+		))
+
+		if err != nil {
+			return err
+		}
+
+		return appendChangeEvent(ctx, tx, encryptor, targetType, action, id, version, fields, nil)
+	})
+}
+
+func populateRecordVersion(ctx context.Context, encryptor *encryption.Encryptor, versionEntity model.RecordVersion) (dto.RecordVersion, error) {
+	fields := auditedFields{CPF: versionEntity.CPF, Email: versionEntity.Email}
+
+	err := fields.decrypt(ctx, encryptor)
+
+	if err != nil {
+		return dto.RecordVersion{}, err
+	}
+
+	return dto.RecordVersion{
+		Version:   versionEntity.Version,
+		Action:    versionEntity.Action,
+		Name:      versionEntity.Name,
+		CPF:       fields.CPF,
+		Email:     fields.Email,
+		Deleted:   versionEntity.Deleted,
+		Actor:     versionEntity.Actor,
+		RequestID: versionEntity.RequestID,
+		ValidFrom: versionEntity.ValidFrom,
+	}, nil
+}
@@ -0,0 +1,203 @@
+package repositories_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+const selectDeletedCustomerQuery = "SELECT `name`,`cpf`,`email`,`deleted_at` FROM `customers` WHERE id = ? LIMIT ? FOR UPDATE"
+
+func TestSetDeletedLocal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got identity provider called before audit chain lock when calling DeleteCustomer local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		cpf, err := testEncryptor.Encrypt(context.TODO(), "12312312312")
+		assert.NoError(t, err)
+
+		// the identity provider fails after the row is locked, so nothing else
+		// is written or locked before the rollback
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectDeletedCustomerQuery).
+			WithArgs(uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "cpf", "email", "deleted_at"}).AddRow("Teste", cpf, "", nil))
+		sqlMock.ExpectRollback()
+
+		mockCognito := new(MockCognitoRemoteDataSource)
+		mockCognito.On("DisableUser", mock.Anything, "12312312312").Return(&responses.NetworkError{Code: 503})
+
+		repo := repositories.NewCustomerRepository(&database.Database{Connection: db}, mockCognito, testEncryptor)
+
+		err = repo.DeleteCustomer(context.TODO(), 1)
+
+		assert.Error(t, err)
+		mockCognito.AssertExpectations(t)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
+
+func (suite *RepositoryTestSuite) TestDeleteAndRestoreCustomer() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+	mockCognito.On("DisableUser", suite.ctx, "12312312312").Return(nil).Once()
+	mockCognito.On("EnableUser", suite.ctx, "12312312312").Return(nil).Once()
+
+	id, err := repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	err = repo.DeleteCustomer(suite.ctx, id)
+	suite.NoError(err)
+
+	var localError *responses.LocalError
+
+	_, err = repo.GetCustomerByCPF(suite.ctx, "12312312312")
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.NOT_FOUND_ERROR, localError.Code)
+
+	err = repo.DeleteCustomer(suite.ctx, id)
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.NOT_FOUND_ERROR, localError.Code)
+
+	err = repo.RestoreCustomer(suite.ctx, id)
+	suite.NoError(err)
+
+	err = repo.RestoreCustomer(suite.ctx, id)
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.DATABASE_CONFLICT_ERROR, localError.Code)
+
+	customer, err := repo.GetCustomerByCPF(suite.ctx, "12312312312")
+	suite.NoError(err)
+	suite.Equal(uint(3), customer.Version)
+
+	history, err := repo.GetCustomerHistory(suite.ctx, id)
+	suite.NoError(err)
+	suite.Len(history, 3)
+
+	suite.Equal([]string{"restore", "delete", "create"}, []string{history[0].Action, history[1].Action, history[2].Action})
+	suite.Equal([]uint{3, 2, 1}, []uint{history[0].Version, history[1].Version, history[2].Version})
+	suite.Equal([]bool{false, true, false}, []bool{history[0].Deleted, history[1].Deleted, history[2].Deleted})
+	suite.Equal("12312312312", history[1].CPF)
+	suite.Equal("teste@teste.com", history[1].Email)
+	suite.Equal("anonymous", history[1].Actor)
+
+	mockCognito.AssertExpectations(suite.T())
+}
+
+func (suite *RepositoryTestSuite) TestDeleteCustomerRolledBackWithCognitoError() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+	mockCognito.On("DisableUser", suite.ctx, "12312312312").Return(&responses.NetworkError{Code: 503})
+
+	id, err := repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	err = repo.DeleteCustomer(suite.ctx, id)
+	suite.Error(err)
+
+	customer, err := repo.GetCustomerById(suite.ctx, id)
+	suite.NoError(err)
+	suite.Equal(uint(1), customer.Version)
+
+	history, err := repo.GetCustomerHistory(suite.ctx, id)
+	suite.NoError(err)
+	suite.Len(history, 1)
+
+	var auditLogs []model.AuditLog
+	suite.NoError(suite.db.Connection.Find(&auditLogs).Error)
+	suite.Len(auditLogs, 1)
+}
+
+func (suite *RepositoryTestSuite) TestGetCustomerAsOf() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+
+	beforeCreate := time.Now()
+
+	id, err := repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	afterCreate := time.Now()
+	name := "Teste 2"
+
+	err = repo.PatchCustomer(suite.ctx, dto.CustomerPatch{ID: id, Name: &name})
+	suite.NoError(err)
+
+	var localError *responses.LocalError
+
+	_, err = repo.GetCustomerAsOf(suite.ctx, id, beforeCreate)
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.NOT_FOUND_ERROR, localError.Code)
+
+	version, err := repo.GetCustomerAsOf(suite.ctx, id, afterCreate)
+	suite.NoError(err)
+	suite.Equal("Teste", version.Name)
+	suite.Equal(uint(1), version.Version)
+
+	version, err = repo.GetCustomerAsOf(suite.ctx, id, time.Now())
+	suite.NoError(err)
+	suite.Equal("Teste 2", version.Name)
+	suite.Equal("patch", version.Action)
+
+	_, err = repo.GetCustomerHistory(suite.ctx, 999)
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.NOT_FOUND_ERROR, localError.Code)
+}
+
+func (suite *RepositoryTestSuite) TestDeleteAndRestoreUser() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewUserAdminRepository(suite.db, mockCognito, testEncryptor)
+
+	mockCognito.On("SignUpAdmin", suite.ctx, mock.Anything).Return(nil)
+	mockCognito.On("DisableUser", suite.ctx, "12312312312").Return(nil).Once()
+	mockCognito.On("EnableUser", suite.ctx, "12312312312").Return(nil).Once()
+
+	id, err := repo.CreateUser(suite.ctx, dto.UserAdmin{Name: "Admin", CPF: "12312312312", Email: "admin@teste.com"})
+	suite.NoError(err)
+
+	err = repo.DeleteUser(suite.ctx, id)
+	suite.NoError(err)
+
+	var localError *responses.LocalError
+
+	_, err = repo.GetUserById(suite.ctx, id)
+	suite.ErrorAs(err, &localError)
+	suite.Equal(responses.NOT_FOUND_ERROR, localError.Code)
+
+	deleted, err := repo.GetUserAsOf(suite.ctx, id, time.Now())
+	suite.NoError(err)
+	suite.True(deleted.Deleted)
+
+	err = repo.RestoreUser(suite.ctx, id)
+	suite.NoError(err)
+
+	user, err := repo.GetUserById(suite.ctx, id)
+	suite.NoError(err)
+	suite.Equal("Admin", user.Name)
+
+	history, err := repo.GetUserHistory(suite.ctx, id)
+	suite.NoError(err)
+	suite.Len(history, 3)
+
+	mockCognito.AssertExpectations(suite.T())
+}
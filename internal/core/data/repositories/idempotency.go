@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/idempotency"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	db *database.Database
+}
+
+func NewIdempotencyRepository(db *database.Database) idempotency.Store {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Acquire inserts the in progress record or takes over an expired one in a
+// single `INSERT ... ON CONFLICT DO UPDATE ... WHERE expires_at < now`, so only
+// one of the concurrent requests with the same key acquires it. Taking over a
+// record changes its owner, so the request that lost the lease can no longer
+// complete or release it
+func (repository *IdempotencyRepository) Acquire(ctx context.Context, key string, fingerprint string, leaseUntil time.Time) (idempotency.Record, bool, error) {
+	now := time.Now()
+
+	owner, err := idempotency.NewOwner()
+
+	if err != nil {
+		return idempotency.Record{}, false, err
+	}
+
+	entity := &model.IdempotencyKey{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Owner:       owner,
+		ExpiresAt:   leaseUntil,
+		CreatedAt:   now,
+	}
+
+	result := repository.db.Connection.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "idempotency_key"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"fingerprint":  fingerprint,
+				"owner":        owner,
+				"status_code":  0,
+				"content_type": "",
+				"body":         nil,
+				"expires_at":   leaseUntil,
+				"created_at":   now,
+			}),
+			Where: clause.Where{Exprs: []clause.Expression{
+				clause.Lt{Column: clause.Column{Table: "idempotency_keys", Name: "expires_at"}, Value: now},
+			}},
+		}).
+		Create(entity)
+
+	if result.Error != nil {
+		return idempotency.Record{}, false, responses.GetDatabaseError(result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		return idempotency.Record{Key: key, Fingerprint: fingerprint, Owner: owner}, true, nil
+	}
+
+	var current model.IdempotencyKey
+
+	err = repository.db.Connection.WithContext(ctx).
+		Where("idempotency_key = ?", key).
+		First(&current).
+		Error
+
+	// released between the insert and the select. The caller tries again
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return idempotency.Record{Key: key}, false, nil
+	}
+
+	if err != nil {
+		return idempotency.Record{}, false, responses.GetDatabaseError(err)
+	}
+
+	return idempotency.Record{
+		Key:         current.Key,
+		Fingerprint: current.Fingerprint,
+		StatusCode:  current.StatusCode,
+		ContentType: current.ContentType,
+		Body:        current.Body,
+	}, false, nil
+}
+
+func (repository *IdempotencyRepository) Complete(ctx context.Context, record idempotency.Record, expiresAt time.Time) error {
+	result := repository.db.Connection.WithContext(ctx).
+		Model(&model.IdempotencyKey{}).
+		Where("idempotency_key = ? AND fingerprint = ? AND owner = ?", record.Key, record.Fingerprint, record.Owner).
+		Updates(map[string]any{
+			"status_code":  record.StatusCode,
+			"content_type": record.ContentType,
+			"body":         record.Body,
+			"expires_at":   expiresAt,
+		})
+
+	if result.Error != nil {
+		return responses.GetDatabaseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return idempotency.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (repository *IdempotencyRepository) Release(ctx context.Context, record idempotency.Record) error {
+	result := repository.db.Connection.WithContext(ctx).
+		Where("idempotency_key = ? AND owner = ? AND status_code = 0", record.Key, record.Owner).
+		Delete(&model.IdempotencyKey{})
+
+	if result.Error != nil {
+		return responses.GetDatabaseError(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return idempotency.ErrLeaseLost
+	}
+
+	return nil
+}
+
+func (repository *IdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := repository.db.Connection.WithContext(ctx).
+		Where("expires_at < ?", now).
+		Delete(&model.IdempotencyKey{})
+
+	if result.Error != nil {
+		return 0, responses.GetDatabaseError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,99 @@
+package repositories_test
+
+import (
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/pkg/idempotency"
+)
+
+func (suite *RepositoryTestSuite) TestIdempotencyAcquireOnlyOnce() {
+	repo := repositories.NewIdempotencyRepository(suite.db)
+
+	record, acquired, err := repo.Acquire(suite.ctx, "KEY-1", "FINGERPRINT", time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.True(acquired)
+
+	current, acquired, err := repo.Acquire(suite.ctx, "KEY-1", "FINGERPRINT", time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.False(acquired)
+	suite.False(current.Completed())
+
+	record.StatusCode = 200
+	record.ContentType = "application/json"
+	record.Body = []byte(`{"id":1}`)
+
+	err = repo.Complete(suite.ctx, record, time.Now().Add(time.Hour))
+	suite.NoError(err)
+
+	current, acquired, err = repo.Acquire(suite.ctx, "KEY-1", "FINGERPRINT", time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.False(acquired)
+	suite.Equal(idempotency.Record{
+		Key:         "KEY-1",
+		Fingerprint: "FINGERPRINT",
+		StatusCode:  200,
+		ContentType: "application/json",
+		Body:        []byte(`{"id":1}`),
+	}, current)
+}
+
+func (suite *RepositoryTestSuite) TestIdempotencyAcquireExpiredAndReleased() {
+	repo := repositories.NewIdempotencyRepository(suite.db)
+
+	_, acquired, err := repo.Acquire(suite.ctx, "KEY-2", "FINGERPRINT", time.Now().Add(-time.Second))
+	suite.NoError(err)
+	suite.True(acquired)
+
+	// the lease has expired, like when the instance crashed
+	record, acquired, err := repo.Acquire(suite.ctx, "KEY-2", "OTHER", time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.True(acquired)
+
+	err = repo.Release(suite.ctx, record)
+	suite.NoError(err)
+
+	_, acquired, err = repo.Acquire(suite.ctx, "KEY-2", "FINGERPRINT", time.Now().Add(-time.Second))
+	suite.NoError(err)
+	suite.True(acquired)
+
+	deleted, err := repo.DeleteExpired(suite.ctx, time.Now())
+	suite.NoError(err)
+	suite.Equal(int64(1), deleted)
+}
+
+func (suite *RepositoryTestSuite) TestIdempotencyLeaseLost() {
+	repo := repositories.NewIdempotencyRepository(suite.db)
+
+	record, acquired, err := repo.Acquire(suite.ctx, "KEY-3", "FINGERPRINT", time.Now().Add(-time.Second))
+	suite.NoError(err)
+	suite.True(acquired)
+
+	// another request takes the key over after the lease has expired
+	current, acquired, err := repo.Acquire(suite.ctx, "KEY-3", "FINGERPRINT", time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.True(acquired)
+	suite.NotEqual(record.Owner, current.Owner)
+
+	record.StatusCode = 200
+	record.ContentType = "application/json"
+	record.Body = []byte(`{"id":1}`)
+
+	err = repo.Complete(suite.ctx, record, time.Now().Add(time.Hour))
+	suite.ErrorIs(err, idempotency.ErrLeaseLost)
+
+	err = repo.Release(suite.ctx, record)
+	suite.ErrorIs(err, idempotency.ErrLeaseLost)
+
+	stored, acquired, err := repo.Acquire(suite.ctx, "KEY-3", "FINGERPRINT", time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.False(acquired)
+	suite.False(stored.Completed())
+
+	current.StatusCode = 201
+	err = repo.Complete(suite.ctx, current, time.Now().Add(time.Hour))
+	suite.NoError(err)
+
+	err = repo.Release(suite.ctx, current)
+	suite.ErrorIs(err, idempotency.ErrLeaseLost)
+}
@@ -1,7 +1,10 @@
 package repositories_test
 
 import (
+	"bytes"
 	"context"
+	"database/sql/driver"
+	"path/filepath"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
@@ -11,19 +14,51 @@ import (
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
 	"gorm.io/driver/mysql"
 	pg "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+// testEncryptor encrypts with fixed keys, so the blind indexes of the queries
+// are known
+var testEncryptor = newTestEncryptor()
+
+func newTestEncryptor() *encryption.Encryptor {
+	provider, err := encryption.NewLocalKeyProviderFromKeys("test", map[string][]byte{
+		"test": bytes.Repeat([]byte("k"), 32),
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	encryptor, err := encryption.New(provider, bytes.Repeat([]byte("i"), 32))
+
+	if err != nil {
+		panic(err)
+	}
+
+	return encryptor
+}
+
+// encrypted matches a value written by the Encryptor
+type encrypted struct{}
+
+func (encrypted) Match(value driver.Value) bool {
+	text, ok := value.(string)
+	return ok && encryption.IsEncrypted(text)
+}
+
 type MockCognitoRemoteDataSource struct {
 	mock.Mock
 }
 
-func (mock *MockCognitoRemoteDataSource) SignUp(user *model.Customer) error {
-	args := mock.Called(user)
+func (mock *MockCognitoRemoteDataSource) SignUp(ctx context.Context, user *model.Customer) error {
+	args := mock.Called(ctx, user)
 	err := args.Error(0)
 
 	if err != nil {
@@ -33,8 +68,8 @@ func (mock *MockCognitoRemoteDataSource) SignUp(user *model.Customer) error {
 	return nil
 }
 
-func (mock *MockCognitoRemoteDataSource) SignUpAdmin(user *model.UserAdmin) error {
-	args := mock.Called(user)
+func (mock *MockCognitoRemoteDataSource) SignUpAdmin(ctx context.Context, user *model.UserAdmin) error {
+	args := mock.Called(ctx, user)
 	err := args.Error(0)
 
 	if err != nil {
@@ -44,8 +79,19 @@ func (mock *MockCognitoRemoteDataSource) SignUpAdmin(user *model.UserAdmin) erro
 	return nil
 }
 
-func (mock *MockCognitoRemoteDataSource) Login(cpf string) (string, error) {
-	args := mock.Called(cpf)
+func (mock *MockCognitoRemoteDataSource) Login(ctx context.Context, cpf string) (string, error) {
+	args := mock.Called(ctx, cpf)
+	err := args.Error(1)
+
+	if err != nil {
+		return "", err
+	}
+
+	return args.Get(0).(string), nil
+}
+
+func (mock *MockCognitoRemoteDataSource) LoginUnknown(ctx context.Context) (string, error) {
+	args := mock.Called(ctx)
 	err := args.Error(1)
 
 	if err != nil {
@@ -55,8 +101,8 @@ func (mock *MockCognitoRemoteDataSource) Login(cpf string) (string, error) {
 	return args.Get(0).(string), nil
 }
 
-func (mock *MockCognitoRemoteDataSource) LoginUnknown() (string, error) {
-	args := mock.Called()
+func (mock *MockCognitoRemoteDataSource) GetUsername(ctx context.Context, accessToken string) (string, error) {
+	args := mock.Called(ctx, accessToken)
 	err := args.Error(1)
 
 	if err != nil {
@@ -66,9 +112,170 @@ func (mock *MockCognitoRemoteDataSource) LoginUnknown() (string, error) {
 	return args.Get(0).(string), nil
 }
 
+func (mock *MockCognitoRemoteDataSource) DisableUser(ctx context.Context, cpf string) error {
+	args := mock.Called(ctx, cpf)
+
+	return args.Error(0)
+}
+
+func (mock *MockCognitoRemoteDataSource) EnableUser(ctx context.Context, cpf string) error {
+	args := mock.Called(ctx, cpf)
+
+	return args.Error(0)
+}
+
+func (mock *MockCognitoRemoteDataSource) Ping(ctx context.Context) error {
+	args := mock.Called(ctx)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type MockCustomerRepository struct {
+	mock.Mock
+}
+
+func (mock *MockCustomerRepository) CreateCustomer(ctx context.Context, customer dto.Customer) (uint, error) {
+	args := mock.Called(ctx, customer)
+	err := args.Error(1)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return args.Get(0).(uint), nil
+}
+
+func (mock *MockCustomerRepository) UpdateCustomer(ctx context.Context, customer dto.Customer) error {
+	args := mock.Called(ctx, customer)
+	return args.Error(0)
+}
+
+func (mock *MockCustomerRepository) PatchCustomer(ctx context.Context, patch dto.CustomerPatch) error {
+	args := mock.Called(ctx, patch)
+	return args.Error(0)
+}
+
+func (mock *MockCustomerRepository) GetCustomerById(ctx context.Context, id uint) (dto.Customer, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	return args.Get(0).(dto.Customer), nil
+}
+
+func (mock *MockCustomerRepository) GetCustomerByCPF(ctx context.Context, cpf string) (dto.Customer, error) {
+	args := mock.Called(ctx, cpf)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	return args.Get(0).(dto.Customer), nil
+}
+
+func (mock *MockCustomerRepository) GetCustomersByIDsOrCPFs(ctx context.Context, ids []uint, cpfs []string) ([]dto.Customer, error) {
+	args := mock.Called(ctx, ids, cpfs)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.Customer), nil
+}
+
+func (mock *MockCustomerRepository) DeleteCustomer(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockCustomerRepository) RestoreCustomer(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockCustomerRepository) GetCustomerHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.RecordVersion), nil
+}
+
+func (mock *MockCustomerRepository) GetCustomerAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	args := mock.Called(ctx, id, at)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.RecordVersion{}, err
+	}
+
+	return args.Get(0).(dto.RecordVersion), nil
+}
+
+func (mock *MockCustomerRepository) Login(ctx context.Context, cpf string) (string, error) {
+	args := mock.Called(ctx, cpf)
+	err := args.Error(1)
+
+	if err != nil {
+		return "", err
+	}
+
+	return args.Get(0).(string), nil
+}
+
+func (mock *MockCustomerRepository) LoginUnknown(ctx context.Context) (string, error) {
+	args := mock.Called(ctx)
+	err := args.Error(1)
+
+	if err != nil {
+		return "", err
+	}
+
+	return args.Get(0).(string), nil
+}
+
+func (mock *MockCustomerRepository) ValidateToken(ctx context.Context, accessToken string) (dto.TokenInfo, error) {
+	args := mock.Called(ctx, accessToken)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.TokenInfo{}, err
+	}
+
+	return args.Get(0).(dto.TokenInfo), nil
+}
+
+// RepositoryTestSuite runs the repositories on a real database: Postgres, in a
+// container, or SQLite, in a temporary file, when dialect is sqlite
 type RepositoryTestSuite struct {
 	suite.Suite
 	ctx                context.Context
+	dialect            string
 	db                 *database.Database
 	pgContainer        *postgres.PostgresContainer
 	pgConnectionString string
@@ -76,6 +283,16 @@ type RepositoryTestSuite struct {
 
 func (suite *RepositoryTestSuite) SetupSuite() {
 	suite.ctx = context.Background()
+
+	if suite.dialect == "sqlite" {
+		db, err := database.ConfigDatabase(database.SQLiteDialector(filepath.Join(suite.T().TempDir(), "repositories.db")))
+		suite.Require().NoError(err)
+
+		suite.db = db
+
+		return
+	}
+
 	pgContainer, err := postgres.RunContainer(
 		suite.ctx,
 		testcontainers.WithImage("postgres:15.3-alpine"),
@@ -106,21 +323,29 @@ func (suite *RepositoryTestSuite) SetupSuite() {
 }
 
 func (suite *RepositoryTestSuite) TearDownSuite() {
+	if suite.pgContainer == nil {
+		suite.NoError(suite.db.Close(suite.ctx))
+		return
+	}
+
 	err := suite.pgContainer.Terminate(suite.ctx)
 	suite.NoError(err)
 }
 
 func (suite *RepositoryTestSuite) SetupTest() {
-	err := suite.db.Connection.AutoMigrate(
-		&model.Customer{},
-		&model.UserAdmin{},
-	)
+	migrator, err := database.NewMigrator(suite.db)
+	suite.NoError(err)
+
+	err = migrator.Up(suite.ctx)
 	suite.NoError(err)
 }
 
 func (suite *RepositoryTestSuite) TearDownTest() {
-	suite.db.Connection.Exec("DROP TABLE IF EXISTS customers CASCADE;")
-	suite.db.Connection.Exec("DROP TABLE IF EXISTS user_admins CASCADE;")
+	migrator, err := database.NewMigrator(suite.db)
+	suite.NoError(err)
+
+	err = migrator.To(suite.ctx, 0)
+	suite.NoError(err)
 }
 
 func SetupDBMocks() (*gorm.DB, sqlmock.Sqlmock, error) {
@@ -139,3 +364,57 @@ func SetupDBMocks() (*gorm.DB, sqlmock.Sqlmock, error) {
 
 	return gormDB, mock, err
 }
+
+const (
+	selectAuditHeadQuery = "SELECT * FROM `audit_chain_heads` WHERE `audit_chain_heads`.`id` = ? ORDER BY `audit_chain_heads`.`id` LIMIT ? FOR UPDATE"
+	insertAuditLogQuery  = "INSERT INTO `audit_logs` (`sequence`,`actor`,`action`,`target_type`,`target_id`,`diff`,`request_id`,`ip`,`created_at`,`prev_hash`,`hash`) VALUES (?,?,?,?,?,?,?,?,?,?,?)"
+	updateAuditHeadQuery = "UPDATE `audit_chain_heads` SET `hash`=?,`sequence`=? WHERE id = ?"
+)
+
+// ExpectAuditLog expects the audit record of a change appended to a chain
+// whose head has the sequence
+func ExpectAuditLog(sqlMock sqlmock.Sqlmock, action string, targetType string, targetID uint, diff any, sequence uint64) {
+	sqlMock.ExpectQuery(selectAuditHeadQuery).
+		WithArgs(1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sequence", "hash"}).AddRow(1, sequence, "PREVIOUS_HASH"))
+	sqlMock.ExpectExec(insertAuditLogQuery).
+		WithArgs(sequence+1, "anonymous", action, targetType, targetID, diff, "", "", sqlmock.AnyArg(), "PREVIOUS_HASH", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(int64(sequence+1), 1))
+	sqlMock.ExpectExec(updateAuditHeadQuery).
+		WithArgs(sqlmock.AnyArg(), sequence+1, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+const (
+	insertRecordVersionQuery = "INSERT INTO `record_versions` (`target_type`,`target_id`,`version`,`action`,`name`,`cpf`,`email`,`cpf_index`,`email_index`,`deleted`,`actor`,`request_id`,`valid_from`) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?)"
+)
+
+// ExpectRecordVersion expects the row of the table, with the version, copied
+// to its history
+func ExpectRecordVersion(sqlMock sqlmock.Sqlmock, table string, action string, targetType string, targetID uint, version uint, deleted bool) {
+	var deletedAt any
+
+	if deleted {
+		deletedAt = time.Now()
+	}
+
+	sqlMock.ExpectQuery("SELECT `version`,`name`,`cpf`,`email`,`cpf_index`,`email_index`,`deleted_at` FROM `"+table+"` WHERE id = ? LIMIT ?").
+		WithArgs(targetID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "cpf", "email", "cpf_index", "email_index", "deleted_at"}).
+			AddRow(version, "NAME", "CPF", "EMAIL", "CPF_INDEX", "EMAIL_INDEX", deletedAt))
+	sqlMock.ExpectExec(insertRecordVersionQuery).
+		WithArgs(targetType, targetID, version, action, "NAME", "CPF", "EMAIL", "CPF_INDEX", "EMAIL_INDEX", deleted, "anonymous", "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+const (
+	insertOutboxEventQuery = "INSERT INTO `outbox_events` (`event_id`,`event_type`,`schema_version`,`aggregate_key`,`payload`,`occurred_at`,`published_at`,`attempts`,`last_error`) VALUES (?,?,?,?,?,?,?,?,?)"
+)
+
+// ExpectOutboxEvent expects the domain event of a change, with its payload
+// encrypted, written to the outbox
+func ExpectOutboxEvent(sqlMock sqlmock.Sqlmock, eventType string, key string) {
+	sqlMock.ExpectExec(insertOutboxEventQuery).
+		WithArgs(sqlmock.AnyArg(), eventType, 1, key, encrypted{}, sqlmock.AnyArg(), nil, 0, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
@@ -0,0 +1,264 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/events"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"github.com/thiagoluis88git/tech1-customer/pkg/outbox"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"gorm.io/gorm"
+)
+
+// _outboxLockKey is the Postgres advisory lock held by the outbox relay, so
+// only one instance publishes at a time
+const _outboxLockKey int64 = 5_170_201_443
+
+type OutboxRepository struct {
+	db        *database.Database
+	encryptor *encryption.Encryptor
+}
+
+func NewOutboxRepository(db *database.Database, encryptor *encryption.Encryptor) outbox.Store {
+	return &OutboxRepository{
+		db:        db,
+		encryptor: encryptor,
+	}
+}
+
+// Lock runs fn in a transaction holding a transaction level advisory lock on
+// Postgres, which is released by the commit or the rollback. SQLite has a
+// single writer, so its transaction is enough for a local instance
+func (repository *OutboxRepository) Lock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	acquired := false
+
+	err := repository.db.Transaction(ctx, func(ctx context.Context) error {
+		conn := repository.db.Conn(ctx)
+
+		if conn.Dialector.Name() == "postgres" {
+			locked := false
+
+			err := conn.Raw("SELECT pg_try_advisory_xact_lock(?)", _outboxLockKey).Scan(&locked).Error
+
+			if err != nil {
+				return responses.GetDatabaseError(err)
+			}
+
+			if !locked {
+				return nil
+			}
+		}
+
+		acquired = true
+
+		return fn(ctx)
+	})
+
+	return acquired, err
+}
+
+// Pending leaves out the events with an older failed event of the same key,
+// found by the idx_outbox_events_failed partial index
+func (repository *OutboxRepository) Pending(ctx context.Context, limit int) ([]outbox.Message, error) {
+	var eventEntities []model.OutboxEvent
+
+	olderFailed := repository.db.Conn(ctx).
+		Table("outbox_events AS failed").
+		Select("1").
+		Where("failed.aggregate_key = outbox_events.aggregate_key").
+		Where("failed.published_at IS NULL AND failed.attempts > 0 AND failed.id < outbox_events.id")
+
+	err := repository.db.Conn(ctx).
+		Where("published_at IS NULL").
+		Where("NOT EXISTS (?)", olderFailed).
+		Order("id").
+		Limit(limit).
+		Find(&eventEntities).
+		Error
+
+	if err != nil {
+		return nil, responses.GetDatabaseError(err)
+	}
+
+	messages := make([]outbox.Message, 0, len(eventEntities))
+
+	for _, eventEntity := range eventEntities {
+		data, err := repository.encryptor.Decrypt(ctx, eventEntity.Payload)
+
+		if err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, outbox.Message{
+			Sequence:      eventEntity.ID,
+			ID:            eventEntity.EventID,
+			Type:          eventEntity.EventType,
+			SchemaVersion: eventEntity.SchemaVersion,
+			Key:           eventEntity.AggregateKey,
+			OccurredAt:    eventEntity.OccurredAt,
+			Data:          []byte(data),
+			Attempts:      eventEntity.Attempts,
+		})
+	}
+
+	return messages, nil
+}
+
+func (repository *OutboxRepository) MarkPublished(ctx context.Context, sequences []uint64, publishedAt time.Time) error {
+	err := repository.db.Conn(ctx).
+		Model(&model.OutboxEvent{}).
+		Where("id IN ?", sequences).
+		Update("published_at", publishedAt.UTC()).
+		Error
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	return nil
+}
+
+func (repository *OutboxRepository) MarkFailed(ctx context.Context, sequence uint64, reason string) error {
+	err := repository.db.Conn(ctx).
+		Model(&model.OutboxEvent{}).
+		Where("id = ?", sequence).
+		Updates(map[string]any{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"last_error": reason,
+		}).
+		Error
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	return nil
+}
+
+func (repository *OutboxRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := repository.db.Conn(ctx).
+		Where("published_at < ?", before.UTC()).
+		Delete(&model.OutboxEvent{})
+
+	if result.Error != nil {
+		return 0, responses.GetDatabaseError(result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// appendChangeEvent writes the domain event of a change of a customer or an
+// admin user to the outbox, with its data encrypted. It must be called with
+// the transaction of the change, after appendAuditLog: the lock of the audit
+// chain head serializes the changes, so the events get their ids in the commit
+// order and the relay never skips one committed later. fields are the values
+// after the change. The changes without an event write nothing
+func appendChangeEvent(
+	ctx context.Context,
+	tx *gorm.DB,
+	encryptor *encryption.Encryptor,
+	targetType string,
+	action string,
+	id uint,
+	version uint,
+	fields auditedFields,
+	changedFields []string,
+) error {
+	event := changeEvent(targetType, action, id, version, fields, changedFields)
+
+	if event == nil {
+		return nil
+	}
+
+	message, err := outbox.NewMessage(event.EventType(), event.SchemaVersion(), event.Key(), event)
+
+	if err != nil {
+		return err
+	}
+
+	payload, err := encryptor.Encrypt(ctx, string(message.Data))
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Create(&model.OutboxEvent{
+		EventID:       message.ID,
+		EventType:     message.Type,
+		SchemaVersion: message.SchemaVersion,
+		AggregateKey:  message.Key,
+		Payload:       payload,
+		OccurredAt:    message.OccurredAt,
+	}).Error
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	return nil
+}
+
+func changeEvent(targetType string, action string, id uint, version uint, fields auditedFields, changedFields []string) events.Event {
+	if targetType == auditTargetUserAdmin {
+		if action != audit.ActionCreate {
+			return nil
+		}
+
+		return events.AdminCreated{
+			AdminID: id,
+			Name:    fields.Name,
+			Email:   fields.Email,
+			Version: version,
+		}
+	}
+
+	switch action {
+	case audit.ActionCreate:
+		return events.CustomerCreated{
+			CustomerID: id,
+			Name:       fields.Name,
+			CPF:        fields.CPF,
+			Email:      fields.Email,
+			Version:    version,
+		}
+	case audit.ActionUpdate, audit.ActionPatch, audit.ActionRestore:
+		if changedFields == nil {
+			changedFields = []string{}
+		}
+
+		return events.CustomerUpdated{
+			CustomerID:    id,
+			Name:          fields.Name,
+			CPF:           fields.CPF,
+			Email:         fields.Email,
+			Version:       version,
+			ChangedFields: changedFields,
+			Restored:      action == audit.ActionRestore,
+		}
+	case audit.ActionDelete:
+		return events.CustomerDeleted{
+			CustomerID: id,
+			CPF:        fields.CPF,
+			Version:    version,
+		}
+	}
+
+	return nil
+}
+
+// changedFields returns the audited fields whose value differs after a change
+func changedFields(before map[string]string, after map[string]string) []string {
+	changed := []string{}
+
+	for _, field := range []string{"name", "cpf", "email"} {
+		if before[field] != after[field] {
+			changed = append(changed, field)
+		}
+	}
+
+	return changed
+}
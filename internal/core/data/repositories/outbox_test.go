@@ -0,0 +1,198 @@
+package repositories_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+	"github.com/thiagoluis88git/tech1-customer/pkg/outbox"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(ctx context.Context, message outbox.Message) error {
+	return errors.New("broker unavailable")
+}
+
+func (suite *RepositoryTestSuite) TestOutboxEventsOfChanges() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	customerRepo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+	userRepo := repositories.NewUserAdminRepository(suite.db, mockCognito, testEncryptor)
+	store := repositories.NewOutboxRepository(suite.db, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+	mockCognito.On("SignUpAdmin", suite.ctx, mock.Anything).Return(nil)
+	mockCognito.On("DisableUser", suite.ctx, "12312312312").Return(nil)
+	mockCognito.On("EnableUser", suite.ctx, "12312312312").Return(nil)
+
+	id, err := customerRepo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	email := "novo@teste.com"
+
+	err = customerRepo.PatchCustomer(suite.ctx, dto.CustomerPatch{ID: id, Email: &email})
+	suite.NoError(err)
+
+	err = customerRepo.DeleteCustomer(suite.ctx, id)
+	suite.NoError(err)
+
+	err = customerRepo.RestoreCustomer(suite.ctx, id)
+	suite.NoError(err)
+
+	userID, err := userRepo.CreateUser(suite.ctx, dto.UserAdmin{Name: "Admin", CPF: "32132132132", Email: "admin@teste.com"})
+	suite.NoError(err)
+
+	err = userRepo.UpdateUser(suite.ctx, dto.UserAdmin{ID: userID, Name: "Admin 2", CPF: "32132132132", Email: "admin@teste.com"})
+	suite.NoError(err)
+
+	messages, err := store.Pending(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Len(messages, 5)
+
+	types := make([]string, 0, len(messages))
+
+	for _, message := range messages {
+		types = append(types, message.Key+"/"+message.Type)
+	}
+
+	suite.Equal([]string{
+		"customer:1/customer.created",
+		"customer:1/customer.updated",
+		"customer:1/customer.deleted",
+		"customer:1/customer.updated",
+		"admin:1/admin.created",
+	}, types)
+
+	var created, patched, deleted, restored map[string]any
+
+	suite.NoError(json.Unmarshal(messages[0].Data, &created))
+	suite.NoError(json.Unmarshal(messages[1].Data, &patched))
+	suite.NoError(json.Unmarshal(messages[2].Data, &deleted))
+	suite.NoError(json.Unmarshal(messages[3].Data, &restored))
+
+	suite.Equal("12312312312", created["cpf"])
+	suite.Equal("teste@teste.com", created["email"])
+	suite.Equal(float64(1), created["version"])
+	suite.Equal("novo@teste.com", patched["email"])
+	suite.Equal([]any{"email"}, patched["changedFields"])
+	suite.Equal(false, patched["restored"])
+	suite.Equal(float64(3), deleted["version"])
+	suite.Equal([]any{}, restored["changedFields"])
+	suite.Equal(true, restored["restored"])
+
+	var eventEntities []model.OutboxEvent
+	suite.NoError(suite.db.Connection.Find(&eventEntities).Error)
+
+	for _, eventEntity := range eventEntities {
+		suite.True(encryption.IsEncrypted(eventEntity.Payload))
+		suite.NotContains(eventEntity.Payload, "12312312312")
+	}
+}
+
+func (suite *RepositoryTestSuite) TestOutboxEventRolledBackWithChange() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+	store := repositories.NewOutboxRepository(suite.db, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+	mockCognito.On("DisableUser", suite.ctx, "12312312312").Return(&responses.NetworkError{Code: 503})
+
+	id, err := repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	err = repo.DeleteCustomer(suite.ctx, id)
+	suite.Error(err)
+
+	messages, err := store.Pending(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Len(messages, 1)
+	suite.Equal("customer.created", messages[0].Type)
+}
+
+func (suite *RepositoryTestSuite) TestRelayOutboxEvents() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+	store := repositories.NewOutboxRepository(suite.db, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+
+	_, err := repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	_, err = repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste 2", CPF: "32132132132", Email: "teste2@teste.com"})
+	suite.NoError(err)
+
+	result, err := outbox.NewRelay(store, failingPublisher{}).RelayOnce(suite.ctx)
+	suite.NoError(err)
+	suite.Equal(outbox.RelayResult{Failed: 2}, result)
+
+	messages, err := store.Pending(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Len(messages, 2)
+	suite.Equal(1, messages[0].Attempts)
+
+	var failed model.OutboxEvent
+	suite.NoError(suite.db.Connection.First(&failed).Error)
+	suite.Equal("broker unavailable", failed.LastError)
+
+	publisher := outbox.NewMemoryPublisher()
+
+	result, err = outbox.NewRelay(store, publisher).RelayOnce(suite.ctx)
+	suite.NoError(err)
+	suite.Equal(outbox.RelayResult{Published: 2}, result)
+	suite.Equal("customer:1", publisher.Messages()[0].Key)
+	suite.Equal("customer:2", publisher.Messages()[1].Key)
+
+	messages, err = store.Pending(suite.ctx, 10)
+	suite.NoError(err)
+	suite.Empty(messages)
+
+	deleted, err := store.DeletePublished(suite.ctx, time.Now().Add(-time.Hour))
+	suite.NoError(err)
+	suite.Equal(int64(0), deleted)
+
+	deleted, err = store.DeletePublished(suite.ctx, time.Now().Add(time.Minute))
+	suite.NoError(err)
+	suite.Equal(int64(2), deleted)
+}
+
+func (suite *RepositoryTestSuite) TestOutboxPendingBehindFailedEvent() {
+	mockCognito := new(MockCognitoRemoteDataSource)
+	repo := repositories.NewCustomerRepository(suite.db, mockCognito, testEncryptor)
+	store := repositories.NewOutboxRepository(suite.db, testEncryptor)
+
+	mockCognito.On("SignUp", suite.ctx, mock.Anything).Return(nil)
+
+	id, err := repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste", CPF: "12312312312", Email: "teste@teste.com"})
+	suite.NoError(err)
+
+	email := "novo@teste.com"
+
+	err = repo.PatchCustomer(suite.ctx, dto.CustomerPatch{ID: id, Email: &email})
+	suite.NoError(err)
+
+	_, err = repo.CreateCustomer(suite.ctx, dto.Customer{Name: "Teste 2", CPF: "32132132132", Email: "teste2@teste.com"})
+	suite.NoError(err)
+
+	messages, err := store.Pending(suite.ctx, 2)
+	suite.NoError(err)
+	suite.Len(messages, 2)
+
+	err = store.MarkFailed(suite.ctx, messages[0].Sequence, "broker unavailable")
+	suite.NoError(err)
+
+	// the update of customer 1 waits for its failed creation, so it does not
+	// take the place of customer 2 in the batch
+	messages, err = store.Pending(suite.ctx, 2)
+	suite.NoError(err)
+	suite.Len(messages, 2)
+	suite.Equal("customer:1/customer.created", messages[0].Key+"/"+messages[0].Type)
+	suite.Equal("customer:2/customer.created", messages[1].Key+"/"+messages[1].Type)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+)
+
+type UnitOfWork struct {
+	db *database.Database
+}
+
+func NewUnitOfWork(db *database.Database) repository.UnitOfWork {
+	return &UnitOfWork{
+		db: db,
+	}
+}
+
+func (unitOfWork *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return unitOfWork.db.Transaction(ctx, fn)
+}
@@ -0,0 +1,124 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/data/repositories"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+)
+
+const patchEmailQuery = "UPDATE `user_admins` SET `email`=?,`email_index`=?,`version`=version + 1,`updated_at`=? WHERE id = ? AND version = ? AND `user_admins`.`deleted_at` IS NULL"
+
+func TestUnitOfWorkLocal(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got writes in a single transaction when running unit of work local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(patchEmailQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("new@teste.com"), sqlmock.AnyArg(), uint(1), uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ExpectRecordVersion(sqlMock, "user_admins", "patch", "user_admin", uint(1), 4, false)
+		ExpectAuditLog(sqlMock, "patch", "user_admin", uint(1), `{"email":{"before":"o***@teste.com","after":"n***@teste.com"}}`, 0)
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(patchEmailQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("other@teste.com"), sqlmock.AnyArg(), uint(1), uint(4)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ExpectRecordVersion(sqlMock, "user_admins", "patch", "user_admin", uint(1), 5, false)
+		ExpectAuditLog(sqlMock, "patch", "user_admin", uint(1), `{"email":{"before":"o***@teste.com","after":"o***@teste.com"}}`, 1)
+		sqlMock.ExpectCommit()
+
+		localDB := &database.Database{Connection: db}
+		unitOfWork := repositories.NewUnitOfWork(localDB)
+		localDs := repositories.NewUserAdminRepository(localDB, new(MockCognitoRemoteDataSource), testEncryptor)
+
+		email := "new@teste.com"
+		otherEmail := "other@teste.com"
+
+		err = unitOfWork.Do(context.TODO(), func(ctx context.Context) error {
+			err := localDs.PatchUser(ctx, dto.UserAdminPatch{ID: 1, Email: &email, Version: 3})
+
+			if err != nil {
+				return err
+			}
+
+			return localDs.PatchUser(ctx, dto.UserAdminPatch{ID: 1, Email: &otherEmail, Version: 4})
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("got every write rolled back when unit of work fails local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(patchEmailQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("new@teste.com"), sqlmock.AnyArg(), uint(1), uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ExpectRecordVersion(sqlMock, "user_admins", "patch", "user_admin", uint(1), 4, false)
+		ExpectAuditLog(sqlMock, "patch", "user_admin", uint(1), `{"email":{"before":"o***@teste.com","after":"n***@teste.com"}}`, 0)
+		sqlMock.ExpectRollback()
+
+		localDB := &database.Database{Connection: db}
+		unitOfWork := repositories.NewUnitOfWork(localDB)
+		localDs := repositories.NewUserAdminRepository(localDB, new(MockCognitoRemoteDataSource), testEncryptor)
+
+		email := "new@teste.com"
+
+		err = unitOfWork.Do(context.TODO(), func(ctx context.Context) error {
+			err := localDs.PatchUser(ctx, dto.UserAdminPatch{ID: 1, Email: &email, Version: 3})
+
+			if err != nil {
+				return err
+			}
+
+			return errors.New("second write failed")
+		})
+
+		assert.Error(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("got reads in the transaction when running unit of work local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectQueryByID).
+			WithArgs(uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "cpf", "email"}).AddRow(1, "Name", "CPF", "Email"))
+		sqlMock.ExpectCommit()
+
+		localDB := &database.Database{Connection: db}
+		unitOfWork := repositories.NewUnitOfWork(localDB)
+		localDs := repositories.NewUserAdminRepository(localDB, new(MockCognitoRemoteDataSource), testEncryptor)
+
+		err = unitOfWork.Do(context.TODO(), func(ctx context.Context) error {
+			_, err := localDs.GetUserById(ctx, uint(1))
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+}
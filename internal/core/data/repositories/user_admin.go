@@ -2,26 +2,30 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
 	"github.com/thiagoluis88git/tech1-customer/internal/integrations/remote"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
 	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
-
 	"gorm.io/gorm"
 )
 
 type UserAdminRepository struct {
 	db            *database.Database
 	cognitoRemote remote.CognitoRemoteDataSource
+	encryptor     *encryption.Encryptor
 }
 
-func NewUserAdminRepository(db *database.Database, cognitoRemote remote.CognitoRemoteDataSource) repository.UserAdminRepository {
+func NewUserAdminRepository(db *database.Database, cognitoRemote remote.CognitoRemoteDataSource, encryptor *encryption.Encryptor) repository.UserAdminRepository {
 	return &UserAdminRepository{
 		db:            db,
 		cognitoRemote: cognitoRemote,
+		encryptor:     encryptor,
 	}
 }
 
@@ -32,43 +36,86 @@ func (repository *UserAdminRepository) CreateUser(ctx context.Context, customer
 		Email: customer.Email,
 	}
 
-	err := repository.cognitoRemote.SignUpAdmin(userEntity)
+	err := repository.cognitoRemote.SignUpAdmin(ctx, userEntity)
 
 	if err != nil {
 		return 0, responses.GetCognitoError(err)
 	}
 
-	err = repository.db.Connection.WithContext(ctx).Create(userEntity).Error
+	created := auditedFields{
+		Name:  userEntity.Name,
+		CPF:   userEntity.CPF,
+		Email: userEntity.Email,
+	}
+
+	userEntity.CPF, userEntity.CPFIndex, err = encryptField(ctx, repository.encryptor, created.CPF)
 
 	if err != nil {
-		return 0, responses.GetDatabaseError(err)
+		return 0, err
 	}
 
-	return userEntity.ID, nil
-}
+	userEntity.Email, userEntity.EmailIndex, err = encryptField(ctx, repository.encryptor, created.Email)
 
-func (repository *UserAdminRepository) UpdateUser(ctx context.Context, customer dto.UserAdmin) error {
-	userEntity := &model.UserAdmin{
-		Model: gorm.Model{ID: customer.ID},
-		Name:  customer.Name,
-		CPF:   customer.CPF,
-		Email: customer.Email,
+	if err != nil {
+		return 0, err
 	}
 
-	err := repository.db.Connection.WithContext(ctx).Save(&userEntity).Error
+	err = repository.db.Conn(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(userEntity).Error
+
+		if err != nil {
+			return responses.GetDatabaseError(err)
+		}
+
+		version, err := appendRecordVersion(ctx, tx, &model.UserAdmin{}, auditTargetUserAdmin, audit.ActionCreate, userEntity.ID)
+
+		if err != nil {
+			return err
+		}
+
+		err = appendAuditLog(ctx, tx, audit.ActionCreate, auditTargetUserAdmin, userEntity.ID, audit.Diff(nil, created.toMap()))
+
+		if err != nil {
+			return err
+		}
+
+		return appendChangeEvent(ctx, tx, repository.encryptor, auditTargetUserAdmin, audit.ActionCreate, userEntity.ID, version, created, nil)
+	})
 
 	if err != nil {
-		return responses.GetDatabaseError(err)
+		return 0, err
+	}
+
+	return userEntity.ID, nil
+}
+
+func (repository *UserAdminRepository) UpdateUser(ctx context.Context, user dto.UserAdmin) error {
+	return updateAudited(ctx, repository.db.Conn(ctx), repository.encryptor, &model.UserAdmin{}, auditTargetUserAdmin, audit.ActionUpdate, user.ID, user.Version, map[string]string{
+		"name":  user.Name,
+		"cpf":   user.CPF,
+		"email": user.Email,
+	})
+}
+
+func (repository *UserAdminRepository) PatchUser(ctx context.Context, patch dto.UserAdminPatch) error {
+	values := map[string]string{}
+
+	if patch.Name != nil {
+		values["name"] = *patch.Name
+	}
+
+	if patch.Email != nil {
+		values["email"] = *patch.Email
 	}
 
-	return nil
+	return updateAudited(ctx, repository.db.Conn(ctx), repository.encryptor, &model.UserAdmin{}, auditTargetUserAdmin, audit.ActionPatch, patch.ID, patch.Version, values)
 }
 
 func (repository *UserAdminRepository) GetUserById(ctx context.Context, id uint) (dto.UserAdmin, error) {
 	var userEntity model.UserAdmin
 
 	err := repository.
-		db.Connection.WithContext(ctx).
+		db.Reader(ctx).
 		First(&userEntity, id).
 		Error
 
@@ -76,15 +123,15 @@ func (repository *UserAdminRepository) GetUserById(ctx context.Context, id uint)
 		return dto.UserAdmin{}, responses.GetDatabaseError(err)
 	}
 
-	return repository.populateUser(userEntity), nil
+	return repository.populateUser(ctx, userEntity)
 }
 
 func (repository *UserAdminRepository) GetUserByCPF(ctx context.Context, cpf string) (dto.UserAdmin, error) {
 	var userEntity model.UserAdmin
 
 	err := repository.
-		db.Connection.WithContext(ctx).
-		Where("cpf = ?", cpf).
+		db.Reader(ctx).
+		Where("cpf_index = ?", repository.encryptor.BlindIndex(cpf)).
 		First(&userEntity).
 		Error
 
@@ -92,20 +139,49 @@ func (repository *UserAdminRepository) GetUserByCPF(ctx context.Context, cpf str
 		return dto.UserAdmin{}, responses.GetDatabaseError(err)
 	}
 
-	return repository.populateUser(userEntity), nil
+	return repository.populateUser(ctx, userEntity)
 }
 
-func (repository *UserAdminRepository) populateUser(userrEntity model.UserAdmin) dto.UserAdmin {
-	return dto.UserAdmin{
-		ID:    userrEntity.ID,
-		Name:  userrEntity.Name,
-		CPF:   userrEntity.CPF,
-		Email: userrEntity.Email,
+// DeleteUser soft deletes the user and disables its identity provider account.
+// Its CPF and email stay taken until it is restored
+func (repository *UserAdminRepository) DeleteUser(ctx context.Context, id uint) error {
+	return setDeleted(ctx, repository.db.Conn(ctx), repository.encryptor, &model.UserAdmin{}, auditTargetUserAdmin, id, true, repository.cognitoRemote.DisableUser)
+}
+
+// RestoreUser restores a deleted user and enables its identity provider account
+// again
+func (repository *UserAdminRepository) RestoreUser(ctx context.Context, id uint) error {
+	return setDeleted(ctx, repository.db.Conn(ctx), repository.encryptor, &model.UserAdmin{}, auditTargetUserAdmin, id, false, repository.cognitoRemote.EnableUser)
+}
+
+func (repository *UserAdminRepository) GetUserHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	return listRecordVersions(ctx, repository.db.Reader(ctx), repository.encryptor, auditTargetUserAdmin, id)
+}
+
+func (repository *UserAdminRepository) GetUserAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	return getRecordVersionAt(ctx, repository.db.Reader(ctx), repository.encryptor, auditTargetUserAdmin, id, at)
+}
+
+func (repository *UserAdminRepository) populateUser(ctx context.Context, userrEntity model.UserAdmin) (dto.UserAdmin, error) {
+	fields := auditedFields{CPF: userrEntity.CPF, Email: userrEntity.Email}
+
+	err := fields.decrypt(ctx, repository.encryptor)
+
+	if err != nil {
+		return dto.UserAdmin{}, err
 	}
+
+	return dto.UserAdmin{
+		ID:      userrEntity.ID,
+		Name:    userrEntity.Name,
+		CPF:     fields.CPF,
+		Email:   fields.Email,
+		Version: userrEntity.Version,
+	}, nil
 }
 
 func (repository *UserAdminRepository) Login(ctx context.Context, cpf string) (string, error) {
-	token, err := repository.cognitoRemote.Login(cpf)
+	token, err := repository.cognitoRemote.Login(ctx, cpf)
 
 	if err != nil {
 		return "", responses.GetCognitoError(err)
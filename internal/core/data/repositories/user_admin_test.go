@@ -16,11 +16,20 @@ import (
 )
 
 const (
-	insertQuery      = "INSERT INTO `user_admins` (`created_at`,`updated_at`,`deleted_at`,`name`,`cpf`,`email`) VALUES (?,?,?,?,?,?)"
+	insertQuery      = "INSERT INTO `user_admins` (`created_at`,`updated_at`,`deleted_at`,`name`,`cpf`,`email`,`cpf_index`,`email_index`,`version`) VALUES (?,?,?,?,?,?,?,?,?)"
+	updateQuery      = "UPDATE `user_admins` SET `cpf`=?,`cpf_index`=?,`email`=?,`email_index`=?,`name`=?,`version`=version + 1,`updated_at`=? WHERE id = ? AND version = ? AND `user_admins`.`deleted_at` IS NULL"
+	selectQueryID    = "SELECT `id` FROM `user_admins` WHERE `user_admins`.`id` = ? AND `user_admins`.`deleted_at` IS NULL ORDER BY `user_admins`.`id` LIMIT ?"
 	selectQueryByID  = "SELECT * FROM `user_admins` WHERE `user_admins`.`id` = ? AND `user_admins`.`deleted_at` IS NULL ORDER BY `user_admins`.`id` LIMIT ?"
-	selectQueryByCPF = "SELECT * FROM `user_admins` WHERE cpf = ? AND `user_admins`.`deleted_at` IS NULL ORDER BY `user_admins`.`id` LIMIT ?"
+	selectQueryByCPF = "SELECT * FROM `user_admins` WHERE cpf_index = ? AND `user_admins`.`deleted_at` IS NULL ORDER BY `user_admins`.`id` LIMIT ?"
+	selectForUpdate  = "SELECT `name`,`cpf`,`email` FROM `user_admins` WHERE id = ? AND `user_admins`.`deleted_at` IS NULL LIMIT ? FOR UPDATE"
 )
 
+func expectSelectForUpdate(sqlMock sqlmock.Sqlmock) {
+	sqlMock.ExpectQuery(selectForUpdate).
+		WithArgs(uint(1), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "cpf", "email"}).AddRow("OLD NAME", "CPF", "old@teste.com"))
+}
+
 func mockDTOUserAdmin() dto.UserAdmin {
 	return dto.UserAdmin{
 		Name:  "NAME",
@@ -49,19 +58,23 @@ func TestUserAdminLocal(t *testing.T) {
 
 		sqlMock.ExpectBegin()
 		sqlMock.ExpectExec(insertQuery).
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", "CPF", "EMAIL").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", encrypted{}, encrypted{}, testEncryptor.BlindIndex("CPF"), testEncryptor.BlindIndex("EMAIL"), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
+		ExpectRecordVersion(sqlMock, "user_admins", "create", "user_admin", uint(1), 1, false)
+		ExpectAuditLog(sqlMock, "create", "user_admin", uint(1), `{"cpf":{"after":"***"},"email":{"after":"***"},"name":{"after":"N***"}}`, 0)
+		ExpectOutboxEvent(sqlMock, "admin.created", "admin:1")
 		sqlMock.ExpectCommit()
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
-		cognitoRemote.On("SignUpAdmin", mockModelUserAdmin()).Return(nil)
+		cognitoRemote.On("SignUpAdmin", context.TODO(), mockModelUserAdmin()).Return(nil)
 
 		id, err := localDs.CreateUser(context.TODO(), mockDTOUserAdmin())
 
 		assert.NoError(t, err)
 		assert.Equal(t, uint(1), id)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
 	})
 
 	t.Run("got error on Cognito remote when saving user admin local", func(t *testing.T) {
@@ -73,14 +86,14 @@ func TestUserAdminLocal(t *testing.T) {
 
 		sqlMock.ExpectBegin()
 		sqlMock.ExpectExec(insertQuery).
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", "CPF", "EMAIL").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", encrypted{}, encrypted{}, testEncryptor.BlindIndex("CPF"), testEncryptor.BlindIndex("EMAIL"), sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		sqlMock.ExpectCommit()
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
-		cognitoRemote.On("SignUpAdmin", mockModelUserAdmin()).Return(&responses.NetworkError{
+		cognitoRemote.On("SignUpAdmin", context.TODO(), mockModelUserAdmin()).Return(&responses.NetworkError{
 			Code: 400,
 		})
 
@@ -99,14 +112,14 @@ func TestUserAdminLocal(t *testing.T) {
 
 		sqlMock.ExpectBegin()
 		sqlMock.ExpectExec(insertQuery).
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", "CPF", "EMAIL").
+			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", encrypted{}, encrypted{}, testEncryptor.BlindIndex("CPF"), testEncryptor.BlindIndex("EMAIL"), sqlmock.AnyArg()).
 			WillReturnError(errors.New("Error on DB"))
-		sqlMock.ExpectCommit()
+		sqlMock.ExpectRollback()
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
-		cognitoRemote.On("SignUpAdmin", mockModelUserAdmin()).Return(nil)
+		cognitoRemote.On("SignUpAdmin", context.TODO(), mockModelUserAdmin()).Return(nil)
 
 		id, err := localDs.CreateUser(context.TODO(), mockDTOUserAdmin())
 
@@ -122,17 +135,83 @@ func TestUserAdminLocal(t *testing.T) {
 		assert.NoError(t, err)
 
 		sqlMock.ExpectBegin()
-		sqlMock.ExpectExec(insertQuery).
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", "CPF", "EMAIL").
-			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(updateQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("CPF"), encrypted{}, testEncryptor.BlindIndex("EMAIL"), "NAME", sqlmock.AnyArg(), uint(1), uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ExpectRecordVersion(sqlMock, "user_admins", "update", "user_admin", uint(1), 4, false)
+		ExpectAuditLog(sqlMock, "update", "user_admin", uint(1), `{"email":{"before":"o***@teste.com","after":"***"},"name":{"before":"O***","after":"N***"}}`, 41)
 		sqlMock.ExpectCommit()
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
+
+		userAdmin := mockDTOUserAdmin()
+		userAdmin.ID = 1
+		userAdmin.Version = 3
+
+		err = localDs.UpdateUser(context.TODO(), userAdmin)
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
+	t.Run("got precondition failed error when updating user admin with old version local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
 
-		err = localDs.UpdateUser(context.TODO(), mockDTOUserAdmin())
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(updateQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("CPF"), encrypted{}, testEncryptor.BlindIndex("EMAIL"), "NAME", sqlmock.AnyArg(), uint(1), uint(2)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		sqlMock.ExpectQuery(selectQueryID).
+			WithArgs(uint(1), 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		sqlMock.ExpectRollback()
+
+		cognitoRemote := new(MockCognitoRemoteDataSource)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
+
+		userAdmin := mockDTOUserAdmin()
+		userAdmin.ID = 1
+		userAdmin.Version = 2
+
+		err = localDs.UpdateUser(context.TODO(), userAdmin)
+
+		var localError *responses.LocalError
+		assert.ErrorAs(t, err, &localError)
+		assert.Equal(t, responses.PRECONDITION_FAILED_ERROR, localError.Code)
+	})
+
+	t.Run("got not found error when updating unknown user admin local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
 
 		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		sqlMock.ExpectQuery(selectForUpdate).
+			WithArgs(uint(1), 1).
+			WillReturnError(gorm.ErrRecordNotFound)
+		sqlMock.ExpectRollback()
+
+		cognitoRemote := new(MockCognitoRemoteDataSource)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
+
+		userAdmin := mockDTOUserAdmin()
+		userAdmin.ID = 1
+		userAdmin.Version = 2
+
+		err = localDs.UpdateUser(context.TODO(), userAdmin)
+
+		var localError *responses.LocalError
+		assert.ErrorAs(t, err, &localError)
+		assert.Equal(t, responses.NOT_FOUND_ERROR, localError.Code)
 	})
 
 	t.Run("got error on Update User DB when updating user admin local", func(t *testing.T) {
@@ -143,19 +222,54 @@ func TestUserAdminLocal(t *testing.T) {
 		assert.NoError(t, err)
 
 		sqlMock.ExpectBegin()
-		sqlMock.ExpectExec(insertQuery).
-			WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), nil, "NAME", "CPF", "EMAIL").
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(updateQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("CPF"), encrypted{}, testEncryptor.BlindIndex("EMAIL"), "NAME", sqlmock.AnyArg(), uint(1), uint(3)).
 			WillReturnError(errors.New("Error on DB"))
-		sqlMock.ExpectCommit()
+		sqlMock.ExpectRollback()
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
+
+		userAdmin := mockDTOUserAdmin()
+		userAdmin.ID = 1
+		userAdmin.Version = 3
 
-		err = localDs.UpdateUser(context.TODO(), mockDTOUserAdmin())
+		err = localDs.UpdateUser(context.TODO(), userAdmin)
 
 		assert.Error(t, err)
 	})
 
+	t.Run("got only changed columns when patching user admin local", func(t *testing.T) {
+		t.Parallel()
+
+		db, sqlMock, err := SetupDBMocks()
+
+		assert.NoError(t, err)
+
+		sqlMock.ExpectBegin()
+		expectSelectForUpdate(sqlMock)
+		sqlMock.ExpectExec(patchEmailQuery).
+			WithArgs(encrypted{}, testEncryptor.BlindIndex("new@teste.com"), sqlmock.AnyArg(), uint(1), uint(3)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		ExpectRecordVersion(sqlMock, "user_admins", "patch", "user_admin", uint(1), 4, false)
+		ExpectAuditLog(sqlMock, "patch", "user_admin", uint(1), `{"email":{"before":"o***@teste.com","after":"n***@teste.com"}}`, 0)
+		sqlMock.ExpectCommit()
+
+		cognitoRemote := new(MockCognitoRemoteDataSource)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
+
+		email := "new@teste.com"
+		err = localDs.PatchUser(context.TODO(), dto.UserAdminPatch{
+			ID:      1,
+			Email:   &email,
+			Version: 3,
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, sqlMock.ExpectationsWereMet())
+	})
+
 	t.Run("got success when getting user admin by id local", func(t *testing.T) {
 		t.Parallel()
 
@@ -168,7 +282,7 @@ func TestUserAdminLocal(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"name", "cpf", "email"}).AddRow("Name", "CPF", "Email"))
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
 		userAdmin, err := localDs.GetUserById(context.TODO(), uint(1))
 
@@ -188,7 +302,7 @@ func TestUserAdminLocal(t *testing.T) {
 			WillReturnError(gorm.ErrRecordNotFound)
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
 		userAdmin, err := localDs.GetUserById(context.TODO(), uint(1))
 
@@ -208,7 +322,7 @@ func TestUserAdminLocal(t *testing.T) {
 			WillReturnRows(sqlmock.NewRows([]string{"name", "cpf", "email"}).AddRow("Name", "CPF", "Email"))
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
 		userAdmin, err := localDs.GetUserByCPF(context.TODO(), "CPF")
 
@@ -228,7 +342,7 @@ func TestUserAdminLocal(t *testing.T) {
 			WillReturnError(gorm.ErrRecordNotFound)
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
 		userAdmin, err := localDs.GetUserByCPF(context.TODO(), "CPF")
 
@@ -244,9 +358,9 @@ func TestUserAdminLocal(t *testing.T) {
 		assert.NoError(t, err)
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
-		cognitoRemote.On("Login", "12345678910").Return("TOKEN", nil)
+		cognitoRemote.On("Login", context.TODO(), "12345678910").Return("TOKEN", nil)
 
 		token, err := localDs.Login(context.TODO(), "12345678910")
 
@@ -262,9 +376,9 @@ func TestUserAdminLocal(t *testing.T) {
 		assert.NoError(t, err)
 
 		cognitoRemote := new(MockCognitoRemoteDataSource)
-		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote)
+		localDs := repositories.NewUserAdminRepository(&database.Database{Connection: db}, cognitoRemote, testEncryptor)
 
-		cognitoRemote.On("Login", "12345678910").Return("", &responses.NetworkError{
+		cognitoRemote.On("Login", context.TODO(), "12345678910").Return("", &responses.NetworkError{
 			Code: 400,
 		})
 
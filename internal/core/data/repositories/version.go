@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"gorm.io/gorm"
+)
+
+// updateVersioned updates the columns of the row with the given id and increments
+// its version in a single `UPDATE ... WHERE id = ? AND version = ?`, so two
+// concurrent updates with the same version can not both succeed. A zero version
+// means an unconditional update.
+//
+// When no row is updated it checks if the row exists to tell a not found error
+// from a precondition failed one
+func updateVersioned(ctx context.Context, db *gorm.DB, entity any, id uint, version uint, values map[string]any) error {
+	values["version"] = gorm.Expr("version + 1")
+
+	query := db.WithContext(ctx).Model(entity).Where("id = ?", id)
+
+	if version != 0 {
+		query = query.Where("version = ?", version)
+	}
+
+	result := query.Updates(values)
+
+	if result.Error != nil {
+		return responses.GetDatabaseError(result.Error)
+	}
+
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	err := db.WithContext(ctx).Select("id").First(entity, id).Error
+
+	if err != nil {
+		return responses.GetDatabaseError(err)
+	}
+
+	return &responses.LocalError{
+		Code:    responses.PRECONDITION_FAILED_ERROR,
+		Message: "version mismatch",
+	}
+}
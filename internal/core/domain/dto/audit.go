@@ -0,0 +1,51 @@
+package dto
+
+import "time"
+
+// AuditChange is a field changed by an action. The personal data is masked
+type AuditChange struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+type AuditLog struct {
+	Sequence   uint64                 `json:"sequence"`
+	Actor      string                 `json:"actor"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"targetType"`
+	TargetID   uint                   `json:"targetId"`
+	Diff       map[string]AuditChange `json:"diff"`
+	RequestID  string                 `json:"requestId,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	PrevHash   string                 `json:"prevHash"`
+	Hash       string                 `json:"hash"`
+}
+
+// AuditLogFilter filters the audit log. The empty fields are not used. The
+// records are sorted from the newest and Before is the sequence where the page
+// starts, exclusive
+type AuditLogFilter struct {
+	Actor      string
+	TargetType string
+	TargetID   uint
+	From       time.Time
+	To         time.Time
+	Before     uint64
+	Limit      int
+}
+
+type AuditLogPage struct {
+	Items []AuditLog `json:"items"`
+	// Next is the Before of the next page. It is absent on the last page
+	Next *uint64 `json:"next,omitempty"`
+}
+
+// AuditChainVerification is the result of checking the hash chain. BrokenAt is
+// the sequence of the first record that was changed, removed or inserted
+type AuditChainVerification struct {
+	Valid    bool    `json:"valid"`
+	Records  uint64  `json:"records"`
+	BrokenAt *uint64 `json:"brokenAt,omitempty"`
+	Reason   string  `json:"reason,omitempty"`
+}
@@ -3,8 +3,21 @@ package dto
 type Customer struct {
 	ID    uint   `json:"id"`
 	Name  string `json:"name" validate:"required"`
-	CPF   string `json:"cpf" validate:"required"`
-	Email string `json:"email" validate:"required"`
+	CPF   string `json:"cpf" validate:"required,cpf"`
+	Email string `json:"email" validate:"required,email"`
+	// Version is sent in the ETag header. On updates it is the version from the
+	// If-Match header and 0 means an unconditional update
+	Version uint `json:"-"`
+}
+
+// CustomerPatch is a JSON Merge Patch (RFC 7396) of a customer. Only the fields
+// present in the body are validated and updated. The CPF can not be changed
+type CustomerPatch struct {
+	ID      uint    `json:"-"`
+	Name    *string `json:"name" validate:"omitnil,min=1"`
+	CPF     *string `json:"cpf" validate:"immutable"`
+	Email   *string `json:"email" validate:"omitnil,email"`
+	Version uint    `json:"-"`
 }
 
 type CustomerForm struct {
@@ -14,3 +27,30 @@ type CustomerForm struct {
 type CustomerResponse struct {
 	Id uint `json:"id"`
 }
+
+// BatchGetCustomers is a lookup of several customers by id and/or CPF
+type BatchGetCustomers struct {
+	IDs  []uint   `json:"ids"`
+	CPFs []string `json:"cpfs"`
+}
+
+type LookupStatus string
+
+const (
+	LookupStatusFound    LookupStatus = "FOUND"
+	LookupStatusNotFound LookupStatus = "NOT_FOUND"
+	// LookupStatusInvalid is the status of a requested CPF that is not valid
+	LookupStatusInvalid LookupStatus = "INVALID"
+)
+
+type CustomerLookup struct {
+	Status   LookupStatus `json:"status"`
+	Customer *Customer    `json:"customer,omitempty"`
+}
+
+// BatchGetCustomersResponse has one entry for every requested identifier. The
+// CPFs are keyed as they were requested, before being cleaned
+type BatchGetCustomersResponse struct {
+	ByID  map[uint]CustomerLookup   `json:"byId"`
+	ByCPF map[string]CustomerLookup `json:"byCpf"`
+}
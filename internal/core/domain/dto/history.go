@@ -0,0 +1,18 @@
+package dto
+
+import "time"
+
+// RecordVersion is a version of a customer or an admin user. Deleted is true
+// when the record was deleted in this version. The version is the current one
+// from ValidFrom until the ValidFrom of the next version
+type RecordVersion struct {
+	Version   uint      `json:"version"`
+	Action    string    `json:"action"`
+	Name      string    `json:"name"`
+	CPF       string    `json:"cpf"`
+	Email     string    `json:"email"`
+	Deleted   bool      `json:"deleted"`
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"requestId,omitempty"`
+	ValidFrom time.Time `json:"validFrom"`
+}
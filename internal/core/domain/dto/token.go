@@ -3,3 +3,11 @@ package dto
 type Token struct {
 	AccessToken string `json:"accessToken"`
 }
+
+// TokenInfo is the owner of a valid access token
+type TokenInfo struct {
+	// Username is the CPF of the customer or admin user
+	Username string
+	// Anonymous is true for the token of the unknown customer login
+	Anonymous bool
+}
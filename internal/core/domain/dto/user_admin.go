@@ -3,8 +3,21 @@ package dto
 type UserAdmin struct {
 	ID    uint   `json:"id"`
 	Name  string `json:"name" validate:"required"`
-	CPF   string `json:"cpf" validate:"required"`
-	Email string `json:"email" validate:"required"`
+	CPF   string `json:"cpf" validate:"required,cpf"`
+	Email string `json:"email" validate:"required,email"`
+	// Version is sent in the ETag header. On updates it is the version from the
+	// If-Match header and 0 means an unconditional update
+	Version uint `json:"-"`
+}
+
+// UserAdminPatch is a JSON Merge Patch (RFC 7396) of a user. Only the fields
+// present in the body are validated and updated. The CPF can not be changed
+type UserAdminPatch struct {
+	ID      uint    `json:"-"`
+	Name    *string `json:"name" validate:"omitnil,min=1"`
+	CPF     *string `json:"cpf" validate:"immutable"`
+	Email   *string `json:"email" validate:"omitnil,email"`
+	Version uint    `json:"-"`
 }
 
 type UserAdminForm struct {
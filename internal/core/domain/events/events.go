@@ -0,0 +1,108 @@
+// Package events has the domain events published to the other services. The
+// JSON of every event is described by its schema in api/events
+package events
+
+import "fmt"
+
+const (
+	TypeCustomerCreated = "customer.created"
+	TypeCustomerUpdated = "customer.updated"
+	TypeCustomerDeleted = "customer.deleted"
+	TypeAdminCreated    = "admin.created"
+)
+
+// Event is a domain event. Key is the entity of the event: the events with
+// the same key are delivered in the order they happened
+type Event interface {
+	EventType() string
+	SchemaVersion() int
+	Key() string
+}
+
+func customerKey(id uint) string {
+	return fmt.Sprintf("customer:%d", id)
+}
+
+// CustomerCreated is published when a customer signs up
+type CustomerCreated struct {
+	CustomerID uint   `json:"customerId"`
+	Name       string `json:"name"`
+	CPF        string `json:"cpf"`
+	Email      string `json:"email"`
+	Version    uint   `json:"version"`
+}
+
+func (CustomerCreated) EventType() string {
+	return TypeCustomerCreated
+}
+
+func (CustomerCreated) SchemaVersion() int {
+	return 1
+}
+
+func (e CustomerCreated) Key() string {
+	return customerKey(e.CustomerID)
+}
+
+// CustomerUpdated is published when a customer is changed or restored, with
+// the customer after the change. ChangedFields is empty on a restore
+type CustomerUpdated struct {
+	CustomerID    uint     `json:"customerId"`
+	Name          string   `json:"name"`
+	CPF           string   `json:"cpf"`
+	Email         string   `json:"email"`
+	Version       uint     `json:"version"`
+	ChangedFields []string `json:"changedFields"`
+	Restored      bool     `json:"restored"`
+}
+
+func (CustomerUpdated) EventType() string {
+	return TypeCustomerUpdated
+}
+
+func (CustomerUpdated) SchemaVersion() int {
+	return 1
+}
+
+func (e CustomerUpdated) Key() string {
+	return customerKey(e.CustomerID)
+}
+
+// CustomerDeleted is published when a customer is deleted
+type CustomerDeleted struct {
+	CustomerID uint   `json:"customerId"`
+	CPF        string `json:"cpf"`
+	Version    uint   `json:"version"`
+}
+
+func (CustomerDeleted) EventType() string {
+	return TypeCustomerDeleted
+}
+
+func (CustomerDeleted) SchemaVersion() int {
+	return 1
+}
+
+func (e CustomerDeleted) Key() string {
+	return customerKey(e.CustomerID)
+}
+
+// AdminCreated is published when an admin user is created
+type AdminCreated struct {
+	AdminID uint   `json:"adminId"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Version uint   `json:"version"`
+}
+
+func (AdminCreated) EventType() string {
+	return TypeAdminCreated
+}
+
+func (AdminCreated) SchemaVersion() int {
+	return 1
+}
+
+func (e AdminCreated) Key() string {
+	return fmt.Sprintf("admin:%d", e.AdminID)
+}
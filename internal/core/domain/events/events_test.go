@@ -0,0 +1,92 @@
+package events_test
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	schemas "github.com/thiagoluis88git/tech1-customer/api/events"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/events"
+)
+
+type eventSchema struct {
+	Properties struct {
+		Type struct {
+			Const string `json:"const"`
+		} `json:"type"`
+		SchemaVersion struct {
+			Const int `json:"const"`
+		} `json:"schemaVersion"`
+		Data struct {
+			Required   []string                   `json:"required"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"data"`
+	} `json:"properties"`
+}
+
+func TestEvents(t *testing.T) {
+	t.Parallel()
+
+	all := []events.Event{
+		events.CustomerCreated{CustomerID: 1, Name: "Teste", CPF: "12312312312", Email: "teste@teste.com", Version: 1},
+		events.CustomerUpdated{CustomerID: 1, Name: "Teste", CPF: "12312312312", Email: "teste@teste.com", Version: 2, ChangedFields: []string{"name"}},
+		events.CustomerDeleted{CustomerID: 1, CPF: "12312312312", Version: 3},
+		events.AdminCreated{AdminID: 1, Name: "Admin", Email: "admin@teste.com", Version: 1},
+	}
+
+	for _, event := range all {
+		t.Run("got data matching schema when calling "+event.EventType(), func(t *testing.T) {
+			t.Parallel()
+
+			raw, err := schemas.Schema(event.EventType(), event.SchemaVersion())
+			assert.NoError(t, err)
+
+			var schema eventSchema
+			assert.NoError(t, json.Unmarshal(raw, &schema))
+
+			assert.Equal(t, event.EventType(), schema.Properties.Type.Const)
+			assert.Equal(t, event.SchemaVersion(), schema.Properties.SchemaVersion.Const)
+
+			data, err := json.Marshal(event)
+			assert.NoError(t, err)
+
+			var fields map[string]any
+			assert.NoError(t, json.Unmarshal(data, &fields))
+
+			names := make([]string, 0, len(fields))
+
+			for name := range fields {
+				names = append(names, name)
+			}
+
+			properties := make([]string, 0, len(schema.Properties.Data.Properties))
+
+			for name := range schema.Properties.Data.Properties {
+				properties = append(properties, name)
+			}
+
+			sort.Strings(names)
+			sort.Strings(properties)
+			sort.Strings(schema.Properties.Data.Required)
+
+			assert.Equal(t, properties, names)
+			assert.Equal(t, properties, schema.Properties.Data.Required)
+		})
+	}
+
+	t.Run("got entity key when calling Key", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "customer:7", events.CustomerDeleted{CustomerID: 7}.Key())
+		assert.Equal(t, "admin:7", events.AdminCreated{AdminID: 7}.Key())
+	})
+
+	t.Run("got error when calling Schema with unknown version", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := schemas.Schema(events.TypeCustomerCreated, 99)
+
+		assert.Error(t, err)
+	})
+}
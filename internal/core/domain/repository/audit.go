@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+)
+
+type AuditRepository interface {
+	ListAuditLogs(ctx context.Context, filter dto.AuditLogFilter) ([]dto.AuditLog, error)
+	VerifyAuditChain(ctx context.Context) (dto.AuditChainVerification, error)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 )
@@ -9,8 +10,15 @@ import (
 type CustomerRepository interface {
 	CreateCustomer(ctx context.Context, customer dto.Customer) (uint, error)
 	UpdateCustomer(ctx context.Context, customer dto.Customer) error
+	PatchCustomer(ctx context.Context, patch dto.CustomerPatch) error
 	GetCustomerById(ctx context.Context, id uint) (dto.Customer, error)
 	GetCustomerByCPF(ctx context.Context, cpf string) (dto.Customer, error)
+	GetCustomersByIDsOrCPFs(ctx context.Context, ids []uint, cpfs []string) ([]dto.Customer, error)
+	DeleteCustomer(ctx context.Context, id uint) error
+	RestoreCustomer(ctx context.Context, id uint) error
+	GetCustomerHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error)
+	GetCustomerAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error)
 	Login(ctx context.Context, cpf string) (string, error)
-	LoginUnknown() (string, error)
+	LoginUnknown(ctx context.Context) (string, error)
+	ValidateToken(ctx context.Context, accessToken string) (dto.TokenInfo, error)
 }
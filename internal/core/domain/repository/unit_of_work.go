@@ -0,0 +1,11 @@
+package repository
+
+import "context"
+
+// UnitOfWork runs a function in a single database transaction. The repository
+// calls made with the context given to fn are part of the transaction, which is
+// committed when fn returns nil and rolled back otherwise. A Do inside another
+// one joins the outer transaction
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 )
@@ -9,7 +10,12 @@ import (
 type UserAdminRepository interface {
 	CreateUser(ctx context.Context, customer dto.UserAdmin) (uint, error)
 	UpdateUser(ctx context.Context, customer dto.UserAdmin) error
+	PatchUser(ctx context.Context, patch dto.UserAdminPatch) error
 	GetUserById(ctx context.Context, id uint) (dto.UserAdmin, error)
 	GetUserByCPF(ctx context.Context, cpf string) (dto.UserAdmin, error)
+	DeleteUser(ctx context.Context, id uint) error
+	RestoreUser(ctx context.Context, id uint) error
+	GetUserHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error)
+	GetUserAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error)
 	Login(ctx context.Context, cpf string) (string, error)
 }
@@ -0,0 +1,103 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+)
+
+const (
+	_auditLogDefaultLimit = 50
+	_auditLogMaxLimit     = 500
+)
+
+type ListAuditLogsUseCase interface {
+	Execute(ctx context.Context, filter dto.AuditLogFilter) (dto.AuditLogPage, error)
+}
+
+type ListAuditLogsUseCaseImpl struct {
+	repository repository.AuditRepository
+}
+
+type VerifyAuditChainUseCase interface {
+	Execute(ctx context.Context) (dto.AuditChainVerification, error)
+}
+
+type VerifyAuditChainUseCaseImpl struct {
+	repository repository.AuditRepository
+}
+
+func NewListAuditLogsUseCase(repository repository.AuditRepository) ListAuditLogsUseCase {
+	return &ListAuditLogsUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func NewVerifyAuditChainUseCase(repository repository.AuditRepository) VerifyAuditChainUseCase {
+	return &VerifyAuditChainUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func (service *ListAuditLogsUseCaseImpl) Execute(ctx context.Context, filter dto.AuditLogFilter) (response dto.AuditLogPage, err error) {
+	ctx, span := tracing.Start(ctx, "ListAuditLogsUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("ListAuditLogsUseCase", &err)
+
+	if filter.Limit < 0 || filter.Limit > _auditLogMaxLimit {
+		return dto.AuditLogPage{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Limit must be between 1 and %v", _auditLogMaxLimit),
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return dto.AuditLogPage{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "From must be before To",
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = _auditLogDefaultLimit
+	}
+
+	auditLogs, err := service.repository.ListAuditLogs(ctx, filter)
+
+	if err != nil {
+		return dto.AuditLogPage{}, responses.GetResponseError(err, "CustomerService")
+	}
+
+	response = dto.AuditLogPage{Items: auditLogs}
+
+	// a full page may not be the last one. The next page starts after the
+	// oldest record of this one
+	if len(auditLogs) == filter.Limit {
+		next := auditLogs[len(auditLogs)-1].Sequence
+		response.Next = &next
+	}
+
+	return response, nil
+}
+
+func (service *VerifyAuditChainUseCaseImpl) Execute(ctx context.Context) (response dto.AuditChainVerification, err error) {
+	ctx, span := tracing.Start(ctx, "VerifyAuditChainUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("VerifyAuditChainUseCase", &err)
+
+	verification, err := service.repository.VerifyAuditChain(ctx)
+
+	if err != nil {
+		return dto.AuditChainVerification{}, responses.GetResponseError(err, "CustomerService")
+	}
+
+	return verification, nil
+}
@@ -0,0 +1,162 @@
+package usecases
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+func mockAuditLogs(sequences ...uint64) []dto.AuditLog {
+	auditLogs := make([]dto.AuditLog, 0, len(sequences))
+
+	for _, sequence := range sequences {
+		auditLogs = append(auditLogs, dto.AuditLog{
+			Sequence:   sequence,
+			Actor:      "admin",
+			Action:     "update",
+			TargetType: "customer",
+			TargetID:   1,
+		})
+	}
+
+	return auditLogs
+}
+
+func TestAuditUseCases(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got page with default limit when listing audit logs use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewListAuditLogsUseCase(mockAuditRepository)
+
+		ctx := context.TODO()
+
+		mockAuditRepository.On("ListAuditLogs", ctx, dto.AuditLogFilter{Actor: "admin", Limit: 50}).Return(mockAuditLogs(3, 2, 1), nil)
+
+		response, err := sut.Execute(ctx, dto.AuditLogFilter{Actor: "admin"})
+
+		assert.NoError(t, err)
+		assert.Len(t, response.Items, 3)
+		assert.Nil(t, response.Next)
+	})
+
+	t.Run("got next page when listing audit logs use case with full page", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewListAuditLogsUseCase(mockAuditRepository)
+
+		ctx := context.TODO()
+
+		mockAuditRepository.On("ListAuditLogs", ctx, dto.AuditLogFilter{Before: 10, Limit: 2}).Return(mockAuditLogs(9, 8), nil)
+
+		response, err := sut.Execute(ctx, dto.AuditLogFilter{Before: 10, Limit: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, response.Items, 2)
+
+		if assert.NotNil(t, response.Next) {
+			assert.Equal(t, uint64(8), *response.Next)
+		}
+	})
+
+	t.Run("got bad request when listing audit logs use case with invalid limit", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewListAuditLogsUseCase(mockAuditRepository)
+
+		_, err := sut.Execute(context.TODO(), dto.AuditLogFilter{Limit: 501})
+
+		assert.Error(t, err)
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+		mockAuditRepository.AssertNotCalled(t, "ListAuditLogs")
+	})
+
+	t.Run("got bad request when listing audit logs use case with inverted time range", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewListAuditLogsUseCase(mockAuditRepository)
+
+		now := time.Now()
+
+		_, err := sut.Execute(context.TODO(), dto.AuditLogFilter{From: now, To: now.Add(-time.Hour)})
+
+		assert.Error(t, err)
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+		mockAuditRepository.AssertNotCalled(t, "ListAuditLogs")
+	})
+
+	t.Run("got error when listing audit logs use case with repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewListAuditLogsUseCase(mockAuditRepository)
+
+		ctx := context.TODO()
+
+		mockAuditRepository.On("ListAuditLogs", ctx, dto.AuditLogFilter{Limit: 50}).Return(nil, &responses.LocalError{
+			Code: responses.DATABASE_ERROR,
+		})
+
+		response, err := sut.Execute(ctx, dto.AuditLogFilter{})
+
+		assert.Error(t, err)
+		assert.Empty(t, response)
+	})
+
+	t.Run("got verification when verifying audit chain use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewVerifyAuditChainUseCase(mockAuditRepository)
+
+		ctx := context.TODO()
+		brokenAt := uint64(4)
+
+		mockAuditRepository.On("VerifyAuditChain", ctx).Return(dto.AuditChainVerification{
+			Valid:    false,
+			Records:  3,
+			BrokenAt: &brokenAt,
+			Reason:   "record was changed",
+		}, nil)
+
+		response, err := sut.Execute(ctx)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Valid)
+		assert.Equal(t, uint64(3), response.Records)
+		assert.Equal(t, &brokenAt, response.BrokenAt)
+	})
+
+	t.Run("got error when verifying audit chain use case with repository error", func(t *testing.T) {
+		t.Parallel()
+
+		mockAuditRepository := new(MockAuditRepository)
+		sut := NewVerifyAuditChainUseCase(mockAuditRepository)
+
+		ctx := context.TODO()
+
+		mockAuditRepository.On("VerifyAuditChain", ctx).Return(dto.AuditChainVerification{}, &responses.LocalError{
+			Code: responses.DATABASE_ERROR,
+		})
+
+		_, err := sut.Execute(ctx)
+
+		assert.Error(t, err)
+	})
+}
@@ -2,11 +2,15 @@ package usecases
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
 )
 
 type CreateCustomerUseCase interface {
@@ -16,6 +20,7 @@ type CreateCustomerUseCase interface {
 type CreateCustomerUseCaseImpl struct {
 	validateCPFUseCase *ValidateCPFUseCase
 	repository         repository.CustomerRepository
+	unitOfWork         repository.UnitOfWork
 }
 
 type UpdateCustomerUseCase interface {
@@ -25,6 +30,16 @@ type UpdateCustomerUseCase interface {
 type UpdateCustomerUseCaseImpl struct {
 	validateCPFUseCase *ValidateCPFUseCase
 	repository         repository.CustomerRepository
+	unitOfWork         repository.UnitOfWork
+}
+
+type PatchCustomerUseCase interface {
+	Execute(ctx context.Context, patch dto.CustomerPatch) error
+}
+
+type PatchCustomerUseCaseImpl struct {
+	repository repository.CustomerRepository
+	unitOfWork repository.UnitOfWork
 }
 
 type GetCustomerByCPFUseCase interface {
@@ -44,6 +59,58 @@ type GetCustomerByIdUseCaseImpl struct {
 	repository repository.CustomerRepository
 }
 
+type BatchGetCustomersUseCase interface {
+	Execute(ctx context.Context, request dto.BatchGetCustomers) (dto.BatchGetCustomersResponse, error)
+}
+
+type BatchGetCustomersUseCaseImpl struct {
+	validateCPFUseCase *ValidateCPFUseCase
+	repository         repository.CustomerRepository
+	maxItems           int
+}
+
+type DeleteCustomerUseCase interface {
+	Execute(ctx context.Context, id uint) error
+}
+
+type DeleteCustomerUseCaseImpl struct {
+	repository repository.CustomerRepository
+	unitOfWork repository.UnitOfWork
+}
+
+type RestoreCustomerUseCase interface {
+	Execute(ctx context.Context, id uint) error
+}
+
+type RestoreCustomerUseCaseImpl struct {
+	repository repository.CustomerRepository
+	unitOfWork repository.UnitOfWork
+}
+
+type GetCustomerHistoryUseCase interface {
+	Execute(ctx context.Context, id uint) ([]dto.RecordVersion, error)
+}
+
+type GetCustomerHistoryUseCaseImpl struct {
+	repository repository.CustomerRepository
+}
+
+type GetCustomerAsOfUseCase interface {
+	Execute(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error)
+}
+
+type GetCustomerAsOfUseCaseImpl struct {
+	repository repository.CustomerRepository
+}
+
+type ValidateTokenUseCase interface {
+	Execute(ctx context.Context, accessToken string) (dto.TokenInfo, error)
+}
+
+type ValidateTokenUseCaseImpl struct {
+	repository repository.CustomerRepository
+}
+
 type LoginCustomerUseCase interface {
 	Execute(ctx context.Context, cpf string) (dto.Token, error)
 }
@@ -60,17 +127,26 @@ type LoginUnknownCustomerUseCaseImpl struct {
 	repository repository.CustomerRepository
 }
 
-func NewUpdateCustomerUseCase(validateCPFUseCase *ValidateCPFUseCase, repository repository.CustomerRepository) UpdateCustomerUseCase {
+func NewUpdateCustomerUseCase(validateCPFUseCase *ValidateCPFUseCase, repository repository.CustomerRepository, unitOfWork repository.UnitOfWork) UpdateCustomerUseCase {
 	return &UpdateCustomerUseCaseImpl{
 		validateCPFUseCase: validateCPFUseCase,
 		repository:         repository,
+		unitOfWork:         unitOfWork,
+	}
+}
+
+func NewPatchCustomerUseCase(repository repository.CustomerRepository, unitOfWork repository.UnitOfWork) PatchCustomerUseCase {
+	return &PatchCustomerUseCaseImpl{
+		repository: repository,
+		unitOfWork: unitOfWork,
 	}
 }
 
-func NewCreateCustomerUseCase(validateCPFUseCase *ValidateCPFUseCase, repository repository.CustomerRepository) CreateCustomerUseCase {
+func NewCreateCustomerUseCase(validateCPFUseCase *ValidateCPFUseCase, repository repository.CustomerRepository, unitOfWork repository.UnitOfWork) CreateCustomerUseCase {
 	return &CreateCustomerUseCaseImpl{
 		validateCPFUseCase: validateCPFUseCase,
 		repository:         repository,
+		unitOfWork:         unitOfWork,
 	}
 }
 
@@ -87,6 +163,48 @@ func NewGetCustomerByIdUseCase(repository repository.CustomerRepository) GetCust
 	}
 }
 
+// NewBatchGetCustomersUseCase creates the batch lookup use case. maxItems is the
+// maximum number of ids plus CPFs of a single request
+func NewBatchGetCustomersUseCase(validateCPFUseCase *ValidateCPFUseCase, repository repository.CustomerRepository, maxItems int) BatchGetCustomersUseCase {
+	return &BatchGetCustomersUseCaseImpl{
+		validateCPFUseCase: validateCPFUseCase,
+		repository:         repository,
+		maxItems:           maxItems,
+	}
+}
+
+func NewDeleteCustomerUseCase(repository repository.CustomerRepository, unitOfWork repository.UnitOfWork) DeleteCustomerUseCase {
+	return &DeleteCustomerUseCaseImpl{
+		repository: repository,
+		unitOfWork: unitOfWork,
+	}
+}
+
+func NewRestoreCustomerUseCase(repository repository.CustomerRepository, unitOfWork repository.UnitOfWork) RestoreCustomerUseCase {
+	return &RestoreCustomerUseCaseImpl{
+		repository: repository,
+		unitOfWork: unitOfWork,
+	}
+}
+
+func NewGetCustomerHistoryUseCase(repository repository.CustomerRepository) GetCustomerHistoryUseCase {
+	return &GetCustomerHistoryUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func NewGetCustomerAsOfUseCase(repository repository.CustomerRepository) GetCustomerAsOfUseCase {
+	return &GetCustomerAsOfUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func NewValidateTokenUseCase(repository repository.CustomerRepository) ValidateTokenUseCase {
+	return &ValidateTokenUseCaseImpl{
+		repository: repository,
+	}
+}
+
 func NewLoginCustomerUseCase(repository repository.CustomerRepository) LoginCustomerUseCase {
 	return &LoginCustomerUseCaseImpl{
 		repository: repository,
@@ -99,18 +217,29 @@ func NewLoginUnknownCustomerUseCase(repository repository.CustomerRepository) Lo
 	}
 }
 
-func (service *CreateCustomerUseCaseImpl) Execute(ctx context.Context, customer dto.Customer) (dto.CustomerResponse, error) {
+func (service *CreateCustomerUseCaseImpl) Execute(ctx context.Context, customer dto.Customer) (response dto.CustomerResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CreateCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("CreateCustomerUseCase", &err)
+
 	cleanedCPF, validate := service.validateCPFUseCase.Execute(customer.CPF)
 
 	if !validate {
 		return dto.CustomerResponse{}, &responses.BusinessResponse{
 			StatusCode: http.StatusBadRequest,
 			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
 		}
 	}
 
 	customer.CPF = cleanedCPF
-	customerId, err := service.repository.CreateCustomer(ctx, customer)
+
+	var customerId uint
+
+	err = service.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		customerId, err = service.repository.CreateCustomer(ctx, customer)
+		return err
+	})
 
 	if err != nil {
 		return dto.CustomerResponse{}, responses.GetResponseError(err, "CustomerService")
@@ -121,18 +250,50 @@ func (service *CreateCustomerUseCaseImpl) Execute(ctx context.Context, customer
 	}, nil
 }
 
-func (service *UpdateCustomerUseCaseImpl) Execute(ctx context.Context, customer dto.Customer) error {
+func (service *UpdateCustomerUseCaseImpl) Execute(ctx context.Context, customer dto.Customer) (err error) {
+	ctx, span := tracing.Start(ctx, "UpdateCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("UpdateCustomerUseCase", &err)
+
 	cleanedCPF, validate := service.validateCPFUseCase.Execute(customer.CPF)
 
 	if !validate {
 		return &responses.BusinessResponse{
 			StatusCode: http.StatusBadRequest,
 			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
 		}
 	}
 
 	customer.CPF = cleanedCPF
-	err := service.repository.UpdateCustomer(ctx, customer)
+
+	err = service.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		return service.repository.UpdateCustomer(ctx, customer)
+	})
+
+	if err != nil {
+		return responses.GetResponseError(err, "CustomerService")
+	}
+
+	return nil
+}
+
+func (service *PatchCustomerUseCaseImpl) Execute(ctx context.Context, patch dto.CustomerPatch) (err error) {
+	ctx, span := tracing.Start(ctx, "PatchCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("PatchCustomerUseCase", &err)
+
+	if patch.Name == nil && patch.Email == nil {
+		return &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Patch has no field to update",
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	err = service.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		return service.repository.PatchCustomer(ctx, patch)
+	})
 
 	if err != nil {
 		return responses.GetResponseError(err, "CustomerService")
@@ -141,7 +302,11 @@ func (service *UpdateCustomerUseCaseImpl) Execute(ctx context.Context, customer
 	return nil
 }
 
-func (service *GetCustomerByIdUseCaseImpl) Execute(ctx context.Context, id uint) (dto.Customer, error) {
+func (service *GetCustomerByIdUseCaseImpl) Execute(ctx context.Context, id uint) (response dto.Customer, err error) {
+	ctx, span := tracing.Start(ctx, "GetCustomerByIdUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetCustomerByIdUseCase", &err)
+
 	customer, err := service.repository.GetCustomerById(ctx, id)
 
 	if err != nil {
@@ -151,13 +316,18 @@ func (service *GetCustomerByIdUseCaseImpl) Execute(ctx context.Context, id uint)
 	return customer, nil
 }
 
-func (service *GetCustomerByCPFUseCaseImpl) Execute(ctx context.Context, cpf string) (dto.Customer, error) {
+func (service *GetCustomerByCPFUseCaseImpl) Execute(ctx context.Context, cpf string) (response dto.Customer, err error) {
+	ctx, span := tracing.Start(ctx, "GetCustomerByCPFUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetCustomerByCPFUseCase", &err)
+
 	cleanedCPF, validate := service.validateCPFUseCase.Execute(cpf)
 
 	if !validate {
 		return dto.Customer{}, &responses.BusinessResponse{
 			StatusCode: http.StatusBadRequest,
 			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
 		}
 	}
 
@@ -170,7 +340,175 @@ func (service *GetCustomerByCPFUseCaseImpl) Execute(ctx context.Context, cpf str
 	return customer, nil
 }
 
-func (uc *LoginCustomerUseCaseImpl) Execute(ctx context.Context, cpf string) (dto.Token, error) {
+// Execute looks up all the customers with a single query. Every requested id
+// and CPF has an entry in the response. An invalid CPF does not fail the batch,
+// its entry has the INVALID status
+func (service *BatchGetCustomersUseCaseImpl) Execute(ctx context.Context, request dto.BatchGetCustomers) (response dto.BatchGetCustomersResponse, err error) {
+	ctx, span := tracing.Start(ctx, "BatchGetCustomersUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("BatchGetCustomersUseCase", &err)
+
+	items := len(request.IDs) + len(request.CPFs)
+
+	if items == 0 || items > service.maxItems {
+		return dto.BatchGetCustomersResponse{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Batch must have between 1 and %v ids and CPFs", service.maxItems),
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	response = dto.BatchGetCustomersResponse{
+		ByID:  make(map[uint]dto.CustomerLookup, len(request.IDs)),
+		ByCPF: make(map[string]dto.CustomerLookup, len(request.CPFs)),
+	}
+
+	// requestedCPFs maps the cleaned CPFs to the CPFs as they were requested
+	requestedCPFs := make(map[string][]string, len(request.CPFs))
+	cleanedCPFs := make([]string, 0, len(request.CPFs))
+
+	for _, cpf := range request.CPFs {
+		cleanedCPF, valid := service.validateCPFUseCase.Execute(cpf)
+
+		if !valid {
+			response.ByCPF[cpf] = dto.CustomerLookup{Status: dto.LookupStatusInvalid}
+			continue
+		}
+
+		if _, ok := requestedCPFs[cleanedCPF]; !ok {
+			cleanedCPFs = append(cleanedCPFs, cleanedCPF)
+		}
+
+		requestedCPFs[cleanedCPF] = append(requestedCPFs[cleanedCPF], cpf)
+		response.ByCPF[cpf] = dto.CustomerLookup{Status: dto.LookupStatusNotFound}
+	}
+
+	ids := make([]uint, 0, len(request.IDs))
+
+	for _, id := range request.IDs {
+		if _, ok := response.ByID[id]; !ok {
+			ids = append(ids, id)
+		}
+
+		response.ByID[id] = dto.CustomerLookup{Status: dto.LookupStatusNotFound}
+	}
+
+	if len(ids) == 0 && len(cleanedCPFs) == 0 {
+		return response, nil
+	}
+
+	customers, err := service.repository.GetCustomersByIDsOrCPFs(ctx, ids, cleanedCPFs)
+
+	if err != nil {
+		return dto.BatchGetCustomersResponse{}, responses.GetResponseError(err, "CustomerService")
+	}
+
+	for _, customer := range customers {
+		found := dto.CustomerLookup{Status: dto.LookupStatusFound, Customer: &customer}
+
+		if _, ok := response.ByID[customer.ID]; ok {
+			response.ByID[customer.ID] = found
+		}
+
+		for _, cpf := range requestedCPFs[customer.CPF] {
+			response.ByCPF[cpf] = found
+		}
+	}
+
+	return response, nil
+}
+
+// Execute soft deletes the customer and disables its identity provider account.
+// The customer keeps its CPF, so it can be restored later
+func (service *DeleteCustomerUseCaseImpl) Execute(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Start(ctx, "DeleteCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("DeleteCustomerUseCase", &err)
+
+	err = service.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		return service.repository.DeleteCustomer(ctx, id)
+	})
+
+	if err != nil {
+		return responses.GetResponseError(err, "CustomerService")
+	}
+
+	return nil
+}
+
+// Execute restores a soft deleted customer and enables its identity provider
+// account again
+func (service *RestoreCustomerUseCaseImpl) Execute(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Start(ctx, "RestoreCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("RestoreCustomerUseCase", &err)
+
+	err = service.unitOfWork.Do(ctx, func(ctx context.Context) error {
+		return service.repository.RestoreCustomer(ctx, id)
+	})
+
+	if err != nil {
+		return responses.GetResponseError(err, "CustomerService")
+	}
+
+	return nil
+}
+
+func (service *GetCustomerHistoryUseCaseImpl) Execute(ctx context.Context, id uint) (response []dto.RecordVersion, err error) {
+	ctx, span := tracing.Start(ctx, "GetCustomerHistoryUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetCustomerHistoryUseCase", &err)
+
+	history, err := service.repository.GetCustomerHistory(ctx, id)
+
+	if err != nil {
+		return nil, responses.GetResponseError(err, "CustomerService")
+	}
+
+	return history, nil
+}
+
+func (service *GetCustomerAsOfUseCaseImpl) Execute(ctx context.Context, id uint, at time.Time) (response dto.RecordVersion, err error) {
+	ctx, span := tracing.Start(ctx, "GetCustomerAsOfUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetCustomerAsOfUseCase", &err)
+
+	version, err := service.repository.GetCustomerAsOf(ctx, id, at)
+
+	if err != nil {
+		return dto.RecordVersion{}, responses.GetResponseError(err, "CustomerService")
+	}
+
+	return version, nil
+}
+
+func (service *ValidateTokenUseCaseImpl) Execute(ctx context.Context, accessToken string) (response dto.TokenInfo, err error) {
+	ctx, span := tracing.Start(ctx, "ValidateTokenUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("ValidateTokenUseCase", &err)
+
+	if accessToken == "" {
+		return dto.TokenInfo{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Access token is required",
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	tokenInfo, err := service.repository.ValidateToken(ctx, accessToken)
+
+	if err != nil {
+		return dto.TokenInfo{}, responses.GetResponseError(err, "CustomerService")
+	}
+
+	return tokenInfo, nil
+}
+
+func (uc *LoginCustomerUseCaseImpl) Execute(ctx context.Context, cpf string) (response dto.Token, err error) {
+	ctx, span := tracing.Start(ctx, "LoginCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("LoginCustomerUseCase", &err)
+
 	token, err := uc.repository.Login(ctx, cpf)
 
 	if err != nil {
@@ -182,8 +520,12 @@ func (uc *LoginCustomerUseCaseImpl) Execute(ctx context.Context, cpf string) (dt
 	}, nil
 }
 
-func (uc *LoginUnknownCustomerUseCaseImpl) Execute(ctx context.Context) (dto.Token, error) {
-	token, err := uc.repository.LoginUnknown()
+func (uc *LoginUnknownCustomerUseCaseImpl) Execute(ctx context.Context) (response dto.Token, err error) {
+	ctx, span := tracing.Start(ctx, "LoginUnknownCustomerUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("LoginUnknownCustomerUseCase", &err)
+
+	token, err := uc.repository.LoginUnknown(ctx)
 
 	if err != nil {
 		return dto.Token{}, responses.GetResponseError(err, "CustomerService")
@@ -5,8 +5,10 @@ import (
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
 )
@@ -47,10 +49,12 @@ func TestCustomerServices(t *testing.T) {
 		t.Parallel()
 
 		mockRepo := new(MockCustomerRepository)
-		sut := NewCreateCustomerUseCase(validateCPFUseCase, mockRepo)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewCreateCustomerUseCase(validateCPFUseCase, mockRepo, mockUnitOfWork)
 
 		ctx := context.TODO()
 
+		mockUnitOfWork.On("Do", ctx).Return(nil)
 		mockRepo.On("CreateCustomer", ctx, mockedSaveCustomer).Return(uint(1), nil)
 
 		response, err := sut.Execute(ctx, saveCustomer)
@@ -65,10 +69,12 @@ func TestCustomerServices(t *testing.T) {
 		t.Parallel()
 
 		mockRepo := new(MockCustomerRepository)
-		sut := NewCreateCustomerUseCase(validateCPFUseCase, mockRepo)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewCreateCustomerUseCase(validateCPFUseCase, mockRepo, mockUnitOfWork)
 
 		ctx := context.TODO()
 
+		mockUnitOfWork.On("Do", ctx).Return(nil)
 		mockRepo.On("CreateCustomer", ctx, mockedSaveCustomer).Return(uint(0), &responses.LocalError{
 			Code:    responses.DATABASE_CONFLICT_ERROR,
 			Message: "Conflict",
@@ -88,10 +94,12 @@ func TestCustomerServices(t *testing.T) {
 		t.Parallel()
 
 		mockRepo := new(MockCustomerRepository)
-		sut := NewUpdateCustomerUseCase(validateCPFUseCase, mockRepo)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewUpdateCustomerUseCase(validateCPFUseCase, mockRepo, mockUnitOfWork)
 
 		ctx := context.TODO()
 
+		mockUnitOfWork.On("Do", ctx).Return(nil)
 		mockRepo.On("UpdateCustomer", ctx, mockedSaveCustomer).Return(nil)
 
 		err := sut.Execute(ctx, saveCustomer)
@@ -103,10 +111,12 @@ func TestCustomerServices(t *testing.T) {
 		t.Parallel()
 
 		mockRepo := new(MockCustomerRepository)
-		sut := NewUpdateCustomerUseCase(validateCPFUseCase, mockRepo)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewUpdateCustomerUseCase(validateCPFUseCase, mockRepo, mockUnitOfWork)
 
 		ctx := context.TODO()
 
+		mockUnitOfWork.On("Do", ctx).Return(nil)
 		mockRepo.On("UpdateCustomer", ctx, mockedSaveCustomer).Return(&responses.NetworkError{
 			Code:    404,
 			Message: "Not Found",
@@ -121,6 +131,91 @@ func TestCustomerServices(t *testing.T) {
 		assert.Equal(t, http.StatusNotFound, businessError.StatusCode)
 	})
 
+	t.Run("got error without repository call when unit of work fails updating customer in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewUpdateCustomerUseCase(validateCPFUseCase, mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+
+		mockUnitOfWork.On("Do", ctx).Return(errors.New("could not begin transaction"))
+
+		err := sut.Execute(ctx, saveCustomer)
+
+		var businessError *responses.BusinessResponse
+		assert.Equal(t, true, errors.As(err, &businessError))
+		assert.Equal(t, http.StatusInternalServerError, businessError.StatusCode)
+		mockRepo.AssertNotCalled(t, "UpdateCustomer", mock.Anything, mock.Anything)
+	})
+
+	t.Run("got success when patching customer in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewPatchCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+		email := "new@teste.com"
+		patch := dto.CustomerPatch{
+			ID:      uint(1),
+			Email:   &email,
+			Version: uint(2),
+		}
+
+		mockUnitOfWork.On("Do", ctx).Return(nil)
+		mockRepo.On("PatchCustomer", ctx, patch).Return(nil)
+
+		err := sut.Execute(ctx, patch)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("got precondition failed error when patching customer with old version in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewPatchCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+		name := "New Name"
+		patch := dto.CustomerPatch{
+			ID:      uint(1),
+			Name:    &name,
+			Version: uint(1),
+		}
+
+		mockUnitOfWork.On("Do", ctx).Return(nil)
+		mockRepo.On("PatchCustomer", ctx, patch).Return(&responses.LocalError{
+			Code:    responses.PRECONDITION_FAILED_ERROR,
+			Message: "version mismatch",
+		})
+
+		err := sut.Execute(ctx, patch)
+
+		var businessError *responses.BusinessResponse
+		assert.Equal(t, true, errors.As(err, &businessError))
+		assert.Equal(t, http.StatusPreconditionFailed, businessError.StatusCode)
+	})
+
+	t.Run("got error when patching customer without fields in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewPatchCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		err := sut.Execute(context.TODO(), dto.CustomerPatch{ID: uint(1)})
+
+		var businessError *responses.BusinessResponse
+		assert.Equal(t, true, errors.As(err, &businessError))
+		assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+		mockRepo.AssertNotCalled(t, "PatchCustomer", mock.Anything, mock.Anything)
+	})
+
 	t.Run("got success when getting customer by ID in services", func(t *testing.T) {
 		t.Parallel()
 
@@ -251,7 +346,7 @@ func TestCustomerServices(t *testing.T) {
 
 		ctx := context.TODO()
 
-		mockRepo.On("LoginUnknown").Return("token", nil)
+		mockRepo.On("LoginUnknown", ctx).Return("token", nil)
 
 		response, err := sut.Execute(ctx)
 
@@ -267,7 +362,7 @@ func TestCustomerServices(t *testing.T) {
 
 		ctx := context.TODO()
 
-		mockRepo.On("LoginUnknown").Return("token", &responses.NetworkError{
+		mockRepo.On("LoginUnknown", ctx).Return("token", &responses.NetworkError{
 			Code: 401,
 		})
 
@@ -276,4 +371,300 @@ func TestCustomerServices(t *testing.T) {
 		assert.Error(t, err)
 		assert.Empty(t, response)
 	})
+
+	t.Run("got found, not found and invalid entries when batch getting customers in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewBatchGetCustomersUseCase(validateCPFUseCase, mockRepo, 100)
+
+		ctx := context.TODO()
+
+		mockRepo.On("GetCustomersByIDsOrCPFs", ctx, []uint{1, 2}, []string{"17107972073"}).Return([]dto.Customer{
+			{ID: 1, Name: "Name", CPF: "07073286083"},
+			{ID: 3, Name: "Name", CPF: "17107972073"},
+		}, nil)
+
+		response, err := sut.Execute(ctx, dto.BatchGetCustomers{
+			IDs:  []uint{1, 2, 1},
+			CPFs: []string{"171.079.720-73", "17107972073", "123"},
+		})
+
+		assert.NoError(t, err)
+		assert.Len(t, response.ByID, 2)
+		assert.Equal(t, dto.LookupStatusFound, response.ByID[1].Status)
+		assert.Equal(t, uint(1), response.ByID[1].Customer.ID)
+		assert.Equal(t, dto.LookupStatusNotFound, response.ByID[2].Status)
+		assert.Nil(t, response.ByID[2].Customer)
+		assert.Len(t, response.ByCPF, 3)
+		assert.Equal(t, uint(3), response.ByCPF["171.079.720-73"].Customer.ID)
+		assert.Equal(t, uint(3), response.ByCPF["17107972073"].Customer.ID)
+		assert.Equal(t, dto.LookupStatusInvalid, response.ByCPF["123"].Status)
+	})
+
+	t.Run("got no query when batch getting only invalid CPFs in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewBatchGetCustomersUseCase(validateCPFUseCase, mockRepo, 100)
+
+		response, err := sut.Execute(context.TODO(), dto.BatchGetCustomers{
+			CPFs: []string{"123"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, dto.LookupStatusInvalid, response.ByCPF["123"].Status)
+		mockRepo.AssertNotCalled(t, "GetCustomersByIDsOrCPFs", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("got bad request when batch getting too many customers in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewBatchGetCustomersUseCase(validateCPFUseCase, mockRepo, 100)
+
+		ids := make([]uint, 101)
+
+		for i := range ids {
+			ids[i] = uint(i + 1)
+		}
+
+		for _, request := range []dto.BatchGetCustomers{{}, {IDs: ids}} {
+			response, err := sut.Execute(context.TODO(), request)
+
+			var businessError *responses.BusinessResponse
+			assert.ErrorAs(t, err, &businessError)
+			assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+			assert.Empty(t, response)
+		}
+	})
+
+	t.Run("got bad request when batch getting more than configured max ids and CPFs in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewBatchGetCustomersUseCase(validateCPFUseCase, mockRepo, 2)
+
+		response, err := sut.Execute(context.TODO(), dto.BatchGetCustomers{
+			IDs:  []uint{1, 2},
+			CPFs: []string{"83212446293"},
+		})
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+		assert.Equal(t, "Batch must have between 1 and 2 ids and CPFs", businessError.Message)
+		assert.Empty(t, response)
+		mockRepo.AssertNotCalled(t, "GetCustomersByIDsOrCPFs")
+	})
+
+	t.Run("got error when batch getting customers in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewBatchGetCustomersUseCase(validateCPFUseCase, mockRepo, 100)
+
+		ctx := context.TODO()
+
+		mockRepo.On("GetCustomersByIDsOrCPFs", ctx, []uint{1}, []string{}).Return(nil, &responses.LocalError{
+			Code: responses.DATABASE_ERROR,
+		})
+
+		response, err := sut.Execute(ctx, dto.BatchGetCustomers{IDs: []uint{1}})
+
+		assert.Error(t, err)
+		assert.Empty(t, response)
+	})
+
+	t.Run("got token info when validating token in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewValidateTokenUseCase(mockRepo)
+
+		ctx := context.TODO()
+
+		mockRepo.On("ValidateToken", ctx, "token").Return(dto.TokenInfo{
+			Username: "17107972073",
+		}, nil)
+
+		response, err := sut.Execute(ctx, "token")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "17107972073", response.Username)
+		assert.False(t, response.Anonymous)
+	})
+
+	t.Run("got unauthorized when validating invalid token in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewValidateTokenUseCase(mockRepo)
+
+		ctx := context.TODO()
+
+		mockRepo.On("ValidateToken", ctx, "token").Return(dto.TokenInfo{}, &responses.NetworkError{
+			Code: http.StatusUnauthorized,
+		})
+
+		response, err := sut.Execute(ctx, "token")
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusUnauthorized, businessError.StatusCode)
+		assert.Empty(t, response)
+	})
+
+	t.Run("got bad request when validating empty token in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewValidateTokenUseCase(mockRepo)
+
+		response, err := sut.Execute(context.TODO(), "")
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+		assert.Empty(t, response)
+	})
+
+	t.Run("got success when deleting customer in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewDeleteCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+
+		mockUnitOfWork.On("Do", ctx).Return(nil)
+		mockRepo.On("DeleteCustomer", ctx, uint(1)).Return(nil)
+
+		err := sut.Execute(ctx, uint(1))
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("got not found error when deleting deleted customer in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewDeleteCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+
+		mockUnitOfWork.On("Do", ctx).Return(nil)
+		mockRepo.On("DeleteCustomer", ctx, uint(1)).Return(&responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		})
+
+		err := sut.Execute(ctx, uint(1))
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusNotFound, businessError.StatusCode)
+	})
+
+	t.Run("got success when restoring customer in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewRestoreCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+
+		mockUnitOfWork.On("Do", ctx).Return(nil)
+		mockRepo.On("RestoreCustomer", ctx, uint(1)).Return(nil)
+
+		err := sut.Execute(ctx, uint(1))
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("got conflict error when restoring customer not deleted in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		mockUnitOfWork := new(MockUnitOfWork)
+		sut := NewRestoreCustomerUseCase(mockRepo, mockUnitOfWork)
+
+		ctx := context.TODO()
+
+		mockUnitOfWork.On("Do", ctx).Return(nil)
+		mockRepo.On("RestoreCustomer", ctx, uint(1)).Return(&responses.LocalError{
+			Code:    responses.DATABASE_CONFLICT_ERROR,
+			Message: "record is not deleted",
+		})
+
+		err := sut.Execute(ctx, uint(1))
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusConflict, businessError.StatusCode)
+	})
+
+	t.Run("got success when getting customer history in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewGetCustomerHistoryUseCase(mockRepo)
+
+		ctx := context.TODO()
+		history := []dto.RecordVersion{
+			{Version: 2, Action: "delete", Name: "Name", Deleted: true, Actor: "admin"},
+			{Version: 1, Action: "create", Name: "Name", Actor: "admin"},
+		}
+
+		mockRepo.On("GetCustomerHistory", ctx, uint(1)).Return(history, nil)
+
+		response, err := sut.Execute(ctx, uint(1))
+
+		assert.NoError(t, err)
+		assert.Equal(t, history, response)
+	})
+
+	t.Run("got error when getting customer history in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewGetCustomerHistoryUseCase(mockRepo)
+
+		ctx := context.TODO()
+
+		mockRepo.On("GetCustomerHistory", ctx, uint(1)).Return(nil, &responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		})
+
+		response, err := sut.Execute(ctx, uint(1))
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusNotFound, businessError.StatusCode)
+		assert.Empty(t, response)
+	})
+
+	t.Run("got success when getting customer as of time in services", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockCustomerRepository)
+		sut := NewGetCustomerAsOfUseCase(mockRepo)
+
+		ctx := context.TODO()
+		at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		version := dto.RecordVersion{Version: 1, Action: "create", Name: "Name", Actor: "admin"}
+
+		mockRepo.On("GetCustomerAsOf", ctx, uint(1), at).Return(version, nil)
+
+		response, err := sut.Execute(ctx, uint(1), at)
+
+		assert.NoError(t, err)
+		assert.Equal(t, version, response)
+	})
 }
@@ -2,6 +2,7 @@ package usecases
 
 import (
 	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
@@ -35,6 +36,14 @@ type MockQRCodePaymentRepository struct {
 	mock.Mock
 }
 
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+type MockUnitOfWork struct {
+	mock.Mock
+}
+
 func (mock *MockCustomerRepository) CreateCustomer(ctx context.Context, customer dto.Customer) (uint, error) {
 	args := mock.Called(ctx, customer)
 	err := args.Error(1)
@@ -57,8 +66,8 @@ func (mock *MockCustomerRepository) Login(ctx context.Context, cpf string) (stri
 	return args.Get(0).(string), nil
 }
 
-func (mock *MockCustomerRepository) LoginUnknown() (string, error) {
-	args := mock.Called()
+func (mock *MockCustomerRepository) LoginUnknown(ctx context.Context) (string, error) {
+	args := mock.Called(ctx)
 	err := args.Error(1)
 
 	if err != nil {
@@ -68,6 +77,17 @@ func (mock *MockCustomerRepository) LoginUnknown() (string, error) {
 	return args.Get(0).(string), nil
 }
 
+func (mock *MockCustomerRepository) PatchCustomer(ctx context.Context, patch dto.CustomerPatch) error {
+	args := mock.Called(ctx, patch)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (mock *MockCustomerRepository) UpdateCustomer(ctx context.Context, customer dto.Customer) error {
 	args := mock.Called(ctx, customer)
 	err := args.Error(0)
@@ -101,6 +121,72 @@ func (mock *MockCustomerRepository) GetCustomerById(ctx context.Context, id uint
 	return args.Get(0).(dto.Customer), nil
 }
 
+func (mock *MockCustomerRepository) GetCustomersByIDsOrCPFs(ctx context.Context, ids []uint, cpfs []string) ([]dto.Customer, error) {
+	args := mock.Called(ctx, ids, cpfs)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.Customer), nil
+}
+
+func (mock *MockCustomerRepository) DeleteCustomer(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockCustomerRepository) RestoreCustomer(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockCustomerRepository) GetCustomerHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.RecordVersion), nil
+}
+
+func (mock *MockCustomerRepository) GetCustomerAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	args := mock.Called(ctx, id, at)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.RecordVersion{}, err
+	}
+
+	return args.Get(0).(dto.RecordVersion), nil
+}
+
+func (mock *MockCustomerRepository) ValidateToken(ctx context.Context, accessToken string) (dto.TokenInfo, error) {
+	args := mock.Called(ctx, accessToken)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.TokenInfo{}, err
+	}
+
+	return args.Get(0).(dto.TokenInfo), nil
+}
+
 func (mock *MockUserAdminRepository) CreateUser(ctx context.Context, customer dto.UserAdmin) (uint, error) {
 	args := mock.Called(ctx, customer)
 	err := args.Error(1)
@@ -145,6 +231,17 @@ func (mock *MockUserAdminRepository) Login(ctx context.Context, cpf string) (str
 	return args.Get(0).(string), nil
 }
 
+func (mock *MockUserAdminRepository) PatchUser(ctx context.Context, patch dto.UserAdminPatch) error {
+	args := mock.Called(ctx, patch)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (mock *MockUserAdminRepository) UpdateUser(ctx context.Context, customer dto.UserAdmin) error {
 	args := mock.Called(ctx, customer)
 	err := args.Error(0)
@@ -155,3 +252,80 @@ func (mock *MockUserAdminRepository) UpdateUser(ctx context.Context, customer dt
 
 	return nil
 }
+
+func (mock *MockUserAdminRepository) DeleteUser(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockUserAdminRepository) RestoreUser(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockUserAdminRepository) GetUserHistory(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.RecordVersion), nil
+}
+
+func (mock *MockUserAdminRepository) GetUserAsOf(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	args := mock.Called(ctx, id, at)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.RecordVersion{}, err
+	}
+
+	return args.Get(0).(dto.RecordVersion), nil
+}
+
+func (mock *MockAuditRepository) ListAuditLogs(ctx context.Context, filter dto.AuditLogFilter) ([]dto.AuditLog, error) {
+	args := mock.Called(ctx, filter)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.AuditLog), nil
+}
+
+func (mock *MockAuditRepository) VerifyAuditChain(ctx context.Context) (dto.AuditChainVerification, error) {
+	args := mock.Called(ctx)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.AuditChainVerification{}, err
+	}
+
+	return args.Get(0).(dto.AuditChainVerification), nil
+}
+
+func (mock *MockUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	args := mock.Called(ctx)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return fn(ctx)
+}
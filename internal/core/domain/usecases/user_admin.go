@@ -3,10 +3,13 @@ package usecases
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/repository"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
 )
 
 type CreateUserUseCase interface {
@@ -27,6 +30,14 @@ type UpdateUserUseCaseImpl struct {
 	repository         repository.UserAdminRepository
 }
 
+type PatchUserUseCase interface {
+	Execute(ctx context.Context, patch dto.UserAdminPatch) error
+}
+
+type PatchUserUseCaseImpl struct {
+	repository repository.UserAdminRepository
+}
+
 type GetUserByCPFUseCase interface {
 	Execute(ctx context.Context, cpf string) (dto.UserAdmin, error)
 }
@@ -44,6 +55,38 @@ type GetUserByIdUseCaseImpl struct {
 	repository repository.UserAdminRepository
 }
 
+type DeleteUserUseCase interface {
+	Execute(ctx context.Context, id uint) error
+}
+
+type DeleteUserUseCaseImpl struct {
+	repository repository.UserAdminRepository
+}
+
+type RestoreUserUseCase interface {
+	Execute(ctx context.Context, id uint) error
+}
+
+type RestoreUserUseCaseImpl struct {
+	repository repository.UserAdminRepository
+}
+
+type GetUserHistoryUseCase interface {
+	Execute(ctx context.Context, id uint) ([]dto.RecordVersion, error)
+}
+
+type GetUserHistoryUseCaseImpl struct {
+	repository repository.UserAdminRepository
+}
+
+type GetUserAsOfUseCase interface {
+	Execute(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error)
+}
+
+type GetUserAsOfUseCaseImpl struct {
+	repository repository.UserAdminRepository
+}
+
 type LoginUserUseCase interface {
 	Execute(ctx context.Context, cpf string) (dto.Token, error)
 }
@@ -59,6 +102,12 @@ func NewUpdateUserUseCase(validateCPFUseCase *ValidateCPFUseCase, repository rep
 	}
 }
 
+func NewPatchUserUseCase(repository repository.UserAdminRepository) PatchUserUseCase {
+	return &PatchUserUseCaseImpl{
+		repository: repository,
+	}
+}
+
 func NewCreateUserUseCase(validateCPFUseCase *ValidateCPFUseCase, repository repository.UserAdminRepository) CreateUserUseCase {
 	return &CreateUserUseCaseImpl{
 		validateCPFUseCase: validateCPFUseCase,
@@ -79,19 +128,48 @@ func NewGetUserByIdUseCase(repository repository.UserAdminRepository) GetUserByI
 	}
 }
 
+func NewDeleteUserUseCase(repository repository.UserAdminRepository) DeleteUserUseCase {
+	return &DeleteUserUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func NewRestoreUserUseCase(repository repository.UserAdminRepository) RestoreUserUseCase {
+	return &RestoreUserUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func NewGetUserHistoryUseCase(repository repository.UserAdminRepository) GetUserHistoryUseCase {
+	return &GetUserHistoryUseCaseImpl{
+		repository: repository,
+	}
+}
+
+func NewGetUserAsOfUseCase(repository repository.UserAdminRepository) GetUserAsOfUseCase {
+	return &GetUserAsOfUseCaseImpl{
+		repository: repository,
+	}
+}
+
 func NewLoginUserUseCase(repository repository.UserAdminRepository) LoginUserUseCase {
 	return &LoginUserUseCaseImpl{
 		repository: repository,
 	}
 }
 
-func (service *CreateUserUseCaseImpl) Execute(ctx context.Context, user dto.UserAdmin) (dto.UserAdminResponse, error) {
+func (service *CreateUserUseCaseImpl) Execute(ctx context.Context, user dto.UserAdmin) (response dto.UserAdminResponse, err error) {
+	ctx, span := tracing.Start(ctx, "CreateUserUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("CreateUserUseCase", &err)
+
 	cleanedCPF, validate := service.validateCPFUseCase.Execute(user.CPF)
 
 	if !validate {
 		return dto.UserAdminResponse{}, &responses.BusinessResponse{
 			StatusCode: http.StatusBadRequest,
 			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
 		}
 	}
 
@@ -107,18 +185,45 @@ func (service *CreateUserUseCaseImpl) Execute(ctx context.Context, user dto.User
 	}, nil
 }
 
-func (service *UpdateUserUseCaseImpl) Execute(ctx context.Context, user dto.UserAdmin) error {
+func (service *UpdateUserUseCaseImpl) Execute(ctx context.Context, user dto.UserAdmin) (err error) {
+	ctx, span := tracing.Start(ctx, "UpdateUserUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("UpdateUserUseCase", &err)
+
 	cleanedCPF, validate := service.validateCPFUseCase.Execute(user.CPF)
 
 	if !validate {
 		return &responses.BusinessResponse{
 			StatusCode: http.StatusBadRequest,
 			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
 		}
 	}
 
 	user.CPF = cleanedCPF
-	err := service.repository.UpdateUser(ctx, user)
+	err = service.repository.UpdateUser(ctx, user)
+
+	if err != nil {
+		return responses.GetResponseError(err, "UserService")
+	}
+
+	return nil
+}
+
+func (service *PatchUserUseCaseImpl) Execute(ctx context.Context, patch dto.UserAdminPatch) (err error) {
+	ctx, span := tracing.Start(ctx, "PatchUserUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("PatchUserUseCase", &err)
+
+	if patch.Name == nil && patch.Email == nil {
+		return &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Patch has no field to update",
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	err = service.repository.PatchUser(ctx, patch)
 
 	if err != nil {
 		return responses.GetResponseError(err, "UserService")
@@ -127,7 +232,11 @@ func (service *UpdateUserUseCaseImpl) Execute(ctx context.Context, user dto.User
 	return nil
 }
 
-func (service *GetUserByIdUseCaseImpl) Execute(ctx context.Context, id uint) (dto.UserAdmin, error) {
+func (service *GetUserByIdUseCaseImpl) Execute(ctx context.Context, id uint) (response dto.UserAdmin, err error) {
+	ctx, span := tracing.Start(ctx, "GetUserByIdUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetUserByIdUseCase", &err)
+
 	user, err := service.repository.GetUserById(ctx, id)
 
 	if err != nil {
@@ -137,13 +246,18 @@ func (service *GetUserByIdUseCaseImpl) Execute(ctx context.Context, id uint) (dt
 	return user, nil
 }
 
-func (service *GetUserByCPFUseCaseImpl) Execute(ctx context.Context, cpf string) (dto.UserAdmin, error) {
+func (service *GetUserByCPFUseCaseImpl) Execute(ctx context.Context, cpf string) (response dto.UserAdmin, err error) {
+	ctx, span := tracing.Start(ctx, "GetUserByCPFUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetUserByCPFUseCase", &err)
+
 	cleanedCPF, validate := service.validateCPFUseCase.Execute(cpf)
 
 	if !validate {
 		return dto.UserAdmin{}, &responses.BusinessResponse{
 			StatusCode: http.StatusBadRequest,
 			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
 		}
 	}
 
@@ -156,7 +270,67 @@ func (service *GetUserByCPFUseCaseImpl) Execute(ctx context.Context, cpf string)
 	return user, nil
 }
 
-func (uc *LoginUserUseCaseImpl) Execute(ctx context.Context, cpf string) (dto.Token, error) {
+func (service *DeleteUserUseCaseImpl) Execute(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Start(ctx, "DeleteUserUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("DeleteUserUseCase", &err)
+
+	err = service.repository.DeleteUser(ctx, id)
+
+	if err != nil {
+		return responses.GetResponseError(err, "UserService")
+	}
+
+	return nil
+}
+
+func (service *RestoreUserUseCaseImpl) Execute(ctx context.Context, id uint) (err error) {
+	ctx, span := tracing.Start(ctx, "RestoreUserUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("RestoreUserUseCase", &err)
+
+	err = service.repository.RestoreUser(ctx, id)
+
+	if err != nil {
+		return responses.GetResponseError(err, "UserService")
+	}
+
+	return nil
+}
+
+func (service *GetUserHistoryUseCaseImpl) Execute(ctx context.Context, id uint) (response []dto.RecordVersion, err error) {
+	ctx, span := tracing.Start(ctx, "GetUserHistoryUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetUserHistoryUseCase", &err)
+
+	history, err := service.repository.GetUserHistory(ctx, id)
+
+	if err != nil {
+		return nil, responses.GetResponseError(err, "UserService")
+	}
+
+	return history, nil
+}
+
+func (service *GetUserAsOfUseCaseImpl) Execute(ctx context.Context, id uint, at time.Time) (response dto.RecordVersion, err error) {
+	ctx, span := tracing.Start(ctx, "GetUserAsOfUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("GetUserAsOfUseCase", &err)
+
+	version, err := service.repository.GetUserAsOf(ctx, id, at)
+
+	if err != nil {
+		return dto.RecordVersion{}, responses.GetResponseError(err, "UserService")
+	}
+
+	return version, nil
+}
+
+func (uc *LoginUserUseCaseImpl) Execute(ctx context.Context, cpf string) (response dto.Token, err error) {
+	ctx, span := tracing.Start(ctx, "LoginUserUseCase")
+	defer tracing.End(span, &err)
+	defer metrics.ObserveUseCase("LoginUserUseCase", &err)
+
 	token, err := uc.repository.Login(ctx, cpf)
 
 	if err != nil {
@@ -2,7 +2,9 @@ package usecases
 
 import (
 	"context"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
@@ -176,6 +178,59 @@ func TestUserAdminUseCases(t *testing.T) {
 		assert.Empty(t, response)
 	})
 
+	t.Run("got success when patching user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewPatchUserUseCase(mockRepo)
+
+		ctx := context.TODO()
+		name := "New Name"
+		patch := dto.UserAdminPatch{
+			ID:   uint(1),
+			Name: &name,
+		}
+
+		mockRepo.On("PatchUser", ctx, patch).Return(nil)
+
+		err := sut.Execute(ctx, patch)
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("got error on PatchUser Repository when patching user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewPatchUserUseCase(mockRepo)
+
+		ctx := context.TODO()
+		email := "teste@teste.com"
+		patch := dto.UserAdminPatch{
+			ID:    uint(1),
+			Email: &email,
+		}
+
+		mockRepo.On("PatchUser", ctx, patch).Return(&responses.LocalError{
+			Code: responses.DATABASE_CONFLICT_ERROR,
+		})
+
+		err := sut.Execute(ctx, patch)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("got error without fields when patching user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewPatchUserUseCase(mockRepo)
+
+		err := sut.Execute(context.TODO(), dto.UserAdminPatch{ID: uint(1)})
+
+		assert.Error(t, err)
+	})
+
 	t.Run("got success when login user admin use case", func(t *testing.T) {
 		t.Parallel()
 
@@ -254,4 +309,92 @@ func TestUserAdminUseCases(t *testing.T) {
 
 		assert.Error(t, err)
 	})
+
+	t.Run("got success when deleting user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewDeleteUserUseCase(mockRepo)
+
+		ctx := context.TODO()
+
+		mockRepo.On("DeleteUser", ctx, uint(2)).Return(nil)
+
+		err := sut.Execute(ctx, uint(2))
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("got error on Cognito when deleting user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewDeleteUserUseCase(mockRepo)
+
+		ctx := context.TODO()
+
+		mockRepo.On("DeleteUser", ctx, uint(2)).Return(&responses.NetworkError{
+			Code: http.StatusServiceUnavailable,
+		})
+
+		err := sut.Execute(ctx, uint(2))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("got success when restoring user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewRestoreUserUseCase(mockRepo)
+
+		ctx := context.TODO()
+
+		mockRepo.On("RestoreUser", ctx, uint(2)).Return(nil)
+
+		err := sut.Execute(ctx, uint(2))
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("got success when getting user history user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewGetUserHistoryUseCase(mockRepo)
+
+		ctx := context.TODO()
+		history := []dto.RecordVersion{{Version: 1, Action: "create", Name: "User Name", Actor: "admin"}}
+
+		mockRepo.On("GetUserHistory", ctx, uint(2)).Return(history, nil)
+
+		response, err := sut.Execute(ctx, uint(2))
+
+		assert.NoError(t, err)
+		assert.Equal(t, history, response)
+	})
+
+	t.Run("got not found when getting user as of time before creation user admin use case", func(t *testing.T) {
+		t.Parallel()
+
+		mockRepo := new(MockUserAdminRepository)
+		sut := NewGetUserAsOfUseCase(mockRepo)
+
+		ctx := context.TODO()
+		at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+		mockRepo.On("GetUserAsOf", ctx, uint(2), at).Return(dto.RecordVersion{}, &responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		})
+
+		response, err := sut.Execute(ctx, uint(2), at)
+
+		var businessError *responses.BusinessResponse
+		assert.ErrorAs(t, err, &businessError)
+		assert.Equal(t, http.StatusNotFound, businessError.StatusCode)
+		assert.Empty(t, response)
+	})
 }
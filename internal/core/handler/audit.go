@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+)
+
+// @Summary List audit logs
+// @Description List the audit log of the changes, from the newest. The personal data of the diff is masked. Use the next field of the response as the before parameter to get the next page
+// @Tags Audit
+// @Produce json
+// @Param actor query string false "Username of who made the change"
+// @Param targetType query string false "customer or user_admin"
+// @Param targetId query int false "ID of the changed entity"
+// @Param from query string false "Start of the time range, inclusive, in RFC 3339"
+// @Param to query string false "End of the time range, exclusive, in RFC 3339"
+// @Param before query int false "Sequence where the page starts, exclusive"
+// @Param limit query int false "Page size, from 1 to 500. Default is 50"
+// @Success 200 {object} dto.AuditLogPage
+// @Failure 400 "Invalid filter"
+// @Router /api/admin/audit-logs [get]
+func ListAuditLogsHandler(listAuditLogs usecases.ListAuditLogsUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := getAuditLogFilter(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "list audit logs", err, http.StatusBadRequest)
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		response, err := listAuditLogs.Execute(r.Context(), filter)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "list audit logs", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
+
+// @Summary Verify audit chain
+// @Description Recompute the hash chain of the audit log to find records that were changed, removed or inserted
+// @Tags Audit
+// @Produce json
+// @Success 200 {object} dto.AuditChainVerification
+// @Router /api/admin/audit-logs/verify [get]
+func VerifyAuditChainHandler(verifyAuditChain usecases.VerifyAuditChainUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, err := verifyAuditChain.Execute(r.Context())
+
+		if err != nil {
+			logger.RequestError(r.Context(), "verify audit chain", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
+
+func getAuditLogFilter(r *http.Request) (dto.AuditLogFilter, error) {
+	query := r.URL.Query()
+	filter := dto.AuditLogFilter{
+		Actor:      query.Get("actor"),
+		TargetType: query.Get("targetType"),
+	}
+
+	var err error
+
+	if value := query.Get("targetId"); value != "" {
+		var targetID uint64
+		targetID, err = strconv.ParseUint(value, 10, 0)
+		filter.TargetID = uint(targetID)
+	}
+
+	if value := query.Get("from"); value != "" && err == nil {
+		filter.From, err = time.Parse(time.RFC3339, value)
+	}
+
+	if value := query.Get("to"); value != "" && err == nil {
+		filter.To, err = time.Parse(time.RFC3339, value)
+	}
+
+	if value := query.Get("before"); value != "" && err == nil {
+		filter.Before, err = strconv.ParseUint(value, 10, 64)
+	}
+
+	if value := query.Get("limit"); value != "" && err == nil {
+		filter.Limit, err = strconv.Atoi(value)
+	}
+
+	return filter, err
+}
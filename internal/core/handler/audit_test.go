@@ -0,0 +1,147 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/handler"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+func TestAuditHandler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got success when calling list audit logs handler with filters", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?actor=admin&targetType=customer&targetId=3&from=2026-10-01T00:00:00Z&to=2026-10-02T00:00:00Z&before=40&limit=2", nil)
+		recorder := httptest.NewRecorder()
+
+		next := uint64(38)
+		listAuditLogs := new(MockListAuditLogsUseCase)
+		listAuditLogs.On("Execute", req.Context(), dto.AuditLogFilter{
+			Actor:      "admin",
+			TargetType: "customer",
+			TargetID:   3,
+			From:       time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+			To:         time.Date(2026, time.October, 2, 0, 0, 0, 0, time.UTC),
+			Before:     40,
+			Limit:      2,
+		}).Return(dto.AuditLogPage{
+			Items: []dto.AuditLog{
+				{Sequence: 39, Actor: "admin", Action: "patch", TargetType: "customer", TargetID: 3, Diff: map[string]dto.AuditChange{
+					"email": {Before: "o***@gmail.com", After: "n***@gmail.com"},
+				}},
+				{Sequence: 38, Actor: "admin", Action: "create", TargetType: "customer", TargetID: 3},
+			},
+			Next: &next,
+		}, nil)
+
+		handler.ListAuditLogsHandler(listAuditLogs).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response dto.AuditLogPage
+		err := json.Unmarshal(recorder.Body.Bytes(), &response)
+
+		assert.NoError(t, err)
+		assert.Len(t, response.Items, 2)
+		assert.Equal(t, "n***@gmail.com", response.Items[0].Diff["email"].After)
+		assert.Equal(t, &next, response.Next)
+	})
+
+	t.Run("got bad request when calling list audit logs handler with invalid time", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?from=yesterday", nil)
+		recorder := httptest.NewRecorder()
+
+		listAuditLogs := new(MockListAuditLogsUseCase)
+
+		handler.ListAuditLogsHandler(listAuditLogs).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		listAuditLogs.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+
+	t.Run("got bad request when calling list audit logs handler with invalid target id", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?targetId=-1", nil)
+		recorder := httptest.NewRecorder()
+
+		listAuditLogs := new(MockListAuditLogsUseCase)
+
+		handler.ListAuditLogsHandler(listAuditLogs).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		listAuditLogs.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+
+	t.Run("got error on UseCase when calling list audit logs handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs?limit=1000", nil)
+		recorder := httptest.NewRecorder()
+
+		listAuditLogs := new(MockListAuditLogsUseCase)
+		listAuditLogs.On("Execute", req.Context(), dto.AuditLogFilter{Limit: 1000}).Return(dto.AuditLogPage{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Code:       responses.CodeBadRequest,
+		})
+
+		handler.ListAuditLogsHandler(listAuditLogs).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("got success when calling verify audit chain handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs/verify", nil)
+		recorder := httptest.NewRecorder()
+
+		brokenAt := uint64(7)
+		verifyAuditChain := new(MockVerifyAuditChainUseCase)
+		verifyAuditChain.On("Execute", req.Context()).Return(dto.AuditChainVerification{
+			Valid:    false,
+			Records:  6,
+			BrokenAt: &brokenAt,
+			Reason:   "record was changed",
+		}, nil)
+
+		handler.VerifyAuditChainHandler(verifyAuditChain).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response dto.AuditChainVerification
+		err := json.Unmarshal(recorder.Body.Bytes(), &response)
+
+		assert.NoError(t, err)
+		assert.False(t, response.Valid)
+		assert.Equal(t, &brokenAt, response.BrokenAt)
+		assert.Equal(t, "record was changed", response.Reason)
+	})
+
+	t.Run("got error on UseCase when calling verify audit chain handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/audit-logs/verify", nil)
+		recorder := httptest.NewRecorder()
+
+		verifyAuditChain := new(MockVerifyAuditChainUseCase)
+		verifyAuditChain.On("Execute", req.Context()).Return(dto.AuditChainVerification{}, &responses.LocalError{
+			Code: responses.DATABASE_ERROR,
+		})
+
+		handler.VerifyAuditChainHandler(verifyAuditChain).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+	})
+}
@@ -1,12 +1,12 @@
 package handler
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
 )
 
 // @Summary Login
@@ -25,22 +25,16 @@ func LoginCustomerHandler(loginCustomerUseCase usecases.LoginCustomerUseCase) ht
 		err := httpserver.DecodeJSONBody(w, r, &customerForm)
 
 		if err != nil {
-			log.Print("decoding customer form body", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "decoding customer form body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		token, err := loginCustomerUseCase.Execute(r.Context(), customerForm.CPF)
 
 		if err != nil {
-			log.Print("login user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "login user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -61,11 +55,8 @@ func LoginUnknownCustomerHandler(loginCustomerUseCase usecases.LoginUnknownCusto
 		token, err := loginCustomerUseCase.Execute(r.Context())
 
 		if err != nil {
-			log.Print("login user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "login user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -1,13 +1,15 @@
 package handler
 
 import (
-	"log"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
 )
 
 // @Summary Create new customer
@@ -16,9 +18,11 @@ import (
 // @Accept json
 // @Produce json
 // @Param product body dto.Customer true "customer"
+// @Param Idempotency-Key header string false "Unique key to retry the sign up safely"
 // @Success 200 {object} dto.CustomerResponse
 // @Failure 400 "Customer has required fields"
-// @Failure 409 "This Customer is already added"
+// @Failure 409 "This Customer is already added or a request with the same Idempotency-Key is in progress"
+// @Failure 422 "Idempotency-Key reused with a different request"
 // @Router /auth/signup [post]
 func CreateCustomerHandler(createCustomer usecases.CreateCustomerUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -27,22 +31,16 @@ func CreateCustomerHandler(createCustomer usecases.CreateCustomerUseCase) http.H
 		err := httpserver.DecodeJSONBody(w, r, &customer)
 
 		if err != nil {
-			log.Print("decoding customer body", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "decoding customer body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
 		response, err := createCustomer.Execute(r.Context(), customer)
 
 		if err != nil {
-			log.Print("create customer", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "create customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -56,32 +54,37 @@ func CreateCustomerHandler(createCustomer usecases.CreateCustomerUseCase) http.H
 // @Accept json
 // @Produce json
 // @Param id path int true "12"
+// @Param If-Match header string false "ETag of the GET response. Required when IF_MATCH_REQUIRED is enabled"
 // @Param product body dto.Customer true "customer"
 // @Success 204
 // @Failure 400 "Customer has required fields"
 // @Failure 404 "Customer not found"
+// @Failure 412 "The resource was changed by another request"
+// @Failure 428 "If-Match header is required"
 // @Router /api/admin/customers/{id} [put]
 func UpdateCustomerHandler(updateCustomer usecases.UpdateCustomerUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
 
 		if err != nil {
-			log.Print("update customer", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "update customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		customerId, err := strconv.Atoi(customerIdStr)
 
 		if err != nil {
-			log.Print("update customer", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "update customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		version, err := httpserver.GetIfMatchVersion(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "update customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -89,23 +92,83 @@ func UpdateCustomerHandler(updateCustomer usecases.UpdateCustomerUseCase) http.H
 		err = httpserver.DecodeJSONBody(w, r, &customer)
 
 		if err != nil {
-			log.Print("decoding customer body for update", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "decoding customer body for update", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
 		customer.ID = uint(customerId)
+		customer.Version = version
 		err = updateCustomer.Execute(r.Context(), customer)
 
 		if err != nil {
-			log.Print("update customer", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "update customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseNoContentSuccess(w)
+	}
+}
+
+// @Summary Patch customer
+// @Description Update only the given fields of the customer with a JSON Merge Patch (RFC 7396). The CPF can not be changed
+// @Tags Customer
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "12"
+// @Param If-Match header string false "ETag of the GET response. Required when IF_MATCH_REQUIRED is enabled"
+// @Param patch body dto.CustomerPatch true "customer patch"
+// @Success 204
+// @Failure 400 "Invalid field, null field or CPF change"
+// @Failure 404 "Customer not found"
+// @Failure 409 "Email already registered"
+// @Failure 412 "The resource was changed by another request"
+// @Failure 415 "Content-Type is not application/merge-patch+json"
+// @Failure 428 "If-Match header is required"
+// @Router /api/admin/customers/{id} [patch]
+func PatchCustomerHandler(patchCustomer usecases.PatchCustomerUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		customerId, err := strconv.Atoi(customerIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		version, err := httpserver.GetIfMatchVersion(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		var patch dto.CustomerPatch
+		err = httpserver.DecodeMergePatchBody(w, r, &patch)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "decoding customer patch body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		patch.ID = uint(customerId)
+		patch.Version = version
+		err = patchCustomer.Execute(r.Context(), patch)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -120,6 +183,7 @@ func UpdateCustomerHandler(updateCustomer usecases.UpdateCustomerUseCase) http.H
 // @Produce json
 // @Param Id path string true "12"
 // @Success 200 {object} dto.Customer
+// @Header 200 {string} ETag "Version of the resource to be sent in If-Match"
 // @Failure 404 "Customer not found"
 // @Router /api/customers/{id} [get]
 func GetCustomerByIdHandler(getCustomerById usecases.GetCustomerByIdUseCase) http.HandlerFunc {
@@ -127,36 +191,28 @@ func GetCustomerByIdHandler(getCustomerById usecases.GetCustomerByIdUseCase) htt
 		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
 
 		if err != nil {
-			log.Print("get customer by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "get customer by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		customerId, err := strconv.Atoi(customerIdStr)
 
 		if err != nil {
-			log.Print("get customer by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "get customer by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		customer, err := getCustomerById.Execute(r.Context(), uint(customerId))
 
 		if err != nil {
-			log.Print("get customer by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "get customer by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
+		httpserver.SetETag(w, customer.Version)
 		httpserver.SendResponseSuccess(w, customer)
 	}
 }
@@ -168,6 +224,7 @@ func GetCustomerByIdHandler(getCustomerById usecases.GetCustomerByIdUseCase) htt
 // @Produce json
 // @Param customer body dto.CustomerForm true "customerForm"
 // @Success 200 {object} dto.Customer
+// @Header 200 {string} ETag "Version of the resource to be sent in If-Match"
 // @Failure 404 "Customer not found"
 // @Router /api/customers/{cpf} [post]
 func GetCustomerByCPFHandler(getCustomerByCPF usecases.GetCustomerByCPFUseCase) http.HandlerFunc {
@@ -175,25 +232,228 @@ func GetCustomerByCPFHandler(getCustomerByCPF usecases.GetCustomerByCPFUseCase)
 		cpf, err := httpserver.GetPathParamFromRequest(r, "cpf")
 
 		if err != nil {
-			log.Print("update customer", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "update customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		customer, err := getCustomerByCPF.Execute(r.Context(), cpf)
 
 		if err != nil {
-			log.Print("get customer by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "get customer by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
+		httpserver.SetETag(w, customer.Version)
 		httpserver.SendResponseSuccess(w, customer)
 	}
 }
+
+// @Summary Get several customers
+// @Description Get several customers by ids and/or CPFs with a single query. Every requested identifier
+// @Description has an entry in the response, keyed as it was sent, with the FOUND, NOT_FOUND or INVALID status
+// @Tags Customer
+// @Accept json
+// @Produce json
+// @Param request body dto.BatchGetCustomers true "ids and CPFs"
+// @Success 200 {object} dto.BatchGetCustomersResponse
+// @Failure 400 "No identifier or more than BATCH_GET_MAX_ITEMS identifiers"
+// @Router /api/customers/batch-get [post]
+func BatchGetCustomersHandler(batchGetCustomers usecases.BatchGetCustomersUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request dto.BatchGetCustomers
+
+		err := httpserver.DecodeJSONBody(w, r, &request)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "decoding batch get customers body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		response, err := batchGetCustomers.Execute(r.Context(), request)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "batch get customers", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
+
+// @Summary Delete customer
+// @Description Soft delete the customer and disable its identity provider account. The customer is kept in the history and can be restored
+// @Tags Customer
+// @Produce json
+// @Param id path int true "12"
+// @Success 204
+// @Failure 404 "Customer not found or already deleted"
+// @Router /api/admin/customers/{id} [delete]
+func DeleteCustomerHandler(deleteCustomer usecases.DeleteCustomerUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "delete customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		customerId, err := strconv.Atoi(customerIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "delete customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		err = deleteCustomer.Execute(r.Context(), uint(customerId))
+
+		if err != nil {
+			logger.RequestError(r.Context(), "delete customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseNoContentSuccess(w)
+	}
+}
+
+// @Summary Restore customer
+// @Description Restore a soft deleted customer and enable its identity provider account again
+// @Tags Customer
+// @Produce json
+// @Param id path int true "12"
+// @Success 204
+// @Failure 404 "Customer not found"
+// @Failure 409 "Customer is not deleted"
+// @Router /api/admin/customers/{id}/restore [post]
+func RestoreCustomerHandler(restoreCustomer usecases.RestoreCustomerUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "restore customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		customerId, err := strconv.Atoi(customerIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "restore customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		err = restoreCustomer.Execute(r.Context(), uint(customerId))
+
+		if err != nil {
+			logger.RequestError(r.Context(), "restore customer", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseNoContentSuccess(w)
+	}
+}
+
+// @Summary Get customer history
+// @Description List every version of the customer, from the newest, with who made the change and when. Deleted customers are included
+// @Tags Customer
+// @Produce json
+// @Param id path int true "12"
+// @Success 200 {array} dto.RecordVersion
+// @Failure 404 "Customer not found"
+// @Router /api/admin/customers/{id}/history [get]
+func GetCustomerHistoryHandler(getCustomerHistory usecases.GetCustomerHistoryUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer history", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		customerId, err := strconv.Atoi(customerIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer history", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		response, err := getCustomerHistory.Execute(r.Context(), uint(customerId))
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer history", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
+
+// @Summary Get customer as of a time
+// @Description Get the version of the customer that was the current one at the given time. The deleted field tells if the customer was deleted then
+// @Tags Customer
+// @Produce json
+// @Param id path int true "12"
+// @Param at query string true "Time in RFC 3339"
+// @Success 200 {object} dto.RecordVersion
+// @Failure 400 "Invalid time"
+// @Failure 404 "Customer did not exist at the given time"
+// @Router /api/admin/customers/{id}/as-of [get]
+func GetCustomerAsOfHandler(getCustomerAsOf usecases.GetCustomerAsOfUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		customerIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer as of", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		customerId, err := strconv.Atoi(customerIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer as of", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		at, err := getAsOfTime(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer as of", err, http.StatusBadRequest)
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		response, err := getCustomerAsOf.Execute(r.Context(), uint(customerId), at)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get customer as of", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
+
+// getAsOfTime parses the required at query parameter of the as of routes
+func getAsOfTime(r *http.Request) (time.Time, error) {
+	value := r.URL.Query().Get("at")
+
+	if value == "" {
+		return time.Time{}, errors.New("at is required")
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
@@ -170,6 +171,152 @@ func TestCustomerHandler(t *testing.T) {
 		assert.Equal(t, http.StatusNoContent, recorder.Code)
 	})
 
+	t.Run("got success with version when calling update customer handler with If-Match", func(t *testing.T) {
+		t.Parallel()
+
+		jsonData, err := json.Marshal(mockCustomer())
+
+		assert.NoError(t, err)
+
+		body := bytes.NewBuffer(jsonData)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/customer/{id}", body)
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("If-Match", `"4"`)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		updateCustomerUseCase := new(MockUpdateCustomerUseCase)
+
+		updateCustomerUseCase.On("Execute", req.Context(), dto.Customer{
+			ID:      uint(123),
+			Name:    "Teste",
+			CPF:     "83212446293",
+			Email:   "teste@gmail.com",
+			Version: uint(4),
+		}).Return(nil)
+
+		updateCustomerHandler := handler.UpdateCustomerHandler(updateCustomerUseCase)
+
+		updateCustomerHandler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got precondition failed when calling update customer handler with old version", func(t *testing.T) {
+		t.Parallel()
+
+		jsonData, err := json.Marshal(mockCustomer())
+
+		assert.NoError(t, err)
+
+		body := bytes.NewBuffer(jsonData)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/customer/{id}", body)
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("If-Match", `"3"`)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		updateCustomerUseCase := new(MockUpdateCustomerUseCase)
+
+		updateCustomerUseCase.On("Execute", req.Context(), mock.Anything).Return(responses.GetResponseError(&responses.LocalError{
+			Code:    responses.PRECONDITION_FAILED_ERROR,
+			Message: "version mismatch",
+		}, "CustomerService"))
+
+		updateCustomerHandler := handler.UpdateCustomerHandler(updateCustomerUseCase)
+
+		updateCustomerHandler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), string(responses.CodePreconditionFailed))
+	})
+
+	t.Run("got constraint and column in problem when calling update customer handler with constraint violation", func(t *testing.T) {
+		t.Parallel()
+
+		jsonData, err := json.Marshal(mockCustomer())
+
+		assert.NoError(t, err)
+
+		body := bytes.NewBuffer(jsonData)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/customer/{id}", body)
+		req.Header.Add("Content-Type", "application/json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		updateCustomerUseCase := new(MockUpdateCustomerUseCase)
+
+		updateCustomerUseCase.On("Execute", req.Context(), mock.Anything).Return(responses.GetResponseError(&responses.LocalError{
+			Code:       responses.DATABASE_CONSTRAINT_ERROR,
+			Message:    "null value in column \"name\" of relation \"customers\" violates not-null constraint",
+			Constraint: "chk_customers_name",
+			Column:     "name",
+		}, "CustomerService"))
+
+		updateCustomerHandler := handler.UpdateCustomerHandler(updateCustomerUseCase)
+
+		updateCustomerHandler.ServeHTTP(recorder, req)
+
+		var problem responses.ProblemDetails
+
+		err = json.Unmarshal(recorder.Body.Bytes(), &problem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, responses.CodeConstraintViolation, problem.Code)
+		assert.Equal(t, "chk_customers_name", problem.Constraint)
+		assert.Equal(t, "name", problem.Column)
+		assert.NotContains(t, recorder.Body.String(), "violates")
+	})
+
+	t.Run("got precondition failed when calling update customer handler with weak If-Match", func(t *testing.T) {
+		t.Parallel()
+
+		jsonData, err := json.Marshal(mockCustomer())
+
+		assert.NoError(t, err)
+
+		body := bytes.NewBuffer(jsonData)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/customer/{id}", body)
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("If-Match", `W/"3"`)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		updateCustomerUseCase := new(MockUpdateCustomerUseCase)
+
+		updateCustomerHandler := handler.UpdateCustomerHandler(updateCustomerUseCase)
+
+		updateCustomerHandler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+		updateCustomerUseCase.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+
 	t.Run("got error without param when calling update customer handler", func(t *testing.T) {
 		t.Parallel()
 
@@ -311,10 +458,11 @@ func TestCustomerHandler(t *testing.T) {
 		getCustomerById := new(MockGetCustomerByIdUseCase)
 
 		getCustomerById.On("Execute", req.Context(), uint(123)).Return(dto.Customer{
-			ID:    uint(123),
-			Name:  "Teste",
-			CPF:   "83212446293",
-			Email: "teste@gmail.com",
+			ID:      uint(123),
+			Name:    "Teste",
+			CPF:     "83212446293",
+			Email:   "teste@gmail.com",
+			Version: uint(4),
 		}, nil)
 
 		getCustomerByIdHandler := handler.GetCustomerByIdHandler(getCustomerById)
@@ -322,6 +470,7 @@ func TestCustomerHandler(t *testing.T) {
 		getCustomerByIdHandler.ServeHTTP(recorder, req)
 
 		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `"4"`, recorder.Header().Get("ETag"))
 
 		var customer dto.Customer
 		err := json.Unmarshal(recorder.Body.Bytes(), &customer)
@@ -514,4 +663,384 @@ func TestCustomerHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, recorder.Code)
 	})
+
+	t.Run("got lookups keyed by identifier when calling batch get customers handler", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"ids": [1, 2], "cpfs": ["832.124.462-93"]}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/customers/batch-get", body)
+		req.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		batchGetCustomers := new(MockBatchGetCustomersUseCase)
+
+		batchGetCustomers.On("Execute", req.Context(), dto.BatchGetCustomers{
+			IDs:  []uint{1, 2},
+			CPFs: []string{"832.124.462-93"},
+		}).Return(dto.BatchGetCustomersResponse{
+			ByID: map[uint]dto.CustomerLookup{
+				1: {Status: dto.LookupStatusFound, Customer: &dto.Customer{ID: 1, Name: "Teste", CPF: "83212446293"}},
+				2: {Status: dto.LookupStatusNotFound},
+			},
+			ByCPF: map[string]dto.CustomerLookup{
+				"832.124.462-93": {Status: dto.LookupStatusFound, Customer: &dto.Customer{ID: 1, Name: "Teste", CPF: "83212446293"}},
+			},
+		}, nil)
+
+		handler.BatchGetCustomersHandler(batchGetCustomers).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var response dto.BatchGetCustomersResponse
+		err := json.Unmarshal(recorder.Body.Bytes(), &response)
+
+		assert.NoError(t, err)
+		assert.Equal(t, dto.LookupStatusFound, response.ByID[1].Status)
+		assert.Equal(t, dto.LookupStatusNotFound, response.ByID[2].Status)
+		assert.Nil(t, response.ByID[2].Customer)
+		assert.Equal(t, uint(1), response.ByCPF["832.124.462-93"].Customer.ID)
+	})
+
+	t.Run("got error on UseCase when calling batch get customers handler", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"ids": []}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/customers/batch-get", body)
+		req.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		batchGetCustomers := new(MockBatchGetCustomersUseCase)
+
+		batchGetCustomers.On("Execute", req.Context(), dto.BatchGetCustomers{
+			IDs: []uint{},
+		}).Return(dto.BatchGetCustomersResponse{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Code:       responses.CodeBadRequest,
+		})
+
+		handler.BatchGetCustomersHandler(batchGetCustomers).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("got error with invalid json when calling batch get customers handler", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"ids": ["one"]}`)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/customers/batch-get", body)
+		req.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+
+		batchGetCustomers := new(MockBatchGetCustomersUseCase)
+
+		handler.BatchGetCustomersHandler(batchGetCustomers).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		batchGetCustomers.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+
+	t.Run("got success with only present fields when calling patch customer handler", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"email": "new@gmail.com"}`)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/admin/customers/{id}", body)
+		req.Header.Add("Content-Type", "application/merge-patch+json")
+		req.Header.Add("If-Match", `"2"`)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		email := "new@gmail.com"
+		patchCustomerUseCase := new(MockPatchCustomerUseCase)
+		patchCustomerUseCase.On("Execute", req.Context(), dto.CustomerPatch{
+			ID:      uint(123),
+			Email:   &email,
+			Version: uint(2),
+		}).Return(nil)
+
+		handler.PatchCustomerHandler(patchCustomerUseCase).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got validation error when calling patch customer handler changing cpf", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"cpf": "83212446293"}`)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/admin/customers/{id}", body)
+		req.Header.Add("Content-Type", "application/merge-patch+json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		patchCustomerUseCase := new(MockPatchCustomerUseCase)
+
+		handler.PatchCustomerHandler(patchCustomerUseCase).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+		var problem responses.ProblemDetails
+		err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, responses.CodeValidationFailed, problem.Code)
+		assert.Equal(t, []responses.FieldError{{Pointer: "/cpf", Rule: "immutable", Message: "can not be changed"}}, problem.Errors)
+		patchCustomerUseCase.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+
+	t.Run("got validation error when calling patch customer handler with null or invalid fields", func(t *testing.T) {
+		t.Parallel()
+
+		for body, pointer := range map[string]string{
+			`{"name": null}`:         "/name",
+			`{"name": ""}`:           "/name",
+			`{"email": "not-email"}`: "/email",
+		} {
+			req := httptest.NewRequest(http.MethodPatch, "/api/admin/customers/{id}", bytes.NewBufferString(body))
+			req.Header.Add("Content-Type", "application/merge-patch+json")
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "123")
+
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			recorder := httptest.NewRecorder()
+
+			handler.PatchCustomerHandler(new(MockPatchCustomerUseCase)).ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+			var problem responses.ProblemDetails
+			err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+
+			assert.NoError(t, err)
+			assert.Len(t, problem.Errors, 1)
+			assert.Equal(t, pointer, problem.Errors[0].Pointer)
+		}
+	})
+
+	t.Run("got unsupported media type when calling patch customer handler with json content type", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"email": "new@gmail.com"}`)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/admin/customers/{id}", body)
+		req.Header.Add("Content-Type", "application/json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		handler.PatchCustomerHandler(new(MockPatchCustomerUseCase)).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+	})
+
+	t.Run("got success when calling delete customer handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/customers/{id}", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		deleteCustomer := new(MockDeleteCustomerUseCase)
+		deleteCustomer.On("Execute", req.Context(), uint(123)).Return(nil)
+
+		handler.DeleteCustomerHandler(deleteCustomer).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		deleteCustomer.AssertExpectations(t)
+	})
+
+	t.Run("got not found when calling delete customer handler with deleted customer", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/customers/{id}", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		deleteCustomer := new(MockDeleteCustomerUseCase)
+		deleteCustomer.On("Execute", req.Context(), uint(123)).Return(&responses.BusinessResponse{
+			StatusCode: http.StatusNotFound,
+		})
+
+		handler.DeleteCustomerHandler(deleteCustomer).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("got error with invalid param when calling delete customer handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/admin/customers/{id}", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123srvb")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		deleteCustomer := new(MockDeleteCustomerUseCase)
+
+		handler.DeleteCustomerHandler(deleteCustomer).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		deleteCustomer.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything)
+	})
+
+	t.Run("got success when calling restore customer handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/customers/{id}/restore", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		restoreCustomer := new(MockRestoreCustomerUseCase)
+		restoreCustomer.On("Execute", req.Context(), uint(123)).Return(nil)
+
+		handler.RestoreCustomerHandler(restoreCustomer).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got conflict when calling restore customer handler with customer not deleted", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/customers/{id}/restore", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		restoreCustomer := new(MockRestoreCustomerUseCase)
+		restoreCustomer.On("Execute", req.Context(), uint(123)).Return(&responses.BusinessResponse{
+			StatusCode: http.StatusConflict,
+		})
+
+		handler.RestoreCustomerHandler(restoreCustomer).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusConflict, recorder.Code)
+	})
+
+	t.Run("got success when calling get customer history handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/customers/{id}/history", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		getCustomerHistory := new(MockGetCustomerHistoryUseCase)
+		getCustomerHistory.On("Execute", req.Context(), uint(123)).Return([]dto.RecordVersion{
+			{Version: 2, Action: "delete", Name: "Teste", Deleted: true, Actor: "admin"},
+			{Version: 1, Action: "create", Name: "Teste", Actor: "admin"},
+		}, nil)
+
+		handler.GetCustomerHistoryHandler(getCustomerHistory).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var history []dto.RecordVersion
+		err := json.Unmarshal(recorder.Body.Bytes(), &history)
+
+		assert.NoError(t, err)
+		assert.Len(t, history, 2)
+		assert.True(t, history[0].Deleted)
+	})
+
+	t.Run("got success when calling get customer as of handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/customers/{id}/as-of?at=2024-05-01T10:00:00Z", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "123")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		at := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+		getCustomerAsOf := new(MockGetCustomerAsOfUseCase)
+		getCustomerAsOf.On("Execute", req.Context(), uint(123), at).Return(dto.RecordVersion{
+			Version: 1,
+			Action:  "create",
+			Name:    "Teste",
+		}, nil)
+
+		handler.GetCustomerAsOfHandler(getCustomerAsOf).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var version dto.RecordVersion
+		err := json.Unmarshal(recorder.Body.Bytes(), &version)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Teste", version.Name)
+	})
+
+	t.Run("got error with invalid time when calling get customer as of handler", func(t *testing.T) {
+		t.Parallel()
+
+		for _, query := range []string{"", "?at=yesterday"} {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/customers/{id}/as-of"+query, nil)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "123")
+
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			recorder := httptest.NewRecorder()
+
+			getCustomerAsOf := new(MockGetCustomerAsOfUseCase)
+
+			handler.GetCustomerAsOfHandler(getCustomerAsOf).ServeHTTP(recorder, req)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			getCustomerAsOf.AssertNotCalled(t, "Execute", mock.Anything, mock.Anything, mock.Anything)
+		}
+	})
 }
@@ -3,6 +3,7 @@ package handler_test
 import (
 	"context"
 	"os"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
@@ -23,12 +24,13 @@ func setup() {
 	os.Setenv(environment.WebhookMercadoLivrePaymentURL, "WEBHOOK")
 	os.Setenv(environment.QRCodeGatewayToken, "token")
 	os.Setenv(environment.Region, "Region")
+	os.Setenv(environment.EncryptionIndexKey, "IndexKey")
 }
 
 func mockCreateUserForm() dto.UserAdmin {
 	return dto.UserAdmin{
 		Name:  "Name",
-		CPF:   "12345678910",
+		CPF:   "83212446293",
 		Email: "teste@email.com",
 	}
 }
@@ -37,7 +39,7 @@ func mockUpdateUserForm() dto.UserAdmin {
 	return dto.UserAdmin{
 		ID:    uint(3),
 		Name:  "Name",
-		CPF:   "12345678910",
+		CPF:   "83212446293",
 		Email: "teste@email.com",
 	}
 }
@@ -52,6 +54,14 @@ type MockCreateCustomerUseCase struct {
 	mock.Mock
 }
 
+type MockPatchCustomerUseCase struct {
+	mock.Mock
+}
+
+type MockPatchUserUseCase struct {
+	mock.Mock
+}
+
 type MockUpdateCustomerUseCase struct {
 	mock.Mock
 }
@@ -64,10 +74,22 @@ type MockGetCustomerByCPFUseCase struct {
 	mock.Mock
 }
 
+type MockBatchGetCustomersUseCase struct {
+	mock.Mock
+}
+
 type MockLoginCustomerUseCase struct {
 	mock.Mock
 }
 
+type MockListAuditLogsUseCase struct {
+	mock.Mock
+}
+
+type MockVerifyAuditChainUseCase struct {
+	mock.Mock
+}
+
 type MockLoginUnknownCustomerUseCase struct {
 	mock.Mock
 }
@@ -164,6 +186,38 @@ type MockLoginUserUseCase struct {
 	mock.Mock
 }
 
+type MockDeleteCustomerUseCase struct {
+	mock.Mock
+}
+
+type MockRestoreCustomerUseCase struct {
+	mock.Mock
+}
+
+type MockGetCustomerHistoryUseCase struct {
+	mock.Mock
+}
+
+type MockGetCustomerAsOfUseCase struct {
+	mock.Mock
+}
+
+type MockDeleteUserUseCase struct {
+	mock.Mock
+}
+
+type MockRestoreUserUseCase struct {
+	mock.Mock
+}
+
+type MockGetUserHistoryUseCase struct {
+	mock.Mock
+}
+
+type MockGetUserAsOfUseCase struct {
+	mock.Mock
+}
+
 func (mock *MockCreateCustomerUseCase) Execute(ctx context.Context, customer dto.Customer) (dto.CustomerResponse, error) {
 	args := mock.Called(ctx, customer)
 	err := args.Error(1)
@@ -186,6 +240,17 @@ func (mock *MockUpdateCustomerUseCase) Execute(ctx context.Context, customer dto
 	return nil
 }
 
+func (mock *MockPatchCustomerUseCase) Execute(ctx context.Context, patch dto.CustomerPatch) error {
+	args := mock.Called(ctx, patch)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (mock *MockGetCustomerByIdUseCase) Execute(ctx context.Context, id uint) (dto.Customer, error) {
 	args := mock.Called(ctx, id)
 	err := args.Error(1)
@@ -208,6 +273,39 @@ func (mock *MockGetCustomerByCPFUseCase) Execute(ctx context.Context, cpf string
 	return args.Get(0).(dto.Customer), nil
 }
 
+func (mock *MockBatchGetCustomersUseCase) Execute(ctx context.Context, request dto.BatchGetCustomers) (dto.BatchGetCustomersResponse, error) {
+	args := mock.Called(ctx, request)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.BatchGetCustomersResponse{}, err
+	}
+
+	return args.Get(0).(dto.BatchGetCustomersResponse), nil
+}
+
+func (mock *MockListAuditLogsUseCase) Execute(ctx context.Context, filter dto.AuditLogFilter) (dto.AuditLogPage, error) {
+	args := mock.Called(ctx, filter)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.AuditLogPage{}, err
+	}
+
+	return args.Get(0).(dto.AuditLogPage), nil
+}
+
+func (mock *MockVerifyAuditChainUseCase) Execute(ctx context.Context) (dto.AuditChainVerification, error) {
+	args := mock.Called(ctx)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.AuditChainVerification{}, err
+	}
+
+	return args.Get(0).(dto.AuditChainVerification), nil
+}
+
 func (mock *MockLoginCustomerUseCase) Execute(ctx context.Context, cpf string) (dto.Token, error) {
 	args := mock.Called(ctx, cpf)
 	err := args.Error(1)
@@ -284,3 +382,102 @@ func (mock *MockUpdateUserUseCase) Execute(ctx context.Context, user dto.UserAdm
 
 	return nil
 }
+
+func (mock *MockPatchUserUseCase) Execute(ctx context.Context, patch dto.UserAdminPatch) error {
+	args := mock.Called(ctx, patch)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockDeleteCustomerUseCase) Execute(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockRestoreCustomerUseCase) Execute(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockGetCustomerHistoryUseCase) Execute(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.RecordVersion), nil
+}
+
+func (mock *MockGetCustomerAsOfUseCase) Execute(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	args := mock.Called(ctx, id, at)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.RecordVersion{}, err
+	}
+
+	return args.Get(0).(dto.RecordVersion), nil
+}
+
+func (mock *MockDeleteUserUseCase) Execute(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockRestoreUserUseCase) Execute(ctx context.Context, id uint) error {
+	args := mock.Called(ctx, id)
+	err := args.Error(0)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (mock *MockGetUserHistoryUseCase) Execute(ctx context.Context, id uint) ([]dto.RecordVersion, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return args.Get(0).([]dto.RecordVersion), nil
+}
+
+func (mock *MockGetUserAsOfUseCase) Execute(ctx context.Context, id uint, at time.Time) (dto.RecordVersion, error) {
+	args := mock.Called(ctx, id, at)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.RecordVersion{}, err
+	}
+
+	return args.Get(0).(dto.RecordVersion), nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
+)
+
+// UseCases has every use case used by the handlers. It is shared by all the API
+// versions: a new version registers its own handlers, in a file like routes_v2.go,
+// on top of the same use cases
+type UseCases struct {
+	LoginCustomer        usecases.LoginCustomerUseCase
+	LoginUnknownCustomer usecases.LoginUnknownCustomerUseCase
+	CreateCustomer       usecases.CreateCustomerUseCase
+	UpdateCustomer       usecases.UpdateCustomerUseCase
+	PatchCustomer        usecases.PatchCustomerUseCase
+	GetCustomerByCPF     usecases.GetCustomerByCPFUseCase
+	BatchGetCustomers    usecases.BatchGetCustomersUseCase
+	DeleteCustomer       usecases.DeleteCustomerUseCase
+	RestoreCustomer      usecases.RestoreCustomerUseCase
+	GetCustomerHistory   usecases.GetCustomerHistoryUseCase
+	GetCustomerAsOf      usecases.GetCustomerAsOfUseCase
+	LoginUser            usecases.LoginUserUseCase
+	CreateUser           usecases.CreateUserUseCase
+	UpdateUser           usecases.UpdateUserUseCase
+	PatchUser            usecases.PatchUserUseCase
+	GetUserById          usecases.GetUserByIdUseCase
+	GetUserByCPF         usecases.GetUserByCPFUseCase
+	DeleteUser           usecases.DeleteUserUseCase
+	RestoreUser          usecases.RestoreUserUseCase
+	GetUserHistory       usecases.GetUserHistoryUseCase
+	GetUserAsOf          usecases.GetUserAsOfUseCase
+	ListAuditLogs        usecases.ListAuditLogsUseCase
+	VerifyAuditChain     usecases.VerifyAuditChainUseCase
+}
+
+type routeConfig struct {
+	idempotency func(http.Handler) http.Handler
+	audit       func(http.Handler) http.Handler
+	primary     func(http.Handler) http.Handler
+}
+
+type RouteOption func(*routeConfig)
+
+// Idempotency sets the middleware of the sign up routes, which create resources
+// and are retried by the clients
+func Idempotency(middleware func(http.Handler) http.Handler) RouteOption {
+	return func(c *routeConfig) {
+		c.idempotency = middleware
+	}
+}
+
+// Audit sets the middleware of the routes that change customers and users,
+// which resolves who made the change for the audit log
+func Audit(middleware func(http.Handler) http.Handler) RouteOption {
+	return func(c *routeConfig) {
+		c.audit = middleware
+	}
+}
+
+// PrimaryReads sets the middleware of the routes that change data, which sends
+// their reads to the primary database instead of a replica
+func PrimaryReads(middleware func(http.Handler) http.Handler) RouteOption {
+	return func(c *routeConfig) {
+		c.primary = middleware
+	}
+}
+
+// RegisterV1Routes registers the v1 API routes. The router is mounted under
+// /v1 and, while the legacy paths are supported, under the root path
+func RegisterV1Routes(router chi.Router, useCases UseCases, opts ...RouteOption) {
+	cfg := &routeConfig{
+		idempotency: func(next http.Handler) http.Handler { return next },
+		audit:       func(next http.Handler) http.Handler { return next },
+		primary:     func(next http.Handler) http.Handler { return next },
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	router.Post("/auth/login", LoginCustomerHandler(useCases.LoginCustomer))
+	router.Post("/auth/login/unknown", LoginUnknownCustomerHandler(useCases.LoginUnknownCustomer))
+	router.Post("/auth/admin/login", LoginUserHandler(useCases.LoginUser))
+	router.With(cfg.primary, cfg.audit, cfg.idempotency).Post("/auth/signup", CreateCustomerHandler(useCases.CreateCustomer))
+	router.With(cfg.primary, cfg.audit, cfg.idempotency).Post("/auth/admin/signup", CreateUserHandler(useCases.CreateUser))
+
+	router.With(cfg.primary, cfg.audit).Put("/api/admin/customers/{id}", UpdateCustomerHandler(useCases.UpdateCustomer))
+	router.With(cfg.primary, cfg.audit).Patch("/api/admin/customers/{id}", PatchCustomerHandler(useCases.PatchCustomer))
+	router.With(cfg.primary, cfg.audit).Delete("/api/admin/customers/{id}", DeleteCustomerHandler(useCases.DeleteCustomer))
+	router.With(cfg.primary, cfg.audit).Post("/api/admin/customers/{id}/restore", RestoreCustomerHandler(useCases.RestoreCustomer))
+	router.Get("/api/admin/customers/{id}/history", GetCustomerHistoryHandler(useCases.GetCustomerHistory))
+	router.Get("/api/admin/customers/{id}/as-of", GetCustomerAsOfHandler(useCases.GetCustomerAsOf))
+	router.Get("/api/customers/{cpf}", GetCustomerByCPFHandler(useCases.GetCustomerByCPF))
+	router.Post("/api/customers/batch-get", BatchGetCustomersHandler(useCases.BatchGetCustomers))
+
+	router.With(cfg.primary, cfg.audit).Put("/api/users/{id}", UpdateUserHandler(useCases.UpdateUser))
+	router.With(cfg.primary, cfg.audit).Patch("/api/users/{id}", PatchUserHandler(useCases.PatchUser))
+	router.With(cfg.primary, cfg.audit).Delete("/api/users/{id}", DeleteUserHandler(useCases.DeleteUser))
+	router.With(cfg.primary, cfg.audit).Post("/api/users/{id}/restore", RestoreUserHandler(useCases.RestoreUser))
+	router.Get("/api/users/{id}/history", GetUserHistoryHandler(useCases.GetUserHistory))
+	router.Get("/api/users/{id}/as-of", GetUserAsOfHandler(useCases.GetUserAsOf))
+	router.Get("/api/users/{id}", GetUserByIdHandler(useCases.GetUserById))
+	router.Post("/api/users/login", GetUserByCPFHandler(useCases.GetUserByCPF))
+
+	router.Get("/api/admin/audit-logs", ListAuditLogsHandler(useCases.ListAuditLogs))
+	router.Get("/api/admin/audit-logs/verify", VerifyAuditChainHandler(useCases.VerifyAuditChain))
+}
@@ -0,0 +1,192 @@
+package handler_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/handler"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/idempotency"
+)
+
+func mockVersionedRouter() http.Handler {
+	loginUnknownUseCase := new(MockLoginUnknownCustomerUseCase)
+	loginUnknownUseCase.On("Execute", mock.Anything).Return(dto.Token{
+		AccessToken: "eYmly",
+	}, nil)
+
+	useCases := handler.UseCases{
+		LoginUnknownCustomer: loginUnknownUseCase,
+	}
+
+	router := chi.NewRouter()
+
+	router.Route("/v1", func(r chi.Router) {
+		handler.RegisterV1Routes(r, useCases)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(httpserver.Deprecated(
+			time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+			time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC),
+			"/v1",
+		))
+		handler.RegisterV1Routes(r, useCases)
+	})
+
+	return router
+}
+
+func TestRoutes(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got success without deprecation headers when calling v1 route", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/auth/login/unknown", nil)
+		recorder := httptest.NewRecorder()
+
+		mockVersionedRouter().ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Empty(t, recorder.Header().Get("Deprecation"))
+		assert.Empty(t, recorder.Header().Get("Sunset"))
+	})
+
+	t.Run("got success with deprecation headers when calling legacy route", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/auth/login/unknown", nil)
+		recorder := httptest.NewRecorder()
+
+		mockVersionedRouter().ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "@1792368000", recorder.Header().Get("Deprecation"))
+		assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", recorder.Header().Get("Sunset"))
+		assert.Equal(t, `</v1/auth/login/unknown>; rel="successor-version"`, recorder.Header().Get("Link"))
+	})
+
+	t.Run("got not found when calling unknown version", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/v2/auth/login/unknown", nil)
+		recorder := httptest.NewRecorder()
+
+		mockVersionedRouter().ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("got replayed response when calling sign up twice with same Idempotency-Key", func(t *testing.T) {
+		t.Parallel()
+
+		createCustomerUseCase := new(MockCreateCustomerUseCase)
+		createCustomerUseCase.On("Execute", mock.Anything, mock.Anything).Return(dto.CustomerResponse{
+			Id: 1,
+		}, nil).Once()
+
+		router := chi.NewRouter()
+		handler.RegisterV1Routes(
+			router,
+			handler.UseCases{CreateCustomer: createCustomerUseCase},
+			handler.Idempotency(idempotency.Middleware(idempotency.NewMemoryStore())),
+		)
+
+		body := `{"name":"Teste","cpf":"83212446293","email":"teste@teste.com"}`
+		recorders := make([]*httptest.ResponseRecorder, 2)
+
+		for i := range recorders {
+			req := httptest.NewRequest(http.MethodPost, "/auth/signup", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(idempotency.HeaderKey, "KEY-1")
+			recorders[i] = httptest.NewRecorder()
+
+			router.ServeHTTP(recorders[i], req)
+		}
+
+		createCustomerUseCase.AssertNumberOfCalls(t, "Execute", 1)
+		assert.Equal(t, http.StatusOK, recorders[1].Code)
+		assert.Equal(t, recorders[0].Body.String(), recorders[1].Body.String())
+		assert.Equal(t, "true", recorders[1].Header().Get(idempotency.HeaderReplayed))
+	})
+
+	t.Run("got audit actor in context when calling sign up with access token", func(t *testing.T) {
+		t.Parallel()
+
+		createCustomerUseCase := new(MockCreateCustomerUseCase)
+		createCustomerUseCase.On("Execute", mock.MatchedBy(func(ctx context.Context) bool {
+			return audit.FromContext(ctx).Actor == "admin"
+		}), mock.Anything).Return(dto.CustomerResponse{
+			Id: 1,
+		}, nil)
+
+		router := chi.NewRouter()
+		handler.RegisterV1Routes(
+			router,
+			handler.UseCases{CreateCustomer: createCustomerUseCase},
+			handler.Audit(audit.Middleware(func(ctx context.Context, accessToken string) (string, error) {
+				return "admin", nil
+			})),
+		)
+
+		body := `{"name":"Teste","cpf":"83212446293","email":"teste@teste.com"}`
+		req := httptest.NewRequest(http.MethodPost, "/auth/signup", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer eYmly")
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		createCustomerUseCase.AssertNumberOfCalls(t, "Execute", 1)
+	})
+
+	t.Run("got primary reads only on write routes when calling routes with PrimaryReads", func(t *testing.T) {
+		t.Parallel()
+
+		batchGetCustomers := new(MockBatchGetCustomersUseCase)
+		batchGetCustomers.On("Execute", mock.MatchedBy(func(ctx context.Context) bool {
+			return !database.IsPrimaryRequired(ctx)
+		}), mock.Anything).Return(dto.BatchGetCustomersResponse{}, nil)
+
+		deleteCustomer := new(MockDeleteCustomerUseCase)
+		deleteCustomer.On("Execute", mock.MatchedBy(func(ctx context.Context) bool {
+			return database.IsPrimaryRequired(ctx)
+		}), uint(123)).Return(nil)
+
+		router := chi.NewRouter()
+		handler.RegisterV1Routes(
+			router,
+			handler.UseCases{BatchGetCustomers: batchGetCustomers, DeleteCustomer: deleteCustomer},
+			handler.PrimaryReads(database.PrimaryReads),
+		)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/customers/batch-get", strings.NewReader(`{"ids":[1]}`))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		req = httptest.NewRequest(http.MethodDelete, "/api/admin/customers/123", nil)
+		recorder = httptest.NewRecorder()
+
+		router.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+		batchGetCustomers.AssertExpectations(t)
+		deleteCustomer.AssertExpectations(t)
+	})
+}
@@ -1,13 +1,13 @@
 package handler
 
 import (
-	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
 )
 
 // @Summary Create new user admin
@@ -16,9 +16,11 @@ import (
 // @Accept json
 // @Produce json
 // @Param product body dto.UserAdmin true "user admin"
+// @Param Idempotency-Key header string false "Unique key to retry the sign up safely"
 // @Success 200 {object} dto.UserAdminResponse
 // @Failure 400 "Customer has required fields"
-// @Failure 409 "This user is already added"
+// @Failure 409 "This user is already added or a request with the same Idempotency-Key is in progress"
+// @Failure 422 "Idempotency-Key reused with a different request"
 // @Router /auth/admin/signup [post]
 func CreateUserHandler(createUserAdmin usecases.CreateUserUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -27,22 +29,16 @@ func CreateUserHandler(createUserAdmin usecases.CreateUserUseCase) http.HandlerF
 		err := httpserver.DecodeJSONBody(w, r, &user)
 
 		if err != nil {
-			log.Print("decoding user body", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "decoding user body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
 		response, err := createUserAdmin.Execute(r.Context(), user)
 
 		if err != nil {
-			log.Print("create user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "create user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -56,32 +52,37 @@ func CreateUserHandler(createUserAdmin usecases.CreateUserUseCase) http.HandlerF
 // @Accept json
 // @Produce json
 // @Param id path int true "12"
+// @Param If-Match header string false "ETag of the GET response. Required when IF_MATCH_REQUIRED is enabled"
 // @Param product body dto.Customer true "customer"
 // @Success 204
 // @Failure 400 "User has required fields"
 // @Failure 404 "User not found"
+// @Failure 412 "The resource was changed by another request"
+// @Failure 428 "If-Match header is required"
 // @Router /api/users/{id} [put]
 func UpdateUserHandler(updateUser usecases.UpdateUserUseCase) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
 
 		if err != nil {
-			log.Print("update user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "update user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		userId, err := strconv.Atoi(userIdStr)
 
 		if err != nil {
-			log.Print("update user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "update user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		version, err := httpserver.GetIfMatchVersion(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "update user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -89,23 +90,83 @@ func UpdateUserHandler(updateUser usecases.UpdateUserUseCase) http.HandlerFunc {
 		err = httpserver.DecodeJSONBody(w, r, &user)
 
 		if err != nil {
-			log.Print("decoding user body for update", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "decoding user body for update", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
 		user.ID = uint(userId)
+		user.Version = version
 		err = updateUser.Execute(r.Context(), user)
 
 		if err != nil {
-			log.Print("update user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "update user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseNoContentSuccess(w)
+	}
+}
+
+// @Summary Patch user
+// @Description Update only the given fields of the user with a JSON Merge Patch (RFC 7396). The CPF can not be changed
+// @Tags UserAdmin
+// @Accept application/merge-patch+json
+// @Produce json
+// @Param id path int true "12"
+// @Param If-Match header string false "ETag of the GET response. Required when IF_MATCH_REQUIRED is enabled"
+// @Param patch body dto.UserAdminPatch true "user patch"
+// @Success 204
+// @Failure 400 "Invalid field, null field or CPF change"
+// @Failure 404 "User not found"
+// @Failure 409 "Email already registered"
+// @Failure 412 "The resource was changed by another request"
+// @Failure 415 "Content-Type is not application/merge-patch+json"
+// @Failure 428 "If-Match header is required"
+// @Router /api/users/{id} [patch]
+func PatchUserHandler(patchUser usecases.PatchUserUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		userId, err := strconv.Atoi(userIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		version, err := httpserver.GetIfMatchVersion(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		var patch dto.UserAdminPatch
+		err = httpserver.DecodeMergePatchBody(w, r, &patch)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "decoding user patch body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		patch.ID = uint(userId)
+		patch.Version = version
+		err = patchUser.Execute(r.Context(), patch)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "patch user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
@@ -120,6 +181,7 @@ func UpdateUserHandler(updateUser usecases.UpdateUserUseCase) http.HandlerFunc {
 // @Produce json
 // @Param Id path string true "12"
 // @Success 200 {object} dto.UserAdmin
+// @Header 200 {string} ETag "Version of the resource to be sent in If-Match"
 // @Failure 404 "User not found"
 // @Router /api/users/{id} [get]
 func GetUserByIdHandler(getUserById usecases.GetUserByIdUseCase) http.HandlerFunc {
@@ -127,36 +189,28 @@ func GetUserByIdHandler(getUserById usecases.GetUserByIdUseCase) http.HandlerFun
 		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
 
 		if err != nil {
-			log.Print("get user by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "get user by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		userId, err := strconv.Atoi(userIdStr)
 
 		if err != nil {
-			log.Print("get user by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "get user by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		user, err := getUserById.Execute(r.Context(), uint(userId))
 
 		if err != nil {
-			log.Print("get user by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "get user by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
+		httpserver.SetETag(w, user.Version)
 		httpserver.SendResponseSuccess(w, user)
 	}
 }
@@ -168,6 +222,7 @@ func GetUserByIdHandler(getUserById usecases.GetUserByIdUseCase) http.HandlerFun
 // @Produce json
 // @Param user body dto.UserAdminForm true "UserAdminForm"
 // @Success 200 {object} dto.UserAdmin
+// @Header 200 {string} ETag "Version of the resource to be sent in If-Match"
 // @Failure 404 "User not found"
 // @Router /api/users/login [post]
 func GetUserByCPFHandler(getUserByCPF usecases.GetUserByCPFUseCase) http.HandlerFunc {
@@ -177,25 +232,20 @@ func GetUserByCPFHandler(getUserByCPF usecases.GetUserByCPFUseCase) http.Handler
 		err := httpserver.DecodeJSONBody(w, r, &userForm)
 
 		if err != nil {
-			log.Print("decoding user form body", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "decoding user form body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		user, err := getUserByCPF.Execute(r.Context(), userForm.CPF)
 
 		if err != nil {
-			log.Print("get user by id", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "get user by id", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
+		httpserver.SetETag(w, user.Version)
 		httpserver.SendResponseSuccess(w, user)
 	}
 }
@@ -216,25 +266,182 @@ func LoginUserHandler(loginUserUseCase usecases.LoginUserUseCase) http.HandlerFu
 		err := httpserver.DecodeJSONBody(w, r, &userForm)
 
 		if err != nil {
-			log.Print("decoding user form body", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendBadRequestError(w, err)
+			logger.RequestError(r.Context(), "decoding user form body", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
 			return
 		}
 
 		token, err := loginUserUseCase.Execute(r.Context(), userForm.CPF)
 
 		if err != nil {
-			log.Print("login user", map[string]interface{}{
-				"error":  err.Error(),
-				"status": httpserver.GetStatusCodeFromError(err),
-			})
-			httpserver.SendResponseError(w, err)
+			logger.RequestError(r.Context(), "login user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
 			return
 		}
 
 		httpserver.SendResponseSuccess(w, token)
 	}
 }
+
+// @Summary Delete user
+// @Description Soft delete the user and disable its identity provider account. The user is kept in the history and can be restored
+// @Tags UserAdmin
+// @Produce json
+// @Param id path int true "12"
+// @Success 204
+// @Failure 404 "User not found or already deleted"
+// @Router /api/users/{id} [delete]
+func DeleteUserHandler(deleteUser usecases.DeleteUserUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "delete user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		userId, err := strconv.Atoi(userIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "delete user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		err = deleteUser.Execute(r.Context(), uint(userId))
+
+		if err != nil {
+			logger.RequestError(r.Context(), "delete user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseNoContentSuccess(w)
+	}
+}
+
+// @Summary Restore user
+// @Description Restore a soft deleted user and enable its identity provider account again
+// @Tags UserAdmin
+// @Produce json
+// @Param id path int true "12"
+// @Success 204
+// @Failure 404 "User not found"
+// @Failure 409 "User is not deleted"
+// @Router /api/users/{id}/restore [post]
+func RestoreUserHandler(restoreUser usecases.RestoreUserUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "restore user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		userId, err := strconv.Atoi(userIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "restore user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		err = restoreUser.Execute(r.Context(), uint(userId))
+
+		if err != nil {
+			logger.RequestError(r.Context(), "restore user", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseNoContentSuccess(w)
+	}
+}
+
+// @Summary Get user history
+// @Description List every version of the user, from the newest, with who made the change and when. Deleted users are included
+// @Tags UserAdmin
+// @Produce json
+// @Param id path int true "12"
+// @Success 200 {array} dto.RecordVersion
+// @Failure 404 "User not found"
+// @Router /api/users/{id}/history [get]
+func GetUserHistoryHandler(getUserHistory usecases.GetUserHistoryUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user history", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		userId, err := strconv.Atoi(userIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user history", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		response, err := getUserHistory.Execute(r.Context(), uint(userId))
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user history", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
+
+// @Summary Get user as of a time
+// @Description Get the version of the user that was the current one at the given time. The deleted field tells if the user was deleted then
+// @Tags UserAdmin
+// @Produce json
+// @Param id path int true "12"
+// @Param at query string true "Time in RFC 3339"
+// @Success 200 {object} dto.RecordVersion
+// @Failure 400 "Invalid time"
+// @Failure 404 "User did not exist at the given time"
+// @Router /api/users/{id}/as-of [get]
+func GetUserAsOfHandler(getUserAsOf usecases.GetUserAsOfUseCase) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userIdStr, err := httpserver.GetPathParamFromRequest(r, "id")
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user as of", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		userId, err := strconv.Atoi(userIdStr)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user as of", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		at, err := getAsOfTime(r)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user as of", err, http.StatusBadRequest)
+			httpserver.SendBadRequestError(w, r, err)
+			return
+		}
+
+		response, err := getUserAsOf.Execute(r.Context(), uint(userId), at)
+
+		if err != nil {
+			logger.RequestError(r.Context(), "get user as of", err, httpserver.GetStatusCodeFromError(err))
+			httpserver.SendResponseError(w, r, err)
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, response)
+	}
+}
@@ -7,9 +7,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/handler"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
@@ -144,6 +146,41 @@ func TestUserAdminHandler(t *testing.T) {
 		assert.Equal(t, http.StatusNoContent, recorder.Code)
 	})
 
+	t.Run("got success with version when calling update user admin handler with If-Match", func(t *testing.T) {
+		t.Parallel()
+
+		jsonData, err := json.Marshal(mockCreateUserForm())
+
+		assert.NoError(t, err)
+
+		body := bytes.NewBuffer(jsonData)
+
+		req := httptest.NewRequest(http.MethodPut, "/auth/user/{id}", body)
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Add("If-Match", `"2"`)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "3")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		user := mockUpdateUserForm()
+		user.Version = 2
+
+		updateUserUseCase := new(MockUpdateUserUseCase)
+
+		updateUserUseCase.On("Execute", req.Context(), user).
+			Return(nil)
+
+		createUserHandler := handler.UpdateUserHandler(updateUserUseCase)
+
+		createUserHandler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
 	t.Run("got error on UpdateUser UseCase when calling update user admin handler", func(t *testing.T) {
 		t.Parallel()
 
@@ -273,8 +310,9 @@ func TestUserAdminHandler(t *testing.T) {
 
 		getUserByIDUseCase.On("Execute", req.Context(), uint(3)).
 			Return(dto.UserAdmin{
-				ID:   uint(3),
-				Name: "Test Name",
+				ID:      uint(3),
+				Name:    "Test Name",
+				Version: uint(2),
 			}, nil)
 
 		createUserHandler := handler.GetUserByIdHandler(getUserByIDUseCase)
@@ -282,6 +320,7 @@ func TestUserAdminHandler(t *testing.T) {
 		createUserHandler.ServeHTTP(recorder, req)
 
 		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `"2"`, recorder.Header().Get("ETag"))
 
 		var response dto.UserAdmin
 		err := json.Unmarshal(recorder.Body.Bytes(), &response)
@@ -556,4 +595,141 @@ func TestUserAdminHandler(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, recorder.Code)
 	})
+
+	t.Run("got success when calling patch user admin handler", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"name": "New Name"}`)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/{id}", body)
+		req.Header.Add("Content-Type", "application/merge-patch+json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "3")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		name := "New Name"
+		patchUserUseCase := new(MockPatchUserUseCase)
+		patchUserUseCase.On("Execute", req.Context(), dto.UserAdminPatch{
+			ID:   uint(3),
+			Name: &name,
+		}).Return(nil)
+
+		handler.PatchUserHandler(patchUserUseCase).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got error on unknown field when calling patch user admin handler", func(t *testing.T) {
+		t.Parallel()
+
+		body := bytes.NewBufferString(`{"role": "admin"}`)
+
+		req := httptest.NewRequest(http.MethodPatch, "/api/users/{id}", body)
+		req.Header.Add("Content-Type", "application/merge-patch+json")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "3")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		handler.PatchUserHandler(new(MockPatchUserUseCase)).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("got success when calling delete user handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/users/{id}", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "12")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		deleteUser := new(MockDeleteUserUseCase)
+		deleteUser.On("Execute", req.Context(), uint(12)).Return(nil)
+
+		handler.DeleteUserHandler(deleteUser).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got success when calling restore user handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/users/{id}/restore", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "12")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		restoreUser := new(MockRestoreUserUseCase)
+		restoreUser.On("Execute", req.Context(), uint(12)).Return(nil)
+
+		handler.RestoreUserHandler(restoreUser).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got not found when calling get user history handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/users/{id}/history", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "12")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		getUserHistory := new(MockGetUserHistoryUseCase)
+		getUserHistory.On("Execute", req.Context(), uint(12)).Return(nil, &responses.BusinessResponse{
+			StatusCode: http.StatusNotFound,
+		})
+
+		handler.GetUserHistoryHandler(getUserHistory).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNotFound, recorder.Code)
+	})
+
+	t.Run("got success when calling get user as of handler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/users/{id}/as-of?at=2024-05-01T07:00:00-03:00", nil)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "12")
+
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+
+		getUserAsOf := new(MockGetUserAsOfUseCase)
+		getUserAsOf.On("Execute", req.Context(), uint(12), mock.MatchedBy(func(at time.Time) bool {
+			return at.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+		})).Return(dto.RecordVersion{Version: 3, Name: "User Name", Deleted: true}, nil)
+
+		handler.GetUserAsOfHandler(getUserAsOf).ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		var version dto.RecordVersion
+		err := json.Unmarshal(recorder.Body.Bytes(), &version)
+
+		assert.NoError(t, err)
+		assert.True(t, version.Deleted)
+	})
 }
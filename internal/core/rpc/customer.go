@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+
+	customerv1 "github.com/thiagoluis88git/tech1-customer/api/proto/customer/v1"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/usecases"
+	"github.com/thiagoluis88git/tech1-customer/pkg/grpcserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+)
+
+// UseCases has every use case used by the gRPC API. They are the same use
+// cases of the HTTP handlers
+type UseCases struct {
+	GetCustomerByCPF  usecases.GetCustomerByCPFUseCase
+	GetCustomerById   usecases.GetCustomerByIdUseCase
+	BatchGetCustomers usecases.BatchGetCustomersUseCase
+	ValidateToken     usecases.ValidateTokenUseCase
+}
+
+// CustomerServer implements the customerv1.CustomerService for the internal
+// service-to-service calls
+type CustomerServer struct {
+	customerv1.UnimplementedCustomerServiceServer
+	useCases UseCases
+}
+
+func NewCustomerServer(useCases UseCases) *CustomerServer {
+	return &CustomerServer{
+		useCases: useCases,
+	}
+}
+
+func (server *CustomerServer) GetCustomerByCPF(ctx context.Context, request *customerv1.GetCustomerByCPFRequest) (*customerv1.GetCustomerByCPFResponse, error) {
+	customer, err := server.useCases.GetCustomerByCPF.Execute(ctx, request.GetCpf())
+
+	if err != nil {
+		logger.RequestError(ctx, "get customer by cpf", err, httpserver.GetStatusCodeFromError(err))
+		return nil, grpcserver.Status(err)
+	}
+
+	return &customerv1.GetCustomerByCPFResponse{
+		Customer: toCustomerMessage(customer),
+	}, nil
+}
+
+func (server *CustomerServer) GetCustomerByID(ctx context.Context, request *customerv1.GetCustomerByIDRequest) (*customerv1.GetCustomerByIDResponse, error) {
+	customer, err := server.useCases.GetCustomerById.Execute(ctx, uint(request.GetId()))
+
+	if err != nil {
+		logger.RequestError(ctx, "get customer by id", err, httpserver.GetStatusCodeFromError(err))
+		return nil, grpcserver.Status(err)
+	}
+
+	return &customerv1.GetCustomerByIDResponse{
+		Customer: toCustomerMessage(customer),
+	}, nil
+}
+
+func (server *CustomerServer) BatchGetCustomers(ctx context.Context, request *customerv1.BatchGetCustomersRequest) (*customerv1.BatchGetCustomersResponse, error) {
+	ids := make([]uint, 0, len(request.GetIds()))
+
+	for _, id := range request.GetIds() {
+		ids = append(ids, uint(id))
+	}
+
+	result, err := server.useCases.BatchGetCustomers.Execute(ctx, dto.BatchGetCustomers{
+		IDs:  ids,
+		CPFs: request.GetCpfs(),
+	})
+
+	if err != nil {
+		logger.RequestError(ctx, "batch get customers", err, httpserver.GetStatusCodeFromError(err))
+		return nil, grpcserver.Status(err)
+	}
+
+	response := &customerv1.BatchGetCustomersResponse{
+		ById:  make(map[uint64]*customerv1.CustomerLookup, len(result.ByID)),
+		ByCpf: make(map[string]*customerv1.CustomerLookup, len(result.ByCPF)),
+	}
+
+	for id, lookup := range result.ByID {
+		response.ById[uint64(id)] = toCustomerLookupMessage(lookup)
+	}
+
+	for cpf, lookup := range result.ByCPF {
+		response.ByCpf[cpf] = toCustomerLookupMessage(lookup)
+	}
+
+	return response, nil
+}
+
+func (server *CustomerServer) ValidateToken(ctx context.Context, request *customerv1.ValidateTokenRequest) (*customerv1.ValidateTokenResponse, error) {
+	tokenInfo, err := server.useCases.ValidateToken.Execute(ctx, request.GetAccessToken())
+
+	if err != nil {
+		logger.RequestError(ctx, "validate token", err, httpserver.GetStatusCodeFromError(err))
+		return nil, grpcserver.Status(err)
+	}
+
+	return &customerv1.ValidateTokenResponse{
+		Username:  tokenInfo.Username,
+		Anonymous: tokenInfo.Anonymous,
+	}, nil
+}
+
+func toCustomerMessage(customer dto.Customer) *customerv1.Customer {
+	return &customerv1.Customer{
+		Id:      uint64(customer.ID),
+		Name:    customer.Name,
+		Cpf:     customer.CPF,
+		Email:   customer.Email,
+		Version: uint64(customer.Version),
+	}
+}
+
+func toCustomerLookupMessage(lookup dto.CustomerLookup) *customerv1.CustomerLookup {
+	message := &customerv1.CustomerLookup{}
+
+	switch lookup.Status {
+	case dto.LookupStatusFound:
+		message.Status = customerv1.LookupStatus_LOOKUP_STATUS_FOUND
+	case dto.LookupStatusNotFound:
+		message.Status = customerv1.LookupStatus_LOOKUP_STATUS_NOT_FOUND
+	case dto.LookupStatusInvalid:
+		message.Status = customerv1.LookupStatus_LOOKUP_STATUS_INVALID
+	}
+
+	if lookup.Customer != nil {
+		message.Customer = toCustomerMessage(*lookup.Customer)
+	}
+
+	return message
+}
@@ -0,0 +1,238 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	customerv1 "github.com/thiagoluis88git/tech1-customer/api/proto/customer/v1"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/rpc"
+	"github.com/thiagoluis88git/tech1-customer/pkg/grpcserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// mockClient starts the gRPC server on an in memory listener, so the tests go
+// through the interceptors, the status mapping and the protobuf encoding
+func mockClient(t *testing.T, useCases rpc.UseCases) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpcserver.New()
+	server.RegisterService(&customerv1.CustomerService_ServiceDesc, rpc.NewCustomerServer(useCases))
+	server.Serve(listener)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Shutdown(context.Background())
+	})
+
+	return conn
+}
+
+func TestCustomerServer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got customer when calling GetCustomerByCPF", func(t *testing.T) {
+		t.Parallel()
+
+		getCustomerByCPF := new(MockGetCustomerByCPFUseCase)
+		getCustomerByCPF.On("Execute", mock.Anything, "171.079.720-73").Return(dto.Customer{
+			ID:      1,
+			Name:    "Teste",
+			CPF:     "17107972073",
+			Email:   "teste@teste.com",
+			Version: 3,
+		}, nil)
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{GetCustomerByCPF: getCustomerByCPF}))
+
+		response, err := client.GetCustomerByCPF(context.Background(), &customerv1.GetCustomerByCPFRequest{Cpf: "171.079.720-73"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), response.GetCustomer().GetId())
+		assert.Equal(t, "17107972073", response.GetCustomer().GetCpf())
+		assert.Equal(t, uint64(3), response.GetCustomer().GetVersion())
+	})
+
+	t.Run("got invalid argument with error code when calling GetCustomerByCPF with invalid CPF", func(t *testing.T) {
+		t.Parallel()
+
+		getCustomerByCPF := new(MockGetCustomerByCPFUseCase)
+		getCustomerByCPF.On("Execute", mock.Anything, "123").Return(dto.Customer{}, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
+		})
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{GetCustomerByCPF: getCustomerByCPF}))
+
+		response, err := client.GetCustomerByCPF(context.Background(), &customerv1.GetCustomerByCPFRequest{Cpf: "123"})
+
+		assert.Nil(t, response)
+
+		st := status.Convert(err)
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Len(t, st.Details(), 1)
+
+		errorInfo, ok := st.Details()[0].(*errdetails.ErrorInfo)
+		assert.True(t, ok)
+		assert.Equal(t, string(responses.CodeCPFInvalid), errorInfo.GetReason())
+		assert.Equal(t, grpcserver.ErrorDomain, errorInfo.GetDomain())
+	})
+
+	t.Run("got not found when calling GetCustomerByID with unknown id", func(t *testing.T) {
+		t.Parallel()
+
+		getCustomerById := new(MockGetCustomerByIdUseCase)
+		getCustomerById.On("Execute", mock.Anything, uint(9)).Return(dto.Customer{}, responses.GetResponseError(&responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		}, "CustomerService"))
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{GetCustomerById: getCustomerById}))
+
+		response, err := client.GetCustomerByID(context.Background(), &customerv1.GetCustomerByIDRequest{Id: 9})
+
+		assert.Nil(t, response)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.NotContains(t, status.Convert(err).Message(), "record not found")
+	})
+
+	t.Run("got lookups keyed by the requested identifiers when calling BatchGetCustomers", func(t *testing.T) {
+		t.Parallel()
+
+		batchGetCustomers := new(MockBatchGetCustomersUseCase)
+		batchGetCustomers.On("Execute", mock.Anything, dto.BatchGetCustomers{
+			IDs:  []uint{1, 2},
+			CPFs: []string{"123"},
+		}).Return(dto.BatchGetCustomersResponse{
+			ByID: map[uint]dto.CustomerLookup{
+				1: {Status: dto.LookupStatusFound, Customer: &dto.Customer{ID: 1, Name: "Teste"}},
+				2: {Status: dto.LookupStatusNotFound},
+			},
+			ByCPF: map[string]dto.CustomerLookup{
+				"123": {Status: dto.LookupStatusInvalid},
+			},
+		}, nil)
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{BatchGetCustomers: batchGetCustomers}))
+
+		response, err := client.BatchGetCustomers(context.Background(), &customerv1.BatchGetCustomersRequest{
+			Ids:  []uint64{1, 2},
+			Cpfs: []string{"123"},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, customerv1.LookupStatus_LOOKUP_STATUS_FOUND, response.GetById()[1].GetStatus())
+		assert.Equal(t, "Teste", response.GetById()[1].GetCustomer().GetName())
+		assert.Equal(t, customerv1.LookupStatus_LOOKUP_STATUS_NOT_FOUND, response.GetById()[2].GetStatus())
+		assert.Nil(t, response.GetById()[2].GetCustomer())
+		assert.Equal(t, customerv1.LookupStatus_LOOKUP_STATUS_INVALID, response.GetByCpf()["123"].GetStatus())
+	})
+
+	t.Run("got token owner when calling ValidateToken", func(t *testing.T) {
+		t.Parallel()
+
+		validateToken := new(MockValidateTokenUseCase)
+		validateToken.On("Execute", mock.Anything, "eYmly").Return(dto.TokenInfo{
+			Username:  "unknown-user",
+			Anonymous: true,
+		}, nil)
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{ValidateToken: validateToken}))
+
+		response, err := client.ValidateToken(context.Background(), &customerv1.ValidateTokenRequest{AccessToken: "eYmly"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "unknown-user", response.GetUsername())
+		assert.True(t, response.GetAnonymous())
+	})
+
+	t.Run("got unauthenticated when calling ValidateToken with expired token", func(t *testing.T) {
+		t.Parallel()
+
+		validateToken := new(MockValidateTokenUseCase)
+		validateToken.On("Execute", mock.Anything, "eYmly").Return(dto.TokenInfo{}, responses.GetResponseError(&responses.NetworkError{
+			Code:    http.StatusUnauthorized,
+			Message: "NotAuthorizedException: Access Token has expired",
+		}, "CustomerService"))
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{ValidateToken: validateToken}))
+
+		response, err := client.ValidateToken(context.Background(), &customerv1.ValidateTokenRequest{AccessToken: "eYmly"})
+
+		assert.Nil(t, response)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("got internal when the use case panics", func(t *testing.T) {
+		t.Parallel()
+
+		getCustomerById := new(MockGetCustomerByIdUseCase)
+		getCustomerById.On("Execute", mock.Anything, uint(1)).Panic("mock panic")
+
+		client := customerv1.NewCustomerServiceClient(mockClient(t, rpc.UseCases{GetCustomerById: getCustomerById}))
+
+		_, err := client.GetCustomerByID(context.Background(), &customerv1.GetCustomerByIDRequest{Id: 1})
+
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+
+	t.Run("got serving when calling the health service", func(t *testing.T) {
+		t.Parallel()
+
+		client := healthpb.NewHealthClient(mockClient(t, rpc.UseCases{}))
+
+		for _, service := range []string{"", customerv1.CustomerService_ServiceDesc.ServiceName} {
+			response, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+
+			assert.NoError(t, err)
+			assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.GetStatus())
+		}
+	})
+
+	t.Run("got customer service when listing services with reflection", func(t *testing.T) {
+		t.Parallel()
+
+		client := reflectionpb.NewServerReflectionClient(mockClient(t, rpc.UseCases{}))
+
+		stream, err := client.ServerReflectionInfo(context.Background())
+		assert.NoError(t, err)
+
+		err = stream.Send(&reflectionpb.ServerReflectionRequest{
+			MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+		})
+		assert.NoError(t, err)
+
+		response, err := stream.Recv()
+		assert.NoError(t, err)
+
+		var services []string
+
+		for _, service := range response.GetListServicesResponse().GetService() {
+			services = append(services, service.GetName())
+		}
+
+		assert.Contains(t, services, customerv1.CustomerService_ServiceDesc.ServiceName)
+		assert.Contains(t, services, healthpb.Health_ServiceDesc.ServiceName)
+	})
+}
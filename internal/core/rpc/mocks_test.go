@@ -0,0 +1,68 @@
+package rpc_test
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+)
+
+type MockGetCustomerByCPFUseCase struct {
+	mock.Mock
+}
+
+type MockGetCustomerByIdUseCase struct {
+	mock.Mock
+}
+
+type MockBatchGetCustomersUseCase struct {
+	mock.Mock
+}
+
+type MockValidateTokenUseCase struct {
+	mock.Mock
+}
+
+func (mock *MockGetCustomerByCPFUseCase) Execute(ctx context.Context, cpf string) (dto.Customer, error) {
+	args := mock.Called(ctx, cpf)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	return args.Get(0).(dto.Customer), nil
+}
+
+func (mock *MockGetCustomerByIdUseCase) Execute(ctx context.Context, id uint) (dto.Customer, error) {
+	args := mock.Called(ctx, id)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.Customer{}, err
+	}
+
+	return args.Get(0).(dto.Customer), nil
+}
+
+func (mock *MockBatchGetCustomersUseCase) Execute(ctx context.Context, request dto.BatchGetCustomers) (dto.BatchGetCustomersResponse, error) {
+	args := mock.Called(ctx, request)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.BatchGetCustomersResponse{}, err
+	}
+
+	return args.Get(0).(dto.BatchGetCustomersResponse), nil
+}
+
+func (mock *MockValidateTokenUseCase) Execute(ctx context.Context, accessToken string) (dto.TokenInfo, error) {
+	args := mock.Called(ctx, accessToken)
+	err := args.Error(1)
+
+	if err != nil {
+		return dto.TokenInfo{}, err
+	}
+
+	return args.Get(0).(dto.TokenInfo), nil
+}
@@ -1,24 +1,40 @@
 package remote
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/session"
 	cognito "github.com/aws/aws-sdk-go/service/cognitoidentityprovider"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	passwordSufixTemp = "12!@Az"
 	passwordSufix     = "1234&$sWa"
+
+	// UnknownUsername is the Cognito user of the customers that do not identify
+	// themselves
+	UnknownUsername = "unknown-user"
 )
 
 type CognitoRemoteDataSource interface {
-	SignUp(user *model.Customer) error
-	SignUpAdmin(user *model.UserAdmin) error
-	Login(cpf string) (string, error)
-	LoginUnknown() (string, error)
+	SignUp(ctx context.Context, user *model.Customer) error
+	SignUpAdmin(ctx context.Context, user *model.UserAdmin) error
+	Login(ctx context.Context, cpf string) (string, error)
+	LoginUnknown(ctx context.Context) (string, error)
+	GetUsername(ctx context.Context, accessToken string) (string, error)
+	DisableUser(ctx context.Context, cpf string) error
+	EnableUser(ctx context.Context, cpf string) error
+	Ping(ctx context.Context) error
 }
 
 type CognitoRemoteDataSourceImpl struct {
@@ -62,15 +78,23 @@ func NewCognitoRemoteDataSource(
 	}
 }
 
-func (ds *CognitoRemoteDataSourceImpl) SignUpAdmin(user *model.UserAdmin) error {
-	return ds.signUp(user.CPF, user.Name, user.Email, ds.groupAdmin)
+func (ds *CognitoRemoteDataSourceImpl) SignUpAdmin(ctx context.Context, user *model.UserAdmin) (err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.SignUpAdmin")
+	defer tracing.End(span, &err)
+	defer observe("SignUpAdmin", time.Now(), &err)
+
+	return ds.signUp(ctx, user.CPF, user.Name, user.Email, ds.groupAdmin)
 }
 
-func (ds *CognitoRemoteDataSourceImpl) SignUp(user *model.Customer) error {
-	return ds.signUp(user.CPF, user.Name, user.Email, ds.groupUser)
+func (ds *CognitoRemoteDataSourceImpl) SignUp(ctx context.Context, user *model.Customer) (err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.SignUp")
+	defer tracing.End(span, &err)
+	defer observe("SignUp", time.Now(), &err)
+
+	return ds.signUp(ctx, user.CPF, user.Name, user.Email, ds.groupUser)
 }
 
-func (ds *CognitoRemoteDataSourceImpl) signUp(cpf, name, email, groupName string) error {
+func (ds *CognitoRemoteDataSourceImpl) signUp(ctx context.Context, cpf, name, email, groupName string) error {
 	messageAction := "SUPPRESS"
 
 	pass := fmt.Sprintf("%v%v", cpf, passwordSufixTemp)
@@ -96,7 +120,10 @@ func (ds *CognitoRemoteDataSourceImpl) signUp(cpf, name, email, groupName string
 		},
 	}
 
-	_, err := ds.cognitoClient.AdminCreateUser(userCognito)
+	err := call(ctx, "AdminCreateUser", func(ctx context.Context) error {
+		_, err := ds.cognitoClient.AdminCreateUserWithContext(ctx, userCognito)
+		return err
+	})
 
 	if err != nil {
 		return err
@@ -112,7 +139,10 @@ func (ds *CognitoRemoteDataSourceImpl) signUp(cpf, name, email, groupName string
 		Permanent:  &permanent,
 	}
 
-	_, errPasswd := ds.cognitoClient.AdminSetUserPassword(setPasswordInput)
+	errPasswd := call(ctx, "AdminSetUserPassword", func(ctx context.Context) error {
+		_, err := ds.cognitoClient.AdminSetUserPasswordWithContext(ctx, setPasswordInput)
+		return err
+	})
 
 	if errPasswd != nil {
 		return errPasswd
@@ -124,7 +154,10 @@ func (ds *CognitoRemoteDataSourceImpl) signUp(cpf, name, email, groupName string
 		Username:   aws.String(cpf),
 	}
 
-	_, errGroup := ds.cognitoClient.AdminAddUserToGroup(addUserToGroupInput)
+	errGroup := call(ctx, "AdminAddUserToGroup", func(ctx context.Context) error {
+		_, err := ds.cognitoClient.AdminAddUserToGroupWithContext(ctx, addUserToGroupInput)
+		return err
+	})
 
 	if errGroup != nil {
 		return errGroup
@@ -133,7 +166,11 @@ func (ds *CognitoRemoteDataSourceImpl) signUp(cpf, name, email, groupName string
 	return nil
 }
 
-func (ds *CognitoRemoteDataSourceImpl) Login(cpf string) (string, error) {
+func (ds *CognitoRemoteDataSourceImpl) Login(ctx context.Context, cpf string) (token string, err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.Login")
+	defer tracing.End(span, &err)
+	defer observe("Login", time.Now(), &err)
+
 	password := fmt.Sprintf("%v%v", cpf, passwordSufix)
 
 	authInput := &cognito.InitiateAuthInput{
@@ -144,7 +181,13 @@ func (ds *CognitoRemoteDataSourceImpl) Login(cpf string) (string, error) {
 		}),
 		ClientId: aws.String(ds.appClientID),
 	}
-	result, err := ds.cognitoClient.InitiateAuth(authInput)
+
+	var result *cognito.InitiateAuthOutput
+
+	err = call(ctx, "InitiateAuth", func(ctx context.Context) (err error) {
+		result, err = ds.cognitoClient.InitiateAuthWithContext(ctx, authInput)
+		return err
+	})
 
 	if err != nil {
 		return "", err
@@ -153,16 +196,26 @@ func (ds *CognitoRemoteDataSourceImpl) Login(cpf string) (string, error) {
 	return *result.AuthenticationResult.AccessToken, nil
 }
 
-func (ds *CognitoRemoteDataSourceImpl) LoginUnknown() (string, error) {
+func (ds *CognitoRemoteDataSourceImpl) LoginUnknown(ctx context.Context) (token string, err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.LoginUnknown")
+	defer tracing.End(span, &err)
+	defer observe("LoginUnknown", time.Now(), &err)
+
 	authInput := &cognito.InitiateAuthInput{
 		AuthFlow: aws.String("USER_PASSWORD_AUTH"),
 		AuthParameters: aws.StringMap(map[string]string{
-			"USERNAME": "unknown-user",
-			"PASSWORD": "unknown-user",
+			"USERNAME": UnknownUsername,
+			"PASSWORD": UnknownUsername,
 		}),
 		ClientId: aws.String(ds.appClientID),
 	}
-	result, err := ds.cognitoClient.InitiateAuth(authInput)
+
+	var result *cognito.InitiateAuthOutput
+
+	err = call(ctx, "InitiateAuth", func(ctx context.Context) (err error) {
+		result, err = ds.cognitoClient.InitiateAuthWithContext(ctx, authInput)
+		return err
+	})
 
 	if err != nil {
 		return "", err
@@ -170,3 +223,100 @@ func (ds *CognitoRemoteDataSourceImpl) LoginUnknown() (string, error) {
 
 	return *result.AuthenticationResult.AccessToken, nil
 }
+
+// GetUsername returns the username of the access token owner. Cognito fails
+// with NotAuthorizedException when the token is expired, revoked or invalid
+func (ds *CognitoRemoteDataSourceImpl) GetUsername(ctx context.Context, accessToken string) (username string, err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.GetUsername")
+	defer tracing.End(span, &err)
+	defer observe("GetUsername", time.Now(), &err)
+
+	var result *cognito.GetUserOutput
+
+	err = call(ctx, "GetUser", func(ctx context.Context) (err error) {
+		result, err = ds.cognitoClient.GetUserWithContext(ctx, &cognito.GetUserInput{
+			AccessToken: aws.String(accessToken),
+		})
+		return err
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return aws.StringValue(result.Username), nil
+}
+
+// DisableUser disables the user of the CPF, which can not log in until it is
+// enabled again. Its access tokens are not revoked and last until they expire
+func (ds *CognitoRemoteDataSourceImpl) DisableUser(ctx context.Context, cpf string) (err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.DisableUser")
+	defer tracing.End(span, &err)
+	defer observe("DisableUser", time.Now(), &err)
+
+	return call(ctx, "AdminDisableUser", func(ctx context.Context) error {
+		_, err := ds.cognitoClient.AdminDisableUserWithContext(ctx, &cognito.AdminDisableUserInput{
+			UserPoolId: aws.String(ds.userPoolID),
+			Username:   aws.String(cpf),
+		})
+		return err
+	})
+}
+
+// EnableUser enables the user of the CPF disabled by DisableUser
+func (ds *CognitoRemoteDataSourceImpl) EnableUser(ctx context.Context, cpf string) (err error) {
+	ctx, span := tracing.Start(ctx, "Cognito.EnableUser")
+	defer tracing.End(span, &err)
+	defer observe("EnableUser", time.Now(), &err)
+
+	return call(ctx, "AdminEnableUser", func(ctx context.Context) error {
+		_, err := ds.cognitoClient.AdminEnableUserWithContext(ctx, &cognito.AdminEnableUserInput{
+			UserPoolId: aws.String(ds.userPoolID),
+			Username:   aws.String(cpf),
+		})
+		return err
+	})
+}
+
+// Ping checks if the user pool is reachable with the current credentials
+func (ds *CognitoRemoteDataSourceImpl) Ping(ctx context.Context) (err error) {
+	defer observe("Ping", time.Now(), &err)
+
+	_, err = ds.cognitoClient.DescribeUserPoolWithContext(ctx, &cognito.DescribeUserPoolInput{
+		UserPoolId: aws.String(ds.userPoolID),
+	})
+
+	return err
+}
+
+// call runs one Cognito API call inside its own client span
+func call(ctx context.Context, operation string, fn func(ctx context.Context) error) (err error) {
+	ctx, span := tracing.Start(ctx, "Cognito."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("aws-api"),
+			semconv.RPCService("CognitoIdentityProvider"),
+			semconv.RPCMethod(operation),
+		),
+	)
+	defer tracing.End(span, &err)
+
+	return fn(ctx)
+}
+
+// observe records the call metrics. Only the AWS error code is used as label
+// because the error message may have the CPF of the user
+func observe(operation string, start time.Time, err *error) {
+	code := ""
+
+	if *err != nil {
+		code = "Unknown"
+
+		var awsErr awserr.Error
+		if errors.As(*err, &awsErr) {
+			code = awsErr.Code()
+		}
+	}
+
+	metrics.ObserveIdentityProvider(operation, time.Since(start), code)
+}
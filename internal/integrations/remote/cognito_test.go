@@ -1,11 +1,16 @@
 package remote_test
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
 	"github.com/thiagoluis88git/tech1-customer/internal/integrations/remote"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 func TestCognitoRemote(t *testing.T) {
@@ -14,7 +19,7 @@ func TestCognitoRemote(t *testing.T) {
 	t.Run("got error when login cognito remote", func(t *testing.T) {
 		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
 
-		result, err := sut.Login("cpf")
+		result, err := sut.Login(context.TODO(), "cpf")
 		assert.Error(t, err)
 		assert.Empty(t, result)
 	})
@@ -22,7 +27,15 @@ func TestCognitoRemote(t *testing.T) {
 	t.Run("got error when login unknown cognito remote", func(t *testing.T) {
 		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
 
-		result, err := sut.LoginUnknown()
+		result, err := sut.LoginUnknown(context.TODO())
+		assert.Error(t, err)
+		assert.Empty(t, result)
+	})
+
+	t.Run("got error when getting username cognito remote", func(t *testing.T) {
+		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
+
+		result, err := sut.GetUsername(context.TODO(), "token")
 		assert.Error(t, err)
 		assert.Empty(t, result)
 	})
@@ -30,14 +43,69 @@ func TestCognitoRemote(t *testing.T) {
 	t.Run("got error when sign up cognito remote", func(t *testing.T) {
 		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
 
-		err := sut.SignUp(&model.Customer{})
+		err := sut.SignUp(context.TODO(), &model.Customer{})
 		assert.Error(t, err)
 	})
 
 	t.Run("got error when sign up admin cognito remote", func(t *testing.T) {
 		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
 
-		err := sut.SignUpAdmin(&model.UserAdmin{})
+		err := sut.SignUpAdmin(context.TODO(), &model.UserAdmin{})
+		assert.Error(t, err)
+	})
+
+	t.Run("got error when disabling user cognito remote", func(t *testing.T) {
+		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
+
+		err := sut.DisableUser(context.TODO(), "cpf")
+		assert.Error(t, err)
+	})
+
+	t.Run("got error when enabling user cognito remote", func(t *testing.T) {
+		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
+
+		err := sut.EnableUser(context.TODO(), "cpf")
+		assert.Error(t, err)
+	})
+
+	t.Run("got error when pinging cognito remote", func(t *testing.T) {
+		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		err := sut.Ping(ctx)
 		assert.Error(t, err)
 	})
 }
+
+// The test is not parallel because the tracer provider is global
+func TestCognitoRemoteTracing(t *testing.T) {
+	t.Run("got one span per cognito call when sign up cognito remote", func(t *testing.T) {
+		exporter := tracetest.NewInMemoryExporter()
+
+		shutdown, err := tracing.Setup(context.Background(), tracing.SpanExporter(exporter))
+		assert.NoError(t, err)
+
+		defer shutdown(context.Background())
+
+		sut := remote.NewCognitoRemoteDataSource("region", "userPool", "appClient", "groupUser", "adminUser")
+
+		err = sut.SignUp(context.TODO(), &model.Customer{CPF: "83212446293"})
+		assert.Error(t, err)
+
+		spans := exporter.GetSpans()
+
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "Cognito.AdminCreateUser", spans[0].Name)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Equal(t, "Cognito.SignUp", spans[1].Name)
+		assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+
+		for _, span := range spans {
+			for _, attr := range span.Attributes {
+				assert.NotContains(t, attr.Value.Emit(), "83212446293")
+			}
+		}
+	})
+}
@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+)
+
+const (
+	// AnonymousActor is the actor of the requests without an access token,
+	// like the sign up
+	AnonymousActor = "anonymous"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionPatch   = "patch"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+type metadataKey struct{}
+
+// Metadata is who made a change and from where
+type Metadata struct {
+	Actor     string
+	RequestID string
+	IP        string
+}
+
+// ActorResolver returns the username of an access token. It fails when the
+// token is expired or revoked
+type ActorResolver func(ctx context.Context, accessToken string) (string, error)
+
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, metadata)
+}
+
+// FromContext returns the metadata set by Middleware. Without it, like in the
+// gRPC API, the actor is anonymous
+func FromContext(ctx context.Context) Metadata {
+	metadata, ok := ctx.Value(metadataKey{}).(Metadata)
+
+	if !ok {
+		metadata.RequestID = chiMiddleware.GetReqID(ctx)
+	}
+
+	if metadata.Actor == "" {
+		metadata.Actor = AnonymousActor
+	}
+
+	return metadata
+}
+
+// Middleware sets the audit metadata of the request. The actor is the owner of
+// the Authorization access token, resolved with the identity provider, so it
+// can not be forged. A request with an invalid token is rejected and one
+// without a token is anonymous. It must be used after the RequestID and RealIP
+// middlewares
+func Middleware(resolve ActorResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			metadata := Metadata{
+				Actor:     AnonymousActor,
+				RequestID: chiMiddleware.GetReqID(r.Context()),
+				IP:        remoteIP(r.RemoteAddr),
+			}
+
+			if accessToken := bearerToken(r.Header.Get("Authorization")); accessToken != "" {
+				actor, err := resolve(r.Context(), accessToken)
+
+				if err != nil {
+					logger.RequestError(r.Context(), "resolving audit actor", err, httpserver.GetStatusCodeFromError(err))
+					httpserver.SendResponseError(w, r, err)
+					return
+				}
+
+				metadata.Actor = actor
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithMetadata(r.Context(), metadata)))
+		})
+	}
+}
+
+func bearerToken(header string) string {
+	if len(header) > 7 && strings.EqualFold(header[:7], "bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+
+	return strings.TrimSpace(header)
+}
+
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+
+	if err != nil {
+		return remoteAddr
+	}
+
+	return host
+}
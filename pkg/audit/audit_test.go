@@ -0,0 +1,141 @@
+package audit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/audit"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+func mockResolver(actor string, err error) audit.ActorResolver {
+	return func(ctx context.Context, accessToken string) (string, error) {
+		if accessToken != "eYmly" {
+			return "", &responses.BusinessResponse{StatusCode: http.StatusUnauthorized, Code: responses.CodeUnauthorized}
+		}
+
+		return actor, err
+	}
+}
+
+func doRequest(resolve audit.ActorResolver, authorization string) (*httptest.ResponseRecorder, *audit.Metadata) {
+	var metadata *audit.Metadata
+
+	handler := chiMiddleware.RequestID(audit.Middleware(resolve)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		found := audit.FromContext(r.Context())
+		metadata = &found
+	})))
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/admin/customers/1", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder, metadata
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got anonymous actor when calling Middleware without access token", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, metadata := doRequest(mockResolver("admin", nil), "")
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+
+		if assert.NotNil(t, metadata) {
+			assert.Equal(t, audit.AnonymousActor, metadata.Actor)
+			assert.Equal(t, "10.0.0.7", metadata.IP)
+			assert.NotEmpty(t, metadata.RequestID)
+		}
+	})
+
+	t.Run("got token owner as actor when calling Middleware with bearer token", func(t *testing.T) {
+		t.Parallel()
+
+		_, metadata := doRequest(mockResolver("admin", nil), "Bearer eYmly")
+
+		if assert.NotNil(t, metadata) {
+			assert.Equal(t, "admin", metadata.Actor)
+		}
+	})
+
+	t.Run("got token owner as actor when calling Middleware with raw token", func(t *testing.T) {
+		t.Parallel()
+
+		_, metadata := doRequest(mockResolver("admin", nil), "eYmly")
+
+		if assert.NotNil(t, metadata) {
+			assert.Equal(t, "admin", metadata.Actor)
+		}
+	})
+
+	t.Run("got unauthorized when calling Middleware with invalid token", func(t *testing.T) {
+		t.Parallel()
+
+		recorder, metadata := doRequest(mockResolver("admin", nil), "Bearer forged")
+
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+		assert.Nil(t, metadata)
+	})
+
+	t.Run("got anonymous actor when calling FromContext without metadata", func(t *testing.T) {
+		t.Parallel()
+
+		metadata := audit.FromContext(context.Background())
+
+		assert.Equal(t, audit.AnonymousActor, metadata.Actor)
+		assert.Empty(t, metadata.RequestID)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got masked changes when calling Diff on creation", func(t *testing.T) {
+		t.Parallel()
+
+		changes := audit.Diff(nil, map[string]string{
+			"name":  "João",
+			"cpf":   "832.124.462-93",
+			"email": "joao@gmail.com",
+		})
+
+		assert.Equal(t, map[string]audit.Change{
+			"name":  {After: "J***"},
+			"cpf":   {After: "***.***.***-93"},
+			"email": {After: "j***@gmail.com"},
+		}, changes)
+	})
+
+	t.Run("got only changed fields when calling Diff on update", func(t *testing.T) {
+		t.Parallel()
+
+		changes := audit.Diff(
+			map[string]string{"name": "Maria", "cpf": "83212446293", "email": "maria@gmail.com"},
+			map[string]string{"name": "Maria", "cpf": "83212446293", "email": "mary@gmail.com"},
+		)
+
+		assert.Equal(t, map[string]audit.Change{
+			"email": {Before: "m***@gmail.com", After: "m***@gmail.com"},
+		}, changes)
+	})
+
+	t.Run("got fully masked value when calling Mask with malformed data", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "***", audit.Mask("cpf", "123"))
+		assert.Equal(t, "***", audit.Mask("email", "invalid"))
+		assert.Equal(t, "open", audit.Mask("status", "open"))
+	})
+}
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Change is the masked value of a field before and after an action. Before is
+// empty for a creation
+type Change struct {
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// maskers are the personal data fields, which are never stored in clear in
+// the audit log
+var maskers = map[string]func(string) string{
+	"name":  maskName,
+	"cpf":   maskCPF,
+	"email": maskEmail,
+}
+
+// Diff returns the masked changes of the fields of after that are different
+// in before. The comparison uses the clear values, so a change is recorded
+// even when both masked values are equal
+func Diff(before, after map[string]string) map[string]Change {
+	changes := map[string]Change{}
+
+	for field, value := range after {
+		if before[field] == value {
+			continue
+		}
+
+		changes[field] = Change{
+			Before: Mask(field, before[field]),
+			After:  Mask(field, value),
+		}
+	}
+
+	return changes
+}
+
+// Mask masks the value of a personal data field. Other fields are unchanged
+func Mask(field string, value string) string {
+	masker, ok := maskers[field]
+
+	if !ok || value == "" {
+		return value
+	}
+
+	return masker(value)
+}
+
+// maskName keeps the first letter, like J***
+func maskName(name string) string {
+	first, _ := utf8.DecodeRuneInString(name)
+
+	return string(first) + "***"
+}
+
+// maskCPF keeps the check digits, like ***.***.***-93
+func maskCPF(cpf string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+
+		return -1
+	}, cpf)
+
+	if len(digits) != 11 {
+		return "***"
+	}
+
+	return "***.***.***-" + digits[9:]
+}
+
+// maskEmail keeps the first letter and the domain, like j***@gmail.com
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+
+	if at < 1 {
+		return "***"
+	}
+
+	first, _ := utf8.DecodeRuneInString(email)
+
+	return string(first) + "***" + email[at:]
+}
@@ -0,0 +1,113 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/cache"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got value when calling Get after Set", func(t *testing.T) {
+		t.Parallel()
+
+		lru := cache.NewLRU(2)
+
+		err := lru.Set(context.Background(), "key", []byte("value"), time.Minute)
+		assert.NoError(t, err)
+
+		value, found, err := lru.Get(context.Background(), "key")
+
+		assert.NoError(t, err)
+		assert.True(t, found)
+		assert.Equal(t, []byte("value"), value)
+	})
+
+	t.Run("got not found when calling Get with missing key", func(t *testing.T) {
+		t.Parallel()
+
+		lru := cache.NewLRU(2)
+
+		value, found, err := lru.Get(context.Background(), "missing")
+
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.Nil(t, value)
+	})
+
+	t.Run("got not found when calling Get after TTL", func(t *testing.T) {
+		t.Parallel()
+
+		lru := cache.NewLRU(2)
+
+		err := lru.Set(context.Background(), "key", []byte("value"), time.Millisecond)
+		assert.NoError(t, err)
+
+		time.Sleep(5 * time.Millisecond)
+
+		_, found, err := lru.Get(context.Background(), "key")
+
+		assert.NoError(t, err)
+		assert.False(t, found)
+		assert.Equal(t, 0, lru.Len())
+	})
+
+	t.Run("got least recently used entry evicted when calling Set on full cache", func(t *testing.T) {
+		t.Parallel()
+
+		lru := cache.NewLRU(2)
+
+		assert.NoError(t, lru.Set(context.Background(), "first", []byte("1"), time.Minute))
+		assert.NoError(t, lru.Set(context.Background(), "second", []byte("2"), time.Minute))
+
+		_, found, _ := lru.Get(context.Background(), "first")
+		assert.True(t, found)
+
+		assert.NoError(t, lru.Set(context.Background(), "third", []byte("3"), time.Minute))
+
+		_, found, _ = lru.Get(context.Background(), "second")
+		assert.False(t, found)
+
+		_, found, _ = lru.Get(context.Background(), "first")
+		assert.True(t, found)
+
+		_, found, _ = lru.Get(context.Background(), "third")
+		assert.True(t, found)
+		assert.Equal(t, 2, lru.Len())
+	})
+
+	t.Run("got new value when calling Set with existing key", func(t *testing.T) {
+		t.Parallel()
+
+		lru := cache.NewLRU(2)
+
+		assert.NoError(t, lru.Set(context.Background(), "key", []byte("old"), time.Minute))
+		assert.NoError(t, lru.Set(context.Background(), "key", []byte("new"), time.Minute))
+
+		value, found, _ := lru.Get(context.Background(), "key")
+
+		assert.True(t, found)
+		assert.Equal(t, []byte("new"), value)
+		assert.Equal(t, 1, lru.Len())
+	})
+
+	t.Run("got not found when calling Get after Delete", func(t *testing.T) {
+		t.Parallel()
+
+		lru := cache.NewLRU(2)
+
+		assert.NoError(t, lru.Set(context.Background(), "first", []byte("1"), time.Minute))
+		assert.NoError(t, lru.Set(context.Background(), "second", []byte("2"), time.Minute))
+
+		err := lru.Delete(context.Background(), "first", "second", "missing")
+		assert.NoError(t, err)
+
+		_, found, _ := lru.Get(context.Background(), "first")
+		assert.False(t, found)
+		assert.Equal(t, 0, lru.Len())
+	})
+}
@@ -1,28 +1,179 @@
 package database
 
 import (
-	"github.com/thiagoluis88git/tech1-customer/internal/core/data/model"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+const _defaultReplicaCheckInterval = 5 * time.Second
+
+// Database is the primary connection pool, used by the writes, and the
+// optional read replica pools, used through Reader
 type Database struct {
 	Connection *gorm.DB
+
+	replicas             []*replica
+	nextReplica          atomic.Uint64
+	replicaCheckInterval time.Duration
+	logger               *slog.Logger
 }
 
-func ConfigDatabase(dialector gorm.Dialector) (*Database, error) {
+func ConfigDatabase(dialector gorm.Dialector, opts ...Option) (*Database, error) {
+	cfg := &config{
+		replicaCheckInterval: _defaultReplicaCheckInterval,
+		logger:               slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
 	db, err := gorm.Open(dialector, &gorm.Config{})
 
 	if err != nil {
 		return &Database{}, err
 	}
 
-	db.AutoMigrate(
-		&model.UserAdmin{},
-		&model.Customer{},
-	)
+	err = configPool(db, cfg)
+
+	if err != nil {
+		return &Database{}, err
+	}
+
+	database := &Database{
+		Connection:           db,
+		replicaCheckInterval: cfg.replicaCheckInterval,
+		logger:               cfg.logger,
+	}
+
+	for i, replicaDialector := range cfg.replicas {
+		replicaDB, err := gorm.Open(replicaDialector, &gorm.Config{DisableAutomaticPing: true})
+
+		if err != nil {
+			return &Database{}, errors.Join(fmt.Errorf("opening read replica %v: %w", i, err), database.Close(context.Background()))
+		}
+
+		err = configPool(replicaDB, cfg)
+
+		if err != nil {
+			return &Database{}, errors.Join(err, database.Close(context.Background()))
+		}
+
+		replica := &replica{name: fmt.Sprintf("replica-%v", i), db: replicaDB}
+		database.replicas = append(database.replicas, replica)
+
+		err = replicaDB.Callback().Query().After("gorm:query").Register("database:replica_health", database.replicaHealthCallback(replica))
+
+		if err != nil {
+			return &Database{}, errors.Join(err, database.Close(context.Background()))
+		}
+	}
+
+	database.checkReplicas(context.Background())
+
+	return database, nil
+}
+
+func configPool(db *gorm.DB, cfg *config) error {
+	sqlDB, err := db.DB()
+
+	if err != nil {
+		return err
+	}
+
+	if cfg.maxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.maxOpenConns)
+	}
+
+	if cfg.maxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.maxIdleConns)
+	}
+
+	if cfg.connMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.connMaxLifetime)
+	}
+
+	if cfg.connMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.connMaxIdleTime)
+	}
+
+	return nil
+}
+
+// Use registers a plugin, like tracing or metrics, in the primary and in every
+// replica
+func (db *Database) Use(plugin gorm.Plugin) error {
+	err := db.Connection.Use(plugin)
+
+	if err != nil {
+		return err
+	}
+
+	for _, replica := range db.replicas {
+		err = replica.db.Use(plugin)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ping checks if the primary accepts connections. The replicas are not
+// checked, since the reads fall back to the primary
+func (db *Database) Ping(ctx context.Context) error {
+	return ping(ctx, db.Connection)
+}
+
+// Close closes the connection pools. It must be called after every user of
+// the database has stopped
+func (db *Database) Close(ctx context.Context) error {
+	var errs []error
+
+	for _, replica := range db.replicas {
+		sqlDB, err := replica.db.DB()
+
+		if err == nil {
+			err = sqlDB.Close()
+		}
+
+		errs = append(errs, err)
+	}
+
+	sqlDB, err := db.Connection.DB()
+
+	if err == nil {
+		err = sqlDB.Close()
+	}
+
+	return errors.Join(append(errs, err)...)
+}
+
+// CheckMigrations returns an error if some embedded migration was not applied
+// yet. A schema ahead of the binary, during a rolling deploy, is accepted
+func (db *Database) CheckMigrations(ctx context.Context) error {
+	migrator, err := NewMigrator(db)
+
+	if err != nil {
+		return err
+	}
+
+	pending, err := migrator.Pending(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind: %v pending migrations, from %v_%v", len(pending), pending[0].Version, pending[0].Name)
+	}
 
-	return &Database{
-		Connection: db,
-	}, nil
+	return nil
 }
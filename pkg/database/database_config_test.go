@@ -1,6 +1,8 @@
 package database_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -10,6 +12,7 @@ import (
 	"github.com/thiagoluis88git/tech1-customer/pkg/database"
 	"github.com/thiagoluis88git/tech1-customer/pkg/environment"
 	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
 )
 
 func setup() {
@@ -26,6 +29,7 @@ func setup() {
 	os.Setenv(environment.WebhookMercadoLivrePaymentURL, "WEBHOOK")
 	os.Setenv(environment.QRCodeGatewayToken, "token")
 	os.Setenv(environment.Region, "Region")
+	os.Setenv(environment.EncryptionIndexKey, "IndexKey")
 }
 
 func TestDatabaseConfig(t *testing.T) {
@@ -67,4 +71,72 @@ func TestDatabaseConfig(t *testing.T) {
 		assert.Error(t, err)
 		assert.Empty(t, config)
 	})
+
+	t.Run("got success when pinging database", func(t *testing.T) {
+		conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		assert.NoError(t, err)
+
+		mock.ExpectPing()
+
+		db, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_1",
+			DriverName:           "postgres",
+			Conn:                 conn,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{DisableAutomaticPing: true})
+
+		assert.NoError(t, err)
+
+		config := &database.Database{Connection: db}
+
+		err = config.Ping(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got error when pinging unreachable database", func(t *testing.T) {
+		conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		assert.NoError(t, err)
+
+		mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+
+		db, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_2",
+			DriverName:           "postgres",
+			Conn:                 conn,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{DisableAutomaticPing: true})
+
+		assert.NoError(t, err)
+
+		config := &database.Database{Connection: db}
+
+		err = config.Ping(context.Background())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("got connection pool closed when closing database", func(t *testing.T) {
+		conn, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		mock.ExpectClose()
+
+		db, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_db_3",
+			DriverName:           "postgres",
+			Conn:                 conn,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{DisableAutomaticPing: true})
+
+		assert.NoError(t, err)
+
+		config := &database.Database{Connection: db}
+
+		err = config.Close(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
 }
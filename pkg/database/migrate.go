@@ -0,0 +1,411 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	_migrationsTable = "schema_migrations"
+
+	// _migrationLockKey is the Postgres advisory lock held while migrating, so
+	// only one runner changes the schema at a time
+	_migrationLockKey int64 = 5_170_201_442
+)
+
+//go:embed migrations/*.sql migrations/sqlite/*.sql
+var migrationsFS embed.FS
+
+// migrationDialect has the directory of the migrations of a dialect and the
+// creation of its schema version table
+type migrationDialect struct {
+	dir         string
+	createTable string
+}
+
+// migrationDialects are the dialects with migrations. They have the same
+// versions and names, so a schema version means the same on every dialect
+var migrationDialects = map[string]migrationDialect{
+	"postgres": {
+		dir: "migrations",
+		createTable: `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" bigint PRIMARY KEY,
+			"name" varchar(255) NOT NULL,
+			"applied_at" timestamptz NOT NULL
+		)`,
+	},
+	"sqlite": {
+		dir: "migrations/sqlite",
+		createTable: `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" integer PRIMARY KEY,
+			"name" varchar(255) NOT NULL,
+			"applied_at" datetime NOT NULL
+		)`,
+	},
+}
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a pair of SQL scripts embedded in the binary. Up changes the
+// schema to Version and Down reverts it to the previous version
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied. AppliedAt is nil for
+// a pending migration
+type MigrationStatus struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (schemaMigration) TableName() string {
+	return _migrationsTable
+}
+
+// Migrator applies the embedded migrations. Every migration runs in its own
+// transaction, together with the update of the schema version table
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *Database) (*Migrator, error) {
+	migrations, err := Migrations(db.Connection.Dialector.Name())
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db.Connection,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrations returns the embedded migrations of the dialect sorted by version
+func Migrations(dialect string) ([]Migration, error) {
+	migrationDialect, ok := migrationDialects[dialect]
+
+	if !ok {
+		return nil, fmt.Errorf("there are no migrations for the %v dialect", dialect)
+	}
+
+	files, err := fs.Glob(migrationsFS, migrationDialect.dir+"/*.sql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+
+	for _, file := range files {
+		match := migrationFileRegex.FindStringSubmatch(path.Base(file))
+
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %v", file)
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 0)
+
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %v", file)
+		}
+
+		content, err := migrationsFS.ReadFile(file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %v has two names: %v and %v", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %v must have an up and a down script", migration.Version)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest returns the version of the last embedded migration
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.Latest())
+}
+
+// Down reverts the last applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+
+		if err != nil {
+			return err
+		}
+
+		current := currentVersion(applied)
+
+		if current == 0 {
+			return nil
+		}
+
+		target := uint(0)
+
+		for version := range applied {
+			if version < current && version > target {
+				target = version
+			}
+		}
+
+		return m.migrate(conn, applied, target)
+	})
+}
+
+// To applies or reverts the migrations until the schema is at version. Zero
+// reverts every migration
+func (m *Migrator) To(ctx context.Context, version uint) error {
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("unknown migration version %v", version)
+	}
+
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := appliedMigrations(conn)
+
+		if err != nil {
+			return err
+		}
+
+		return m.migrate(conn, applied, version)
+	})
+}
+
+// Status returns every embedded migration and when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+
+	for _, migration := range m.migrations {
+		migrationStatus := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+
+		if row, ok := applied[migration.Version]; ok {
+			migrationStatus.AppliedAt = &row.AppliedAt
+		}
+
+		status = append(status, migrationStatus)
+	}
+
+	return status, nil
+}
+
+// Pending returns the embedded migrations that were not applied yet
+func (m *Migrator) Pending(ctx context.Context) ([]Migration, error) {
+	applied, err := m.applied(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// applied reads the schema version table without creating it, so a read only
+// check does not change the database
+func (m *Migrator) applied(ctx context.Context) (map[uint]schemaMigration, error) {
+	db := m.db.WithContext(ctx)
+
+	if !db.Migrator().HasTable(_migrationsTable) {
+		return map[uint]schemaMigration{}, nil
+	}
+
+	return appliedMigrations(db)
+}
+
+// migrate reverts the applied migrations above target, from the newest, and
+// then applies the missing ones up to target, from the oldest
+func (m *Migrator) migrate(conn *gorm.DB, applied map[uint]schemaMigration, target uint) error {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= target {
+			continue
+		}
+
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			err := tx.Exec(migration.Down).Error
+
+			if err != nil {
+				return err
+			}
+
+			return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+		})
+
+		if err != nil {
+			return fmt.Errorf("reverting migration %v_%v: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > target {
+			continue
+		}
+
+		err := conn.Transaction(func(tx *gorm.DB) error {
+			err := tx.Exec(migration.Up).Error
+
+			if err != nil {
+				return err
+			}
+
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		})
+
+		if err != nil {
+			return fmt.Errorf("applying migration %v_%v: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// withLock runs fn on a single connection holding the migration lock, after
+// creating the schema version table. The other runners wait for the lock and
+// then find the migrations already applied
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) (err error) {
+		// a session, so the conditions of a statement do not leak into the next
+		conn = conn.Session(&gorm.Session{})
+
+		if conn.Dialector.Name() == "postgres" {
+			err = conn.Exec("SELECT pg_advisory_lock(?)", _migrationLockKey).Error
+
+			if err != nil {
+				return fmt.Errorf("acquiring migration lock: %w", err)
+			}
+
+			defer func() {
+				unlockErr := conn.Exec("SELECT pg_advisory_unlock(?)", _migrationLockKey).Error
+				err = errors.Join(err, unlockErr)
+			}()
+		}
+
+		err = conn.Exec(migrationDialects[conn.Dialector.Name()].createTable).Error
+
+		if err != nil {
+			return fmt.Errorf("creating the schema version table: %w", err)
+		}
+
+		return fn(conn)
+	})
+}
+
+func (m *Migrator) find(version uint) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+func appliedMigrations(db *gorm.DB) (map[uint]schemaMigration, error) {
+	var rows []schemaMigration
+
+	err := db.Find(&rows).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[uint]schemaMigration, len(rows))
+
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+
+	return applied, nil
+}
+
+func currentVersion(applied map[uint]schemaMigration) uint {
+	current := uint(0)
+
+	for version := range applied {
+		current = max(current, version)
+	}
+
+	return current
+}
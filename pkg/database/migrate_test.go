@@ -0,0 +1,405 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const (
+	hasMigrationsTableQuery = `SELECT count\(\*\) FROM information_schema.tables`
+	selectMigrationsQuery   = `SELECT \* FROM "schema_migrations"`
+	insertMigrationQuery    = `INSERT INTO "schema_migrations" \("version","name","applied_at"\)`
+	deleteMigrationQuery    = `DELETE FROM "schema_migrations" WHERE "schema_migrations"."version" = \$1`
+)
+
+func mockMigrationsDatabase(t *testing.T) (*database.Database, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN:                  "sqlmock_migrations",
+		DriverName:           "postgres",
+		Conn:                 conn,
+		PreferSimpleProtocol: true,
+	}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               logger.Default.LogMode(logger.Silent),
+	})
+	assert.NoError(t, err)
+
+	return &database.Database{Connection: db}, mock
+}
+
+func appliedRows(versions ...uint) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+
+	for _, version := range versions {
+		rows.AddRow(version, "migration", time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC))
+	}
+
+	return rows
+}
+
+func expectLockedMigration(mock sqlmock.Sqlmock, applied ...uint) {
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "schema_migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(selectMigrationsQuery).WillReturnRows(appliedRows(applied...))
+}
+
+func TestMigrations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got sorted migrations with up and down scripts when calling Migrations", func(t *testing.T) {
+		t.Parallel()
+
+		migrations, err := database.Migrations("postgres")
+
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 9)
+
+		for i, migration := range migrations {
+			assert.Equal(t, uint(i+1), migration.Version)
+			assert.NotEmpty(t, migration.Up)
+			assert.NotEmpty(t, migration.Down)
+		}
+
+		assert.Equal(t, "create_user_admins_and_customers", migrations[0].Name)
+		assert.Contains(t, migrations[0].Up, `CONSTRAINT "uni_customers_cpf" UNIQUE ("cpf")`)
+		assert.NotContains(t, migrations[0].Up, `"version"`)
+		assert.Contains(t, migrations[1].Up, `ALTER TABLE "customers" ADD COLUMN IF NOT EXISTS "version" bigint NOT NULL DEFAULT 1`)
+		assert.Contains(t, migrations[3].Up, `CREATE UNIQUE INDEX IF NOT EXISTS "idx_audit_logs_sequence"`)
+		assert.Contains(t, migrations[4].Up, `CREATE UNIQUE INDEX IF NOT EXISTS "idx_customers_cpf_index" ON "customers" ("cpf_index")`)
+		assert.Contains(t, migrations[5].Up, `CREATE TABLE IF NOT EXISTS "record_versions"`)
+	})
+
+	t.Run("got same versions and names on every dialect when calling Migrations", func(t *testing.T) {
+		t.Parallel()
+
+		postgresMigrations, err := database.Migrations("postgres")
+		assert.NoError(t, err)
+
+		sqliteMigrations, err := database.Migrations("sqlite")
+		assert.NoError(t, err)
+
+		assert.Len(t, sqliteMigrations, len(postgresMigrations))
+
+		for i := range postgresMigrations {
+			assert.Equal(t, postgresMigrations[i].Version, sqliteMigrations[i].Version)
+			assert.Equal(t, postgresMigrations[i].Name, sqliteMigrations[i].Name)
+			assert.NotEqual(t, postgresMigrations[i].Up, sqliteMigrations[i].Up)
+		}
+	})
+
+	t.Run("got error when calling Migrations with unknown dialect", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := database.Migrations("mysql")
+
+		assert.ErrorContains(t, err, "mysql")
+	})
+
+	t.Run("got every migration applied and reverted when calling Up and To on SQLite", func(t *testing.T) {
+		t.Parallel()
+
+		db, err := database.ConfigDatabase(database.SQLiteDialector(filepath.Join(t.TempDir(), "migrations.db")))
+		assert.NoError(t, err)
+
+		defer db.Close(context.Background())
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.Up(context.Background())
+		assert.NoError(t, err)
+
+		assert.NoError(t, db.CheckMigrations(context.Background()))
+		assert.True(t, db.Connection.Migrator().HasColumn("customers", "cpf_index"))
+
+		err = migrator.To(context.Background(), 0)
+		assert.NoError(t, err)
+
+		assert.False(t, db.Connection.Migrator().HasTable("customers"))
+
+		pending, err := migrator.Pending(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, pending, 9)
+	})
+
+	t.Run("got version column added when calling Up on SQLite created by AutoMigrate", func(t *testing.T) {
+		t.Parallel()
+
+		db, err := database.ConfigDatabase(database.SQLiteDialector(filepath.Join(t.TempDir(), "automigrate.db")))
+		assert.NoError(t, err)
+
+		defer db.Close(context.Background())
+
+		// the tables of the baseline models, created before the versioned migrations
+		type UserAdmin struct {
+			gorm.Model
+			Name  string
+			CPF   string `gorm:"index;unique"`
+			Email string `gorm:"unique"`
+		}
+
+		type Customer struct {
+			gorm.Model
+			Name  string
+			CPF   string `gorm:"index;unique"`
+			Email string `gorm:"unique"`
+		}
+
+		err = db.Connection.AutoMigrate(&UserAdmin{}, &Customer{})
+		assert.NoError(t, err)
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.Up(context.Background())
+		assert.NoError(t, err)
+
+		assert.True(t, db.Connection.Migrator().HasColumn("customers", "version"))
+		assert.True(t, db.Connection.Migrator().HasColumn("user_admins", "version"))
+	})
+
+	t.Run("got pending migrations applied under lock when calling Up", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		expectLockedMigration(mock, 1)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`ALTER TABLE "user_admins" ADD COLUMN IF NOT EXISTS "version"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(2), "add_version_columns", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "idempotency_keys"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(3), "create_idempotency_keys", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "audit_logs"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(4), "create_audit_logs", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`ALTER TABLE "user_admins" ADD COLUMN IF NOT EXISTS "cpf_index"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(5), "add_blind_indexes", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "record_versions"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(6), "create_record_versions", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "outbox_events"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(7), "create_outbox_events", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`ALTER TABLE "idempotency_keys" ADD COLUMN IF NOT EXISTS "owner"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(8), "add_idempotency_key_owner", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE INDEX IF NOT EXISTS "idx_outbox_events_failed"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertMigrationQuery).
+			WithArgs(uint(9), "add_outbox_events_failed_index", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.Up(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got migration rolled back and lock released when calling Up with failing script", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		expectLockedMigration(mock, 1, 2)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "idempotency_keys"`).WillReturnError(errors.New("permission denied"))
+		mock.ExpectRollback()
+
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.Up(context.Background())
+
+		assert.ErrorContains(t, err, "applying migration 3_create_idempotency_keys: permission denied")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got last migration reverted when calling Down", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		expectLockedMigration(mock, 1, 2, 3, 4)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DROP TABLE IF EXISTS "audit_chain_heads"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteMigrationQuery).WithArgs(uint(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.Down(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got migrations reverted from the newest when calling To with older version", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		expectLockedMigration(mock, 1, 2, 3, 4)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DROP TABLE IF EXISTS "audit_chain_heads"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteMigrationQuery).WithArgs(uint(4)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`DROP TABLE IF EXISTS "idempotency_keys"`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteMigrationQuery).WithArgs(uint(3)).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.To(context.Background(), 2)
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got error when calling To with unknown version", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		err = migrator.To(context.Background(), 99)
+
+		assert.ErrorContains(t, err, "unknown migration version 99")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got applied and pending migrations when calling Status", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		mock.ExpectQuery(hasMigrationsTableQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(selectMigrationsQuery).WillReturnRows(appliedRows(1, 2))
+
+		migrator, err := database.NewMigrator(db)
+		assert.NoError(t, err)
+
+		status, err := migrator.Status(context.Background())
+
+		assert.NoError(t, err)
+		assert.Len(t, status, 9)
+		assert.NotNil(t, status[0].AppliedAt)
+		assert.NotNil(t, status[1].AppliedAt)
+		assert.Nil(t, status[2].AppliedAt)
+		assert.Nil(t, status[3].AppliedAt)
+		assert.Nil(t, status[4].AppliedAt)
+		assert.Nil(t, status[5].AppliedAt)
+		assert.Nil(t, status[6].AppliedAt)
+		assert.Nil(t, status[7].AppliedAt)
+		assert.Nil(t, status[8].AppliedAt)
+	})
+}
+
+func TestCheckMigrations(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got success when calling CheckMigrations with every migration applied", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		mock.ExpectQuery(hasMigrationsTableQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(selectMigrationsQuery).WillReturnRows(appliedRows(1, 2, 3, 4, 5, 6, 7, 8, 9))
+
+		err := db.CheckMigrations(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("got error when calling CheckMigrations with schema behind", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		mock.ExpectQuery(hasMigrationsTableQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(selectMigrationsQuery).WillReturnRows(appliedRows(1, 2))
+
+		err := db.CheckMigrations(context.Background())
+
+		assert.ErrorContains(t, err, "database schema is behind: 7 pending migrations, from 3_create_idempotency_keys")
+	})
+
+	t.Run("got error when calling CheckMigrations without schema version table", func(t *testing.T) {
+		t.Parallel()
+
+		db, mock := mockMigrationsDatabase(t)
+
+		mock.ExpectQuery(hasMigrationsTableQuery).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		err := db.CheckMigrations(context.Background())
+
+		assert.ErrorContains(t, err, "9 pending migrations, from 1_create_user_admins_and_customers")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "customers";
+DROP TABLE IF EXISTS "user_admins";
@@ -0,0 +1,34 @@
+-- The tables of the admin users and the customers, exactly as gorm AutoMigrate
+-- created them before the versioned migrations, so IF NOT EXISTS adopts those
+-- databases. The columns added later have their own migrations
+CREATE TABLE IF NOT EXISTS "user_admins" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "cpf" text,
+    "email" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_user_admins_cpf" UNIQUE ("cpf"),
+    CONSTRAINT "uni_user_admins_email" UNIQUE ("email")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_user_admins_cpf" ON "user_admins" ("cpf");
+CREATE INDEX IF NOT EXISTS "idx_user_admins_deleted_at" ON "user_admins" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "customers" (
+    "id" bigserial,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    "name" text,
+    "cpf" text,
+    "email" text,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_customers_cpf" UNIQUE ("cpf"),
+    CONSTRAINT "uni_customers_email" UNIQUE ("email")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_customers_cpf" ON "customers" ("cpf");
+CREATE INDEX IF NOT EXISTS "idx_customers_deleted_at" ON "customers" ("deleted_at");
@@ -0,0 +1,2 @@
+ALTER TABLE "customers" DROP COLUMN IF EXISTS "version";
+ALTER TABLE "user_admins" DROP COLUMN IF EXISTS "version";
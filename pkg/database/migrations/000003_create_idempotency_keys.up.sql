@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "idempotency_keys" (
+    "idempotency_key" varchar(255),
+    "fingerprint" varchar(64) NOT NULL,
+    "status_code" bigint NOT NULL DEFAULT 0,
+    "content_type" text,
+    "body" bytea,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("idempotency_key")
+);
+
+CREATE INDEX IF NOT EXISTS "idx_idempotency_keys_expires_at" ON "idempotency_keys" ("expires_at");
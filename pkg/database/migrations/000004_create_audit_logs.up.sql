@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS "audit_logs" (
+    "id" bigserial,
+    "sequence" bigint NOT NULL,
+    "actor" varchar(255) NOT NULL,
+    "action" varchar(32) NOT NULL,
+    "target_type" varchar(32) NOT NULL,
+    "target_id" bigint NOT NULL,
+    "diff" text NOT NULL,
+    "request_id" varchar(255),
+    "ip" varchar(64),
+    "created_at" timestamptz NOT NULL,
+    "prev_hash" varchar(64) NOT NULL,
+    "hash" varchar(64) NOT NULL,
+    PRIMARY KEY ("id")
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_audit_logs_sequence" ON "audit_logs" ("sequence");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_actor" ON "audit_logs" ("actor");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_target" ON "audit_logs" ("target_type", "target_id");
+CREATE INDEX IF NOT EXISTS "idx_audit_logs_created_at" ON "audit_logs" ("created_at");
+
+-- the single row with the last record of the audit chain
+CREATE TABLE IF NOT EXISTS "audit_chain_heads" (
+    "id" bigint,
+    "sequence" bigint,
+    "hash" varchar(64),
+    PRIMARY KEY ("id")
+);
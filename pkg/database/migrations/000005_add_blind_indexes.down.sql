@@ -0,0 +1,17 @@
+-- The values stay encrypted: the previous versions can not read the rows
+-- written or backfilled after the up migration
+DROP INDEX IF EXISTS "idx_customers_email_index";
+DROP INDEX IF EXISTS "idx_customers_cpf_index";
+ALTER TABLE "customers" DROP COLUMN IF EXISTS "email_index";
+ALTER TABLE "customers" DROP COLUMN IF EXISTS "cpf_index";
+ALTER TABLE "customers" ADD CONSTRAINT "uni_customers_cpf" UNIQUE ("cpf");
+ALTER TABLE "customers" ADD CONSTRAINT "uni_customers_email" UNIQUE ("email");
+CREATE INDEX IF NOT EXISTS "idx_customers_cpf" ON "customers" ("cpf");
+
+DROP INDEX IF EXISTS "idx_user_admins_email_index";
+DROP INDEX IF EXISTS "idx_user_admins_cpf_index";
+ALTER TABLE "user_admins" DROP COLUMN IF EXISTS "email_index";
+ALTER TABLE "user_admins" DROP COLUMN IF EXISTS "cpf_index";
+ALTER TABLE "user_admins" ADD CONSTRAINT "uni_user_admins_cpf" UNIQUE ("cpf");
+ALTER TABLE "user_admins" ADD CONSTRAINT "uni_user_admins_email" UNIQUE ("email");
+CREATE INDEX IF NOT EXISTS "idx_user_admins_cpf" ON "user_admins" ("cpf");
@@ -0,0 +1,20 @@
+-- The CPF and the email are stored encrypted (see pkg/encryption), so their
+-- unique constraints move to the HMAC blind indexes. The rows written before
+-- this migration are encrypted by the encryption backfill command
+ALTER TABLE "user_admins" ADD COLUMN IF NOT EXISTS "cpf_index" varchar(64);
+ALTER TABLE "user_admins" ADD COLUMN IF NOT EXISTS "email_index" varchar(64);
+ALTER TABLE "user_admins" DROP CONSTRAINT IF EXISTS "uni_user_admins_cpf";
+ALTER TABLE "user_admins" DROP CONSTRAINT IF EXISTS "uni_user_admins_email";
+DROP INDEX IF EXISTS "idx_user_admins_cpf";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_admins_cpf_index" ON "user_admins" ("cpf_index");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_admins_email_index" ON "user_admins" ("email_index");
+
+ALTER TABLE "customers" ADD COLUMN IF NOT EXISTS "cpf_index" varchar(64);
+ALTER TABLE "customers" ADD COLUMN IF NOT EXISTS "email_index" varchar(64);
+ALTER TABLE "customers" DROP CONSTRAINT IF EXISTS "uni_customers_cpf";
+ALTER TABLE "customers" DROP CONSTRAINT IF EXISTS "uni_customers_email";
+DROP INDEX IF EXISTS "idx_customers_cpf";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_customers_cpf_index" ON "customers" ("cpf_index");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_customers_email_index" ON "customers" ("email_index");
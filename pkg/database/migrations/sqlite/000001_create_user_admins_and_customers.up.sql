@@ -0,0 +1,31 @@
+-- SQLite has no constraints to drop, so the unique constraints of the
+-- Postgres migrations are unique indexes with the same names
+CREATE TABLE IF NOT EXISTS "user_admins" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "name" text,
+    "cpf" text,
+    "email" text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_user_admins_cpf" ON "user_admins" ("cpf");
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_user_admins_email" ON "user_admins" ("email");
+CREATE INDEX IF NOT EXISTS "idx_user_admins_cpf" ON "user_admins" ("cpf");
+CREATE INDEX IF NOT EXISTS "idx_user_admins_deleted_at" ON "user_admins" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "customers" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "created_at" datetime,
+    "updated_at" datetime,
+    "deleted_at" datetime,
+    "name" text,
+    "cpf" text,
+    "email" text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_customers_cpf" ON "customers" ("cpf");
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_customers_email" ON "customers" ("email");
+CREATE INDEX IF NOT EXISTS "idx_customers_cpf" ON "customers" ("cpf");
+CREATE INDEX IF NOT EXISTS "idx_customers_deleted_at" ON "customers" ("deleted_at");
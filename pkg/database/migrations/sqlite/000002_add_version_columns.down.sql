@@ -0,0 +1,2 @@
+ALTER TABLE "customers" DROP COLUMN "version";
+ALTER TABLE "user_admins" DROP COLUMN "version";
@@ -0,0 +1,4 @@
+-- The version of each row, incremented by every change, for the optimistic
+-- concurrency of the ETag and If-Match headers
+ALTER TABLE "user_admins" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
+ALTER TABLE "customers" ADD COLUMN "version" integer NOT NULL DEFAULT 1;
@@ -0,0 +1,17 @@
+-- The values stay encrypted: the previous versions can not read the rows
+-- written or backfilled after the up migration
+DROP INDEX IF EXISTS "idx_customers_email_index";
+DROP INDEX IF EXISTS "idx_customers_cpf_index";
+ALTER TABLE "customers" DROP COLUMN "email_index";
+ALTER TABLE "customers" DROP COLUMN "cpf_index";
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_customers_cpf" ON "customers" ("cpf");
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_customers_email" ON "customers" ("email");
+CREATE INDEX IF NOT EXISTS "idx_customers_cpf" ON "customers" ("cpf");
+
+DROP INDEX IF EXISTS "idx_user_admins_email_index";
+DROP INDEX IF EXISTS "idx_user_admins_cpf_index";
+ALTER TABLE "user_admins" DROP COLUMN "email_index";
+ALTER TABLE "user_admins" DROP COLUMN "cpf_index";
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_user_admins_cpf" ON "user_admins" ("cpf");
+CREATE UNIQUE INDEX IF NOT EXISTS "uni_user_admins_email" ON "user_admins" ("email");
+CREATE INDEX IF NOT EXISTS "idx_user_admins_cpf" ON "user_admins" ("cpf");
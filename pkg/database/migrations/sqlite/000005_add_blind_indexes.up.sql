@@ -0,0 +1,19 @@
+-- The CPF and the email are stored encrypted (see pkg/encryption), so their
+-- unique indexes move to the HMAC blind indexes
+ALTER TABLE "user_admins" ADD COLUMN "cpf_index" varchar(64);
+ALTER TABLE "user_admins" ADD COLUMN "email_index" varchar(64);
+DROP INDEX IF EXISTS "uni_user_admins_cpf";
+DROP INDEX IF EXISTS "uni_user_admins_email";
+DROP INDEX IF EXISTS "idx_user_admins_cpf";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_admins_cpf_index" ON "user_admins" ("cpf_index");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_admins_email_index" ON "user_admins" ("email_index");
+
+ALTER TABLE "customers" ADD COLUMN "cpf_index" varchar(64);
+ALTER TABLE "customers" ADD COLUMN "email_index" varchar(64);
+DROP INDEX IF EXISTS "uni_customers_cpf";
+DROP INDEX IF EXISTS "uni_customers_email";
+DROP INDEX IF EXISTS "idx_customers_cpf";
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_customers_cpf_index" ON "customers" ("cpf_index");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_customers_email_index" ON "customers" ("email_index");
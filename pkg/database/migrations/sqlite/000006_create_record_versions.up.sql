@@ -0,0 +1,32 @@
+-- Every version of the customers and the admin users, written in the
+-- transaction of the change. The CPF and the email are copied encrypted, as
+-- they are in the row. The current rows are the first version of the history.
+-- SQLite needs the WHERE to parse the ON CONFLICT of an INSERT ... SELECT
+CREATE TABLE IF NOT EXISTS "record_versions" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "target_type" varchar(32) NOT NULL,
+    "target_id" integer NOT NULL,
+    "version" integer NOT NULL,
+    "action" varchar(32) NOT NULL,
+    "name" text,
+    "cpf" text,
+    "email" text,
+    "cpf_index" varchar(64),
+    "email_index" varchar(64),
+    "deleted" boolean NOT NULL DEFAULT false,
+    "actor" varchar(255) NOT NULL,
+    "request_id" varchar(255),
+    "valid_from" datetime NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_record_versions_target" ON "record_versions" ("target_type", "target_id", "version");
+
+INSERT INTO "record_versions" ("target_type", "target_id", "version", "action", "name", "cpf", "email", "cpf_index", "email_index", "deleted", "actor", "valid_from")
+SELECT 'customer', "id", "version", 'baseline', "name", "cpf", "email", "cpf_index", "email_index", "deleted_at" IS NOT NULL, 'migration', COALESCE("deleted_at", "updated_at", "created_at", CURRENT_TIMESTAMP)
+FROM "customers"
+WHERE true ON CONFLICT DO NOTHING;
+
+INSERT INTO "record_versions" ("target_type", "target_id", "version", "action", "name", "cpf", "email", "cpf_index", "email_index", "deleted", "actor", "valid_from")
+SELECT 'user_admin', "id", "version", 'baseline', "name", "cpf", "email", "cpf_index", "email_index", "deleted_at" IS NOT NULL, 'migration', COALESCE("deleted_at", "updated_at", "created_at", CURRENT_TIMESTAMP)
+FROM "user_admins"
+WHERE true ON CONFLICT DO NOTHING;
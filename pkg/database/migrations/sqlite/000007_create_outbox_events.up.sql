@@ -0,0 +1,21 @@
+-- The domain events, written in the transaction of the change and published
+-- by the outbox relay. The payload is encrypted, as it has the CPF and the
+-- email. The partial index keeps the lookup of the pending events small
+CREATE TABLE IF NOT EXISTS "outbox_events" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "event_id" varchar(36) NOT NULL,
+    "event_type" varchar(64) NOT NULL,
+    "schema_version" integer NOT NULL,
+    "aggregate_key" varchar(255) NOT NULL,
+    "payload" text NOT NULL,
+    "occurred_at" datetime NOT NULL,
+    "published_at" datetime,
+    "attempts" integer NOT NULL DEFAULT 0,
+    "last_error" text
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_outbox_events_event_id" ON "outbox_events" ("event_id");
+
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_pending" ON "outbox_events" ("id") WHERE "published_at" IS NULL;
+
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_published_at" ON "outbox_events" ("published_at");
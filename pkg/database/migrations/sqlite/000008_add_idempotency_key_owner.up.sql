@@ -0,0 +1,4 @@
+-- The token of the request holding the lease of a key. The completion and the
+-- release of a key are conditional on it, so a request whose lease expired
+-- does not change the record of the request that took the key over
+ALTER TABLE "idempotency_keys" ADD COLUMN "owner" varchar(64) NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- The pending events that failed to be published. The relay skips the events
+-- behind them, so it looks them up by key on every batch. SQLite has partial
+-- indexes too, so the index is the same as on Postgres
+CREATE INDEX IF NOT EXISTS "idx_outbox_events_failed" ON "outbox_events" ("aggregate_key", "id") WHERE "published_at" IS NULL AND "attempts" > 0;
@@ -0,0 +1,77 @@
+package database
+
+import (
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type config struct {
+	maxOpenConns         int
+	maxIdleConns         int
+	connMaxLifetime      time.Duration
+	connMaxIdleTime      time.Duration
+	replicas             []gorm.Dialector
+	replicaCheckInterval time.Duration
+	logger               *slog.Logger
+}
+
+// Option -.
+type Option func(*config)
+
+// MaxOpenConns is the maximum number of connections of each pool, the primary
+// and every replica. Zero keeps the database/sql default, unlimited
+func MaxOpenConns(conns int) Option {
+	return func(c *config) {
+		c.maxOpenConns = conns
+	}
+}
+
+// MaxIdleConns is the number of connections kept open while idle. It must not
+// be greater than MaxOpenConns. Zero keeps the database/sql default
+func MaxIdleConns(conns int) Option {
+	return func(c *config) {
+		c.maxIdleConns = conns
+	}
+}
+
+// ConnMaxLifetime closes the connections older than lifetime, so the pool
+// follows a failover or a DNS change of the database host. Zero keeps the
+// connections open forever
+func ConnMaxLifetime(lifetime time.Duration) Option {
+	return func(c *config) {
+		c.connMaxLifetime = lifetime
+	}
+}
+
+// ConnMaxIdleTime closes the connections idle for longer than idleTime. Zero
+// keeps the idle connections open
+func ConnMaxIdleTime(idleTime time.Duration) Option {
+	return func(c *config) {
+		c.connMaxIdleTime = idleTime
+	}
+}
+
+// ReadReplicas adds the replicas used by Reader. A replica that can not be
+// reached at startup is not an error: the reads go to the primary until it is
+// healthy
+func ReadReplicas(dialectors ...gorm.Dialector) Option {
+	return func(c *config) {
+		c.replicas = append(c.replicas, dialectors...)
+	}
+}
+
+// ReplicaCheckInterval is how often MonitorReplicas pings the replicas
+func ReplicaCheckInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.replicaCheckInterval = interval
+	}
+}
+
+// Logger -. The default is the slog default logger
+func Logger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
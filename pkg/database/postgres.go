@@ -0,0 +1,30 @@
+package database
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// PostgresDialector returns the dialector of a Postgres DSN, in the keyword or
+// URL format. A positive statementTimeout is set as the statement_timeout of
+// every connection, so the server cancels the slow queries
+func PostgresDialector(dsn string, statementTimeout time.Duration) (gorm.Dialector, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if statementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(statementTimeout.Milliseconds(), 10)
+	}
+
+	return postgres.New(postgres.Config{
+		Conn: stdlib.OpenDB(*connConfig),
+	}), nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type primaryKey struct{}
+
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// WithPrimary makes Reader return the primary. It is used by the reads that
+// must see a write just made, which may not be in the replicas yet
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimaryRequired tells if the reads of the context must see the latest
+// writes: they were made by WithPrimary or inside a Transaction. The caches are
+// bypassed for these reads
+func IsPrimaryRequired(ctx context.Context) bool {
+	if _, ok := transactionFrom(ctx); ok {
+		return true
+	}
+
+	required, _ := ctx.Value(primaryKey{}).(bool)
+	return required
+}
+
+// PrimaryReads sends the reads of a request to the primary. It is set on the
+// routes that change data, so they read the latest writes. Read-only routes,
+// including read-only POSTs like batch get and login, are not pinned
+func PrimaryReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithPrimary(r.Context())))
+	})
+}
+
+// NoCacheReads sends the reads of a request with the Cache-Control: no-cache
+// header to the primary. A client that just changed a resource uses the header
+// to read it back
+func NoCacheReads(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Cache-Control") == "no-cache" {
+			r = r.WithContext(WithPrimary(r.Context()))
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reader returns the connection for a read. It is a healthy replica, chosen
+// round robin, or the primary when there is no healthy replica or the context
+// requires the primary. Inside a Transaction it is the transaction, so the
+// reads see its writes. Writes always use Conn
+func (db *Database) Reader(ctx context.Context) *gorm.DB {
+	if _, ok := transactionFrom(ctx); ok {
+		return db.Conn(ctx)
+	}
+
+	if !IsPrimaryRequired(ctx) && len(db.replicas) > 0 {
+		start := db.nextReplica.Add(1)
+
+		for i := range uint64(len(db.replicas)) {
+			replica := db.replicas[(start+i)%uint64(len(db.replicas))]
+
+			if replica.healthy.Load() {
+				return replica.db.WithContext(ctx)
+			}
+		}
+	}
+
+	return db.Connection.WithContext(ctx)
+}
+
+// MonitorReplicas pings the replicas every check interval until ctx is done.
+// A replica that fails the ping, or a query with a connection error, stops
+// receiving reads until the next successful ping
+func (db *Database) MonitorReplicas(ctx context.Context) error {
+	if len(db.replicas) == 0 {
+		return nil
+	}
+
+	ticker := time.NewTicker(db.replicaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			db.checkReplicas(ctx)
+		}
+	}
+}
+
+func (db *Database) checkReplicas(ctx context.Context) {
+	for _, replica := range db.replicas {
+		pingCtx, cancel := context.WithTimeout(ctx, db.replicaCheckInterval)
+		err := ping(pingCtx, replica.db)
+		cancel()
+
+		db.setReplicaHealth(replica, err)
+	}
+}
+
+func (db *Database) setReplicaHealth(replica *replica, err error) {
+	healthy := err == nil
+
+	if replica.healthy.Swap(healthy) == healthy {
+		return
+	}
+
+	if healthy {
+		db.logger.Info("read replica is healthy", slog.String("replica", replica.name))
+		return
+	}
+
+	db.logger.Warn("read replica is unhealthy, reading from the primary", slog.String("replica", replica.name), slog.Any("error", err))
+}
+
+// replicaHealthCallback marks the replica unhealthy as soon as a query fails
+// to reach it, instead of waiting for the next ping
+func (db *Database) replicaHealthCallback(replica *replica) func(tx *gorm.DB) {
+	return func(tx *gorm.DB) {
+		if isConnectionError(tx.Error) {
+			db.setReplicaHealth(replica, tx.Error)
+		}
+	}
+}
+
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var connectError *pgconn.ConnectError
+	var netError net.Error
+
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &connectError) || errors.As(err, &netError)
+}
+
+func ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+
+	if err != nil {
+		return err
+	}
+
+	return sqlDB.PingContext(ctx)
+}
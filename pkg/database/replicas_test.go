@@ -0,0 +1,238 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const selectCustomerIDsQuery = `SELECT id FROM "customers"`
+
+func mockDialector(t *testing.T, monitorPings bool) (gorm.Dialector, sqlmock.Sqlmock) {
+	conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(monitorPings))
+	assert.NoError(t, err)
+
+	return postgres.New(postgres.Config{
+		Conn:                 conn,
+		PreferSimpleProtocol: true,
+	}), mock
+}
+
+func selectCustomerIDs(db *gorm.DB) error {
+	var ids []uint
+	return db.Table("customers").Select("id").Find(&ids).Error
+}
+
+func idRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id"}).AddRow(1)
+}
+
+func TestReadReplicas(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got primary when calling Reader without replicas", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+
+		db, err := database.ConfigDatabase(primary, database.MaxOpenConns(5), database.MaxIdleConns(2))
+		assert.NoError(t, err)
+
+		primaryMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+
+		err = selectCustomerIDs(db.Reader(context.Background()))
+
+		assert.NoError(t, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("got replica when calling Reader with healthy replica", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+		replica, replicaMock := mockDialector(t, true)
+
+		replicaMock.ExpectPing()
+		replicaMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+
+		db, err := database.ConfigDatabase(primary, database.ReadReplicas(replica))
+		assert.NoError(t, err)
+
+		err = selectCustomerIDs(db.Reader(context.Background()))
+
+		assert.NoError(t, err)
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("got primary when calling Reader with unreachable replica", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+		replica, replicaMock := mockDialector(t, true)
+
+		replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		primaryMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+
+		db, err := database.ConfigDatabase(primary, database.ReadReplicas(replica))
+		assert.NoError(t, err)
+
+		err = selectCustomerIDs(db.Reader(context.Background()))
+
+		assert.NoError(t, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("got primary when calling Reader with primary required", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+		replica, replicaMock := mockDialector(t, true)
+
+		replicaMock.ExpectPing()
+		primaryMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+
+		db, err := database.ConfigDatabase(primary, database.ReadReplicas(replica))
+		assert.NoError(t, err)
+
+		err = selectCustomerIDs(db.Reader(database.WithPrimary(context.Background())))
+
+		assert.NoError(t, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("got primary after replica connection error when calling Reader", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+		replica, replicaMock := mockDialector(t, true)
+
+		replicaMock.ExpectPing()
+		replicaMock.ExpectQuery(selectCustomerIDsQuery).WillReturnError(&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")})
+		primaryMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+
+		db, err := database.ConfigDatabase(primary, database.ReadReplicas(replica))
+		assert.NoError(t, err)
+
+		err = selectCustomerIDs(db.Reader(context.Background()))
+		assert.Error(t, err)
+
+		err = selectCustomerIDs(db.Reader(context.Background()))
+
+		assert.NoError(t, err)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("got replica back when calling MonitorReplicas after recovery", func(t *testing.T) {
+		t.Parallel()
+
+		primary, _ := mockDialector(t, false)
+		replica, replicaMock := mockDialector(t, true)
+
+		replicaMock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		replicaMock.ExpectPing()
+		replicaMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+
+		db, err := database.ConfigDatabase(
+			primary,
+			database.ReadReplicas(replica),
+			database.ReplicaCheckInterval(10*time.Millisecond),
+		)
+		assert.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Millisecond)
+		defer cancel()
+
+		err = db.MonitorReplicas(ctx)
+		assert.NoError(t, err)
+
+		err = selectCustomerIDs(db.Reader(context.Background()))
+
+		assert.NoError(t, err)
+		assert.NoError(t, replicaMock.ExpectationsWereMet())
+	})
+}
+
+func TestPrimaryReads(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name         string
+		middleware   func(http.Handler) http.Handler
+		method       string
+		cacheControl string
+		primary      bool
+	}{
+		{name: "got primary when calling PrimaryReads with PATCH", middleware: database.PrimaryReads, method: http.MethodPatch, primary: true},
+		{name: "got replica when calling NoCacheReads with GET", middleware: database.NoCacheReads, method: http.MethodGet},
+		{name: "got replica when calling NoCacheReads with read-only POST", middleware: database.NoCacheReads, method: http.MethodPost},
+		{name: "got primary when calling NoCacheReads with GET and no-cache", middleware: database.NoCacheReads, method: http.MethodGet, cacheControl: "no-cache", primary: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+
+			primary, primaryMock := mockDialector(t, false)
+			replica, replicaMock := mockDialector(t, true)
+
+			replicaMock.ExpectPing()
+
+			if c.primary {
+				primaryMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+			} else {
+				replicaMock.ExpectQuery(selectCustomerIDsQuery).WillReturnRows(idRows())
+			}
+
+			db, err := database.ConfigDatabase(primary, database.ReadReplicas(replica))
+			assert.NoError(t, err)
+
+			handler := c.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.NoError(t, selectCustomerIDs(db.Reader(r.Context())))
+			}))
+
+			req := httptest.NewRequest(c.method, "/api/customers/1", nil)
+
+			if c.cacheControl != "" {
+				req.Header.Set("Cache-Control", c.cacheControl)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.NoError(t, primaryMock.ExpectationsWereMet())
+			assert.NoError(t, replicaMock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPostgresDialector(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got dialector when calling PostgresDialector with statement timeout", func(t *testing.T) {
+		t.Parallel()
+
+		dialector, err := database.PostgresDialector("host=localhost user=postgres dbname=customer", 5*time.Second)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "postgres", dialector.Name())
+	})
+
+	t.Run("got error when calling PostgresDialector with invalid DSN", func(t *testing.T) {
+		t.Parallel()
+
+		dialector, err := database.PostgresDialector("host=localhost port=invalid", 5*time.Second)
+
+		assert.Error(t, err)
+		assert.Nil(t, dialector)
+	})
+}
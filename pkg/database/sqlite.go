@@ -0,0 +1,25 @@
+package database
+
+import (
+	"net/url"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// SQLiteDialector returns the dialector of a SQLite database file, for local
+// development and tests without external services. The transactions take the
+// write lock when they begin, since SQLite has no row locks and ignores the
+// FOR UPDATE clauses, and a locked database is waited for instead of failing
+// right away. The path must be a file: every connection of the pool to an in
+// memory database would open a different one
+func SQLiteDialector(path string) gorm.Dialector {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	params.Add("_pragma", "busy_timeout(5000)")
+	params.Add("_pragma", "journal_mode(WAL)")
+	params.Add("_txlock", "immediate")
+	params.Add("_time_format", "sqlite")
+
+	return sqlite.Open("file:" + path + "?" + params.Encode())
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+type transactionKey struct{}
+
+type transaction struct {
+	tx *gorm.DB
+
+	mu          sync.Mutex
+	afterCommit []func()
+}
+
+// Transaction runs fn in a transaction of the primary. The transaction is
+// carried by the context given to fn, so every Conn and Reader of that context
+// uses it. A Transaction inside another one joins the outer transaction, which
+// is committed only when the outermost fn returns nil
+func (db *Database) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := transactionFrom(ctx); ok {
+		return fn(ctx)
+	}
+
+	current := &transaction{}
+
+	err := db.Connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		current.tx = tx
+		return fn(context.WithValue(ctx, transactionKey{}, current))
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, callback := range current.afterCommit {
+		callback()
+	}
+
+	return nil
+}
+
+// AfterCommit runs fn after the transaction of the context is committed, and
+// never when it is rolled back. Without a transaction fn runs right away. It
+// is used for the side effects that must not see uncommitted data, like cache
+// invalidations
+func AfterCommit(ctx context.Context, fn func()) {
+	current, ok := ctx.Value(transactionKey{}).(*transaction)
+
+	if !ok {
+		fn()
+		return
+	}
+
+	current.mu.Lock()
+	defer current.mu.Unlock()
+
+	current.afterCommit = append(current.afterCommit, fn)
+}
+
+// Conn returns the connection for a write: the transaction of the context or
+// the primary. The gorm transactions opened on it join the context
+// transaction, instead of creating save points
+func (db *Database) Conn(ctx context.Context) *gorm.DB {
+	if tx, ok := transactionFrom(ctx); ok {
+		return tx.Session(&gorm.Session{Context: ctx, DisableNestedTransaction: true})
+	}
+
+	return db.Connection.WithContext(ctx)
+}
+
+func transactionFrom(ctx context.Context) (*gorm.DB, bool) {
+	current, ok := ctx.Value(transactionKey{}).(*transaction)
+
+	if !ok {
+		return nil, false
+	}
+
+	return current.tx, true
+}
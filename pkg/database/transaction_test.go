@@ -0,0 +1,75 @@
+package database_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/database"
+)
+
+func TestAfterCommit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got callback run right away when calling AfterCommit without transaction", func(t *testing.T) {
+		t.Parallel()
+
+		called := false
+
+		database.AfterCommit(context.Background(), func() { called = true })
+
+		assert.True(t, called)
+	})
+
+	t.Run("got callback run after commit when calling AfterCommit in transaction", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+
+		db, err := database.ConfigDatabase(primary)
+		assert.NoError(t, err)
+
+		primaryMock.ExpectBegin()
+		primaryMock.ExpectCommit()
+
+		called := false
+
+		err = db.Transaction(context.Background(), func(ctx context.Context) error {
+			assert.True(t, database.IsPrimaryRequired(ctx))
+
+			return db.Transaction(ctx, func(ctx context.Context) error {
+				database.AfterCommit(ctx, func() { called = true })
+				assert.False(t, called)
+
+				return nil
+			})
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, called)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+
+	t.Run("got callback not run when calling AfterCommit in rolled back transaction", func(t *testing.T) {
+		t.Parallel()
+
+		primary, primaryMock := mockDialector(t, false)
+
+		db, err := database.ConfigDatabase(primary)
+		assert.NoError(t, err)
+
+		primaryMock.ExpectBegin()
+		primaryMock.ExpectRollback()
+
+		called := false
+
+		err = db.Transaction(context.Background(), func(ctx context.Context) error {
+			database.AfterCommit(ctx, func() { called = true })
+			return assert.AnError
+		})
+
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.False(t, called)
+		assert.NoError(t, primaryMock.ExpectationsWereMet())
+	})
+}
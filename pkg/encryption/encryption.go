@@ -0,0 +1,313 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// _prefix starts every encrypted value. A value without it was written
+	// before the encryption and is returned as is by Decrypt
+	_prefix = "enc.v1."
+
+	_dataKeySize       = 32
+	_minIndexKeySize   = 32
+	_maxCachedDataKeys = 1024
+
+	_defaultDataKeyTTL = time.Hour
+)
+
+var ErrInvalidCiphertext = errors.New("invalid encrypted value")
+
+// DataKey is a key generated by a KeyProvider to encrypt the values. Only the
+// encrypted form is stored, next to each value, and KeyID is the master key
+// that encrypted it
+type DataKey struct {
+	KeyID     string
+	Plaintext []byte
+	Encrypted []byte
+}
+
+// KeyProvider keeps the master keys, which never leave it, and encrypts the
+// data keys with them. It has the shape of a KMS, so the local key file used
+// in development and AWS KMS are interchangeable
+type KeyProvider interface {
+	// ActiveKeyID is the master key of the new data keys. The values of the
+	// other keys are re-encrypted by the backfill
+	ActiveKeyID() string
+	GenerateDataKey(ctx context.Context) (DataKey, error)
+	DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error)
+}
+
+type dataKey struct {
+	keyID     string
+	encrypted string
+	aead      cipher.AEAD
+	expiresAt time.Time
+}
+
+// Encryptor encrypts the personal data columns with envelope encryption: the
+// values are encrypted with AES-256-GCM by a data key, which is stored
+// encrypted by the master key next to them. A data key is reused for the
+// encryptions of DataKeyTTL, and the decrypted ones are cached, so the key
+// provider is not called for every value
+type Encryptor struct {
+	provider   KeyProvider
+	indexKey   []byte
+	dataKeyTTL time.Duration
+
+	mu        sync.Mutex
+	current   *dataKey
+	decrypted map[string]cipher.AEAD
+}
+
+// Option -.
+type Option func(*Encryptor)
+
+// DataKeyTTL is how long a data key encrypts new values before a new one is
+// generated
+func DataKeyTTL(ttl time.Duration) Option {
+	return func(e *Encryptor) {
+		e.dataKeyTTL = ttl
+	}
+}
+
+// New creates the Encryptor. indexKey is the HMAC key of the blind indexes and
+// must have at least 32 bytes. Unlike the master keys it can not be rotated
+// without recomputing every index
+func New(provider KeyProvider, indexKey []byte, opts ...Option) (*Encryptor, error) {
+	if len(indexKey) < _minIndexKeySize {
+		return nil, fmt.Errorf("the blind index key must have at least %v bytes", _minIndexKeySize)
+	}
+
+	encryptor := &Encryptor{
+		provider:   provider,
+		indexKey:   indexKey,
+		dataKeyTTL: _defaultDataKeyTTL,
+		decrypted:  make(map[string]cipher.AEAD),
+	}
+
+	for _, opt := range opts {
+		opt(encryptor)
+	}
+
+	return encryptor, nil
+}
+
+// Encrypt returns the encrypted value, with the master key ID and the
+// encrypted data key. An empty value stays empty
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	key, err := e.currentKey(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, key.aead.NonceSize())
+
+	_, err = rand.Read(nonce)
+
+	if err != nil {
+		return "", err
+	}
+
+	sealed := key.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+
+	return _prefix + strings.Join([]string{
+		encode([]byte(key.keyID)),
+		key.encrypted,
+		encode(sealed),
+	}, "."), nil
+}
+
+// Decrypt returns the plaintext of an encrypted value. A value that is not
+// encrypted, written before the encryption and not backfilled yet, is
+// returned as is
+func (e *Encryptor) Decrypt(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	keyID, encryptedKey, sealed, err := parse(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	aead, err := e.decryptedKey(ctx, keyID, encryptedKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrInvalidCiphertext
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+
+	if err != nil {
+		return "", ErrInvalidCiphertext
+	}
+
+	return string(plaintext), nil
+}
+
+// BlindIndex returns the HMAC-SHA256 of the value. It is deterministic, so
+// it is used in the lookups and in the unique constraints instead of the
+// encrypted value
+func (e *Encryptor) BlindIndex(value string) string {
+	mac := hmac.New(sha256.New, e.indexKey)
+	mac.Write([]byte(value))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NeedsEncryption tells if the backfill must encrypt the value again: it is
+// not encrypted or its master key is not the active one
+func (e *Encryptor) NeedsEncryption(value string) bool {
+	if value == "" {
+		return false
+	}
+
+	if !IsEncrypted(value) {
+		return true
+	}
+
+	keyID, _, _, err := parse(value)
+
+	return err != nil || keyID != e.provider.ActiveKeyID()
+}
+
+// IsEncrypted tells if the value was written by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, _prefix)
+}
+
+func (e *Encryptor) currentKey(ctx context.Context) (*dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.current != nil && time.Now().Before(e.current.expiresAt) && e.current.keyID == e.provider.ActiveKeyID() {
+		return e.current, nil
+	}
+
+	generated, err := e.provider.GenerateDataKey(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("generating data key: %w", err)
+	}
+
+	aead, err := newAEAD(generated.Plaintext)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.current = &dataKey{
+		keyID:     generated.KeyID,
+		encrypted: encode(generated.Encrypted),
+		aead:      aead,
+		expiresAt: time.Now().Add(e.dataKeyTTL),
+	}
+
+	return e.current, nil
+}
+
+func (e *Encryptor) decryptedKey(ctx context.Context, keyID string, encryptedKey string) (cipher.AEAD, error) {
+	e.mu.Lock()
+	aead, ok := e.decrypted[encryptedKey]
+	e.mu.Unlock()
+
+	if ok {
+		return aead, nil
+	}
+
+	encrypted, err := decode(encryptedKey)
+
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := e.provider.DecryptDataKey(ctx, keyID, encrypted)
+
+	if err != nil {
+		return nil, fmt.Errorf("decrypting data key: %w", err)
+	}
+
+	aead, err = newAEAD(plaintext)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.decrypted) >= _maxCachedDataKeys {
+		e.decrypted = make(map[string]cipher.AEAD)
+	}
+
+	e.decrypted[encryptedKey] = aead
+
+	return aead, nil
+}
+
+func parse(value string) (keyID string, encryptedKey string, sealed []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(value, _prefix), ".")
+
+	if len(parts) != 3 {
+		return "", "", nil, ErrInvalidCiphertext
+	}
+
+	decodedKeyID, err := decode(parts[0])
+
+	if err != nil {
+		return "", "", nil, ErrInvalidCiphertext
+	}
+
+	sealed, err = decode(parts[2])
+
+	if err != nil {
+		return "", "", nil, ErrInvalidCiphertext
+	}
+
+	return string(decodedKeyID), parts[1], sealed, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != _dataKeySize {
+		return nil, fmt.Errorf("the key must have %v bytes", _dataKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encode(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+func decode(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(value)
+}
@@ -0,0 +1,325 @@
+package encryption_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/encryption"
+)
+
+var (
+	indexKey = bytes.Repeat([]byte("i"), 32)
+	oldKey   = bytes.Repeat([]byte("o"), 32)
+	newKey   = bytes.Repeat([]byte("n"), 32)
+)
+
+type countingProvider struct {
+	encryption.KeyProvider
+	generated int
+}
+
+func (provider *countingProvider) GenerateDataKey(ctx context.Context) (encryption.DataKey, error) {
+	provider.generated++
+	return provider.KeyProvider.GenerateDataKey(ctx)
+}
+
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	keys map[string][]byte
+}
+
+func (client *fakeKMS) GenerateDataKeyWithContext(ctx aws.Context, input *kms.GenerateDataKeyInput, opts ...request.Option) (*kms.GenerateDataKeyOutput, error) {
+	plaintext := bytes.Repeat([]byte("d"), 32)
+	client.keys["blob-"+*input.KeyId] = plaintext
+
+	return &kms.GenerateDataKeyOutput{
+		KeyId:          input.KeyId,
+		Plaintext:      plaintext,
+		CiphertextBlob: []byte("blob-" + *input.KeyId),
+	}, nil
+}
+
+func (client *fakeKMS) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	return &kms.DecryptOutput{
+		KeyId:     input.KeyId,
+		Plaintext: client.keys[string(input.CiphertextBlob)],
+	}, nil
+}
+
+func newLocalProvider(t *testing.T, activeKeyID string) *encryption.LocalKeyProvider {
+	provider, err := encryption.NewLocalKeyProviderFromKeys(activeKeyID, map[string][]byte{
+		"old": oldKey,
+		"new": newKey,
+	})
+	assert.NoError(t, err)
+
+	return provider
+}
+
+func newEncryptor(t *testing.T, provider encryption.KeyProvider) *encryption.Encryptor {
+	encryptor, err := encryption.New(provider, indexKey)
+	assert.NoError(t, err)
+
+	return encryptor
+}
+
+func TestEncryptor(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got plaintext back when calling Decrypt with Encrypt value", func(t *testing.T) {
+		t.Parallel()
+
+		encryptor := newEncryptor(t, newLocalProvider(t, "new"))
+
+		encrypted, err := encryptor.Encrypt(context.Background(), "12345678909")
+		assert.NoError(t, err)
+
+		assert.True(t, encryption.IsEncrypted(encrypted))
+		assert.NotContains(t, encrypted, "12345678909")
+
+		decrypted, err := encryptor.Decrypt(context.Background(), encrypted)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "12345678909", decrypted)
+	})
+
+	t.Run("got different values when calling Encrypt twice", func(t *testing.T) {
+		t.Parallel()
+
+		encryptor := newEncryptor(t, newLocalProvider(t, "new"))
+
+		first, err := encryptor.Encrypt(context.Background(), "teste@teste.com")
+		assert.NoError(t, err)
+
+		second, err := encryptor.Encrypt(context.Background(), "teste@teste.com")
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("got empty value when calling Encrypt with empty value", func(t *testing.T) {
+		t.Parallel()
+
+		encryptor := newEncryptor(t, newLocalProvider(t, "new"))
+
+		encrypted, err := encryptor.Encrypt(context.Background(), "")
+
+		assert.NoError(t, err)
+		assert.Empty(t, encrypted)
+	})
+
+	t.Run("got value as is when calling Decrypt with value not encrypted", func(t *testing.T) {
+		t.Parallel()
+
+		encryptor := newEncryptor(t, newLocalProvider(t, "new"))
+
+		decrypted, err := encryptor.Decrypt(context.Background(), "12345678909")
+
+		assert.NoError(t, err)
+		assert.Equal(t, "12345678909", decrypted)
+	})
+
+	t.Run("got error when calling Decrypt with changed value", func(t *testing.T) {
+		t.Parallel()
+
+		encryptor := newEncryptor(t, newLocalProvider(t, "new"))
+
+		encrypted, err := encryptor.Encrypt(context.Background(), "12345678909")
+		assert.NoError(t, err)
+
+		// changes a character of the sealed value, after the nonce
+		position := strings.LastIndex(encrypted, ".") + 20
+		replacement := "A"
+
+		if encrypted[position] == 'A' {
+			replacement = "B"
+		}
+
+		tampered := encrypted[:position] + replacement + encrypted[position+1:]
+
+		_, err = encryptor.Decrypt(context.Background(), tampered)
+
+		assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+	})
+
+	t.Run("got error when calling Decrypt with unknown master key", func(t *testing.T) {
+		t.Parallel()
+
+		encrypted, err := newEncryptor(t, newLocalProvider(t, "new")).Encrypt(context.Background(), "12345678909")
+		assert.NoError(t, err)
+
+		otherProvider, err := encryption.NewLocalKeyProviderFromKeys("other", map[string][]byte{"other": oldKey})
+		assert.NoError(t, err)
+
+		_, err = newEncryptor(t, otherProvider).Decrypt(context.Background(), encrypted)
+
+		assert.ErrorContains(t, err, `unknown master key "new"`)
+	})
+
+	t.Run("got same index when calling BlindIndex with same value", func(t *testing.T) {
+		t.Parallel()
+
+		encryptor := newEncryptor(t, newLocalProvider(t, "new"))
+
+		index := encryptor.BlindIndex("12345678909")
+
+		assert.Len(t, index, 64)
+		assert.Equal(t, index, encryptor.BlindIndex("12345678909"))
+		assert.NotEqual(t, index, encryptor.BlindIndex("12345678900"))
+	})
+
+	t.Run("got old values decrypted and marked for encryption when rotating master key", func(t *testing.T) {
+		t.Parallel()
+
+		encrypted, err := newEncryptor(t, newLocalProvider(t, "old")).Encrypt(context.Background(), "12345678909")
+		assert.NoError(t, err)
+
+		rotated := newEncryptor(t, newLocalProvider(t, "new"))
+
+		decrypted, err := rotated.Decrypt(context.Background(), encrypted)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "12345678909", decrypted)
+		assert.True(t, rotated.NeedsEncryption(encrypted))
+		assert.True(t, rotated.NeedsEncryption("12345678909"))
+		assert.False(t, rotated.NeedsEncryption(""))
+
+		reencrypted, err := rotated.Encrypt(context.Background(), decrypted)
+
+		assert.NoError(t, err)
+		assert.False(t, rotated.NeedsEncryption(reencrypted))
+	})
+
+	t.Run("got data key reused when calling Encrypt within data key TTL", func(t *testing.T) {
+		t.Parallel()
+
+		provider := &countingProvider{KeyProvider: newLocalProvider(t, "new")}
+		encryptor := newEncryptor(t, provider)
+
+		for range 3 {
+			_, err := encryptor.Encrypt(context.Background(), "12345678909")
+			assert.NoError(t, err)
+		}
+
+		assert.Equal(t, 1, provider.generated)
+	})
+
+	t.Run("got new data key when calling Encrypt after data key TTL", func(t *testing.T) {
+		t.Parallel()
+
+		provider := &countingProvider{KeyProvider: newLocalProvider(t, "new")}
+		encryptor, err := encryption.New(provider, indexKey, encryption.DataKeyTTL(time.Nanosecond))
+		assert.NoError(t, err)
+
+		for range 2 {
+			_, err := encryptor.Encrypt(context.Background(), "12345678909")
+			assert.NoError(t, err)
+			time.Sleep(time.Millisecond)
+		}
+
+		assert.Equal(t, 2, provider.generated)
+	})
+
+	t.Run("got error when calling New with short index key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := encryption.New(newLocalProvider(t, "new"), []byte("short"))
+
+		assert.Error(t, err)
+	})
+}
+
+func TestLocalKeyProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got provider when calling NewLocalKeyProvider with key file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "keys.json")
+		err := os.WriteFile(path, []byte(`{
+			"activeKey": "2026-10",
+			"keys": {
+				"2026-10": "bm5ubm5ubm5ubm5ubm5ubm5ubm5ubm5ubm5ubm5ubm4=",
+				"2025-01": "b29vb29vb29vb29vb29vb29vb29vb29vb29vb29vb28="
+			}
+		}`), 0o600)
+		assert.NoError(t, err)
+
+		provider, err := encryption.NewLocalKeyProvider(path)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "2026-10", provider.ActiveKeyID())
+
+		dataKey, err := provider.GenerateDataKey(context.Background())
+		assert.NoError(t, err)
+
+		plaintext, err := provider.DecryptDataKey(context.Background(), dataKey.KeyID, dataKey.Encrypted)
+
+		assert.NoError(t, err)
+		assert.Equal(t, dataKey.Plaintext, plaintext)
+	})
+
+	t.Run("got error when calling NewLocalKeyProvider without active key", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "keys.json")
+		err := os.WriteFile(path, []byte(`{"activeKey": "missing", "keys": {}}`), 0o600)
+		assert.NoError(t, err)
+
+		_, err = encryption.NewLocalKeyProvider(path)
+
+		assert.ErrorContains(t, err, `"missing"`)
+	})
+
+	t.Run("got error when calling NewLocalKeyProviderFromKeys with short key", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := encryption.NewLocalKeyProviderFromKeys("short", map[string][]byte{"short": []byte("short")})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("got error when calling DecryptDataKey with data key of other master key", func(t *testing.T) {
+		t.Parallel()
+
+		provider := newLocalProvider(t, "new")
+
+		dataKey, err := provider.GenerateDataKey(context.Background())
+		assert.NoError(t, err)
+
+		_, err = provider.DecryptDataKey(context.Background(), "old", dataKey.Encrypted)
+
+		assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+	})
+}
+
+func TestKMSKeyProvider(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got plaintext back when calling Decrypt with KMS data key", func(t *testing.T) {
+		t.Parallel()
+
+		provider := encryption.NewKMSKeyProvider(&fakeKMS{keys: map[string][]byte{}}, "alias/customer-data")
+		encryptor := newEncryptor(t, provider)
+
+		encrypted, err := encryptor.Encrypt(context.Background(), "teste@teste.com")
+		assert.NoError(t, err)
+
+		decrypted, err := encryptor.Decrypt(context.Background(), encrypted)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "teste@teste.com", decrypted)
+		assert.False(t, encryptor.NeedsEncryption(encrypted))
+		assert.False(t, strings.Contains(encrypted, "alias/customer-data"))
+	})
+}
@@ -0,0 +1,58 @@
+package encryption
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+)
+
+// KMSKeyProvider generates and decrypts the data keys with an AWS KMS key.
+// keyID is the ID, the ARN or the alias of the key. Changing it rotates the
+// master key; the automatic rotation of KMS keys needs no backfill
+type KMSKeyProvider struct {
+	client kmsiface.KMSAPI
+	keyID  string
+}
+
+func NewKMSKeyProvider(client kmsiface.KMSAPI, keyID string) *KMSKeyProvider {
+	return &KMSKeyProvider{
+		client: client,
+		keyID:  keyID,
+	}
+}
+
+func (provider *KMSKeyProvider) ActiveKeyID() string {
+	return provider.keyID
+}
+
+func (provider *KMSKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	output, err := provider.client.GenerateDataKeyWithContext(ctx, &kms.GenerateDataKeyInput{
+		KeyId:   aws.String(provider.keyID),
+		KeySpec: aws.String(kms.DataKeySpecAes256),
+	})
+
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{
+		KeyID:     provider.keyID,
+		Plaintext: output.Plaintext,
+		Encrypted: output.CiphertextBlob,
+	}, nil
+}
+
+func (provider *KMSKeyProvider) DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	output, err := provider.client.DecryptWithContext(ctx, &kms.DecryptInput{
+		KeyId:          aws.String(keyID),
+		CiphertextBlob: encrypted,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Plaintext, nil
+}
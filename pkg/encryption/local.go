@@ -0,0 +1,134 @@
+package encryption
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// localKeyFile is the key file of the LocalKeyProvider, with the base64 of the
+// 32 bytes master keys by ID:
+//
+//	{"activeKey": "2026-10", "keys": {"2026-10": "...", "2025-01": "..."}}
+//
+// A key is rotated by adding a new one, making it the active one and running
+// the backfill. The old key can be removed only after the backfill
+type localKeyFile struct {
+	ActiveKey string            `json:"activeKey"`
+	Keys      map[string]string `json:"keys"`
+}
+
+// LocalKeyProvider keeps the master keys in memory, loaded from a key file.
+// It is meant for development, production uses the KMSKeyProvider
+type LocalKeyProvider struct {
+	activeKeyID string
+	keys        map[string]cipher.AEAD
+}
+
+// NewLocalKeyProvider loads the master keys of the key file
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	content, err := os.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("reading key file: %w", err)
+	}
+
+	var keyFile localKeyFile
+
+	err = json.Unmarshal(content, &keyFile)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid key file %v: %w", path, err)
+	}
+
+	keys := make(map[string][]byte, len(keyFile.Keys))
+
+	for keyID, encodedKey := range keyFile.Keys {
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %v in key file: %w", keyID, err)
+		}
+
+		keys[keyID] = key
+	}
+
+	return NewLocalKeyProviderFromKeys(keyFile.ActiveKey, keys)
+}
+
+// NewLocalKeyProviderFromKeys creates the provider with the master keys by ID
+func NewLocalKeyProviderFromKeys(activeKeyID string, keys map[string][]byte) (*LocalKeyProvider, error) {
+	provider := &LocalKeyProvider{
+		activeKeyID: activeKeyID,
+		keys:        make(map[string]cipher.AEAD, len(keys)),
+	}
+
+	for keyID, key := range keys {
+		aead, err := newAEAD(key)
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid master key %v: %w", keyID, err)
+		}
+
+		provider.keys[keyID] = aead
+	}
+
+	if _, ok := provider.keys[activeKeyID]; !ok {
+		return nil, fmt.Errorf("the active master key %q is not in the keys", activeKeyID)
+	}
+
+	return provider, nil
+}
+
+func (provider *LocalKeyProvider) ActiveKeyID() string {
+	return provider.activeKeyID
+}
+
+func (provider *LocalKeyProvider) GenerateDataKey(ctx context.Context) (DataKey, error) {
+	plaintext := make([]byte, _dataKeySize)
+
+	_, err := rand.Read(plaintext)
+
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	masterKey := provider.keys[provider.activeKeyID]
+	nonce := make([]byte, masterKey.NonceSize())
+
+	_, err = rand.Read(nonce)
+
+	if err != nil {
+		return DataKey{}, err
+	}
+
+	return DataKey{
+		KeyID:     provider.activeKeyID,
+		Plaintext: plaintext,
+		Encrypted: masterKey.Seal(nonce, nonce, plaintext, []byte(provider.activeKeyID)),
+	}, nil
+}
+
+func (provider *LocalKeyProvider) DecryptDataKey(ctx context.Context, keyID string, encrypted []byte) ([]byte, error) {
+	masterKey, ok := provider.keys[keyID]
+
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", keyID)
+	}
+
+	if len(encrypted) < masterKey.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+
+	plaintext, err := masterKey.Open(nil, encrypted[:masterKey.NonceSize()], encrypted[masterKey.NonceSize():], []byte(keyID))
+
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+
+	return plaintext, nil
+}
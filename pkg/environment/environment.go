@@ -4,13 +4,16 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 var (
-	RedocFolderPath *string = flag.String("PATH_REDOC_FOLDER", "/docs/swagger.json", "Swagger docs folder")
+	RedocFolderPath *string = flag.String("PATH_REDOC_FOLDER", "/docs/v1/v1_swagger.json", "Swagger docs folder")
 
 	localDev = flag.String("localDev", "false", "local development")
 
@@ -31,6 +34,110 @@ const (
 	CognitoGroupAdmin             = "AWS_COGNITO_GROUP_ADMIN"
 	CognitoUserPoolID             = "AWS_COGNITO_USER_POOL_ID"
 	Region                        = "AWS_REGION"
+
+	HTTPHost            = "HTTP_HOST"
+	HTTPPort            = "HTTP_PORT"
+	DocsPort            = "DOCS_PORT"
+	HTTPReadTimeout     = "HTTP_READ_TIMEOUT"
+	HTTPWriteTimeout    = "HTTP_WRITE_TIMEOUT"
+	HTTPIdleTimeout     = "HTTP_IDLE_TIMEOUT"
+	HTTPShutdownTimeout = "HTTP_SHUTDOWN_TIMEOUT"
+	ShutdownTimeout     = "SHUTDOWN_TIMEOUT"
+	ShutdownDrainDelay  = "SHUTDOWN_DRAIN_DELAY"
+	HTTPMaxHeaderBytes  = "HTTP_MAX_HEADER_BYTES"
+	HTTPTLSCertFile     = "HTTP_TLS_CERT_FILE"
+	HTTPTLSKeyFile      = "HTTP_TLS_KEY_FILE"
+	HTTPH2C             = "HTTP_H2C_ENABLED"
+	SwaggerDocURL       = "SWAGGER_DOC_URL"
+	HealthCheckTimeout  = "HEALTH_CHECK_TIMEOUT"
+	HealthCacheTTL      = "HEALTH_CACHE_TTL"
+	MetricsEnabled      = "METRICS_ENABLED"
+	MetricsPort         = "METRICS_PORT"
+	TracingExporter     = "TRACING_EXPORTER"
+	TracingSampleRatio  = "TRACING_SAMPLE_RATIO"
+	ServiceName         = "OTEL_SERVICE_NAME"
+	LogLevel            = "LOG_LEVEL"
+	LogFormat           = "LOG_FORMAT"
+	LegacyRoutesEnabled = "LEGACY_ROUTES_ENABLED"
+	LegacyDeprecatedAt  = "LEGACY_ROUTES_DEPRECATED_AT"
+	LegacySunsetAt      = "LEGACY_ROUTES_SUNSET_AT"
+	IfMatchRequired     = "IF_MATCH_REQUIRED"
+	IdempotencyTTL      = "IDEMPOTENCY_TTL"
+	IdempotencyLease    = "IDEMPOTENCY_LEASE"
+	IdempotencyWait     = "IDEMPOTENCY_WAIT_TIMEOUT"
+	GRPCPort            = "GRPC_PORT"
+	GRPCReflection      = "GRPC_REFLECTION_ENABLED"
+	BatchGetMaxItems    = "BATCH_GET_MAX_ITEMS"
+	DBDriver            = "DB_DRIVER"
+	DBSQLitePath        = "DB_SQLITE_PATH"
+	DBMaxOpenConns      = "DB_MAX_OPEN_CONNS"
+	DBMaxIdleConns      = "DB_MAX_IDLE_CONNS"
+	DBConnMaxLifetime   = "DB_CONN_MAX_LIFETIME"
+	DBConnMaxIdleTime   = "DB_CONN_MAX_IDLE_TIME"
+	DBStatementTimeout  = "DB_STATEMENT_TIMEOUT"
+	DBReadReplicaDSNs   = "DB_READ_REPLICA_DSNS"
+	DBReplicaCheck      = "DB_REPLICA_CHECK_INTERVAL"
+	EncryptionProvider  = "ENCRYPTION_KEY_PROVIDER"
+	EncryptionKeyFile   = "ENCRYPTION_KEY_FILE"
+	EncryptionKMSKeyID  = "ENCRYPTION_KMS_KEY_ID"
+	EncryptionIndexKey  = "ENCRYPTION_INDEX_KEY"
+	CustomerCacheSize   = "CUSTOMER_CACHE_SIZE"
+	CustomerCacheTTL    = "CUSTOMER_CACHE_TTL"
+	CustomerCacheMiss   = "CUSTOMER_CACHE_NOT_FOUND_TTL"
+	OutboxPublisher     = "OUTBOX_PUBLISHER"
+	OutboxFilePath      = "OUTBOX_FILE_PATH"
+	OutboxSNSTopicARN   = "OUTBOX_SNS_TOPIC_ARN"
+	OutboxBatchSize     = "OUTBOX_BATCH_SIZE"
+	OutboxPollInterval  = "OUTBOX_POLL_INTERVAL"
+	OutboxRetention     = "OUTBOX_RETENTION"
+)
+
+const (
+	defaultHTTPPort            = "3210"
+	defaultDocsPort            = "3211"
+	defaultHTTPReadTimeout     = "10s"
+	defaultHTTPWriteTimeout    = "10s"
+	defaultHTTPIdleTimeout     = "60s"
+	defaultHTTPShutdownTimeout = "6s"
+	defaultShutdownTimeout     = "15s"
+	defaultShutdownDrainDelay  = "5s"
+	defaultHTTPMaxHeaderBytes  = "1048576"
+	defaultSwaggerDocURL       = "/swagger/v1/doc.json"
+	defaultHealthCheckTimeout  = "2s"
+	defaultHealthCacheTTL      = "5s"
+	defaultMetricsEnabled      = "true"
+	defaultTracingExporter     = "none"
+	defaultTracingSampleRatio  = "1"
+	defaultServiceName         = "tech1-customer"
+	defaultLogLevel            = "info"
+	defaultLogFormat           = "json"
+	defaultLegacyRoutesEnabled = "true"
+	defaultLegacyDeprecatedAt  = "2026-10-19"
+	defaultLegacySunsetAt      = "2027-04-30"
+	defaultIfMatchRequired     = "false"
+	defaultIdempotencyTTL      = "24h"
+	defaultIdempotencyLease    = "30s"
+	defaultIdempotencyWait     = "10s"
+	defaultGRPCPort            = "3212"
+	defaultGRPCReflection      = "true"
+	defaultBatchGetMaxItems    = "100"
+	defaultDBDriver            = "postgres"
+	defaultDBSQLitePath        = "tech1-customer.db"
+	defaultDBMaxOpenConns      = "25"
+	defaultDBMaxIdleConns      = "10"
+	defaultDBConnMaxLifetime   = "30m"
+	defaultDBConnMaxIdleTime   = "5m"
+	defaultDBStatementTimeout  = "5s"
+	defaultDBReplicaCheck      = "5s"
+	defaultEncryptionProvider  = "local"
+	defaultCustomerCacheSize   = "10000"
+	defaultCustomerCacheTTL    = "5m"
+	defaultCustomerCacheMiss   = "30s"
+	defaultOutboxPublisher     = "none"
+	defaultOutboxFilePath      = "outbox-events.jsonl"
+	defaultOutboxBatchSize     = "100"
+	defaultOutboxPollInterval  = "1s"
+	defaultOutboxRetention     = "168h"
 )
 
 type Environment struct {
@@ -47,6 +154,61 @@ type Environment struct {
 	cognitoGroupAdmin             string
 	cognitoUserPoolID             string
 	region                        string
+	httpHost                      string
+	httpPort                      string
+	docsPort                      string
+	httpReadTimeout               time.Duration
+	httpWriteTimeout              time.Duration
+	httpIdleTimeout               time.Duration
+	httpShutdownTimeout           time.Duration
+	shutdownTimeout               time.Duration
+	shutdownDrainDelay            time.Duration
+	httpMaxHeaderBytes            int
+	httpTLSCertFile               string
+	httpTLSKeyFile                string
+	httpH2C                       bool
+	swaggerDocURL                 string
+	healthCheckTimeout            time.Duration
+	healthCacheTTL                time.Duration
+	metricsEnabled                bool
+	metricsPort                   string
+	tracingExporter               string
+	tracingSampleRatio            float64
+	serviceName                   string
+	logLevel                      string
+	logFormat                     string
+	legacyRoutesEnabled           bool
+	legacyDeprecatedAt            time.Time
+	legacySunsetAt                time.Time
+	ifMatchRequired               bool
+	idempotencyTTL                time.Duration
+	idempotencyLease              time.Duration
+	idempotencyWaitTimeout        time.Duration
+	grpcPort                      string
+	grpcReflection                bool
+	batchGetMaxItems              int
+	dbDriver                      string
+	dbSQLitePath                  string
+	dbMaxOpenConns                int
+	dbMaxIdleConns                int
+	dbConnMaxLifetime             time.Duration
+	dbConnMaxIdleTime             time.Duration
+	dbStatementTimeout            time.Duration
+	dbReadReplicaDSNs             []string
+	dbReplicaCheckInterval        time.Duration
+	encryptionProvider            string
+	encryptionKeyFile             string
+	encryptionKMSKeyID            string
+	encryptionIndexKey            string
+	customerCacheSize             int
+	customerCacheTTL              time.Duration
+	customerCacheNotFoundTTL      time.Duration
+	outboxPublisher               string
+	outboxFilePath                string
+	outboxSNSTopicARN             string
+	outboxBatchSize               int
+	outboxPollInterval            time.Duration
+	outboxRetention               time.Duration
 }
 
 func LoadEnvironmentVariables() {
@@ -73,6 +235,61 @@ func LoadEnvironmentVariables() {
 	cognitoGroupAdmin := getEnvironmentVariable(CognitoGroupAdmin)
 	cognitoUserPoolID := getEnvironmentVariable(CognitoUserPoolID)
 	region := getEnvironmentVariable(Region)
+	httpHost := getOptionalEnvironmentVariable(HTTPHost, "")
+	httpPort := getOptionalEnvironmentVariable(HTTPPort, defaultHTTPPort)
+	docsPort := getOptionalEnvironmentVariable(DocsPort, defaultDocsPort)
+	httpReadTimeout := getDurationEnvironmentVariable(HTTPReadTimeout, defaultHTTPReadTimeout)
+	httpWriteTimeout := getDurationEnvironmentVariable(HTTPWriteTimeout, defaultHTTPWriteTimeout)
+	httpIdleTimeout := getDurationEnvironmentVariable(HTTPIdleTimeout, defaultHTTPIdleTimeout)
+	httpShutdownTimeout := getDurationEnvironmentVariable(HTTPShutdownTimeout, defaultHTTPShutdownTimeout)
+	shutdownTimeout := getDurationEnvironmentVariable(ShutdownTimeout, defaultShutdownTimeout)
+	shutdownDrainDelay := getDurationEnvironmentVariable(ShutdownDrainDelay, defaultShutdownDrainDelay)
+	httpMaxHeaderBytes := getIntEnvironmentVariable(HTTPMaxHeaderBytes, defaultHTTPMaxHeaderBytes)
+	httpTLSCertFile := getOptionalEnvironmentVariable(HTTPTLSCertFile, "")
+	httpTLSKeyFile := getOptionalEnvironmentVariable(HTTPTLSKeyFile, "")
+	httpH2C := getBoolEnvironmentVariable(HTTPH2C, "false")
+	swaggerDocURL := getOptionalEnvironmentVariable(SwaggerDocURL, defaultSwaggerDocURL)
+	healthCheckTimeout := getDurationEnvironmentVariable(HealthCheckTimeout, defaultHealthCheckTimeout)
+	healthCacheTTL := getDurationEnvironmentVariable(HealthCacheTTL, defaultHealthCacheTTL)
+	metricsEnabled := getBoolEnvironmentVariable(MetricsEnabled, defaultMetricsEnabled)
+	metricsPort := getOptionalEnvironmentVariable(MetricsPort, "")
+	tracingExporter := getOptionalEnvironmentVariable(TracingExporter, defaultTracingExporter)
+	tracingSampleRatio := getFloatEnvironmentVariable(TracingSampleRatio, defaultTracingSampleRatio)
+	serviceName := getOptionalEnvironmentVariable(ServiceName, defaultServiceName)
+	logLevel := getOptionalEnvironmentVariable(LogLevel, defaultLogLevel)
+	logFormat := getOptionalEnvironmentVariable(LogFormat, defaultLogFormat)
+	legacyRoutesEnabled := getBoolEnvironmentVariable(LegacyRoutesEnabled, defaultLegacyRoutesEnabled)
+	legacyDeprecatedAt := getDateEnvironmentVariable(LegacyDeprecatedAt, defaultLegacyDeprecatedAt)
+	legacySunsetAt := getDateEnvironmentVariable(LegacySunsetAt, defaultLegacySunsetAt)
+	ifMatchRequired := getBoolEnvironmentVariable(IfMatchRequired, defaultIfMatchRequired)
+	idempotencyTTL := getDurationEnvironmentVariable(IdempotencyTTL, defaultIdempotencyTTL)
+	idempotencyLease := getDurationEnvironmentVariable(IdempotencyLease, defaultIdempotencyLease)
+	idempotencyWaitTimeout := getDurationEnvironmentVariable(IdempotencyWait, defaultIdempotencyWait)
+	grpcPort := getOptionalEnvironmentVariable(GRPCPort, defaultGRPCPort)
+	grpcReflection := getBoolEnvironmentVariable(GRPCReflection, defaultGRPCReflection)
+	batchGetMaxItems := getIntEnvironmentVariable(BatchGetMaxItems, defaultBatchGetMaxItems)
+	dbDriver := getOptionalEnvironmentVariable(DBDriver, defaultDBDriver)
+	dbSQLitePath := getOptionalEnvironmentVariable(DBSQLitePath, defaultDBSQLitePath)
+	dbMaxOpenConns := getIntEnvironmentVariable(DBMaxOpenConns, defaultDBMaxOpenConns)
+	dbMaxIdleConns := getIntEnvironmentVariable(DBMaxIdleConns, defaultDBMaxIdleConns)
+	dbConnMaxLifetime := getDurationEnvironmentVariable(DBConnMaxLifetime, defaultDBConnMaxLifetime)
+	dbConnMaxIdleTime := getDurationEnvironmentVariable(DBConnMaxIdleTime, defaultDBConnMaxIdleTime)
+	dbStatementTimeout := getDurationEnvironmentVariable(DBStatementTimeout, defaultDBStatementTimeout)
+	dbReadReplicaDSNs := getListEnvironmentVariable(DBReadReplicaDSNs)
+	dbReplicaCheckInterval := getDurationEnvironmentVariable(DBReplicaCheck, defaultDBReplicaCheck)
+	encryptionProvider := getOptionalEnvironmentVariable(EncryptionProvider, defaultEncryptionProvider)
+	encryptionKeyFile := getOptionalEnvironmentVariable(EncryptionKeyFile, "")
+	encryptionKMSKeyID := getOptionalEnvironmentVariable(EncryptionKMSKeyID, "")
+	encryptionIndexKey := getOptionalEnvironmentVariable(EncryptionIndexKey, "")
+	customerCacheSize := getIntEnvironmentVariable(CustomerCacheSize, defaultCustomerCacheSize)
+	customerCacheTTL := getDurationEnvironmentVariable(CustomerCacheTTL, defaultCustomerCacheTTL)
+	customerCacheNotFoundTTL := getDurationEnvironmentVariable(CustomerCacheMiss, defaultCustomerCacheMiss)
+	outboxPublisher := getOptionalEnvironmentVariable(OutboxPublisher, defaultOutboxPublisher)
+	outboxFilePath := getOptionalEnvironmentVariable(OutboxFilePath, defaultOutboxFilePath)
+	outboxSNSTopicARN := getOptionalEnvironmentVariable(OutboxSNSTopicARN, "")
+	outboxBatchSize := getIntEnvironmentVariable(OutboxBatchSize, defaultOutboxBatchSize)
+	outboxPollInterval := getDurationEnvironmentVariable(OutboxPollInterval, defaultOutboxPollInterval)
+	outboxRetention := getDurationEnvironmentVariable(OutboxRetention, defaultOutboxRetention)
 
 	once := &sync.Once{}
 
@@ -91,6 +308,61 @@ func LoadEnvironmentVariables() {
 			cognitoGroupAdmin:             cognitoGroupAdmin,
 			cognitoUserPoolID:             cognitoUserPoolID,
 			region:                        region,
+			httpHost:                      httpHost,
+			httpPort:                      httpPort,
+			docsPort:                      docsPort,
+			httpReadTimeout:               httpReadTimeout,
+			httpWriteTimeout:              httpWriteTimeout,
+			httpIdleTimeout:               httpIdleTimeout,
+			httpShutdownTimeout:           httpShutdownTimeout,
+			shutdownTimeout:               shutdownTimeout,
+			shutdownDrainDelay:            shutdownDrainDelay,
+			httpMaxHeaderBytes:            httpMaxHeaderBytes,
+			httpTLSCertFile:               httpTLSCertFile,
+			httpTLSKeyFile:                httpTLSKeyFile,
+			httpH2C:                       httpH2C,
+			swaggerDocURL:                 swaggerDocURL,
+			healthCheckTimeout:            healthCheckTimeout,
+			healthCacheTTL:                healthCacheTTL,
+			metricsEnabled:                metricsEnabled,
+			metricsPort:                   metricsPort,
+			tracingExporter:               tracingExporter,
+			tracingSampleRatio:            tracingSampleRatio,
+			serviceName:                   serviceName,
+			logLevel:                      logLevel,
+			logFormat:                     logFormat,
+			legacyRoutesEnabled:           legacyRoutesEnabled,
+			legacyDeprecatedAt:            legacyDeprecatedAt,
+			legacySunsetAt:                legacySunsetAt,
+			ifMatchRequired:               ifMatchRequired,
+			idempotencyTTL:                idempotencyTTL,
+			idempotencyLease:              idempotencyLease,
+			idempotencyWaitTimeout:        idempotencyWaitTimeout,
+			grpcPort:                      grpcPort,
+			grpcReflection:                grpcReflection,
+			batchGetMaxItems:              batchGetMaxItems,
+			dbDriver:                      dbDriver,
+			dbSQLitePath:                  dbSQLitePath,
+			dbMaxOpenConns:                dbMaxOpenConns,
+			dbMaxIdleConns:                dbMaxIdleConns,
+			dbConnMaxLifetime:             dbConnMaxLifetime,
+			dbConnMaxIdleTime:             dbConnMaxIdleTime,
+			dbStatementTimeout:            dbStatementTimeout,
+			dbReadReplicaDSNs:             dbReadReplicaDSNs,
+			dbReplicaCheckInterval:        dbReplicaCheckInterval,
+			encryptionProvider:            encryptionProvider,
+			encryptionKeyFile:             encryptionKeyFile,
+			encryptionKMSKeyID:            encryptionKMSKeyID,
+			encryptionIndexKey:            encryptionIndexKey,
+			customerCacheSize:             customerCacheSize,
+			customerCacheTTL:              customerCacheTTL,
+			customerCacheNotFoundTTL:      customerCacheNotFoundTTL,
+			outboxPublisher:               outboxPublisher,
+			outboxFilePath:                outboxFilePath,
+			outboxSNSTopicARN:             outboxSNSTopicARN,
+			outboxBatchSize:               outboxBatchSize,
+			outboxPollInterval:            outboxPollInterval,
+			outboxRetention:               outboxRetention,
 		}
 	})
 }
@@ -105,6 +377,85 @@ func getEnvironmentVariable(key string) string {
 	return value
 }
 
+func getOptionalEnvironmentVariable(key string, defaultValue string) string {
+	value, hashKey := os.LookupEnv(key)
+
+	if !hashKey || value == "" {
+		return defaultValue
+	}
+
+	return value
+}
+
+func getDurationEnvironmentVariable(key string, defaultValue string) time.Duration {
+	value := getOptionalEnvironmentVariable(key, defaultValue)
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		log.Fatalf("Invalid %v environment variable %q: must be a duration like 10s or 500ms", key, value)
+	}
+
+	return duration
+}
+
+func getIntEnvironmentVariable(key string, defaultValue string) int {
+	value := getOptionalEnvironmentVariable(key, defaultValue)
+	number, err := strconv.Atoi(value)
+
+	if err != nil {
+		log.Fatalf("Invalid %v environment variable %q: must be an integer", key, value)
+	}
+
+	return number
+}
+
+func getFloatEnvironmentVariable(key string, defaultValue string) float64 {
+	value := getOptionalEnvironmentVariable(key, defaultValue)
+	number, err := strconv.ParseFloat(value, 64)
+
+	if err != nil {
+		log.Fatalf("Invalid %v environment variable %q: must be a number", key, value)
+	}
+
+	return number
+}
+
+func getDateEnvironmentVariable(key string, defaultValue string) time.Time {
+	value := getOptionalEnvironmentVariable(key, defaultValue)
+	date, err := time.Parse(time.DateOnly, value)
+
+	if err != nil {
+		log.Fatalf("Invalid %v environment variable %q: must be a date like 2006-01-02", key, value)
+	}
+
+	return date
+}
+
+// getListEnvironmentVariable returns the comma separated values, without the
+// empty ones
+func getListEnvironmentVariable(key string) []string {
+	var values []string
+
+	for _, value := range strings.Split(getOptionalEnvironmentVariable(key, ""), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func getBoolEnvironmentVariable(key string, defaultValue string) bool {
+	value := getOptionalEnvironmentVariable(key, defaultValue)
+	boolean, err := strconv.ParseBool(value)
+
+	if err != nil {
+		log.Fatalf("Invalid %v environment variable %q: must be true or false", key, value)
+	}
+
+	return boolean
+}
+
 func GetWebhookMercadoLivrePaymentURL() string {
 	return singleton.webhookMercadoLivrePaymentURL
 }
@@ -156,3 +507,261 @@ func GetCognitoUserPoolID() string {
 func GetRegion() string {
 	return singleton.region
 }
+
+func GetHTTPHost() string {
+	return singleton.httpHost
+}
+
+func GetHTTPPort() string {
+	return singleton.httpPort
+}
+
+func GetDocsPort() string {
+	return singleton.docsPort
+}
+
+func GetHTTPReadTimeout() time.Duration {
+	return singleton.httpReadTimeout
+}
+
+func GetHTTPWriteTimeout() time.Duration {
+	return singleton.httpWriteTimeout
+}
+
+func GetHTTPIdleTimeout() time.Duration {
+	return singleton.httpIdleTimeout
+}
+
+func GetHTTPShutdownTimeout() time.Duration {
+	return singleton.httpShutdownTimeout
+}
+
+// GetShutdownTimeout is the time to drain every listener and background job
+// and close the database. It must be longer than HTTP_SHUTDOWN_TIMEOUT
+func GetShutdownTimeout() time.Duration {
+	return singleton.shutdownTimeout
+}
+
+// GetShutdownDrainDelay is how long the readiness probe fails before the
+// listeners stop, so the pod is removed from the endpoints first. It is part
+// of SHUTDOWN_TIMEOUT
+func GetShutdownDrainDelay() time.Duration {
+	return singleton.shutdownDrainDelay
+}
+
+func GetHTTPMaxHeaderBytes() int {
+	return singleton.httpMaxHeaderBytes
+}
+
+func GetHTTPTLSCertFile() string {
+	return singleton.httpTLSCertFile
+}
+
+func GetHTTPTLSKeyFile() string {
+	return singleton.httpTLSKeyFile
+}
+
+func IsHTTPH2CEnabled() bool {
+	return singleton.httpH2C
+}
+
+func GetSwaggerDocURL() string {
+	return singleton.swaggerDocURL
+}
+
+func GetHealthCheckTimeout() time.Duration {
+	return singleton.healthCheckTimeout
+}
+
+func GetHealthCacheTTL() time.Duration {
+	return singleton.healthCacheTTL
+}
+
+func IsMetricsEnabled() bool {
+	return singleton.metricsEnabled
+}
+
+// GetMetricsPort returns the port of the metrics listener. When it is empty
+// the metrics are served by the API listener
+func GetMetricsPort() string {
+	return singleton.metricsPort
+}
+
+// GetTracingExporter returns none, stdout or otlp
+func GetTracingExporter() string {
+	return singleton.tracingExporter
+}
+
+func GetTracingSampleRatio() float64 {
+	return singleton.tracingSampleRatio
+}
+
+func GetServiceName() string {
+	return singleton.serviceName
+}
+
+// GetLogLevel returns debug, info, warn or error
+func GetLogLevel() string {
+	return singleton.logLevel
+}
+
+// GetLogFormat returns json or text
+func GetLogFormat() string {
+	return singleton.logFormat
+}
+
+// IsLegacyRoutesEnabled tells if the unversioned routes are still served as
+// aliases of the v1 routes
+func IsLegacyRoutesEnabled() bool {
+	return singleton.legacyRoutesEnabled
+}
+
+func GetLegacyDeprecatedAt() time.Time {
+	return singleton.legacyDeprecatedAt
+}
+
+func GetLegacySunsetAt() time.Time {
+	return singleton.legacySunsetAt
+}
+
+// IsIfMatchRequired tells if PUT and PATCH requests without the If-Match header
+// are rejected with 428 Precondition Required
+func IsIfMatchRequired() bool {
+	return singleton.ifMatchRequired
+}
+
+// GetIdempotencyTTL is how long the response of a sign up with an
+// Idempotency-Key header is replayed for the same key
+func GetIdempotencyTTL() time.Duration {
+	return singleton.idempotencyTTL
+}
+
+func GetIdempotencyLease() time.Duration {
+	return singleton.idempotencyLease
+}
+
+func GetIdempotencyWaitTimeout() time.Duration {
+	return singleton.idempotencyWaitTimeout
+}
+
+func GetGRPCPort() string {
+	return singleton.grpcPort
+}
+
+func IsGRPCReflectionEnabled() bool {
+	return singleton.grpcReflection
+}
+
+// GetBatchGetMaxItems is the maximum number of ids plus CPFs of a batch
+// customer lookup
+func GetBatchGetMaxItems() int {
+	return singleton.batchGetMaxItems
+}
+
+// GetDBDriver returns postgres or sqlite. SQLite is meant for local development
+// and tests, without external services
+func GetDBDriver() string {
+	return singleton.dbDriver
+}
+
+// GetDBSQLitePath is the database file of the sqlite driver
+func GetDBSQLitePath() string {
+	return singleton.dbSQLitePath
+}
+
+func GetDBMaxOpenConns() int {
+	return singleton.dbMaxOpenConns
+}
+
+func GetDBMaxIdleConns() int {
+	return singleton.dbMaxIdleConns
+}
+
+func GetDBConnMaxLifetime() time.Duration {
+	return singleton.dbConnMaxLifetime
+}
+
+func GetDBConnMaxIdleTime() time.Duration {
+	return singleton.dbConnMaxIdleTime
+}
+
+// GetDBStatementTimeout is the Postgres statement_timeout of every connection.
+// Zero disables it
+func GetDBStatementTimeout() time.Duration {
+	return singleton.dbStatementTimeout
+}
+
+// GetDBReadReplicaDSNs returns the DSNs of the read replicas. When it is empty
+// every query goes to the primary
+func GetDBReadReplicaDSNs() []string {
+	return singleton.dbReadReplicaDSNs
+}
+
+func GetDBReplicaCheckInterval() time.Duration {
+	return singleton.dbReplicaCheckInterval
+}
+
+// GetEncryptionProvider returns local, for the key file, or kms
+func GetEncryptionProvider() string {
+	return singleton.encryptionProvider
+}
+
+// GetEncryptionKeyFile is the master keys file of the local provider
+func GetEncryptionKeyFile() string {
+	return singleton.encryptionKeyFile
+}
+
+// GetEncryptionKMSKeyID is the ID, ARN or alias of the KMS master key
+func GetEncryptionKMSKeyID() string {
+	return singleton.encryptionKMSKeyID
+}
+
+// GetEncryptionIndexKey returns the base64 of the blind index HMAC key
+func GetEncryptionIndexKey() string {
+	return singleton.encryptionIndexKey
+}
+
+// GetCustomerCacheSize is the maximum number of customer lookups kept in
+// memory. Zero disables the cache
+func GetCustomerCacheSize() int {
+	return singleton.customerCacheSize
+}
+
+func GetCustomerCacheTTL() time.Duration {
+	return singleton.customerCacheTTL
+}
+
+// GetCustomerCacheNotFoundTTL is how long a CPF without customer is cached
+func GetCustomerCacheNotFoundTTL() time.Duration {
+	return singleton.customerCacheNotFoundTTL
+}
+
+// GetOutboxPublisher is where the relay publishes the domain events: none, file
+// or sns. With none the events are kept in the outbox
+func GetOutboxPublisher() string {
+	return singleton.outboxPublisher
+}
+
+// GetOutboxFilePath is the JSON lines file of the file publisher
+func GetOutboxFilePath() string {
+	return singleton.outboxFilePath
+}
+
+// GetOutboxSNSTopicARN is the topic of the sns publisher. A FIFO topic keeps
+// the events of each customer in order
+func GetOutboxSNSTopicARN() string {
+	return singleton.outboxSNSTopicARN
+}
+
+func GetOutboxBatchSize() int {
+	return singleton.outboxBatchSize
+}
+
+func GetOutboxPollInterval() time.Duration {
+	return singleton.outboxPollInterval
+}
+
+// GetOutboxRetention is how long the published events are kept in the outbox
+func GetOutboxRetention() time.Duration {
+	return singleton.outboxRetention
+}
@@ -3,6 +3,7 @@ package environment_test
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thiagoluis88git/tech1-customer/pkg/environment"
@@ -22,6 +23,7 @@ func setup() {
 	os.Setenv(environment.QRCodeGatewayToken, "QRCodeGatewayToken")
 	os.Setenv(environment.Region, "Region")
 	os.Setenv(environment.WebhookMercadoLivrePaymentURL, "WebhookMercadoLivrePaymentURL")
+	os.Setenv(environment.EncryptionIndexKey, "EncryptionIndexKey")
 }
 
 func TestEnvironment(t *testing.T) {
@@ -48,5 +50,118 @@ func TestEnvironment(t *testing.T) {
 		assert.Equal(t, "QRCodeGatewayToken", environment.GetQRCodeGatewayToken())
 		assert.Equal(t, "Region", environment.GetRegion())
 		assert.Equal(t, "WebhookMercadoLivrePaymentURL", environment.GetWebhookMercadoLivrePaymentURL())
+		assert.Equal(t, "EncryptionIndexKey", environment.GetEncryptionIndexKey())
+	})
+
+	t.Run("got default http values when optional variables are not set", func(t *testing.T) {
+		environment.LoadEnvironmentVariables()
+
+		assert.Equal(t, "", environment.GetHTTPHost())
+		assert.Equal(t, "3210", environment.GetHTTPPort())
+		assert.Equal(t, "3211", environment.GetDocsPort())
+		assert.Equal(t, 10*time.Second, environment.GetHTTPReadTimeout())
+		assert.Equal(t, 10*time.Second, environment.GetHTTPWriteTimeout())
+		assert.Equal(t, 60*time.Second, environment.GetHTTPIdleTimeout())
+		assert.Equal(t, 6*time.Second, environment.GetHTTPShutdownTimeout())
+		assert.Equal(t, 1048576, environment.GetHTTPMaxHeaderBytes())
+		assert.Equal(t, "", environment.GetHTTPTLSCertFile())
+		assert.Equal(t, "", environment.GetHTTPTLSKeyFile())
+		assert.False(t, environment.IsHTTPH2CEnabled())
+		assert.Equal(t, "/swagger/v1/doc.json", environment.GetSwaggerDocURL())
+		assert.Equal(t, 2*time.Second, environment.GetHealthCheckTimeout())
+		assert.Equal(t, 5*time.Second, environment.GetHealthCacheTTL())
+		assert.True(t, environment.IsMetricsEnabled())
+		assert.Empty(t, environment.GetMetricsPort())
+		assert.Equal(t, "none", environment.GetTracingExporter())
+		assert.Equal(t, 1.0, environment.GetTracingSampleRatio())
+		assert.Equal(t, "tech1-customer", environment.GetServiceName())
+		assert.Equal(t, "info", environment.GetLogLevel())
+		assert.Equal(t, "json", environment.GetLogFormat())
+		assert.True(t, environment.IsLegacyRoutesEnabled())
+		assert.Equal(t, time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC), environment.GetLegacyDeprecatedAt())
+		assert.Equal(t, time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC), environment.GetLegacySunsetAt())
+		assert.False(t, environment.IsIfMatchRequired())
+		assert.Equal(t, 24*time.Hour, environment.GetIdempotencyTTL())
+		assert.Equal(t, 30*time.Second, environment.GetIdempotencyLease())
+		assert.Equal(t, 10*time.Second, environment.GetIdempotencyWaitTimeout())
+		assert.Equal(t, "3212", environment.GetGRPCPort())
+		assert.True(t, environment.IsGRPCReflectionEnabled())
+		assert.Equal(t, 100, environment.GetBatchGetMaxItems())
+		assert.Equal(t, 15*time.Second, environment.GetShutdownTimeout())
+		assert.Equal(t, 5*time.Second, environment.GetShutdownDrainDelay())
+		assert.Equal(t, "postgres", environment.GetDBDriver())
+		assert.Equal(t, "tech1-customer.db", environment.GetDBSQLitePath())
+		assert.Equal(t, 25, environment.GetDBMaxOpenConns())
+		assert.Equal(t, 10, environment.GetDBMaxIdleConns())
+		assert.Equal(t, 30*time.Minute, environment.GetDBConnMaxLifetime())
+		assert.Equal(t, 5*time.Minute, environment.GetDBConnMaxIdleTime())
+		assert.Equal(t, 5*time.Second, environment.GetDBStatementTimeout())
+		assert.Empty(t, environment.GetDBReadReplicaDSNs())
+		assert.Equal(t, 5*time.Second, environment.GetDBReplicaCheckInterval())
+		assert.Equal(t, "local", environment.GetEncryptionProvider())
+		assert.Equal(t, "", environment.GetEncryptionKeyFile())
+		assert.Equal(t, "", environment.GetEncryptionKMSKeyID())
+		assert.Equal(t, 10000, environment.GetCustomerCacheSize())
+		assert.Equal(t, 5*time.Minute, environment.GetCustomerCacheTTL())
+		assert.Equal(t, 30*time.Second, environment.GetCustomerCacheNotFoundTTL())
+		assert.Equal(t, "none", environment.GetOutboxPublisher())
+		assert.Equal(t, "outbox-events.jsonl", environment.GetOutboxFilePath())
+		assert.Equal(t, "", environment.GetOutboxSNSTopicARN())
+		assert.Equal(t, 100, environment.GetOutboxBatchSize())
+		assert.Equal(t, time.Second, environment.GetOutboxPollInterval())
+		assert.Equal(t, 168*time.Hour, environment.GetOutboxRetention())
+	})
+
+	t.Run("got configured http values when optional variables are set", func(t *testing.T) {
+		os.Setenv(environment.HTTPHost, "0.0.0.0")
+		os.Setenv(environment.HTTPPort, "8080")
+		os.Setenv(environment.DocsPort, "8081")
+		os.Setenv(environment.HTTPReadTimeout, "3s")
+		os.Setenv(environment.HTTPIdleTimeout, "2m")
+		os.Setenv(environment.HTTPMaxHeaderBytes, "4096")
+		os.Setenv(environment.HTTPH2C, "true")
+		os.Setenv(environment.SwaggerDocURL, "https://api.fastfood.com/swagger/doc.json")
+
+		defer func() {
+			os.Unsetenv(environment.HTTPHost)
+			os.Unsetenv(environment.HTTPPort)
+			os.Unsetenv(environment.DocsPort)
+			os.Unsetenv(environment.HTTPReadTimeout)
+			os.Unsetenv(environment.HTTPIdleTimeout)
+			os.Unsetenv(environment.HTTPMaxHeaderBytes)
+			os.Unsetenv(environment.HTTPH2C)
+			os.Unsetenv(environment.SwaggerDocURL)
+		}()
+
+		environment.LoadEnvironmentVariables()
+
+		assert.Equal(t, "0.0.0.0", environment.GetHTTPHost())
+		assert.Equal(t, "8080", environment.GetHTTPPort())
+		assert.Equal(t, "8081", environment.GetDocsPort())
+		assert.Equal(t, 3*time.Second, environment.GetHTTPReadTimeout())
+		assert.Equal(t, 2*time.Minute, environment.GetHTTPIdleTimeout())
+		assert.Equal(t, 4096, environment.GetHTTPMaxHeaderBytes())
+		assert.True(t, environment.IsHTTPH2CEnabled())
+		assert.Equal(t, "https://api.fastfood.com/swagger/doc.json", environment.GetSwaggerDocURL())
+	})
+
+	t.Run("got empty encryption index key when it is not set", func(t *testing.T) {
+		os.Unsetenv(environment.EncryptionIndexKey)
+
+		defer os.Setenv(environment.EncryptionIndexKey, "EncryptionIndexKey")
+
+		environment.LoadEnvironmentVariables()
+
+		assert.Equal(t, "", environment.GetEncryptionIndexKey())
+	})
+
+	t.Run("got replica DSNs when read replica variable is set", func(t *testing.T) {
+		os.Setenv(environment.DBReadReplicaDSNs, "host=replica-1 dbname=fastfood, ,host=replica-2 dbname=fastfood")
+
+		defer os.Unsetenv(environment.DBReadReplicaDSNs)
+
+		environment.LoadEnvironmentVariables()
+
+		assert.Equal(t, []string{"host=replica-1 dbname=fastfood", "host=replica-2 dbname=fastfood"}, environment.GetDBReadReplicaDSNs())
 	})
 }
@@ -0,0 +1,166 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	otelCodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadata is the metadata key with the request id of the caller. It
+// is the gRPC version of the X-Request-Id header
+const RequestIDMetadata = "x-request-id"
+
+// recoveryInterceptor turns a panic of a handler into an Internal status, like
+// the chi Recoverer does for the HTTP handlers
+func recoveryInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.ErrorContext(ctx, "gRPC handler panic",
+					slog.String("method", info.FullMethod),
+					slog.String("panic", fmt.Sprint(recovered)),
+					slog.String("stack", string(debug.Stack())),
+				)
+
+				err = status.Error(codes.Internal, "An unexpected error happened. Try again later")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// tracingInterceptor continues the trace of the incoming traceparent metadata,
+// or starts a new one, and creates the server span of the RPC
+func tracingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, method := splitMethod(info.FullMethod)
+
+	ctx, span := tracing.Tracer().Start(ctx, service+"/"+method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemGRPC,
+			semconv.RPCService(service),
+			semconv.RPCMethod(method),
+		),
+	)
+	defer span.End()
+
+	resp, err = handler(ctx, req)
+
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+
+	if isServerError(code) {
+		span.SetStatus(otelCodes.Error, code.String())
+	}
+
+	return resp, err
+}
+
+// loggingInterceptor puts the logger and the request id in the context and
+// logs one line when the RPC is completed
+func loggingInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		start := time.Now()
+
+		requestID := ""
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(RequestIDMetadata)) > 0 {
+			requestID = md.Get(RequestIDMetadata)[0]
+		}
+
+		if requestID == "" {
+			requestID = fmt.Sprintf("grpc-%06d", chiMiddleware.NextRequestID())
+		}
+
+		ctx = context.WithValue(ctx, chiMiddleware.RequestIDKey, requestID)
+		ctx = logger.WithContext(ctx, log)
+
+		resp, err = handler(ctx, req)
+
+		log.InfoContext(ctx, "rpc completed",
+			slog.String("method", info.FullMethod),
+			slog.String("code", status.Code(err).String()),
+			slog.Duration("duration", time.Since(start)),
+		)
+
+		return resp, err
+	}
+}
+
+func metricsInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	start := time.Now()
+
+	resp, err = handler(ctx, req)
+
+	metrics.ObserveGRPC(info.FullMethod, time.Since(start), status.Code(err).String())
+
+	return resp, err
+}
+
+// isServerError tells if the code is a failure of this service, the same as a
+// 5xx HTTP status
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss, codes.Unimplemented, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// splitMethod splits /package.Service/Method into the service and the method
+func splitMethod(fullMethod string) (string, string) {
+	for i := len(fullMethod) - 1; i > 0; i-- {
+		if fullMethod[i] == '/' {
+			return fullMethod[1:i], fullMethod[i+1:]
+		}
+	}
+
+	return fullMethod, ""
+}
+
+// metadataCarrier adapts the gRPC metadata to the OpenTelemetry propagators
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
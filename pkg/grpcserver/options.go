@@ -0,0 +1,40 @@
+package grpcserver
+
+import (
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Option -.
+type Option func(*Server)
+
+// Address sets the bind host and port. An empty host binds all interfaces
+func Address(host, port string) Option {
+	return func(s *Server) {
+		s.addr = net.JoinHostPort(host, port)
+	}
+}
+
+// ShutdownTimeout is how long the in flight RPCs have to finish before the
+// connections are closed
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// Reflection enables the server reflection service, used by grpcurl and other
+// tools to discover the services without the proto files
+func Reflection(enabled bool) Option {
+	return func(s *Server) {
+		s.reflection = enabled
+	}
+}
+
+// Logger -.
+func Logger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
@@ -0,0 +1,151 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+const (
+	_defaultShutdownTimeout = 6 * time.Second
+	_defaultPort            = "3212"
+)
+
+// Server is the gRPC server of the internal API. It serves the standard
+// grpc.health.v1 service and, when enabled, the server reflection
+type Server struct {
+	server          *grpc.Server
+	health          *health.Server
+	addr            string
+	notify          chan error
+	shutdownTimeout time.Duration
+	reflection      bool
+	logger          *slog.Logger
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{
+		health:          health.NewServer(),
+		addr:            net.JoinHostPort("", _defaultPort),
+		notify:          make(chan error, 1),
+		shutdownTimeout: _defaultShutdownTimeout,
+		reflection:      true,
+		logger:          slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			recoveryInterceptor(s.logger),
+			tracingInterceptor,
+			loggingInterceptor(s.logger),
+			metricsInterceptor,
+		),
+	)
+
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	if s.reflection {
+		reflection.Register(s.server)
+	}
+
+	return s
+}
+
+// RegisterService registers a service implementation and marks it as serving
+// in the health service
+func (s *Server) RegisterService(desc *grpc.ServiceDesc, impl any) {
+	s.server.RegisterService(desc, impl)
+	s.health.SetServingStatus(desc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+}
+
+// Validate checks the server configuration. It must be called before Start
+func (s *Server) Validate() error {
+	var errs []error
+
+	_, port, err := net.SplitHostPort(s.addr)
+
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid address %q: %w", s.addr, err))
+	} else if portNumber, err := strconv.Atoi(port); err != nil || portNumber < 1 || portNumber > 65535 {
+		errs = append(errs, fmt.Errorf("invalid port %q: must be a number between 1 and 65535", port))
+	}
+
+	if s.shutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be greater than zero, got %v", s.shutdownTimeout))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Addr -.
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Start listens and serves in background. The serve error is sent to Notify
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+
+	if err != nil {
+		return err
+	}
+
+	s.Serve(listener)
+
+	return nil
+}
+
+// Serve serves in background on an existing listener
+func (s *Server) Serve(listener net.Listener) {
+	s.logger.Info("gRPC server has started", slog.String("addr", listener.Addr().String()))
+
+	go func() {
+		s.notify <- s.server.Serve(listener)
+		close(s.notify)
+	}()
+}
+
+// Notify -.
+func (s *Server) Notify() <-chan error {
+	return s.notify
+}
+
+// Shutdown marks every service as not serving, so the clients that watch the
+// health service move to another instance, and waits the in flight RPCs until
+// ctx is done or the shutdown timeout has passed before closing the connections
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.logger.Warn("gRPC graceful stop timed out, closing the connections")
+		s.server.Stop()
+
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package grpcserver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/grpcserver"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got no error when calling Validate with default configuration", func(t *testing.T) {
+		t.Parallel()
+
+		server := grpcserver.New()
+
+		assert.NoError(t, server.Validate())
+		assert.Equal(t, ":3212", server.Addr())
+	})
+
+	t.Run("got errors when calling Validate with invalid configuration", func(t *testing.T) {
+		t.Parallel()
+
+		server := grpcserver.New(
+			grpcserver.Address("", "99999"),
+			grpcserver.ShutdownTimeout(0),
+			grpcserver.Reflection(false),
+		)
+
+		err := server.Validate()
+
+		assert.ErrorContains(t, err, "invalid port")
+		assert.ErrorContains(t, err, "shutdown timeout")
+	})
+
+	t.Run("got error when calling Start with address in use", func(t *testing.T) {
+		t.Parallel()
+
+		first := grpcserver.New(grpcserver.Address("127.0.0.1", "3299"))
+		assert.NoError(t, first.Start())
+		defer first.Shutdown(context.Background())
+
+		second := grpcserver.New(grpcserver.Address("127.0.0.1", "3299"))
+
+		assert.Error(t, second.Start())
+	})
+}
@@ -0,0 +1,106 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo sent in the details of
+// every error status
+const ErrorDomain = "tech1-customer"
+
+// Status converts err into a gRPC status error. It is the gRPC version of
+// httpserver.SendResponseError: the message is the client safe detail of the
+// error catalog and the ErrorInfo reason is the catalog code, so the clients
+// can branch on the same codes of the REST API
+func Status(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	problem := responses.NewProblemDetails(err, "", "")
+	st := status.New(CodeForHTTPStatus(problem.Status, problem.Code), problem.Detail)
+
+	withDetails, detailsErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: string(problem.Code),
+		Domain: ErrorDomain,
+	})
+
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	if len(problem.Errors) > 0 {
+		badRequest := &errdetails.BadRequest{}
+
+		for _, fieldError := range problem.Errors {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldError.Pointer,
+				Description: fieldError.Message,
+			})
+		}
+
+		if st, err := withDetails.WithDetails(badRequest); err == nil {
+			withDetails = st
+		}
+	}
+
+	return withDetails.Err()
+}
+
+// CodeForHTTPStatus maps the HTTP status of a BusinessResponse to the gRPC code
+// with the same meaning
+func CodeForHTTPStatus(statusCode int, code responses.ErrorCode) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		switch code {
+		case responses.CodeIdempotencyInFlight, responses.CodeTransactionConflict:
+			return codes.Aborted
+		case responses.CodeReferenceConflict:
+			return codes.FailedPrecondition
+		}
+
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		// the version check failed because of a concurrent update, so the
+		// client must read the resource again and retry
+		return codes.Aborted
+	case http.StatusUnprocessableEntity, http.StatusPreconditionRequired:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
@@ -0,0 +1,108 @@
+package grpcserver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/grpcserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestStatus(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got gRPC codes when calling CodeForHTTPStatus", func(t *testing.T) {
+		t.Parallel()
+
+		cases := map[int]codes.Code{
+			http.StatusBadRequest:            codes.InvalidArgument,
+			http.StatusUnauthorized:          codes.Unauthenticated,
+			http.StatusForbidden:             codes.PermissionDenied,
+			http.StatusNotFound:              codes.NotFound,
+			http.StatusConflict:              codes.AlreadyExists,
+			http.StatusPreconditionFailed:    codes.Aborted,
+			http.StatusUnprocessableEntity:   codes.FailedPrecondition,
+			http.StatusTooManyRequests:       codes.ResourceExhausted,
+			http.StatusInternalServerError:   codes.Internal,
+			http.StatusServiceUnavailable:    codes.Unavailable,
+			http.StatusGatewayTimeout:        codes.DeadlineExceeded,
+			http.StatusRequestEntityTooLarge: codes.ResourceExhausted,
+		}
+
+		for statusCode, code := range cases {
+			assert.Equal(t, code, grpcserver.CodeForHTTPStatus(statusCode, ""), fmt.Sprintf("status %v", statusCode))
+		}
+
+		assert.Equal(t, codes.Aborted, grpcserver.CodeForHTTPStatus(http.StatusConflict, responses.CodeIdempotencyInFlight))
+		assert.Equal(t, codes.Aborted, grpcserver.CodeForHTTPStatus(http.StatusConflict, responses.CodeTransactionConflict))
+		assert.Equal(t, codes.FailedPrecondition, grpcserver.CodeForHTTPStatus(http.StatusConflict, responses.CodeReferenceConflict))
+	})
+
+	t.Run("got safe message and error info when calling Status with database error", func(t *testing.T) {
+		t.Parallel()
+
+		err := grpcserver.Status(responses.GetResponseError(&responses.LocalError{
+			Code:       responses.DATABASE_CONFLICT_ERROR,
+			Message:    "duplicate key value violates unique constraint \"idx_customers_cpf\" 17107972073",
+			Constraint: "idx_customers_cpf",
+		}, "CustomerService"))
+
+		st := status.Convert(err)
+
+		assert.Equal(t, codes.AlreadyExists, st.Code())
+		assert.NotContains(t, st.Message(), "17107972073")
+		assert.Equal(t, string(responses.CodeCPFTaken), st.Details()[0].(*errdetails.ErrorInfo).GetReason())
+	})
+
+	t.Run("got field violations when calling Status with validation error", func(t *testing.T) {
+		t.Parallel()
+
+		err := grpcserver.Status(&responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Code:       responses.CodeValidationFailed,
+			Errors: []responses.FieldError{
+				{Pointer: "/email", Rule: "email", Message: "must be a valid email"},
+			},
+		})
+
+		st := status.Convert(err)
+
+		assert.Equal(t, codes.InvalidArgument, st.Code())
+		assert.Len(t, st.Details(), 2)
+
+		badRequest := st.Details()[1].(*errdetails.BadRequest)
+		assert.Equal(t, "/email", badRequest.GetFieldViolations()[0].GetField())
+	})
+
+	t.Run("got context codes when calling Status with context errors", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, codes.Canceled, status.Code(grpcserver.Status(context.Canceled)))
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(grpcserver.Status(fmt.Errorf("query: %w", context.DeadlineExceeded))))
+	})
+
+	t.Run("got same status when calling Status with status error", func(t *testing.T) {
+		t.Parallel()
+
+		err := status.Error(codes.Unavailable, "unavailable")
+
+		assert.Equal(t, err, grpcserver.Status(err))
+	})
+
+	t.Run("got internal when calling Status with unknown error", func(t *testing.T) {
+		t.Parallel()
+
+		st := status.Convert(grpcserver.Status(errors.New("pq: 17107972073")))
+
+		assert.Equal(t, codes.Internal, st.Code())
+		assert.NotContains(t, st.Message(), "17107972073")
+		assert.Nil(t, grpcserver.Status(nil))
+	})
+}
@@ -0,0 +1,216 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp           = "up"
+	StatusDown         = "down"
+	StatusShuttingDown = "shutting_down"
+
+	_defaultTimeout  = 2 * time.Second
+	_defaultCacheTTL = 5 * time.Second
+)
+
+// CheckFunc verifies one dependency. It must respect the context deadline
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the public result of a check. The error of a failed check is
+// only logged, since it may have hosts, users or provider messages
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	CheckedAt string  `json:"checkedAt"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type check struct {
+	name     string
+	fn       CheckFunc
+	mu       sync.Mutex
+	cached   CheckResult
+	cachedAt time.Time
+}
+
+// Checker runs the readiness checks. Each check has its own timeout and its
+// result is cached for the cache TTL, so probes from several kubelets do not
+// hammer the database or the identity provider
+type Checker struct {
+	checks       []*check
+	timeout      time.Duration
+	cacheTTL     time.Duration
+	shuttingDown atomic.Bool
+}
+
+type Option func(*Checker)
+
+// Timeout -.
+func Timeout(timeout time.Duration) Option {
+	return func(c *Checker) {
+		c.timeout = timeout
+	}
+}
+
+// CacheTTL -.
+func CacheTTL(ttl time.Duration) Option {
+	return func(c *Checker) {
+		c.cacheTTL = ttl
+	}
+}
+
+func NewChecker(opts ...Option) *Checker {
+	c := &Checker{
+		timeout:  _defaultTimeout,
+		cacheTTL: _defaultCacheTTL,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Register adds a readiness check. It is not safe to call it after the
+// handlers start serving
+func (c *Checker) Register(name string, fn CheckFunc) {
+	c.checks = append(c.checks, &check{name: name, fn: fn})
+}
+
+// SetShuttingDown makes the readiness probe fail so that the pod is removed
+// from the service endpoints while the server drains the requests
+func (c *Checker) SetShuttingDown() {
+	c.shuttingDown.Store(true)
+}
+
+// Drain makes the readiness probe fail and waits for the delay, so the load
+// balancers stop routing requests here before the listeners stop accepting
+// them. It returns earlier, with the context error, when ctx is done
+func (c *Checker) Drain(ctx context.Context, delay time.Duration) error {
+	c.SetShuttingDown()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Ready runs all the checks concurrently and returns the report
+func (c *Checker) Ready(ctx context.Context) Report {
+	if c.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+
+	results := make(map[string]CheckResult, len(c.checks))
+	status := StatusUp
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, ch := range c.checks {
+		wg.Add(1)
+
+		go func(ch *check) {
+			defer wg.Done()
+
+			result := c.run(ctx, ch)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			results[ch.name] = result
+
+			if result.Status != StatusUp {
+				status = StatusDown
+			}
+		}(ch)
+	}
+
+	wg.Wait()
+
+	return Report{
+		Status: status,
+		Checks: results,
+	}
+}
+
+func (c *Checker) run(ctx context.Context, ch *check) CheckResult {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+
+	if !ch.cachedAt.IsZero() && time.Since(ch.cachedAt) < c.cacheTTL {
+		return ch.cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := ch.fn(ctx)
+	latency := time.Since(start)
+
+	result := CheckResult{
+		Status:    StatusUp,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+		CheckedAt: start.UTC().Format(time.RFC3339),
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+
+		slog.WarnContext(ctx, "readiness check failed",
+			slog.String("check", ch.name),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	ch.cached = result
+	ch.cachedAt = time.Now()
+
+	return result
+}
+
+// LiveHandler only tells that the process is able to answer requests. It must
+// not check dependencies, otherwise a database outage restarts every pod
+func LiveHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusUp})
+	}
+}
+
+// ReadyHandler answers 200 when every check is up and 503 otherwise
+func ReadyHandler(checker *Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Ready(r.Context())
+		status := http.StatusOK
+
+		if report.Status != StatusUp {
+			status = http.StatusServiceUnavailable
+		}
+
+		writeReport(w, status, report)
+	}
+}
+
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
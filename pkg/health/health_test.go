@@ -0,0 +1,173 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/health"
+)
+
+func doReady(checker *health.Checker) (*httptest.ResponseRecorder, health.Report) {
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	recorder := httptest.NewRecorder()
+
+	health.ReadyHandler(checker).ServeHTTP(recorder, req)
+
+	var report health.Report
+	json.Unmarshal(recorder.Body.Bytes(), &report)
+
+	return recorder, report
+}
+
+func TestHealth(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got success when calling LiveHandler", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodGet, "/health/live", nil)
+		recorder := httptest.NewRecorder()
+
+		health.LiveHandler().ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("got success when all checks are up calling ReadyHandler", func(t *testing.T) {
+		t.Parallel()
+
+		checker := health.NewChecker()
+		checker.Register("database", func(ctx context.Context) error { return nil })
+		checker.Register("identity_provider", func(ctx context.Context) error { return nil })
+
+		recorder, report := doReady(checker)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, health.StatusUp, report.Status)
+		assert.Len(t, report.Checks, 2)
+		assert.Equal(t, health.StatusUp, report.Checks["database"].Status)
+		assert.NotEmpty(t, report.Checks["database"].CheckedAt)
+	})
+
+	t.Run("got service unavailable when one check fails calling ReadyHandler", func(t *testing.T) {
+		t.Parallel()
+
+		checker := health.NewChecker()
+		checker.Register("database", func(ctx context.Context) error { return errors.New("connection refused") })
+		checker.Register("identity_provider", func(ctx context.Context) error { return nil })
+
+		recorder, report := doReady(checker)
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, health.StatusDown, report.Status)
+		assert.Equal(t, health.StatusDown, report.Checks["database"].Status)
+		assert.NotContains(t, recorder.Body.String(), "connection refused")
+		assert.Equal(t, health.StatusUp, report.Checks["identity_provider"].Status)
+	})
+
+	t.Run("got check down when check exceeds timeout calling Ready", func(t *testing.T) {
+		t.Parallel()
+
+		checker := health.NewChecker(health.Timeout(20 * time.Millisecond))
+		checker.Register("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		report := checker.Ready(context.Background())
+
+		assert.Equal(t, health.StatusDown, report.Status)
+		assert.Equal(t, health.StatusDown, report.Checks["slow"].Status)
+	})
+
+	t.Run("got cached result when calling Ready twice within cache TTL", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+
+		checker := health.NewChecker(health.CacheTTL(time.Minute))
+		checker.Register("database", func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+
+		checker.Ready(context.Background())
+		checker.Ready(context.Background())
+
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("got new result when cache TTL expires calling Ready", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+
+		checker := health.NewChecker(health.CacheTTL(time.Nanosecond))
+		checker.Register("database", func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		})
+
+		checker.Ready(context.Background())
+		time.Sleep(time.Millisecond)
+		checker.Ready(context.Background())
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("got service unavailable when shutting down calling ReadyHandler", func(t *testing.T) {
+		t.Parallel()
+
+		checker := health.NewChecker()
+		checker.Register("database", func(ctx context.Context) error { return nil })
+		checker.SetShuttingDown()
+
+		recorder, report := doReady(checker)
+
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Equal(t, health.StatusShuttingDown, report.Status)
+	})
+
+	t.Run("got not ready during delay when calling Drain", func(t *testing.T) {
+		t.Parallel()
+
+		checker := health.NewChecker()
+		checker.Register("database", func(ctx context.Context) error { return nil })
+
+		done := make(chan error)
+		start := time.Now()
+
+		go func() {
+			done <- checker.Drain(context.Background(), 50*time.Millisecond)
+		}()
+
+		assert.Eventually(t, func() bool {
+			_, report := doReady(checker)
+			return report.Status == health.StatusShuttingDown
+		}, time.Second, time.Millisecond)
+
+		assert.NoError(t, <-done)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("got context error when calling Drain with expired context", func(t *testing.T) {
+		t.Parallel()
+
+		checker := health.NewChecker()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := checker.Drain(ctx, time.Hour)
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,153 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+)
+
+// ErrCircuitOpen is returned, wrapped in a *url.Error, for requests to a host
+// whose circuit is open. responses.GetNetworkError maps it to 503
+var ErrCircuitOpen error = circuitOpenError{}
+
+type circuitOpenError struct{}
+
+func (circuitOpenError) Error() string {
+	return "circuit breaker is open"
+}
+
+// Temporary makes the error a 503 in responses.GetNetworkError
+func (circuitOpenError) Temporary() bool {
+	return true
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+type circuit struct {
+	state    circuitState
+	failures int
+	openedAt time.Time
+}
+
+// breakerTransport keeps a circuit for every host. A circuit opens after
+// maxFailures consecutive failures, which are network errors and 5xx
+// responses. After openTimeout it is half open and lets a single probe
+// through: a success closes it and a failure opens it again
+type breakerTransport struct {
+	next        http.RoundTripper
+	maxFailures int
+	openTimeout time.Duration
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+func newBreakerTransport(next http.RoundTripper, maxFailures int, openTimeout time.Duration) *breakerTransport {
+	return &breakerTransport{
+		next:        next,
+		maxFailures: maxFailures,
+		openTimeout: openTimeout,
+		circuits:    map[string]*circuit{},
+	}
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+
+	if !t.allow(host) {
+		return nil, ErrCircuitOpen
+	}
+
+	response, err := t.next.RoundTrip(req)
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		t.cancel(host)
+	case err != nil || response.StatusCode >= http.StatusInternalServerError:
+		t.failure(req.Context(), host)
+	default:
+		t.success(host)
+	}
+
+	return response, err
+}
+
+func (t *breakerTransport) allow(host string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.circuits[host]
+
+	if !ok {
+		return true
+	}
+
+	switch c.state {
+	case circuitOpen:
+		if time.Since(c.openedAt) < t.openTimeout {
+			return false
+		}
+
+		c.state = circuitHalfOpen
+
+		return true
+	case circuitHalfOpen:
+		// a probe is already running
+		return false
+	}
+
+	return true
+}
+
+func (t *breakerTransport) failure(ctx context.Context, host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.circuits[host]
+
+	if !ok {
+		c = &circuit{}
+		t.circuits[host] = c
+	}
+
+	c.failures++
+
+	if c.state == circuitHalfOpen || c.failures >= t.maxFailures {
+		if c.state == circuitClosed {
+			logger.FromContext(ctx).WarnContext(ctx, "circuit breaker opened", slog.String("host", host), slog.Int("failures", c.failures))
+		}
+
+		c.state = circuitOpen
+		c.openedAt = time.Now()
+	}
+}
+
+func (t *breakerTransport) success(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.circuits, host)
+}
+
+// cancel lets another probe through when the caller gave up on the probe,
+// which says nothing about the host
+func (t *breakerTransport) cancel(host string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.circuits[host]; ok && c.state == circuitHalfOpen {
+		c.state = circuitOpen
+		c.openedAt = time.Now().Add(-t.openTimeout)
+	}
+}
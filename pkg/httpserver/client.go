@@ -3,26 +3,149 @@ package httpserver
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
-	"net/url"
+	"os"
 	"time"
 
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func NewHTTPClient() *http.Client {
+const (
+	_defaultClientTimeout       = 20 * time.Second
+	_defaultMaxIdleConns        = 100
+	_defaultMaxIdleConnsPerHost = 10
+	_defaultMaxConnsPerHost     = 50
+	_defaultIdleConnTimeout     = 90 * time.Second
+	_defaultTLSHandshakeTimeout = 10 * time.Second
+	_defaultMaxRetries          = 2
+	_defaultRetryBaseDelay      = 100 * time.Millisecond
+	_defaultRetryMaxDelay       = 2 * time.Second
+	_defaultMaxRetryAfter       = 10 * time.Second
+	_defaultBreakerFailures     = 5
+	_defaultBreakerOpenTimeout  = 30 * time.Second
+)
+
+// NewHTTPClient creates the client of the partner APIs. The server certificates
+// are verified with the system CAs and the CABundle ones. Idempotent requests
+// are retried on network errors, 429 and 502-504, and every host has its own
+// circuit breaker. The request ID and the trace context of the request context
+// are sent with every request
+func NewHTTPClient(opts ...ClientOption) (*http.Client, error) {
+	cfg := &clientConfig{
+		timeout:             _defaultClientTimeout,
+		maxIdleConns:        _defaultMaxIdleConns,
+		maxIdleConnsPerHost: _defaultMaxIdleConnsPerHost,
+		maxConnsPerHost:     _defaultMaxConnsPerHost,
+		idleConnTimeout:     _defaultIdleConnTimeout,
+		tlsHandshakeTimeout: _defaultTLSHandshakeTimeout,
+		maxRetries:          _defaultMaxRetries,
+		retryBaseDelay:      _defaultRetryBaseDelay,
+		retryMaxDelay:       _defaultRetryMaxDelay,
+		maxRetryAfter:       _defaultMaxRetryAfter,
+		breakerFailures:     _defaultBreakerFailures,
+		breakerOpenTimeout:  _defaultBreakerOpenTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	rootCAs, err := loadRootCAs(cfg.caFiles)
+
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    rootCAs,
+	}
+	transport.MaxIdleConns = cfg.maxIdleConns
+	transport.MaxIdleConnsPerHost = cfg.maxIdleConnsPerHost
+	transport.MaxConnsPerHost = cfg.maxConnsPerHost
+	transport.IdleConnTimeout = cfg.idleConnTimeout
+	transport.TLSHandshakeTimeout = cfg.tlsHandshakeTimeout
+
+	var roundTripper http.RoundTripper = transport
+
+	if cfg.breakerFailures > 0 {
+		roundTripper = newBreakerTransport(roundTripper, cfg.breakerFailures, cfg.breakerOpenTimeout)
+	}
+
+	if cfg.maxRetries > 0 {
+		roundTripper = &retryTransport{
+			next:          roundTripper,
+			maxRetries:    cfg.maxRetries,
+			baseDelay:     cfg.retryBaseDelay,
+			maxDelay:      cfg.retryMaxDelay,
+			maxRetryAfter: cfg.maxRetryAfter,
+		}
+	}
+
 	client := http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-			},
-		},
-		Timeout: 20 * time.Second,
+		Transport: &propagationTransport{next: roundTripper},
+		Timeout:   cfg.timeout,
+	}
+
+	return &client, nil
+}
+
+// loadRootCAs returns the system CAs with the certificates of the files. It
+// returns nil, which means the system CAs, when there is no file
+func loadRootCAs(files []string) (*x509.CertPool, error) {
+	if len(files) == 0 {
+		return nil, nil
 	}
 
-	return &client
+	pool, err := x509.SystemCertPool()
+
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificate in CA bundle %q", file)
+		}
+	}
+
+	return pool, nil
+}
+
+// propagationTransport sends the request ID and the trace context, so requests
+// built without DoRequest are propagated too
+type propagationTransport struct {
+	next http.RoundTripper
+}
+
+func (t *propagationTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	propagateHeaders(req.Context(), req.Header)
+
+	return t.next.RoundTrip(req)
+}
+
+func propagateHeaders(ctx context.Context, header http.Header) {
+	if requestID := chiMiddleware.GetReqID(ctx); requestID != "" {
+		header.Set(chiMiddleware.RequestIDHeader, requestID)
+	}
+
+	tracing.InjectHeaders(ctx, header)
 }
 
 func DoRequest[T any](
@@ -36,6 +159,9 @@ func DoRequest[T any](
 ) (T, error) {
 	var empty T
 
+	ctx, span := tracing.Start(ctx, "HTTP "+method, trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+
 	req, err := http.NewRequestWithContext(ctx, method, endpoint, formData)
 
 	if err != nil {
@@ -51,14 +177,18 @@ func DoRequest[T any](
 		req.Header.Set("Authorization", *token)
 	}
 
+	propagateHeaders(ctx, req.Header)
+
 	response, err := client.Do(req)
 
 	if err != nil {
-		return empty, responses.GetNetworkError(err.(*url.Error))
+		return empty, responses.GetNetworkError(err)
 	}
 
 	defer response.Body.Close()
 
+	span.SetAttributes(semconv.HTTPResponseStatusCode(response.StatusCode))
+
 	body, err := io.ReadAll(response.Body)
 
 	if err != nil {
@@ -0,0 +1,110 @@
+package httpserver
+
+import "time"
+
+type clientConfig struct {
+	timeout             time.Duration
+	caFiles             []string
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	idleConnTimeout     time.Duration
+	tlsHandshakeTimeout time.Duration
+	maxRetries          int
+	retryBaseDelay      time.Duration
+	retryMaxDelay       time.Duration
+	maxRetryAfter       time.Duration
+	breakerFailures     int
+	breakerOpenTimeout  time.Duration
+}
+
+// ClientOption -.
+type ClientOption func(*clientConfig)
+
+// ClientTimeout is the limit of a whole request, including the retries and the
+// waits between them
+func ClientTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.timeout = timeout
+	}
+}
+
+// CABundle trusts the certificates of the PEM files besides the system ones.
+// Used by partner APIs signed by a private CA
+func CABundle(files ...string) ClientOption {
+	return func(c *clientConfig) {
+		c.caFiles = append(c.caFiles, files...)
+	}
+}
+
+// MaxIdleConns -.
+func MaxIdleConns(size int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxIdleConns = size
+	}
+}
+
+// MaxIdleConnsPerHost -.
+func MaxIdleConnsPerHost(size int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxIdleConnsPerHost = size
+	}
+}
+
+// MaxConnsPerHost limits the connections to a single host. Requests over the
+// limit wait for a free connection. Zero means no limit
+func MaxConnsPerHost(size int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxConnsPerHost = size
+	}
+}
+
+// IdleConnTimeout -.
+func IdleConnTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.idleConnTimeout = timeout
+	}
+}
+
+// TLSHandshakeTimeout -.
+func TLSHandshakeTimeout(timeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.tlsHandshakeTimeout = timeout
+	}
+}
+
+// Retries is the maximum number of retries of an idempotent request. Zero
+// disables the retries
+func Retries(retries int) ClientOption {
+	return func(c *clientConfig) {
+		c.maxRetries = retries
+	}
+}
+
+// RetryBackoff sets the exponential backoff between the retries. The wait is a
+// random duration up to base * 2^retry, limited to max
+func RetryBackoff(base, max time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.retryBaseDelay = base
+		c.retryMaxDelay = max
+	}
+}
+
+// MaxRetryAfter is the longest Retry-After header that is waited for. A
+// response asking for a longer wait is returned without retrying
+func MaxRetryAfter(wait time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.maxRetryAfter = wait
+	}
+}
+
+// CircuitBreaker opens the circuit of a host after the given consecutive
+// failures. The requests to the host then fail right away until openTimeout
+// has passed, when a single request is let through to probe it. Zero failures
+// disables the circuit breaker
+func CircuitBreaker(failures int, openTimeout time.Duration) ClientOption {
+	return func(c *clientConfig) {
+		c.breakerFailures = failures
+		c.breakerOpenTimeout = openTimeout
+	}
+}
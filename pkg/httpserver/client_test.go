@@ -1,27 +1,83 @@
 package httpserver_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/pem"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
 )
 
+// mockPartnerServer answers the statuses in order and then 200 with a token
+func mockPartnerServer(t *testing.T, calls *atomic.Int32, statuses ...int) *httptest.Server {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+
+		if call <= len(statuses) {
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+
+		httpserver.SendResponseSuccess(w, dto.Token{
+			AccessToken: "TOKEN",
+		})
+	}))
+
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func newTestClient(t *testing.T, opts ...httpserver.ClientOption) *http.Client {
+	opts = append([]httpserver.ClientOption{httpserver.RetryBackoff(time.Millisecond, 5*time.Millisecond)}, opts...)
+	client, err := httpserver.NewHTTPClient(opts...)
+
+	assert.NoError(t, err)
+
+	return client
+}
+
 func TestHttpClient(t *testing.T) {
 	t.Parallel()
 
 	t.Run("got success when creating http client", func(t *testing.T) {
 		t.Parallel()
 
-		client := httpserver.NewHTTPClient()
+		client, err := httpserver.NewHTTPClient()
 
+		assert.NoError(t, err)
 		assert.NotEmpty(t, client)
 	})
 
+	t.Run("got error when creating http client with invalid CA bundle", func(t *testing.T) {
+		t.Parallel()
+
+		file := filepath.Join(t.TempDir(), "ca.pem")
+		os.WriteFile(file, []byte("not a certificate"), 0o600)
+
+		client, err := httpserver.NewHTTPClient(httpserver.CABundle(file))
+
+		assert.Error(t, err)
+		assert.Nil(t, client)
+
+		client, err = httpserver.NewHTTPClient(httpserver.CABundle(filepath.Join(t.TempDir(), "missing.pem")))
+
+		assert.Error(t, err)
+		assert.Nil(t, client)
+	})
+
 	t.Run("got success when calling DoRequest client", func(t *testing.T) {
 		t.Parallel()
 
@@ -59,13 +115,231 @@ func TestHttpClient(t *testing.T) {
 	t.Run("got error when calling DoRequest client", func(t *testing.T) {
 		t.Parallel()
 
-		client := httpserver.NewHTTPClient()
+		client := newTestClient(t)
 
 		assert.NotEmpty(t, client)
 
-		response, err := httpserver.DoRequest(context.TODO(), client, "http://localhost", nil, nil, http.MethodGet, dto.Token{})
+		response, err := httpserver.DoRequest(context.TODO(), client, "http://localhost:1", nil, nil, http.MethodGet, dto.Token{})
 
 		assert.Error(t, err)
 		assert.Empty(t, response)
 	})
+
+	t.Run("got certificate verified with CA bundle when calling DoRequest client", func(t *testing.T) {
+		t.Parallel()
+
+		ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httpserver.SendResponseSuccess(w, dto.Token{
+				AccessToken: "TOKEN",
+			})
+		}))
+		defer ts.Close()
+
+		response, err := httpserver.DoRequest(context.TODO(), newTestClient(t, httpserver.Retries(0)), ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		assert.Error(t, err)
+		assert.Empty(t, response)
+
+		file := filepath.Join(t.TempDir(), "ca.pem")
+		os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600)
+
+		client := newTestClient(t, httpserver.CABundle(file))
+
+		response, err = httpserver.DoRequest(context.TODO(), client, ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TOKEN", response.AccessToken)
+	})
+
+	t.Run("got success after retries when calling DoRequest client with idempotent method", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := mockPartnerServer(t, &calls, http.StatusServiceUnavailable, http.StatusBadGateway)
+
+		response, err := httpserver.DoRequest(context.TODO(), newTestClient(t), ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TOKEN", response.AccessToken)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("got last error when calling DoRequest client after all retries", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := mockPartnerServer(t, &calls, http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusGatewayTimeout, http.StatusOK)
+
+		response, err := httpserver.DoRequest(context.TODO(), newTestClient(t, httpserver.Retries(2)), ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		var networkError *responses.NetworkError
+		assert.ErrorAs(t, err, &networkError)
+		assert.Equal(t, http.StatusGatewayTimeout, networkError.Code)
+		assert.Empty(t, response)
+		assert.Equal(t, int32(3), calls.Load())
+	})
+
+	t.Run("got no retry when calling DoRequest client with POST", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := mockPartnerServer(t, &calls, http.StatusServiceUnavailable)
+
+		response, err := httpserver.DoRequest(context.TODO(), newTestClient(t), ts.URL, nil, bytes.NewBufferString(`{}`), http.MethodPost, dto.Token{})
+
+		assert.Error(t, err)
+		assert.Empty(t, response)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("got same body on every retry when calling client with Idempotency-Key", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		var bodies []string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+		}))
+		defer ts.Close()
+
+		req, err := http.NewRequest(http.MethodPost, ts.URL, bytes.NewBufferString(`{"cpf":"83212446293"}`))
+		assert.NoError(t, err)
+
+		req.Header.Set("Idempotency-Key", "KEY-1")
+
+		response, err := newTestClient(t).Do(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusCreated, response.StatusCode)
+		assert.Equal(t, []string{`{"cpf":"83212446293"}`, `{"cpf":"83212446293"}`}, bodies)
+	})
+
+	t.Run("got Retry-After respected when calling DoRequest client", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			httpserver.SendResponseSuccess(w, dto.Token{AccessToken: "TOKEN"})
+		}))
+		defer ts.Close()
+
+		start := time.Now()
+		response, err := httpserver.DoRequest(context.TODO(), newTestClient(t), ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TOKEN", response.AccessToken)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("got no retry when calling DoRequest client with Retry-After longer than max", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Header().Set("Retry-After", "120")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer ts.Close()
+
+		client := newTestClient(t, httpserver.MaxRetryAfter(time.Second))
+		_, err := httpserver.DoRequest(context.TODO(), client, ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		var networkError *responses.NetworkError
+		assert.ErrorAs(t, err, &networkError)
+		assert.Equal(t, http.StatusTooManyRequests, networkError.Code)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("got context error when calling DoRequest client canceled during backoff", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		ts := mockPartnerServer(t, &calls, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		client := newTestClient(t, httpserver.RetryBackoff(time.Minute, time.Minute))
+
+		start := time.Now()
+		_, err := httpserver.DoRequest(ctx, client, ts.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		var networkError *responses.NetworkError
+		assert.ErrorAs(t, err, &networkError)
+		assert.Equal(t, http.StatusGatewayTimeout, networkError.Code)
+		assert.Less(t, time.Since(start), 10*time.Second)
+	})
+
+	t.Run("got circuit opened per host when calling DoRequest client with failing host", func(t *testing.T) {
+		t.Parallel()
+
+		var failingCalls atomic.Int32
+		failing := mockPartnerServer(t, &failingCalls, http.StatusInternalServerError, http.StatusInternalServerError)
+
+		var healthyCalls atomic.Int32
+		healthy := mockPartnerServer(t, &healthyCalls)
+
+		client := newTestClient(t, httpserver.Retries(0), httpserver.CircuitBreaker(2, 100*time.Millisecond))
+
+		for range 2 {
+			_, err := httpserver.DoRequest(context.TODO(), client, failing.URL, nil, nil, http.MethodGet, dto.Token{})
+			assert.Error(t, err)
+		}
+
+		_, err := httpserver.DoRequest(context.TODO(), client, failing.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		var networkError *responses.NetworkError
+		assert.ErrorAs(t, err, &networkError)
+		assert.Equal(t, http.StatusServiceUnavailable, networkError.Code)
+		assert.Equal(t, httpserver.ErrCircuitOpen.Error(), networkError.Message)
+		assert.Equal(t, int32(2), failingCalls.Load())
+
+		_, err = httpserver.DoRequest(context.TODO(), client, healthy.URL, nil, nil, http.MethodGet, dto.Token{})
+		assert.NoError(t, err)
+
+		time.Sleep(150 * time.Millisecond)
+
+		response, err := httpserver.DoRequest(context.TODO(), client, failing.URL, nil, nil, http.MethodGet, dto.Token{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "TOKEN", response.AccessToken)
+		assert.Equal(t, int32(3), failingCalls.Load())
+	})
+
+	t.Run("got request ID and traceparent propagated when calling client", func(t *testing.T) {
+		t.Parallel()
+
+		var requestID string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID = r.Header.Get(chiMiddleware.RequestIDHeader)
+		}))
+		defer ts.Close()
+
+		ctx := context.WithValue(context.Background(), chiMiddleware.RequestIDKey, "REQUEST-ID")
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL, nil)
+		assert.NoError(t, err)
+
+		_, err = newTestClient(t).Do(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "REQUEST-ID", requestID)
+	})
 }
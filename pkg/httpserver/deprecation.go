@@ -0,0 +1,25 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecated marks the responses of the routes as deprecated. It sets the
+// Deprecation (RFC 9745) and Sunset (RFC 8594) headers and a Link header to the
+// same path in the successor version, like /v1/auth/login for /auth/login
+func Deprecated(deprecatedAt time.Time, sunsetAt time.Time, successorPrefix string) func(http.Handler) http.Handler {
+	deprecation := fmt.Sprintf("@%d", deprecatedAt.Unix())
+	sunset := sunsetAt.UTC().Format(http.TimeFormat)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunset)
+			w.Header().Add("Link", fmt.Sprintf("<%v%v>; rel=\"successor-version\"", successorPrefix, r.URL.Path))
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+// SetETag writes the strong ETag of a versioned resource, like "3"
+func SetETag(w http.ResponseWriter, version uint) {
+	w.Header().Set("ETag", fmt.Sprintf("%q", strconv.FormatUint(uint64(version), 10)))
+}
+
+// GetIfMatchVersion returns the version sent in the If-Match header. It returns 0,
+// which means any version, when the header is missing or is "*".
+//
+// Only one ETag is accepted. A weak or unknown ETag can never match a strong
+// one (RFC 9110) so it returns a precondition failed error
+func GetIfMatchVersion(r *http.Request) (uint, error) {
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+
+	if ifMatch == "" || ifMatch == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(ifMatch, ",") {
+		return 0, &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "If-Match header must have a single ETag",
+			Code:       responses.CodeBadRequest,
+		}
+	}
+
+	version, err := strconv.ParseUint(strings.Trim(ifMatch, `"`), 10, 0)
+
+	if err != nil || version == 0 || !strings.HasPrefix(ifMatch, `"`) || !strings.HasSuffix(ifMatch, `"`) {
+		return 0, &responses.BusinessResponse{
+			StatusCode: http.StatusPreconditionFailed,
+			Message:    "If-Match header does not match the resource ETag",
+			Code:       responses.CodePreconditionFailed,
+		}
+	}
+
+	return uint(version), nil
+}
+
+// RequireIfMatch rejects PUT and PATCH requests without the If-Match header with
+// 428 Precondition Required (RFC 6585), so a client can not overwrite a resource
+// without reading it first
+func RequireIfMatch(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method == http.MethodPut || r.Method == http.MethodPatch) && r.Header.Get("If-Match") == "" {
+			SendResponseError(w, r, &responses.BusinessResponse{
+				StatusCode: http.StatusPreconditionRequired,
+				Message:    "If-Match header is required",
+				Code:       responses.CodePreconditionRequired,
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,118 @@
+package httpserver_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+func TestETag(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got strong ETag when calling SetETag", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := httptest.NewRecorder()
+		httpserver.SetETag(recorder, 3)
+
+		assert.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+	})
+
+	t.Run("got version when calling GetIfMatchVersion with strong ETag", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPut, "/mock", nil)
+		req.Header.Set("If-Match", `"3"`)
+
+		version, err := httpserver.GetIfMatchVersion(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), version)
+	})
+
+	t.Run("got any version when calling GetIfMatchVersion without header or with wildcard", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPut, "/mock", nil)
+
+		version, err := httpserver.GetIfMatchVersion(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), version)
+
+		req.Header.Set("If-Match", "*")
+
+		version, err = httpserver.GetIfMatchVersion(req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uint(0), version)
+	})
+
+	t.Run("got precondition failed error when calling GetIfMatchVersion with weak or unknown ETag", func(t *testing.T) {
+		t.Parallel()
+
+		for _, ifMatch := range []string{`W/"3"`, `"abc"`, "3", `"0"`} {
+			req := httptest.NewRequest(http.MethodPut, "/mock", nil)
+			req.Header.Set("If-Match", ifMatch)
+
+			_, err := httpserver.GetIfMatchVersion(req)
+
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusPreconditionFailed, httpserver.GetStatusCodeFromError(err))
+		}
+	})
+
+	t.Run("got bad request error when calling GetIfMatchVersion with ETag list", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPut, "/mock", nil)
+		req.Header.Set("If-Match", `"3", "4"`)
+
+		_, err := httpserver.GetIfMatchVersion(req)
+
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, httpserver.GetStatusCodeFromError(err))
+	})
+
+	t.Run("got precondition required when calling RequireIfMatch without header", func(t *testing.T) {
+		t.Parallel()
+
+		handler := httpserver.RequireIfMatch(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		req := httptest.NewRequest(http.MethodPut, "/mock/1", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+		assert.Equal(t, responses.ProblemContentType, recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), string(responses.CodePreconditionRequired))
+
+		req = httptest.NewRequest(http.MethodPut, "/mock/1", nil)
+		req.Header.Set("If-Match", `"1"`)
+		recorder = httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusNoContent, recorder.Code)
+	})
+
+	t.Run("got success when calling RequireIfMatch with safe method", func(t *testing.T) {
+		t.Parallel()
+
+		handler := httpserver.RequireIfMatch(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		req := httptest.NewRequest(http.MethodGet, "/mock/1", nil)
+		recorder := httptest.NewRecorder()
+
+		handler.ServeHTTP(recorder, req)
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
@@ -0,0 +1,75 @@
+package httpserver
+
+import (
+	"log/slog"
+	"net"
+	"time"
+)
+
+// Option -.
+type Option func(*Server)
+
+// Address sets the bind host and port. An empty host binds all interfaces
+func Address(host, port string) Option {
+	return func(s *Server) {
+		s.server.Addr = net.JoinHostPort(host, port)
+	}
+}
+
+// ReadTimeout -.
+func ReadTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.server.ReadTimeout = timeout
+	}
+}
+
+// WriteTimeout -.
+func WriteTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.server.WriteTimeout = timeout
+	}
+}
+
+// IdleTimeout -.
+func IdleTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.server.IdleTimeout = timeout
+	}
+}
+
+// ShutdownTimeout -.
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = timeout
+	}
+}
+
+// MaxHeaderBytes -.
+func MaxHeaderBytes(size int) Option {
+	return func(s *Server) {
+		s.server.MaxHeaderBytes = size
+	}
+}
+
+// TLS serves HTTPS with the given certificate and key files. Both must be set
+func TLS(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.tlsCertFile = certFile
+		s.tlsKeyFile = keyFile
+	}
+}
+
+// H2C enables HTTP/2 without TLS (prior knowledge or upgrade). Used when the
+// TLS is terminated by the ingress and it talks HTTP/2 with the pods
+func H2C(enabled bool) Option {
+	return func(s *Server) {
+		s.h2c = enabled
+	}
+}
+
+// Logger -. The default is the slog default logger
+func Logger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
@@ -1,35 +1,117 @@
 package httpserver
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
 
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/golang/gddo/httputil/header"
 )
 
+const MergePatchContentType = "application/merge-patch+json"
+
 func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) error {
 	if r.Header.Get("Content-Type") == "" {
 		msg := "Content-Type header is not application/json"
-		return &responses.BusinessResponse{StatusCode: http.StatusUnsupportedMediaType, Message: msg}
+		return &responses.BusinessResponse{StatusCode: http.StatusUnsupportedMediaType, Message: msg, Code: responses.CodeUnsupportedMediaType}
 	}
 
 	value, _ := header.ParseValueAndParams(r.Header, "Content-Type")
 	if value != "application/json" {
 		msg := "Content-Type header is not application/json"
-		return &responses.BusinessResponse{StatusCode: http.StatusUnsupportedMediaType, Message: msg}
+		return &responses.BusinessResponse{StatusCode: http.StatusUnsupportedMediaType, Message: msg, Code: responses.CodeUnsupportedMediaType}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
+
+	err := decodeJSON(r.Body, dst)
+
+	if err != nil {
+		return err
+	}
+
+	err = ValidateStruct(dst)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// DecodeMergePatchBody decodes a JSON Merge Patch (RFC 7396) body into dst, a
+// struct with pointer fields. Only the members present in the body are set and
+// validated. A null member would remove the field, which is not allowed for any
+// field of this API, so it is returned as a required field error
+func DecodeMergePatchBody(w http.ResponseWriter, r *http.Request, dst any) error {
+	value, _ := header.ParseValueAndParams(r.Header, "Content-Type")
+	if value != MergePatchContentType {
+		msg := "Content-Type header is not application/merge-patch+json"
+		return &responses.BusinessResponse{StatusCode: http.StatusUnsupportedMediaType, Message: msg, Code: responses.CodeUnsupportedMediaType}
 	}
 
 	r.Body = http.MaxBytesReader(w, r.Body, 1048576)
 
-	dec := json.NewDecoder(r.Body)
+	var patch map[string]json.RawMessage
+	err := decodeJSON(r.Body, &patch)
+
+	if err != nil {
+		return err
+	}
+
+	fieldErrors := []responses.FieldError{}
+
+	for member, raw := range patch {
+		if string(raw) == "null" {
+			fieldErrors = append(fieldErrors, responses.FieldError{
+				Pointer: toJSONPointer(member),
+				Rule:    "required",
+				Message: ruleMessages["required"],
+			})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		sort.Slice(fieldErrors, func(i, j int) bool {
+			return fieldErrors[i].Pointer < fieldErrors[j].Pointer
+		})
+
+		return &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Error JSON required fields: members can not be removed",
+			Code:       responses.CodeValidationFailed,
+			Errors:     fieldErrors,
+		}
+	}
+
+	body, err := json.Marshal(patch)
+
+	if err != nil {
+		return err
+	}
+
+	err = decodeJSON(bytes.NewReader(body), dst)
+
+	if err != nil {
+		return err
+	}
+
+	return ValidateStruct(dst)
+}
+
+// decodeJSON decodes a single JSON value without unknown fields from body and
+// converts the decoding errors into client safe responses
+func decodeJSON(body io.Reader, dst any) error {
+	dec := json.NewDecoder(body)
 	dec.DisallowUnknownFields()
 
 	err := dec.Decode(&dst)
@@ -40,28 +122,28 @@ func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) error {
 		switch {
 		case errors.As(err, &syntaxError):
 			msg := fmt.Sprintf("Request body contains badly-formed JSON (at position %d)", syntaxError.Offset)
-			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
+			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg, Code: responses.CodeMalformedBody}
 
 		case errors.Is(err, io.ErrUnexpectedEOF):
 			msg := "Request body contains badly-formed JSON"
-			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
+			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg, Code: responses.CodeMalformedBody}
 
 		case errors.As(err, &unmarshalTypeError):
 			msg := fmt.Sprintf("Request body contains an invalid value for the %q field (at position %d)", unmarshalTypeError.Field, unmarshalTypeError.Offset)
-			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
+			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg, Code: responses.CodeMalformedBody}
 
 		case strings.HasPrefix(err.Error(), "json: unknown field "):
 			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
 			msg := fmt.Sprintf("Request body contains unknown field %s", fieldName)
-			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
+			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg, Code: responses.CodeMalformedBody}
 
 		case errors.Is(err, io.EOF):
 			msg := "Request body must not be empty"
-			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
+			return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg, Code: responses.CodeMalformedBody}
 
 		case err.Error() == "http: request body too large":
 			msg := "Request body must not be larger than 1MB"
-			return &responses.BusinessResponse{StatusCode: http.StatusRequestEntityTooLarge, Message: msg}
+			return &responses.BusinessResponse{StatusCode: http.StatusRequestEntityTooLarge, Message: msg, Code: responses.CodePayloadTooLarge}
 
 		default:
 			return err
@@ -72,41 +154,25 @@ func DecodeJSONBody(w http.ResponseWriter, r *http.Request, dst any) error {
 
 	if !errors.Is(err, io.EOF) {
 		msg := "Request body must only contain a single JSON object"
-		return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
-	}
-
-	validate := validator.New()
-	err = validate.Struct(dst)
-	if err != nil {
-		msg := fmt.Sprintf("Error JSON required fields: %v", err.Error())
-		return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg}
+		return &responses.BusinessResponse{StatusCode: http.StatusBadRequest, Message: msg, Code: responses.CodeMalformedBody}
 	}
 
 	return nil
 }
 
-func SendResponseError(w http.ResponseWriter, err error) {
-	w.Header().Set("Content-Type", "application/json")
-
-	var br *responses.BusinessResponse
-
-	if errors.As(err, &br) {
-		w.WriteHeader(br.StatusCode)
-	} else {
-		br = &responses.BusinessResponse{
-			StatusCode: http.StatusInternalServerError,
-			Message:    "Unexpected internal error",
-		}
-
-		w.WriteHeader(http.StatusInternalServerError)
-	}
+// SendResponseError writes err as a RFC 7807 Problem Details response. Only the
+// catalog code and a safe message are sent. The internal error message must be
+// logged by the caller
+func SendResponseError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := responses.NewProblemDetails(err, r.URL.Path, chiMiddleware.GetReqID(r.Context()))
 
-	json.NewEncoder(w).Encode(br)
+	w.Header().Set("Content-Type", responses.ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
 }
 
 func GetStatusCodeFromError(err error) int {
 	var br *responses.BusinessResponse
-
 	if errors.As(err, &br) {
 		return br.StatusCode
 	}
@@ -114,10 +180,14 @@ func GetStatusCodeFromError(err error) int {
 	return http.StatusInternalServerError
 }
 
-func SendBadRequestError(w http.ResponseWriter, err error) {
-	SendResponseError(w, &responses.BusinessResponse{
+// SendBadRequestError writes the BAD_REQUEST problem of an invalid path or
+// query parameter. err, usually a parser error, is not sent and must be logged
+// by the caller
+func SendBadRequestError(w http.ResponseWriter, r *http.Request, err error) {
+	SendResponseError(w, r, &responses.BusinessResponse{
 		StatusCode: http.StatusBadRequest,
-		Message:    fmt.Sprintf("Bad request: %v", err.Error()),
+		Message:    "Invalid path or query parameter",
+		Code:       responses.CodeBadRequest,
 	})
 }
 
@@ -1,13 +1,16 @@
 package httpserver_test
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
@@ -102,7 +105,7 @@ func TestRequestResponseHelper(t *testing.T) {
 			assert.Error(t, err)
 			assert.Equal(t, "Request body must not be empty", err.Error())
 
-			httpserver.SendResponseError(w, errors.New("ERROR"))
+			httpserver.SendResponseError(w, r, errors.New("ERROR"))
 		})
 
 		ts := httptest.NewServer(responseHandler)
@@ -115,6 +118,51 @@ func TestRequestResponseHelper(t *testing.T) {
 
 		assert.NoError(t, err)
 		defer response.Body.Close()
+
+		var problem responses.ProblemDetails
+		err = json.NewDecoder(response.Body).Decode(&problem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+		assert.Equal(t, responses.ProblemContentType, response.Header.Get("Content-Type"))
+		assert.Equal(t, responses.CodeInternalError, problem.Code)
+		assert.Equal(t, "/mock", problem.Instance)
+		assert.NotContains(t, problem.Detail, "ERROR")
+	})
+
+	t.Run("got request id and safe message when calling SendResponseError with database error", func(t *testing.T) {
+		t.Parallel()
+
+		responseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			err := responses.GetResponseError(&responses.LocalError{
+				Code:       responses.DATABASE_CONFLICT_ERROR,
+				Message:    "duplicate key value violates unique constraint \"uni_customers_email\"",
+				Constraint: "uni_customers_email",
+			}, "CustomerService")
+
+			httpserver.SendResponseError(w, r, err)
+		})
+
+		ts := httptest.NewServer(chiMiddleware.RequestID(responseHandler))
+		defer ts.Close()
+
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/mock", nil)
+		req.Header.Add("X-Request-Id", "REQUEST-ID")
+
+		response, err := ts.Client().Do(req)
+
+		assert.NoError(t, err)
+		defer response.Body.Close()
+
+		var problem responses.ProblemDetails
+		err = json.NewDecoder(response.Body).Decode(&problem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+		assert.Equal(t, http.StatusConflict, problem.Status)
+		assert.Equal(t, responses.CodeEmailTaken, problem.Code)
+		assert.Equal(t, "REQUEST-ID", problem.RequestID)
+		assert.NotContains(t, problem.Detail, "duplicate key")
 	})
 
 	t.Run("get success when calling SendBadRequestError", func(t *testing.T) {
@@ -127,7 +175,7 @@ func TestRequestResponseHelper(t *testing.T) {
 			assert.Error(t, err)
 			assert.Equal(t, "Request body must not be empty", err.Error())
 
-			httpserver.SendBadRequestError(w, errors.New("ERROR"))
+			httpserver.SendBadRequestError(w, r, errors.New("ERROR"))
 		})
 
 		ts := httptest.NewServer(responseHandler)
@@ -142,6 +190,32 @@ func TestRequestResponseHelper(t *testing.T) {
 		defer response.Body.Close()
 	})
 
+	t.Run("got no parser message when calling SendBadRequestError", func(t *testing.T) {
+		t.Parallel()
+
+		responseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, err := strconv.Atoi("abc")
+
+			httpserver.SendBadRequestError(w, r, err)
+		})
+
+		ts := httptest.NewServer(responseHandler)
+		defer ts.Close()
+
+		response, err := ts.Client().Get(ts.URL + "/mock/abc")
+
+		assert.NoError(t, err)
+		defer response.Body.Close()
+
+		var problem responses.ProblemDetails
+		err = json.NewDecoder(response.Body).Decode(&problem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		assert.Equal(t, responses.CodeBadRequest, problem.Code)
+		assert.Equal(t, "Invalid path or query parameter", problem.Detail)
+	})
+
 	t.Run("get StatusInternalServerError when calling GetStatusCodeFromError", func(t *testing.T) {
 		t.Parallel()
 
@@ -425,4 +499,51 @@ func TestRequestResponseHelper(t *testing.T) {
 		assert.NoError(t, err)
 		defer response.Body.Close()
 	})
+
+	t.Run("got field errors for null members when calling DecodeMergePatchBody", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPatch, "/mock/1", strings.NewReader(`{"name": null, "email": null}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		var patch dto.CustomerPatch
+		err := httpserver.DecodeMergePatchBody(httptest.NewRecorder(), req, &patch)
+
+		var businessError *responses.BusinessResponse
+		assert.True(t, errors.As(err, &businessError))
+		assert.Equal(t, responses.CodeValidationFailed, businessError.Code)
+		assert.Equal(t, []responses.FieldError{
+			{Pointer: "/email", Rule: "required", Message: "is required"},
+			{Pointer: "/name", Rule: "required", Message: "is required"},
+		}, businessError.Errors)
+	})
+
+	t.Run("got malformed body when calling DecodeMergePatchBody with array", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPatch, "/mock/1", strings.NewReader(`[{"name": "Name"}]`))
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+
+		var patch dto.CustomerPatch
+		err := httpserver.DecodeMergePatchBody(httptest.NewRecorder(), req, &patch)
+
+		var businessError *responses.BusinessResponse
+		assert.True(t, errors.As(err, &businessError))
+		assert.Equal(t, responses.CodeMalformedBody, businessError.Code)
+	})
+
+	t.Run("got only present fields when calling DecodeMergePatchBody", func(t *testing.T) {
+		t.Parallel()
+
+		req := httptest.NewRequest(http.MethodPatch, "/mock/1", strings.NewReader(`{"name": "Name"}`))
+		req.Header.Set("Content-Type", "application/merge-patch+json; charset=utf-8")
+
+		var patch dto.CustomerPatch
+		err := httpserver.DecodeMergePatchBody(httptest.NewRecorder(), req, &patch)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Name", *patch.Name)
+		assert.Nil(t, patch.Email)
+		assert.Nil(t, patch.CPF)
+	})
 }
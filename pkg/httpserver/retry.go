@@ -0,0 +1,171 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+)
+
+// _maxDrainBytes is how much of a retried response body is read so its
+// connection can be reused
+const _maxDrainBytes = 4096
+
+// retryTransport retries idempotent requests on network errors and on the
+// responses that ask for a retry. Requests with the Idempotency-Key header are
+// handled as idempotent, whatever their method
+type retryTransport struct {
+	next          http.RoundTripper
+	maxRetries    int
+	baseDelay     time.Duration
+	maxDelay      time.Duration
+	maxRetryAfter time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryableRequest(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	attemptReq := req
+
+	for retry := 0; ; retry++ {
+		response, err := t.next.RoundTrip(attemptReq)
+
+		if retry == t.maxRetries || !shouldRetry(ctx, response, err) {
+			return response, err
+		}
+
+		wait, ok := t.wait(retry, response)
+
+		if !ok {
+			return response, err
+		}
+
+		if response != nil {
+			io.CopyN(io.Discard, response.Body, _maxDrainBytes)
+			response.Body.Close()
+		}
+
+		logger.FromContext(ctx).DebugContext(
+			ctx,
+			"retrying request",
+			slog.String("method", req.Method),
+			slog.String("host", req.URL.Host),
+			slog.Int("retry", retry+1),
+			slog.Duration("wait", wait),
+		)
+
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		attemptReq, err = rewind(req)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait returns how long to wait before the retry. It uses the Retry-After
+// header when the response has one and a jittered exponential backoff
+// otherwise. It returns false when the Retry-After is longer than allowed
+func (t *retryTransport) wait(retry int, response *http.Response) (time.Duration, bool) {
+	if response != nil {
+		if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After"), time.Now()); ok {
+			return retryAfter, retryAfter <= t.maxRetryAfter
+		}
+	}
+
+	backoff := t.maxDelay
+
+	if shifted := t.baseDelay << retry; shifted > 0 && shifted < t.maxDelay {
+		backoff = shifted
+	}
+
+	if backoff <= 0 {
+		return 0, true
+	}
+
+	return rand.N(backoff), true
+}
+
+func retryableRequest(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func shouldRetry(ctx context.Context, response *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
+	}
+
+	switch response.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// parseRetryAfter parses the seconds or the HTTP date of a Retry-After header
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	date, err := http.ParseTime(value)
+
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
+
+// rewind returns a copy of the request with a new body for the retry
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+
+	if err != nil {
+		return nil, err
+	}
+
+	attemptReq := req.Clone(req.Context())
+	attemptReq.Body = body
+
+	return attemptReq, nil
+}
@@ -2,88 +2,174 @@ package httpserver
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"strconv"
 	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
 	_defaultReadTimeout     = 10 * time.Second
 	_defaultWriteTimeout    = 10 * time.Second
+	_defaultIdleTimeout     = 60 * time.Second
 	_defaultShutdownTimeout = 6 * time.Second
-	port                    = 3210
+	_defaultMaxHeaderBytes  = 1 << 20
+	_defaultPort            = "3210"
 )
 
 type Server struct {
 	server          *http.Server
 	notify          chan error
 	shutdownTimeout time.Duration
+	tlsCertFile     string
+	tlsKeyFile      string
+	h2c             bool
+	logger          *slog.Logger
 }
 
-func New(handler http.Handler) *Server {
+func New(handler http.Handler, opts ...Option) *Server {
 	httpServer := &http.Server{
-		Handler:      handler,
-		ReadTimeout:  _defaultReadTimeout,
-		WriteTimeout: _defaultWriteTimeout,
-		Addr:         fmt.Sprintf(":%d", port),
+		Handler:        handler,
+		ReadTimeout:    _defaultReadTimeout,
+		WriteTimeout:   _defaultWriteTimeout,
+		IdleTimeout:    _defaultIdleTimeout,
+		MaxHeaderBytes: _defaultMaxHeaderBytes,
+		Addr:           net.JoinHostPort("", _defaultPort),
 	}
 
 	s := &Server{
 		server:          httpServer,
 		notify:          make(chan error, 1),
 		shutdownTimeout: _defaultShutdownTimeout,
+		logger:          slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.server.ErrorLog = slog.NewLogLogger(s.logger.Handler(), slog.LevelError)
+
+	if s.h2c {
+		s.server.Handler = h2c.NewHandler(handler, &http2.Server{
+			IdleTimeout: s.server.IdleTimeout,
+		})
 	}
 
 	return s
 }
 
-func (s *Server) Start() {
-	go func() {
-		listener, err := net.Listen("tcp", s.server.Addr)
-
-		if err != nil {
-			//shutdown
-			err := s.Shutdown()
-			if err != nil {
-				log.Print("httpServer shutdown", map[string]interface{}{
-					"signal": err.Error(),
-				})
+// Validate checks the server configuration. It must be called before Start
+// so that an invalid configuration stops the application with a clear message
+func (s *Server) Validate() error {
+	var errs []error
+
+	host, port, err := net.SplitHostPort(s.server.Addr)
+
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid address %q: %w", s.server.Addr, err))
+	} else {
+		portNumber, err := strconv.Atoi(port)
+
+		if err != nil || portNumber < 1 || portNumber > 65535 {
+			errs = append(errs, fmt.Errorf("invalid port %q: must be a number between 1 and 65535", port))
+		}
+
+		if host != "" && net.ParseIP(host) == nil && host != "localhost" {
+			if _, err := net.LookupHost(host); err != nil {
+				errs = append(errs, fmt.Errorf("invalid bind host %q: %w", host, err))
 			}
 		}
+	}
 
-		log.Print("Fastfood Customers API has started")
+	if s.server.ReadTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("read timeout must be greater than zero, got %v", s.server.ReadTimeout))
+	}
 
-		s.notify <- s.server.Serve(listener)
-		close(s.notify)
-	}()
+	if s.server.WriteTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("write timeout must be greater than zero, got %v", s.server.WriteTimeout))
+	}
 
-	// Waiting signal
-	interrupt := make(chan os.Signal, 1)
-	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	if s.server.IdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("idle timeout must be greater than zero, got %v", s.server.IdleTimeout))
+	}
 
-	select {
-	case signalInterrupt := <-interrupt:
-		log.Print("signal interrupt received", map[string]interface{}{
-			"signal": signalInterrupt.String(),
-		})
-	case err := <-s.Notify():
-		log.Print("httpServer notify and error", map[string]interface{}{
-			"notify": err.Error(),
-		})
+	if s.shutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("shutdown timeout must be greater than zero, got %v", s.shutdownTimeout))
+	}
+
+	if s.server.MaxHeaderBytes <= 0 {
+		errs = append(errs, fmt.Errorf("max header bytes must be greater than zero, got %v", s.server.MaxHeaderBytes))
+	}
+
+	if (s.tlsCertFile == "") != (s.tlsKeyFile == "") {
+		errs = append(errs, errors.New("TLS needs both the certificate and the key files"))
+	}
+
+	for _, file := range []string{s.tlsCertFile, s.tlsKeyFile} {
+		if file == "" {
+			continue
+		}
+
+		if _, err := os.Stat(file); err != nil {
+			errs = append(errs, fmt.Errorf("TLS file %q is not readable: %w", file, err))
+		}
+	}
+
+	if s.h2c && s.isTLS() {
+		errs = append(errs, errors.New("h2c can not be enabled together with TLS"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Addr -.
+func (s *Server) Addr() string {
+	return s.server.Addr
+}
+
+func (s *Server) isTLS() bool {
+	return s.tlsCertFile != "" && s.tlsKeyFile != ""
+}
+
+func (s *Server) serve(listener net.Listener) error {
+	if s.isTLS() {
+		return s.server.ServeTLS(listener, s.tlsCertFile, s.tlsKeyFile)
 	}
 
-	// Shutdown
-	err := s.Shutdown()
+	return s.server.Serve(listener)
+}
+
+// Start listens and serves in background. A listen error, like an address in
+// use, is returned right away. The serve error is sent to Notify, which is
+// closed when the server stops
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+
 	if err != nil {
-		log.Print("httpServer shutdown", map[string]interface{}{
-			"notify": err.Error(),
-		})
+		return err
 	}
+
+	s.logger.Info("http server has started", slog.String("addr", listener.Addr().String()))
+
+	go func() {
+		err := s.serve(listener)
+
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.notify <- err
+		}
+
+		close(s.notify)
+	}()
+
+	return nil
 }
 
 // Notify -.
@@ -91,9 +177,10 @@ func (s *Server) Notify() <-chan error {
 	return s.notify
 }
 
-// Shutdown -.
-func (s *Server) Shutdown() error {
-	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+// Shutdown stops accepting connections and waits for the in flight requests
+// until ctx is done or the shutdown timeout has passed
+func (s *Server) Shutdown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
 	defer cancel()
 
 	return s.server.Shutdown(ctx)
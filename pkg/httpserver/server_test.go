@@ -1,8 +1,10 @@
 package httpserver_test
 
 import (
+	"context"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
@@ -36,11 +38,27 @@ func TestServer(t *testing.T) {
 			w.WriteHeader(http.StatusOK)
 		})
 
-		s := httpserver.New(responseHandler)
+		s := httpserver.New(responseHandler, httpserver.Address("127.0.0.1", "3298"))
+
+		err := s.Start()
+		assert.NoError(t, err)
 
-		err := s.Shutdown()
+		err = s.Shutdown(context.Background())
+		assert.NoError(t, err)
 
+		err, ok := <-s.Notify()
 		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("got error when starting http server with address in use", func(t *testing.T) {
+		first := httpserver.New(http.NotFoundHandler(), httpserver.Address("127.0.0.1", "3297"))
+		assert.NoError(t, first.Start())
+		defer first.Shutdown(context.Background())
+
+		second := httpserver.New(http.NotFoundHandler(), httpserver.Address("127.0.0.1", "3297"))
+
+		assert.Error(t, second.Start())
 	})
 
 	t.Run("got success when notifying http server", func(t *testing.T) {
@@ -58,4 +76,75 @@ func TestServer(t *testing.T) {
 
 		s.Notify()
 	})
+
+	t.Run("got success when validating default http server", func(t *testing.T) {
+		s := httpserver.New(http.NotFoundHandler())
+
+		assert.NoError(t, s.Validate())
+		assert.Equal(t, ":3210", s.Addr())
+	})
+
+	t.Run("got success when validating configured http server", func(t *testing.T) {
+		s := httpserver.New(
+			http.NotFoundHandler(),
+			httpserver.Address("127.0.0.1", "8080"),
+			httpserver.ReadTimeout(time.Second),
+			httpserver.WriteTimeout(time.Second),
+			httpserver.IdleTimeout(time.Minute),
+			httpserver.ShutdownTimeout(time.Second),
+			httpserver.MaxHeaderBytes(4096),
+			httpserver.H2C(true),
+		)
+
+		assert.NoError(t, s.Validate())
+		assert.Equal(t, "127.0.0.1:8080", s.Addr())
+	})
+
+	t.Run("got error when validating http server with invalid port", func(t *testing.T) {
+		s := httpserver.New(http.NotFoundHandler(), httpserver.Address("", "99999"))
+
+		err := s.Validate()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid port \"99999\"")
+	})
+
+	t.Run("got error when validating http server with invalid timeouts", func(t *testing.T) {
+		s := httpserver.New(
+			http.NotFoundHandler(),
+			httpserver.ReadTimeout(0),
+			httpserver.ShutdownTimeout(-time.Second),
+			httpserver.MaxHeaderBytes(0),
+		)
+
+		err := s.Validate()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "read timeout must be greater than zero")
+		assert.Contains(t, err.Error(), "shutdown timeout must be greater than zero")
+		assert.Contains(t, err.Error(), "max header bytes must be greater than zero")
+	})
+
+	t.Run("got error when validating http server with TLS cert without key", func(t *testing.T) {
+		s := httpserver.New(http.NotFoundHandler(), httpserver.TLS("cert.pem", ""))
+
+		err := s.Validate()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "TLS needs both the certificate and the key files")
+	})
+
+	t.Run("got error when validating http server with missing TLS files and h2c", func(t *testing.T) {
+		s := httpserver.New(
+			http.NotFoundHandler(),
+			httpserver.TLS("/not/found/cert.pem", "/not/found/key.pem"),
+			httpserver.H2C(true),
+		)
+
+		err := s.Validate()
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "is not readable")
+		assert.Contains(t, err.Error(), "h2c can not be enabled together with TLS")
+	})
 }
@@ -0,0 +1,154 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/klassmann/cpfcnpj"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+
+	cepRegex     = regexp.MustCompile(`^\d{5}-?\d{3}$`)
+	brPhoneRegex = regexp.MustCompile(`^(\+?55)?\(?[1-9]{2}\)?9?\d{4}-?\d{4}$`)
+	indexRegex   = regexp.MustCompile(`\[(\w+)\]`)
+)
+
+// ruleMessages has the client messages for each validation tag. The field name is
+// not part of the message because it is already in the JSON pointer
+var ruleMessages = map[string]string{
+	"required":  "is required",
+	"email":     "must be a valid email",
+	"cpf":       "must be a valid CPF",
+	"cnpj":      "must be a valid CNPJ",
+	"cep":       "must be a valid CEP",
+	"br_phone":  "must be a valid brazilian phone number with DDD",
+	"immutable": "can not be changed",
+	"min":       "must have at least %v characters",
+}
+
+// GetValidator returns the shared validator with the json field names, the
+// brazilian domain tags (cpf, cnpj, cep and br_phone) and the immutable tag
+// registered
+func GetValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+
+			if name == "-" {
+				return ""
+			}
+
+			if name == "" {
+				return field.Name
+			}
+
+			return name
+		})
+
+		validate.RegisterValidation("cpf", func(fl validator.FieldLevel) bool {
+			return cpfcnpj.ValidateCPF(cpfcnpj.Clean(fl.Field().String()))
+		})
+
+		validate.RegisterValidation("cnpj", func(fl validator.FieldLevel) bool {
+			return cpfcnpj.ValidateCNPJ(cpfcnpj.Clean(fl.Field().String()))
+		})
+
+		validate.RegisterValidation("cep", func(fl validator.FieldLevel) bool {
+			return cepRegex.MatchString(strings.ReplaceAll(fl.Field().String(), ".", ""))
+		})
+
+		validate.RegisterValidation("br_phone", func(fl validator.FieldLevel) bool {
+			phone := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(fl.Field().String())
+			return brPhoneRegex.MatchString(phone)
+		})
+
+		// immutable fails when a patch has the field, like the CPF that can not be
+		// changed after the sign up
+		validate.RegisterValidation("immutable", func(fl validator.FieldLevel) bool {
+			field := fl.Field()
+			return field.Kind() == reflect.Pointer && field.IsNil()
+		}, true)
+	})
+
+	return validate
+}
+
+// ValidateStruct runs the validator against dst and converts the failures into
+// one FieldError per invalid field
+func ValidateStruct(dst any) error {
+	err := GetValidator().Struct(dst)
+
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Error JSON required fields: %v", err.Error()),
+			Code:       responses.CodeValidationFailed,
+		}
+	}
+
+	fieldErrors := make([]responses.FieldError, 0, len(validationErrors))
+	messages := make([]string, 0, len(validationErrors))
+
+	for _, fieldError := range validationErrors {
+		pointer := toJSONPointer(fieldError.Namespace())
+		message, ok := ruleMessages[fieldError.Tag()]
+
+		if !ok {
+			message = fmt.Sprintf("failed on the '%v' rule", fieldError.Tag())
+		}
+
+		if strings.Contains(message, "%v") {
+			message = fmt.Sprintf(message, fieldError.Param())
+		}
+
+		fieldErrors = append(fieldErrors, responses.FieldError{
+			Pointer: pointer,
+			Rule:    fieldError.Tag(),
+			Message: message,
+		})
+
+		messages = append(messages, fmt.Sprintf("%v %v", pointer, message))
+	}
+
+	return &responses.BusinessResponse{
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("Error JSON required fields: %v", strings.Join(messages, "; ")),
+		Code:       responses.CodeValidationFailed,
+		Errors:     fieldErrors,
+	}
+}
+
+// toJSONPointer converts a validator namespace (Customer.address.lines[0]) into
+// a RFC 6901 JSON pointer (/address/lines/0). The root struct name is removed
+func toJSONPointer(namespace string) string {
+	parts := strings.Split(namespace, ".")
+
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+
+	for i, part := range parts {
+		part = strings.ReplaceAll(part, "~", "~0")
+		part = strings.ReplaceAll(part, "/", "~1")
+		parts[i] = indexRegex.ReplaceAllString(part, "/$1")
+	}
+
+	return "/" + strings.Join(parts, "/")
+}
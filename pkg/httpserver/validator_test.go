@@ -0,0 +1,142 @@
+package httpserver_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+type mockAddress struct {
+	CEP string `json:"cep" validate:"required,cep"`
+}
+
+type mockCompany struct {
+	CNPJ      string        `json:"cnpj" validate:"required,cnpj"`
+	Phone     string        `json:"phone" validate:"required,br_phone"`
+	Addresses []mockAddress `json:"addresses" validate:"dive"`
+}
+
+func TestValidator(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got success when validating valid customer", func(t *testing.T) {
+		t.Parallel()
+
+		err := httpserver.ValidateStruct(&dto.Customer{
+			Name:  "Name",
+			CPF:   "832.124.462-93",
+			Email: "teste@teste.com",
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("got field errors when validating invalid customer", func(t *testing.T) {
+		t.Parallel()
+
+		err := httpserver.ValidateStruct(&dto.Customer{
+			CPF:   "12345678910",
+			Email: "teste.com",
+		})
+
+		assert.Error(t, err)
+
+		var businessError *responses.BusinessResponse
+		assert.True(t, errors.As(err, &businessError))
+		assert.Equal(t, http.StatusBadRequest, businessError.StatusCode)
+		assert.Equal(t, responses.CodeValidationFailed, businessError.Code)
+		assert.Equal(t, []responses.FieldError{
+			{Pointer: "/name", Rule: "required", Message: "is required"},
+			{Pointer: "/cpf", Rule: "cpf", Message: "must be a valid CPF"},
+			{Pointer: "/email", Rule: "email", Message: "must be a valid email"},
+		}, businessError.Errors)
+	})
+
+	t.Run("got field errors when validating invalid user admin", func(t *testing.T) {
+		t.Parallel()
+
+		err := httpserver.ValidateStruct(&dto.UserAdmin{
+			Name:  "Name",
+			CPF:   "830.124.462-93",
+			Email: "teste@teste.com",
+		})
+
+		var businessError *responses.BusinessResponse
+		assert.True(t, errors.As(err, &businessError))
+		assert.Len(t, businessError.Errors, 1)
+		assert.Equal(t, "/cpf", businessError.Errors[0].Pointer)
+	})
+
+	t.Run("got success when validating valid cnpj, cep and br_phone", func(t *testing.T) {
+		t.Parallel()
+
+		err := httpserver.ValidateStruct(&mockCompany{
+			CNPJ:  "11.222.333/0001-81",
+			Phone: "+55 (11) 91234-5678",
+			Addresses: []mockAddress{
+				{CEP: "01310-100"},
+				{CEP: "01310100"},
+			},
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("got nested json pointers when validating invalid cnpj, cep and br_phone", func(t *testing.T) {
+		t.Parallel()
+
+		err := httpserver.ValidateStruct(&mockCompany{
+			CNPJ:  "11.222.333/0001-82",
+			Phone: "1234",
+			Addresses: []mockAddress{
+				{CEP: "01310-100"},
+				{CEP: "0131"},
+			},
+		})
+
+		var businessError *responses.BusinessResponse
+		assert.True(t, errors.As(err, &businessError))
+		assert.Equal(t, []responses.FieldError{
+			{Pointer: "/cnpj", Rule: "cnpj", Message: "must be a valid CNPJ"},
+			{Pointer: "/phone", Rule: "br_phone", Message: "must be a valid brazilian phone number with DDD"},
+			{Pointer: "/addresses/1/cep", Rule: "cep", Message: "must be a valid CEP"},
+		}, businessError.Errors)
+	})
+
+	t.Run("got field errors in problem response when decoding invalid body", func(t *testing.T) {
+		t.Parallel()
+
+		responseHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var destination dto.Customer
+			err := httpserver.DecodeJSONBody(w, r, &destination)
+
+			assert.Error(t, err)
+
+			httpserver.SendResponseError(w, r, err)
+		})
+
+		req := httptest.NewRequest(http.MethodPost, "/mock", strings.NewReader(`{"name": "Name", "cpf": "83212446293", "email": "invalid"}`))
+		req.Header.Add("Content-Type", "application/json")
+
+		recorder := httptest.NewRecorder()
+		responseHandler.ServeHTTP(recorder, req)
+
+		var problem responses.ProblemDetails
+		err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, responses.CodeValidationFailed, problem.Code)
+		assert.Equal(t, []responses.FieldError{
+			{Pointer: "/email", Rule: "email", Message: "must be a valid email"},
+		}, problem.Errors)
+	})
+}
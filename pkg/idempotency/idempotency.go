@@ -0,0 +1,321 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	_maxKeyLength        = 255
+	_maxBodyBytes        = 1048576
+	_defaultTTL          = 24 * time.Hour
+	_defaultLease        = 30 * time.Second
+	_defaultWaitTimeout  = 10 * time.Second
+	_defaultPollInterval = 50 * time.Millisecond
+)
+
+type config struct {
+	ttl          time.Duration
+	lease        time.Duration
+	waitTimeout  time.Duration
+	pollInterval time.Duration
+}
+
+type Option func(*config)
+
+// TTL is how long a response is replayed for the same key
+func TTL(ttl time.Duration) Option {
+	return func(c *config) {
+		c.ttl = ttl
+	}
+}
+
+// Lease is how long a key stays locked by a request that did not finish, like
+// when the instance crashed. It must be longer than the slowest request
+func Lease(lease time.Duration) Option {
+	return func(c *config) {
+		c.lease = lease
+	}
+}
+
+// WaitTimeout is how long a duplicate request waits for the first one to finish
+func WaitTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.waitTimeout = timeout
+	}
+}
+
+// PollInterval -.
+func PollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.pollInterval = interval
+	}
+}
+
+// Middleware makes POST requests with the Idempotency-Key header safe to retry.
+// The first request with a key is processed and its response is stored with a
+// fingerprint of the method, path and body. Duplicates get the stored response
+// with the Idempotent-Replayed header. A duplicate that arrives while the first
+// request is still running waits for it, so concurrent duplicates are serialized.
+//
+// A key reused with a different request gets 422. Server errors are not stored,
+// so the request can be retried with the same key. Requests without the header
+// are not changed
+func Middleware(store Store, opts ...Option) func(http.Handler) http.Handler {
+	cfg := &config{
+		ttl:          _defaultTTL,
+		lease:        _defaultLease,
+		waitTimeout:  _defaultWaitTimeout,
+		pollInterval: _defaultPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !validKey(key) {
+				httpserver.SendResponseError(w, r, &responses.BusinessResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    "Idempotency-Key header must have up to 255 visible ASCII characters",
+					Code:       responses.CodeBadRequest,
+				})
+				return
+			}
+
+			fingerprint, err := fingerprintRequest(w, r)
+
+			var maxBytesError *http.MaxBytesError
+			if errors.As(err, &maxBytesError) {
+				httpserver.SendResponseError(w, r, &responses.BusinessResponse{
+					StatusCode: http.StatusRequestEntityTooLarge,
+					Message:    "Request body must not be larger than 1MB",
+					Code:       responses.CodePayloadTooLarge,
+				})
+				return
+			}
+
+			if err != nil {
+				httpserver.SendBadRequestError(w, r, err)
+				return
+			}
+
+			record, acquired, err := acquire(r.Context(), store, cfg, key, fingerprint)
+
+			if err != nil {
+				logger.RequestError(r.Context(), "acquire idempotency key", err, httpserver.GetStatusCodeFromError(err))
+				httpserver.SendResponseError(w, r, err)
+				return
+			}
+
+			if !acquired {
+				replay(w, record)
+				return
+			}
+
+			process(w, r, next, store, cfg, record)
+		})
+	}
+}
+
+// acquire waits until the key is acquired by this request or has a completed
+// response with the same fingerprint
+func acquire(ctx context.Context, store Store, cfg *config, key string, fingerprint string) (Record, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.waitTimeout)
+	defer cancel()
+
+	for {
+		record, acquired, err := store.Acquire(ctx, key, fingerprint, time.Now().Add(cfg.lease))
+
+		if err != nil {
+			return Record{}, false, responses.GetResponseError(err, "IdempotencyService")
+		}
+
+		if acquired {
+			return record, true, nil
+		}
+
+		if record.Fingerprint != "" && record.Fingerprint != fingerprint {
+			return Record{}, false, &responses.BusinessResponse{
+				StatusCode: http.StatusUnprocessableEntity,
+				Message:    "Idempotency-Key reused with a different request",
+				Code:       responses.CodeIdempotencyKeyReused,
+			}
+		}
+
+		if record.Completed() {
+			return record, false, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return Record{}, false, &responses.BusinessResponse{
+				StatusCode: http.StatusConflict,
+				Message:    "Request with the same Idempotency-Key is in progress",
+				Code:       responses.CodeIdempotencyInFlight,
+			}
+		case <-time.After(cfg.pollInterval):
+		}
+	}
+}
+
+// process runs the handler and stores its response. The key is released when
+// the handler fails with a server error or panics. When the handler outlives
+// the lease, another request may own the key: its record is left untouched
+// and the lost lease is logged
+func process(w http.ResponseWriter, r *http.Request, next http.Handler, store Store, cfg *config, record Record) {
+	// the response is stored even if the client has gone away, which is
+	// exactly the case of a retry after a timeout
+	ctx := context.WithoutCancel(r.Context())
+	completed := false
+
+	defer func() {
+		if !completed {
+			release(ctx, store, cfg, record)
+		}
+	}()
+
+	var body bytes.Buffer
+	ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	ww.Tee(&body)
+
+	next.ServeHTTP(ww, r)
+
+	status := ww.Status()
+
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	if status >= http.StatusInternalServerError {
+		return
+	}
+
+	record.StatusCode = status
+	record.ContentType = ww.Header().Get("Content-Type")
+	record.Body = body.Bytes()
+
+	err := store.Complete(ctx, record, time.Now().Add(cfg.ttl))
+
+	if errors.Is(err, ErrLeaseLost) {
+		completed = true
+		logLeaseLost(ctx, record, cfg)
+		return
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).ErrorContext(ctx, "complete idempotency key", slog.String("error", err.Error()))
+		return
+	}
+
+	completed = true
+}
+
+func release(ctx context.Context, store Store, cfg *config, record Record) {
+	err := store.Release(ctx, record)
+
+	if errors.Is(err, ErrLeaseLost) {
+		logLeaseLost(ctx, record, cfg)
+		return
+	}
+
+	if err != nil {
+		logger.FromContext(ctx).ErrorContext(ctx, "release idempotency key", slog.String("error", err.Error()))
+	}
+}
+
+// logLeaseLost reports a request that took longer than the lease, so a
+// duplicate may have been processed too. The lease must be raised above it
+func logLeaseLost(ctx context.Context, record Record, cfg *config) {
+	logger.FromContext(ctx).WarnContext(ctx, "idempotency key lease lost",
+		slog.String("fingerprint", record.Fingerprint),
+		slog.Duration("lease", cfg.lease),
+	)
+}
+
+func replay(w http.ResponseWriter, record Record) {
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// fingerprintRequest hashes the method, path and body, so the request body with
+// the customer data is never stored. The body is restored for the handler
+func fingerprintRequest(w http.ResponseWriter, r *http.Request) (string, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, _maxBodyBytes))
+
+	if err != nil {
+		return "", err
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + "\n" + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func validKey(key string) bool {
+	if len(key) > _maxKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Purge deletes the expired records every interval until ctx is done
+func Purge(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := store.DeleteExpired(ctx, now)
+
+			if err != nil {
+				slog.ErrorContext(ctx, "purge idempotency keys", slog.String("error", err.Error()))
+				continue
+			}
+
+			slog.DebugContext(ctx, "purge idempotency keys", slog.Int64("deleted", deleted))
+		}
+	}
+}
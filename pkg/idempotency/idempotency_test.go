@@ -0,0 +1,259 @@
+package idempotency_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/idempotency"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+func mockSignUpHandler(calls *atomic.Int32, status int, delay time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := calls.Add(1)
+		time.Sleep(delay)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id":` + string('0'+rune(id)) + `}`))
+	})
+}
+
+func doSignUp(handler http.Handler, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/auth/signup", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got replayed response when calling Middleware twice with same key", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(idempotency.NewMemoryStore())(mockSignUpHandler(&calls, http.StatusOK, 0))
+
+		first := doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+		second := doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+		assert.Empty(t, first.Header().Get(idempotency.HeaderReplayed))
+		assert.Equal(t, "true", second.Header().Get(idempotency.HeaderReplayed))
+	})
+
+	t.Run("got handler called every time when calling Middleware without key", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(idempotency.NewMemoryStore())(mockSignUpHandler(&calls, http.StatusOK, 0))
+
+		doSignUp(handler, "", `{"cpf":"83212446293"}`)
+		doSignUp(handler, "", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("got unprocessable entity when calling Middleware with same key and other body", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(idempotency.NewMemoryStore())(mockSignUpHandler(&calls, http.StatusOK, 0))
+
+		doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+		second := doSignUp(handler, "KEY-1", `{"cpf":"17107972073"}`)
+
+		assert.Equal(t, int32(1), calls.Load())
+		assert.Equal(t, http.StatusUnprocessableEntity, second.Code)
+		assert.Contains(t, second.Body.String(), string(responses.CodeIdempotencyKeyReused))
+	})
+
+	t.Run("got one handler call when calling Middleware with concurrent duplicates", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(
+			idempotency.NewMemoryStore(),
+			idempotency.PollInterval(5*time.Millisecond),
+		)(mockSignUpHandler(&calls, http.StatusOK, 50*time.Millisecond))
+
+		var wg sync.WaitGroup
+		recorders := make([]*httptest.ResponseRecorder, 5)
+
+		for i := range recorders {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+				recorders[i] = doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+			}(i)
+		}
+
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+
+		for _, recorder := range recorders {
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, `{"id":1}`, recorder.Body.String())
+		}
+	})
+
+	t.Run("got conflict when calling Middleware while first request exceeds wait timeout", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(
+			idempotency.NewMemoryStore(),
+			idempotency.WaitTimeout(20*time.Millisecond),
+			idempotency.PollInterval(5*time.Millisecond),
+		)(mockSignUpHandler(&calls, http.StatusOK, 200*time.Millisecond))
+
+		go doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+		time.Sleep(20 * time.Millisecond)
+
+		second := doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, http.StatusConflict, second.Code)
+		assert.Contains(t, second.Body.String(), string(responses.CodeIdempotencyInFlight))
+	})
+
+	t.Run("got handler called again when calling Middleware after server error", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(idempotency.NewMemoryStore())(mockSignUpHandler(&calls, http.StatusServiceUnavailable, 0))
+
+		doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+		second := doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, int32(2), calls.Load())
+		assert.Empty(t, second.Header().Get(idempotency.HeaderReplayed))
+	})
+
+	t.Run("got key released when handler panics calling Middleware", func(t *testing.T) {
+		t.Parallel()
+
+		store := idempotency.NewMemoryStore()
+		handler := idempotency.Middleware(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("mock panic")
+		}))
+
+		assert.Panics(t, func() {
+			doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+		})
+
+		_, acquired, err := store.Acquire(context.Background(), "KEY-1", "OTHER", time.Now().Add(time.Minute))
+
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+	})
+
+	t.Run("got response of new owner kept when calling Middleware with first request outliving lease", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(
+			idempotency.NewMemoryStore(),
+			idempotency.Lease(20*time.Millisecond),
+			idempotency.PollInterval(5*time.Millisecond),
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := calls.Add(1)
+
+			if id == 1 {
+				time.Sleep(80 * time.Millisecond)
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"id":` + string('0'+rune(id)) + `}`))
+		}))
+
+		first := make(chan *httptest.ResponseRecorder)
+
+		go func() {
+			first <- doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+		}()
+
+		time.Sleep(40 * time.Millisecond)
+
+		second := doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, `{"id":2}`, second.Body.String())
+		assert.Equal(t, `{"id":1}`, (<-first).Body.String())
+
+		third := doSignUp(handler, "KEY-1", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, "true", third.Header().Get(idempotency.HeaderReplayed))
+		assert.Equal(t, `{"id":2}`, third.Body.String())
+	})
+
+	t.Run("got lease lost when calling MemoryStore Complete and Release with other owner", func(t *testing.T) {
+		t.Parallel()
+
+		store := idempotency.NewMemoryStore()
+
+		first, acquired, err := store.Acquire(context.Background(), "KEY-1", "FINGERPRINT", time.Now().Add(-time.Second))
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+
+		second, acquired, err := store.Acquire(context.Background(), "KEY-1", "FINGERPRINT", time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.NotEqual(t, first.Owner, second.Owner)
+
+		current, acquired, err := store.Acquire(context.Background(), "KEY-1", "FINGERPRINT", time.Now().Add(time.Minute))
+		assert.NoError(t, err)
+		assert.False(t, acquired)
+		assert.Empty(t, current.Owner)
+
+		first.StatusCode = http.StatusOK
+
+		assert.ErrorIs(t, store.Complete(context.Background(), first, time.Now().Add(time.Hour)), idempotency.ErrLeaseLost)
+		assert.ErrorIs(t, store.Release(context.Background(), first), idempotency.ErrLeaseLost)
+		assert.NoError(t, store.Release(context.Background(), second))
+	})
+
+	t.Run("got bad request when calling Middleware with invalid key", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int32
+		handler := idempotency.Middleware(idempotency.NewMemoryStore())(mockSignUpHandler(&calls, http.StatusOK, 0))
+
+		recorder := doSignUp(handler, "KEY WITH SPACES", `{"cpf":"83212446293"}`)
+
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, int32(0), calls.Load())
+	})
+
+	t.Run("got expired records deleted when calling MemoryStore DeleteExpired", func(t *testing.T) {
+		t.Parallel()
+
+		store := idempotency.NewMemoryStore()
+		store.Acquire(context.Background(), "KEY-1", "FINGERPRINT", time.Now().Add(-time.Second))
+		store.Acquire(context.Background(), "KEY-2", "FINGERPRINT", time.Now().Add(time.Minute))
+
+		deleted, err := store.DeleteExpired(context.Background(), time.Now())
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), deleted)
+	})
+}
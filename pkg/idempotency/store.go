@@ -0,0 +1,164 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrLeaseLost is returned by Complete and Release when the record is no longer
+// owned by the caller: its lease expired and another request acquired the key
+var ErrLeaseLost = errors.New("idempotency key lease lost")
+
+// Record is a stored request. StatusCode is 0 while the first request with the
+// key is still being processed. Owner is the token of the request that
+// acquired the key; it is set only in the record returned to that request
+type Record struct {
+	Key         string
+	Fingerprint string
+	Owner       string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func (r Record) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// Store keeps the records shared by all the instances of the application. Every
+// record has an expiration: the lease of an in progress record or the TTL of a
+// completed one. An expired record is handled as if it did not exist
+type Store interface {
+	// Acquire creates an in progress record for the key, which expires at
+	// leaseUntil, with a new Owner token. When the key is in use it returns
+	// acquired false and the current record. It must be atomic, so only one
+	// request acquires a key
+	Acquire(ctx context.Context, key string, fingerprint string, leaseUntil time.Time) (record Record, acquired bool, err error)
+
+	// Complete stores the response of the request that acquired the key. It
+	// returns ErrLeaseLost when record.Owner no longer owns the key
+	Complete(ctx context.Context, record Record, expiresAt time.Time) error
+
+	// Release removes an in progress record, so the request can be retried.
+	// It returns ErrLeaseLost when record.Owner no longer owns the key
+	Release(ctx context.Context, record Record) error
+
+	// DeleteExpired removes the records expired before now
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type memoryRecord struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// MemoryStore is a Store for a single instance and for tests
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]memoryRecord
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records: map[string]memoryRecord{},
+	}
+}
+
+func (s *MemoryStore) Acquire(ctx context.Context, key string, fingerprint string, leaseUntil time.Time) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.records[key]
+
+	if ok && current.expiresAt.After(time.Now()) {
+		record := current.record
+		record.Owner = ""
+
+		return record, false, nil
+	}
+
+	owner, err := NewOwner()
+
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	record := Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		Owner:       owner,
+	}
+
+	s.records[key] = memoryRecord{
+		record:    record,
+		expiresAt: leaseUntil,
+	}
+
+	return record, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, record Record, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.records[record.Key]
+
+	if !ok || current.record.Owner != record.Owner {
+		return ErrLeaseLost
+	}
+
+	s.records[record.Key] = memoryRecord{
+		record:    record,
+		expiresAt: expiresAt,
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.records[record.Key]
+
+	if !ok || current.record.Owner != record.Owner || current.record.Completed() {
+		return ErrLeaseLost
+	}
+
+	delete(s.records, record.Key)
+
+	return nil
+}
+
+func (s *MemoryStore) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+
+	for key, current := range s.records {
+		if !current.expiresAt.After(now) {
+			delete(s.records, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// NewOwner returns a random owner token for an acquired record
+func NewOwner() (string, error) {
+	var token [16]byte
+
+	_, err := rand.Read(token[:])
+
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token[:]), nil
+}
@@ -0,0 +1,191 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+const _defaultShutdownTimeout = 15 * time.Second
+
+// Server is a listener that serves in background, like httpserver.Server and
+// grpcserver.Server
+type Server interface {
+	// Start listens and returns the listen error right away
+	Start() error
+
+	// Notify receives the error that stopped the server. It is closed, without
+	// an error, after Shutdown
+	Notify() <-chan error
+
+	Shutdown(ctx context.Context) error
+}
+
+type component struct {
+	name  string
+	start func(fatal chan<- error) error
+	stop  func(ctx context.Context) error
+}
+
+// Manager owns the listeners, the background jobs and the resources of the
+// application. The components are started in the order they were added and
+// stopped in the reverse order, like deferred calls: the resources needed by
+// everything else, like the database, are added first
+type Manager struct {
+	components      []component
+	shutdownTimeout time.Duration
+	signals         []os.Signal
+	logger          *slog.Logger
+}
+
+func New(opts ...Option) *Manager {
+	m := &Manager{
+		shutdownTimeout: _defaultShutdownTimeout,
+		signals:         []os.Signal{os.Interrupt, syscall.SIGTERM},
+		logger:          slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Server adds a listener. An error sent to its Notify stops the application
+func (m *Manager) Server(name string, server Server) {
+	m.components = append(m.components, component{
+		name: name,
+		start: func(fatal chan<- error) error {
+			err := server.Start()
+
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				if err, ok := <-server.Notify(); ok && err != nil {
+					fatal <- fmt.Errorf("%s: %w", name, err)
+				}
+			}()
+
+			return nil
+		},
+		stop: server.Shutdown,
+	})
+}
+
+// Job adds a background job that runs until its context is canceled. A job
+// that returns an error before that stops the application
+func (m *Manager) Job(name string, job func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	m.components = append(m.components, component{
+		name: name,
+		start: func(fatal chan<- error) error {
+			go func() {
+				defer close(done)
+
+				err := job(ctx)
+
+				if err != nil && ctx.Err() == nil {
+					fatal <- fmt.Errorf("%s: %w", name, err)
+				}
+			}()
+
+			return nil
+		},
+		stop: func(stopCtx context.Context) error {
+			cancel()
+
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// OnShutdown adds a function called during the shutdown, in the position it
+// was added. Used to close resources, like the database connection pool
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.components = append(m.components, component{
+		name: name,
+		stop: fn,
+	})
+}
+
+// Run starts every component and blocks until ctx is done, a shutdown signal
+// is received or a component fails. Then it stops the started components. It
+// returns the start or the fatal error joined with the shutdown errors, or nil
+// after a clean shutdown
+func (m *Manager) Run(ctx context.Context) error {
+	ctx, stopSignals := signal.NotifyContext(ctx, m.signals...)
+	defer stopSignals()
+
+	fatal := make(chan error, len(m.components))
+	started := 0
+
+	var err error
+
+	for _, c := range m.components {
+		if c.start != nil {
+			if startErr := c.start(fatal); startErr != nil {
+				err = fmt.Errorf("start %s: %w", c.name, startErr)
+				break
+			}
+		}
+
+		started++
+	}
+
+	if err == nil {
+		m.logger.Info("application has started")
+
+		select {
+		case <-ctx.Done():
+			m.logger.Info("shutdown requested", slog.String("cause", context.Cause(ctx).Error()))
+		case err = <-fatal:
+		}
+	}
+
+	if err != nil {
+		m.logger.Error("application failed", slog.Any("error", err))
+	}
+
+	return errors.Join(err, m.shutdown(started))
+}
+
+// shutdown stops the first started components in the reverse order. A
+// component is stopped even after the timeout, so the resources are released
+func (m *Manager) shutdown(started int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), m.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+
+	for i := started - 1; i >= 0; i-- {
+		c := m.components[i]
+		start := time.Now()
+
+		err := c.stop(ctx)
+
+		if err != nil {
+			m.logger.Error("component shutdown", slog.String("component", c.name), slog.Any("error", err))
+			errs = append(errs, fmt.Errorf("stop %s: %w", c.name, err))
+			continue
+		}
+
+		m.logger.Info("component stopped", slog.String("component", c.name), slog.Duration("duration", time.Since(start)))
+	}
+
+	return errors.Join(errs...)
+}
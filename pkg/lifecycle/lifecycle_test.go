@@ -0,0 +1,213 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/lifecycle"
+)
+
+// recorder keeps the order the components were stopped in
+type recorder struct {
+	mu      sync.Mutex
+	stopped []string
+}
+
+func (r *recorder) add(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.stopped = append(r.stopped, name)
+}
+
+func (r *recorder) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string{}, r.stopped...)
+}
+
+type mockServer struct {
+	name     string
+	recorder *recorder
+	startErr error
+	notify   chan error
+}
+
+func newMockServer(name string, recorder *recorder) *mockServer {
+	return &mockServer{
+		name:     name,
+		recorder: recorder,
+		notify:   make(chan error, 1),
+	}
+}
+
+func (s *mockServer) Start() error {
+	return s.startErr
+}
+
+func (s *mockServer) Notify() <-chan error {
+	return s.notify
+}
+
+func (s *mockServer) Shutdown(ctx context.Context) error {
+	s.recorder.add(s.name)
+	close(s.notify)
+
+	return nil
+}
+
+func TestManager(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got components stopped in reverse order when calling Run with canceled context", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &recorder{}
+		manager := lifecycle.New(lifecycle.Signals(syscall.SIGUSR2))
+
+		jobStarted := make(chan struct{})
+
+		manager.OnShutdown("database", func(ctx context.Context) error {
+			recorder.add("database")
+			return nil
+		})
+		manager.Job("purge", func(ctx context.Context) error {
+			close(jobStarted)
+			<-ctx.Done()
+			recorder.add("purge")
+
+			return ctx.Err()
+		})
+		manager.Server("docs", newMockServer("docs", recorder))
+		manager.Server("api", newMockServer("api", recorder))
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			<-jobStarted
+			cancel()
+		}()
+
+		err := manager.Run(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"api", "docs", "purge", "database"}, recorder.names())
+	})
+
+	t.Run("got started components stopped when calling Run with listen error", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &recorder{}
+		manager := lifecycle.New(lifecycle.Signals(syscall.SIGUSR2))
+
+		api := newMockServer("api", recorder)
+		api.startErr = errors.New("address already in use")
+
+		manager.OnShutdown("database", func(ctx context.Context) error {
+			recorder.add("database")
+			return nil
+		})
+		manager.Server("docs", newMockServer("docs", recorder))
+		manager.Server("api", api)
+		manager.Server("grpc", newMockServer("grpc", recorder))
+
+		err := manager.Run(context.Background())
+
+		assert.ErrorContains(t, err, "start api: address already in use")
+		assert.Equal(t, []string{"docs", "database"}, recorder.names())
+	})
+
+	t.Run("got first fatal error when calling Run with failing server", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &recorder{}
+		manager := lifecycle.New(lifecycle.Signals(syscall.SIGUSR2))
+
+		docs := newMockServer("docs", recorder)
+		docs.notify <- errors.New("mock serve error")
+
+		manager.Server("docs", docs)
+		manager.Server("api", newMockServer("api", recorder))
+
+		err := manager.Run(context.Background())
+
+		assert.ErrorContains(t, err, "docs: mock serve error")
+		assert.Equal(t, []string{"api", "docs"}, recorder.names())
+	})
+
+	t.Run("got fatal error when calling Run with failing job", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &recorder{}
+		manager := lifecycle.New(lifecycle.Signals(syscall.SIGUSR2))
+
+		manager.Job("purge", func(ctx context.Context) error {
+			return errors.New("mock job error")
+		})
+		manager.Server("api", newMockServer("api", recorder))
+
+		err := manager.Run(context.Background())
+
+		assert.ErrorContains(t, err, "purge: mock job error")
+		assert.Equal(t, []string{"api"}, recorder.names())
+	})
+
+	t.Run("got last component stopped after timeout when calling Run with slow component", func(t *testing.T) {
+		t.Parallel()
+
+		recorder := &recorder{}
+		manager := lifecycle.New(
+			lifecycle.Signals(syscall.SIGUSR2),
+			lifecycle.ShutdownTimeout(20*time.Millisecond),
+		)
+
+		manager.OnShutdown("database", func(ctx context.Context) error {
+			recorder.add("database")
+			return nil
+		})
+		manager.OnShutdown("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := manager.Run(ctx)
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "stop slow")
+		assert.Equal(t, []string{"database"}, recorder.names())
+	})
+
+	t.Run("got shutdown when calling Run and receiving signal", func(t *testing.T) {
+		recorder := &recorder{}
+		manager := lifecycle.New(lifecycle.Signals(syscall.SIGUSR1))
+
+		started := make(chan struct{})
+
+		manager.Job("signal", func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+
+			return nil
+		})
+		manager.Server("api", newMockServer("api", recorder))
+
+		go func() {
+			<-started
+			syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+		}()
+
+		err := manager.Run(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"api"}, recorder.names())
+	})
+}
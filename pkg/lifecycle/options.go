@@ -0,0 +1,33 @@
+package lifecycle
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// Option -.
+type Option func(*Manager)
+
+// ShutdownTimeout is the time to stop every component. The components that
+// are still running when it passes are stopped with an expired context
+func ShutdownTimeout(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.shutdownTimeout = timeout
+	}
+}
+
+// Signals sets the signals that start the shutdown. The default is SIGINT and
+// SIGTERM
+func Signals(signals ...os.Signal) Option {
+	return func(m *Manager) {
+		m.signals = signals
+	}
+}
+
+// Logger -. The default is the slog default logger
+func Logger(logger *slog.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+)
+
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+
+	_defaultLevel  = "info"
+	_defaultFormat = FormatJSON
+)
+
+type contextKey int
+
+const (
+	loggerKey contextKey = iota
+	userKey
+)
+
+type config struct {
+	level  string
+	format string
+	output io.Writer
+}
+
+type Option func(*config)
+
+// Level -. It must be debug, info, warn or error
+func Level(level string) Option {
+	return func(c *config) {
+		c.level = level
+	}
+}
+
+// Format -. It must be json or text
+func Format(format string) Option {
+	return func(c *config) {
+		c.format = format
+	}
+}
+
+// Output -. The default is the stdout
+func Output(output io.Writer) Option {
+	return func(c *config) {
+		c.output = output
+	}
+}
+
+// New creates the application logger. Every attribute goes through the
+// redaction (see Redact) and every line logged with a request context has the
+// request ID, route, user and trace ID
+func New(opts ...Option) (*slog.Logger, error) {
+	c := &config{
+		level:  _defaultLevel,
+		format: _defaultFormat,
+		output: os.Stdout,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	var level slog.Level
+
+	err := level.UnmarshalText([]byte(c.level))
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid log level %q: it must be debug, info, warn or error", c.level)
+	}
+
+	handlerOpts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: Redact,
+	}
+
+	var handler slog.Handler
+
+	switch strings.ToLower(c.format) {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(c.output, handlerOpts)
+	case FormatText:
+		handler = slog.NewTextHandler(c.output, handlerOpts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q: it must be %v or %v", c.format, FormatJSON, FormatText)
+	}
+
+	return slog.New(&contextHandler{Handler: handler}), nil
+}
+
+// WithContext returns a copy of ctx with the logger
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, logger)
+}
+
+// FromContext returns the logger of the context or the default one
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// RequestError logs a failed request step. Client errors (4xx) are logged as
+// warnings and the others as errors
+func RequestError(ctx context.Context, msg string, err error, status int) {
+	level := slog.LevelError
+
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+
+	FromContext(ctx).Log(ctx, level, msg,
+		slog.Any("error", err),
+		slog.Int("status", status),
+	)
+}
+
+// contextHandler adds the request attributes of the context to every record.
+// The route is read when the line is logged because chi only knows the route
+// pattern after the routing
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := chiMiddleware.GetReqID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("requestId", requestID))
+	}
+
+	if rctx := chi.RouteContext(ctx); rctx != nil && rctx.RoutePattern() != "" {
+		record.AddAttrs(slog.String("route", rctx.RoutePattern()))
+	}
+
+	if user, ok := ctx.Value(userKey).(string); ok && user != "" {
+		record.AddAttrs(slog.String("user", user))
+	}
+
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String("traceId", traceID))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,179 @@
+package logger_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/logger"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func readLines(t *testing.T, buffer *bytes.Buffer) []map[string]any {
+	lines := []map[string]any{}
+
+	for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+		var entry map[string]any
+		err := json.Unmarshal([]byte(line), &entry)
+
+		assert.NoError(t, err)
+
+		lines = append(lines, entry)
+	}
+
+	return lines
+}
+
+func mockToken(subject string) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`))
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"` + subject + `","username":"83212446293"}`))
+
+	return header + "." + payload + ".signature"
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got masked cpf, email and token when logging sensitive values", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		log, err := logger.New(logger.Output(&buffer))
+		assert.NoError(t, err)
+
+		log.Info("create customer 832.124.462-93",
+			slog.Any("error", errors.New("duplicate key value (cpf)=(83212446293) for teste@teste.com")),
+			slog.String("header", "Bearer "+mockToken("sub")),
+			slog.String("cpf", "83212446293"),
+			slog.Group("form", slog.String("password", "secret")),
+		)
+
+		output := buffer.String()
+
+		assert.NotContains(t, output, "83212446293")
+		assert.NotContains(t, output, "832.124.462-93")
+		assert.NotContains(t, output, "teste@teste.com")
+		assert.NotContains(t, output, "secret")
+		assert.NotContains(t, output, "eyJ")
+
+		lines := readLines(t, &buffer)
+
+		assert.Equal(t, "create customer ***.***.***-**", lines[0]["msg"])
+		assert.Equal(t, "duplicate key value (cpf)=(***.***.***-**) for ***@***", lines[0]["error"])
+		assert.Equal(t, "Bearer [TOKEN]", lines[0]["header"])
+		assert.Equal(t, "[REDACTED]", lines[0]["cpf"])
+		assert.Equal(t, map[string]any{"password": "[REDACTED]"}, lines[0]["form"])
+	})
+
+	t.Run("got request attributes when logging with request context", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		log, err := logger.New(logger.Output(&buffer))
+		assert.NoError(t, err)
+
+		router := chi.NewRouter()
+		router.Use(chiMiddleware.RequestID)
+		router.Use(logger.Middleware(log))
+		router.Get("/mock/customers/{cpf}", func(w http.ResponseWriter, r *http.Request) {
+			logger.RequestError(r.Context(), "get customer", errors.New("not found"), http.StatusNotFound)
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/mock/customers/83212446293", nil)
+		req.Header.Set("X-Request-Id", "REQ-1")
+		req.Header.Set("Authorization", "Bearer "+mockToken("user-sub"))
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		lines := readLines(t, &buffer)
+
+		assert.Len(t, lines, 2)
+		assert.Equal(t, "WARN", lines[0]["level"])
+		assert.Equal(t, "get customer", lines[0]["msg"])
+		assert.Equal(t, float64(http.StatusNotFound), lines[0]["status"])
+
+		for _, line := range lines {
+			assert.Equal(t, "REQ-1", line["requestId"])
+			assert.Equal(t, "/mock/customers/{cpf}", line["route"])
+			assert.Equal(t, "user-sub", line["user"])
+		}
+
+		assert.Equal(t, "request completed", lines[1]["msg"])
+		assert.NotContains(t, buffer.String(), "83212446293")
+	})
+
+	t.Run("got trace id when logging with span context", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		log, err := logger.New(logger.Output(&buffer))
+		assert.NoError(t, err)
+
+		traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  spanID,
+		}))
+
+		log.InfoContext(ctx, "create customer")
+
+		lines := readLines(t, &buffer)
+
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", lines[0]["traceId"])
+	})
+
+	t.Run("got error level when calling RequestError with server error", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		log, err := logger.New(logger.Output(&buffer))
+		assert.NoError(t, err)
+
+		ctx := logger.WithContext(context.Background(), log)
+		logger.RequestError(ctx, "create customer", errors.New("connection refused"), http.StatusInternalServerError)
+
+		lines := readLines(t, &buffer)
+
+		assert.Equal(t, "ERROR", lines[0]["level"])
+	})
+
+	t.Run("got text output without debug lines when configuring level and format", func(t *testing.T) {
+		t.Parallel()
+
+		var buffer bytes.Buffer
+		log, err := logger.New(logger.Output(&buffer), logger.Level("warn"), logger.Format("text"))
+		assert.NoError(t, err)
+
+		log.Info("ignored")
+		log.Warn("create customer", slog.String("email", "teste@teste.com"))
+
+		assert.Equal(t, "level=WARN msg=\"create customer\" email=[REDACTED]", strings.TrimSpace(buffer.String()[strings.Index(buffer.String(), "level="):]))
+	})
+
+	t.Run("got error when configuring invalid level and format", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := logger.New(logger.Level("verbose"))
+		assert.Error(t, err)
+
+		_, err = logger.New(logger.Format("xml"))
+		assert.Error(t, err)
+	})
+
+	t.Run("got default logger when calling FromContext without logger", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, slog.Default(), logger.FromContext(context.Background()))
+	})
+}
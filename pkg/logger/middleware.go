@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	chiMiddleware "github.com/go-chi/chi/v5/middleware"
+)
+
+// Middleware puts the logger in the request context, identifies the user and
+// logs one line when the request is completed. It must be installed after the
+// chi RequestID middleware
+func Middleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx := WithContext(r.Context(), logger)
+
+			if user := subjectFromToken(r.Header.Get("Authorization")); user != "" {
+				ctx = context.WithValue(ctx, userKey, user)
+			}
+
+			ww := chiMiddleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			r = r.WithContext(ctx)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			logger.InfoContext(ctx, "request completed",
+				slog.String("method", r.Method),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Duration("duration", time.Since(start)),
+			)
+		})
+	}
+}
+
+// subjectFromToken returns the sub claim of the bearer JWT. The token is not
+// verified here, it is only used to identify the user in the logs. The sub is
+// used because the Cognito username is the CPF
+func subjectFromToken(authorization string) string {
+	token := strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return ""
+	}
+
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+
+	return claims.Subject
+}
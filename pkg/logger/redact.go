@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const (
+	redacted     = "[REDACTED]"
+	maskedCPF    = "***.***.***-**"
+	maskedEmail  = "***@***"
+	maskedToken  = "[TOKEN]"
+	maskedBearer = "Bearer " + maskedToken
+)
+
+var (
+	cpfRegex    = regexp.MustCompile(`\b\d{3}\.?\d{3}\.?\d{3}-?\d{2}\b`)
+	emailRegex  = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+	jwtRegex    = regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]*`)
+	bearerRegex = regexp.MustCompile(`(?i)bearer\s+\S+`)
+)
+
+// sensitiveKeys are the attribute keys whose values are never logged
+var sensitiveKeys = map[string]bool{
+	"cpf":           true,
+	"email":         true,
+	"password":      true,
+	"token":         true,
+	"accesstoken":   true,
+	"access_token":  true,
+	"authorization": true,
+	"secret":        true,
+}
+
+// Redact is the slog ReplaceAttr function of the application logger. The values
+// of the sensitive keys are replaced and the CPFs, emails and tokens found in any
+// other string (including the message and the errors) are masked. Values that are
+// not strings, numbers, booleans, times or durations are logged as strings so
+// they can be masked too
+func Redact(groups []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	value := attr.Value.Resolve()
+
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Mask(value.String()))
+	case slog.KindAny:
+		return slog.String(attr.Key, Mask(fmt.Sprintf("%+v", value.Any())))
+	}
+
+	return attr
+}
+
+// Mask replaces the tokens, emails and CPFs in the text
+func Mask(text string) string {
+	text = bearerRegex.ReplaceAllString(text, maskedBearer)
+	text = jwtRegex.ReplaceAllString(text, maskedToken)
+	text = emailRegex.ReplaceAllString(text, maskedEmail)
+	text = cpfRegex.ReplaceAllString(text, maskedCPF)
+
+	return text
+}
@@ -0,0 +1,16 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var cacheRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "cache",
+	Name:      "requests_total",
+	Help:      "Number of cache lookups by cache and result.",
+}, []string{"cache", "result"})
+
+// ObserveCache records one cache lookup. The result is hit, miss, coalesced
+// (a miss that waited for a concurrent one) or error
+func ObserveCache(cache string, result string) {
+	cacheRequestsTotal.WithLabelValues(cache, result).Inc()
+}
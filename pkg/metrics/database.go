@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+)
+
+const gormStartKey = "metrics:start"
+
+var dbQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Subsystem: "db",
+	Name:      "query_duration_seconds",
+	Help:      "Duration of the gorm operations by operation, table and status.",
+	Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+}, []string{"operation", "table", "status"})
+
+// GormPlugin records the duration of every gorm operation. The table label
+// comes from the models, so it is bounded
+type GormPlugin struct{}
+
+func NewGormPlugin() gorm.Plugin {
+	return &GormPlugin{}
+}
+
+func (p *GormPlugin) Name() string {
+	return "metrics"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	if err := callback.Create().Before("gorm:create").Register("metrics:before_create", before); err != nil {
+		return err
+	}
+
+	if err := callback.Create().After("gorm:create").Register("metrics:after_create", after("create")); err != nil {
+		return err
+	}
+
+	if err := callback.Query().Before("gorm:query").Register("metrics:before_query", before); err != nil {
+		return err
+	}
+
+	if err := callback.Query().After("gorm:query").Register("metrics:after_query", after("query")); err != nil {
+		return err
+	}
+
+	if err := callback.Update().Before("gorm:update").Register("metrics:before_update", before); err != nil {
+		return err
+	}
+
+	if err := callback.Update().After("gorm:update").Register("metrics:after_update", after("update")); err != nil {
+		return err
+	}
+
+	if err := callback.Delete().Before("gorm:delete").Register("metrics:before_delete", before); err != nil {
+		return err
+	}
+
+	if err := callback.Delete().After("gorm:delete").Register("metrics:after_delete", after("delete")); err != nil {
+		return err
+	}
+
+	if err := callback.Row().Before("gorm:row").Register("metrics:before_row", before); err != nil {
+		return err
+	}
+
+	if err := callback.Row().After("gorm:row").Register("metrics:after_row", after("row")); err != nil {
+		return err
+	}
+
+	if err := callback.Raw().Before("gorm:raw").Register("metrics:before_raw", before); err != nil {
+		return err
+	}
+
+	return callback.Raw().After("gorm:raw").Register("metrics:after_raw", after("raw"))
+}
+
+func before(db *gorm.DB) {
+	db.InstanceSet(gormStartKey, time.Now())
+}
+
+func after(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(gormStartKey)
+
+		if !ok {
+			return
+		}
+
+		start, ok := value.(time.Time)
+
+		if !ok {
+			return
+		}
+
+		status := "ok"
+
+		if db.Error != nil && db.Error != gorm.ErrRecordNotFound {
+			status = "error"
+		}
+
+		table := db.Statement.Table
+
+		if table == "" {
+			table = "unknown"
+		}
+
+		dbQueryDuration.WithLabelValues(operation, table, status).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	identityProviderDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "identity_provider",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the identity provider calls by operation and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "status"})
+
+	identityProviderErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "identity_provider",
+		Name:      "errors_total",
+		Help:      "Number of identity provider errors by operation and error code.",
+	}, []string{"operation", "code"})
+)
+
+// ObserveIdentityProvider records one identity provider call. The errorCode
+// must be empty on success and the provider error code (UsernameExistsException,
+// NotAuthorizedException, ...) otherwise. Never pass the error message, it may
+// have the CPF of the user
+func ObserveIdentityProvider(operation string, duration time.Duration, errorCode string) {
+	status := "ok"
+
+	if errorCode != "" {
+		status = "error"
+		identityProviderErrorsTotal.WithLabelValues(operation, errorCode).Inc()
+	}
+
+	identityProviderDuration.WithLabelValues(operation, status).Observe(duration.Seconds())
+}
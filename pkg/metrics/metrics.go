@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "tech1_customer"
+
+// Registry has every collector of the application. A dedicated registry is used
+// instead of the global one so that only the metrics below (plus the Go runtime
+// and process ones) are exposed
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequestsTotal,
+		httpRequestDuration,
+		httpRequestsInFlight,
+		grpcRequestsTotal,
+		grpcRequestDuration,
+		useCaseOutcomesTotal,
+		dbQueryDuration,
+		identityProviderDuration,
+		identityProviderErrorsTotal,
+		cacheRequestsTotal,
+		outboxMessagesTotal,
+		outboxPublishLag,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{
+		Registry: Registry,
+	})
+}
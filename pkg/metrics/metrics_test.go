@@ -0,0 +1,181 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func scrape(t *testing.T) string {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	recorder := httptest.NewRecorder()
+
+	metrics.Handler().ServeHTTP(recorder, req)
+
+	body, err := io.ReadAll(recorder.Body)
+	assert.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got route pattern label when calling Middleware", func(t *testing.T) {
+		t.Parallel()
+
+		router := chi.NewRouter()
+		router.Use(metrics.Middleware)
+		router.Get("/mock/customers/{cpf}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/mock/customers/83212446293", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_http_requests_total{method="GET",route="/mock/customers/{cpf}",status="404"} 1`)
+		assert.Contains(t, body, `tech1_customer_http_request_duration_seconds_count{method="GET",route="/mock/customers/{cpf}",status="404"} 1`)
+		assert.NotContains(t, body, "83212446293")
+	})
+
+	t.Run("got unmatched route label when calling Middleware with unknown path", func(t *testing.T) {
+		t.Parallel()
+
+		router := chi.NewRouter()
+		router.Use(metrics.Middleware)
+		router.Get("/mock/known", func(w http.ResponseWriter, r *http.Request) {})
+
+		req := httptest.NewRequest(http.MethodDelete, "/mock/unknown/12345", nil)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_http_requests_total{method="DELETE",route="unmatched",status="404"}`)
+		assert.NotContains(t, body, "/mock/unknown/12345")
+	})
+
+	t.Run("got outcomes when calling ObserveUseCase", func(t *testing.T) {
+		t.Parallel()
+
+		var success error
+		var invalidCPF error = &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
+		}
+		var conflict = responses.GetResponseError(&responses.LocalError{
+			Code:       responses.DATABASE_CONFLICT_ERROR,
+			Message:    "duplicate key",
+			Constraint: "uni_customers_cpf",
+		}, "CustomerService")
+
+		metrics.ObserveUseCase("MockCreateUseCase", &success)
+		metrics.ObserveUseCase("MockCreateUseCase", &invalidCPF)
+		metrics.ObserveUseCase("MockCreateUseCase", &conflict)
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_usecase_outcomes_total{outcome="success",usecase="MockCreateUseCase"} 1`)
+		assert.Contains(t, body, `tech1_customer_usecase_outcomes_total{outcome="cpf_invalid",usecase="MockCreateUseCase"} 1`)
+		assert.Contains(t, body, `tech1_customer_usecase_outcomes_total{outcome="cpf_taken",usecase="MockCreateUseCase"} 1`)
+	})
+
+	t.Run("got internal_error outcome when calling Outcome with unknown error", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Equal(t, "internal_error", metrics.Outcome(errors.New("pq: 83212446293 failed")))
+	})
+
+	t.Run("got latency and errors when calling ObserveIdentityProvider", func(t *testing.T) {
+		t.Parallel()
+
+		metrics.ObserveIdentityProvider("MockSignUp", 10*time.Millisecond, "")
+		metrics.ObserveIdentityProvider("MockSignUp", 10*time.Millisecond, "UsernameExistsException")
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_identity_provider_request_duration_seconds_count{operation="MockSignUp",status="ok"} 1`)
+		assert.Contains(t, body, `tech1_customer_identity_provider_request_duration_seconds_count{operation="MockSignUp",status="error"} 1`)
+		assert.Contains(t, body, `tech1_customer_identity_provider_errors_total{code="UsernameExistsException",operation="MockSignUp"} 1`)
+	})
+
+	t.Run("got lookups by result when calling ObserveCache", func(t *testing.T) {
+		t.Parallel()
+
+		metrics.ObserveCache("mock_cache", "hit")
+		metrics.ObserveCache("mock_cache", "hit")
+		metrics.ObserveCache("mock_cache", "miss")
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_cache_requests_total{cache="mock_cache",result="hit"} 2`)
+		assert.Contains(t, body, `tech1_customer_cache_requests_total{cache="mock_cache",result="miss"} 1`)
+	})
+
+	t.Run("got messages by result and lag of the published ones when calling ObserveOutbox", func(t *testing.T) {
+		t.Parallel()
+
+		metrics.ObserveOutbox("mock.created", "published", 2*time.Second)
+		metrics.ObserveOutbox("mock.created", "failed", 0)
+		metrics.ObserveOutbox("mock.created", "deferred", 0)
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_outbox_messages_total{result="published",type="mock.created"} 1`)
+		assert.Contains(t, body, `tech1_customer_outbox_messages_total{result="failed",type="mock.created"} 1`)
+		assert.Contains(t, body, `tech1_customer_outbox_messages_total{result="deferred",type="mock.created"} 1`)
+		assert.Contains(t, body, `tech1_customer_outbox_publish_lag_seconds_count 1`)
+	})
+
+	t.Run("got requests and duration when calling ObserveGRPC", func(t *testing.T) {
+		t.Parallel()
+
+		metrics.ObserveGRPC("/mock.Service/Get", 10*time.Millisecond, "NotFound")
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_grpc_requests_total{code="NotFound",method="/mock.Service/Get"} 1`)
+		assert.Contains(t, body, `tech1_customer_grpc_request_duration_seconds_count{code="NotFound",method="/mock.Service/Get"} 1`)
+	})
+
+	t.Run("got query duration when using GormPlugin", func(t *testing.T) {
+		t.Parallel()
+
+		conn, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		db, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_metrics",
+			DriverName:           "postgres",
+			Conn:                 conn,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{DisableAutomaticPing: true})
+		assert.NoError(t, err)
+
+		err = db.Use(metrics.NewGormPlugin())
+		assert.NoError(t, err)
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		var ids []int
+		err = db.Table("mock_metrics_table").Select("id").Find(&ids).Error
+		assert.NoError(t, err)
+
+		body := scrape(t)
+
+		assert.Contains(t, body, `tech1_customer_db_query_duration_seconds_count{operation="query",status="ok",table="mock_metrics_table"} 1`)
+	})
+}
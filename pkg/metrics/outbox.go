@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	outboxMessagesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "messages_total",
+		Help:      "Number of outbox messages handled by the relay by event type and result.",
+	}, []string{"type", "result"})
+
+	outboxPublishLag = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "publish_lag_seconds",
+		Help:      "Time from the change to the publication of its event.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300, 900},
+	})
+)
+
+// ObserveOutbox records one message handled by the relay. The result is
+// published, failed or deferred (a message that waits for a failed one with
+// the same key). lag is the time since the change, used only when published
+func ObserveOutbox(eventType string, result string, lag time.Duration) {
+	outboxMessagesTotal.WithLabelValues(eventType, result).Inc()
+
+	if result == "published" {
+		outboxPublishLag.Observe(lag.Seconds())
+	}
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+const OutcomeSuccess = "success"
+
+var useCaseOutcomesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Subsystem: "usecase",
+	Name:      "outcomes_total",
+	Help:      "Number of use case executions by outcome. The outcome is success or the error code (cpf_invalid, cpf_taken, ...).",
+}, []string{"usecase", "outcome"})
+
+// ObserveUseCase counts one execution of the use case. It is meant to be
+// deferred with a pointer to the named error result:
+//
+//	defer metrics.ObserveUseCase("CreateCustomerUseCase", &err)
+//
+// The outcome comes from the error code catalog, so the label values are bounded
+func ObserveUseCase(useCase string, err *error) {
+	useCaseOutcomesTotal.WithLabelValues(useCase, Outcome(*err)).Inc()
+}
+
+// Outcome converts the error into the outcome label value
+func Outcome(err error) string {
+	if err == nil {
+		return OutcomeSuccess
+	}
+
+	code := responses.NewProblemDetails(err, "", "").Code
+
+	return strings.ToLower(string(code))
+}
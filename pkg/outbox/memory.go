@@ -0,0 +1,140 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryMessage struct {
+	message     Message
+	publishedAt *time.Time
+	lastError   string
+}
+
+// MemoryStore is a Store for a single instance and for tests. The changes made
+// under Lock are applied as they are made, not when fn returns
+type MemoryStore struct {
+	lock     sync.Mutex
+	mu       sync.Mutex
+	sequence uint64
+	messages map[uint64]*memoryMessage
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		messages: map[uint64]*memoryMessage{},
+	}
+}
+
+// Append writes the messages to the outbox, in order, and returns them with
+// their sequences
+func (s *MemoryStore) Append(messages ...Message) []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	appended := make([]Message, 0, len(messages))
+
+	for _, message := range messages {
+		s.sequence++
+		message.Sequence = s.sequence
+		s.messages[message.Sequence] = &memoryMessage{message: message}
+		appended = append(appended, message)
+	}
+
+	return appended
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, fn func(ctx context.Context) error) (bool, error) {
+	if !s.lock.TryLock() {
+		return false, nil
+	}
+
+	defer s.lock.Unlock()
+
+	return true, fn(ctx)
+}
+
+func (s *MemoryStore) Pending(ctx context.Context, limit int) ([]Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := []Message{}
+
+	for _, stored := range s.messages {
+		if stored.publishedAt == nil {
+			pending = append(pending, stored.message)
+		}
+	}
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Sequence < pending[j].Sequence
+	})
+
+	failedKeys := map[string]bool{}
+	messages := []Message{}
+
+	for _, message := range pending {
+		if len(messages) == limit {
+			break
+		}
+
+		if failedKeys[message.Key] {
+			continue
+		}
+
+		if message.Attempts > 0 {
+			failedKeys[message.Key] = true
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (s *MemoryStore) MarkPublished(ctx context.Context, sequences []uint64, publishedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sequence := range sequences {
+		stored, ok := s.messages[sequence]
+
+		if ok {
+			stored.publishedAt = &publishedAt
+		}
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) MarkFailed(ctx context.Context, sequence uint64, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.messages[sequence]
+
+	if ok {
+		stored.message.Attempts++
+		stored.lastError = reason
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+
+	for sequence, stored := range s.messages {
+		if stored.publishedAt != nil && stored.publishedAt.Before(before) {
+			delete(s.messages, sequence)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
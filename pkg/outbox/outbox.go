@@ -0,0 +1,292 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/metrics"
+)
+
+const (
+	_defaultBatchSize    = 100
+	_defaultPollInterval = time.Second
+
+	// _maxFailureLength is the size of the publisher error kept with a
+	// message that could not be published
+	_maxFailureLength = 1000
+)
+
+// Message is an event written to the outbox, as it is published. ID is unique
+// and is how the consumers drop the duplicates of the at least once delivery.
+// The messages with the same Key, which is the entity of the event, are
+// published in the order they were written. Sequence is that order
+type Message struct {
+	Sequence      uint64          `json:"-"`
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Key           string          `json:"key"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Data          json.RawMessage `json:"data"`
+	Attempts      int             `json:"-"`
+}
+
+// NewMessage creates the message of an event that happened now, with a new ID
+// and data encoded as JSON
+func NewMessage(eventType string, schemaVersion int, key string, data any) (Message, error) {
+	encoded, err := json.Marshal(data)
+
+	if err != nil {
+		return Message{}, err
+	}
+
+	id, err := newID()
+
+	if err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		ID:            id,
+		Type:          eventType,
+		SchemaVersion: schemaVersion,
+		Key:           key,
+		OccurredAt:    time.Now().UTC().Truncate(time.Microsecond),
+		Data:          encoded,
+	}, nil
+}
+
+// Store keeps the messages written with the changes, until they are published
+type Store interface {
+	// Lock runs fn holding the relay lock, so a single relay of all the
+	// instances publishes at a time. The changes made with the context given
+	// to fn are committed together when it returns nil. When another relay
+	// holds the lock it returns acquired false without running fn
+	Lock(ctx context.Context, fn func(ctx context.Context) error) (acquired bool, err error)
+
+	// Pending returns the oldest messages not published yet, up to limit, in
+	// the order they were written. The messages behind a failed message with
+	// the same key are left out, so a key that keeps failing takes a single
+	// place of the batch and does not starve the other keys
+	Pending(ctx context.Context, limit int) ([]Message, error)
+
+	// MarkPublished marks the messages of the sequences as published at the
+	// given time
+	MarkPublished(ctx context.Context, sequences []uint64, publishedAt time.Time) error
+
+	// MarkFailed keeps the message pending and records the failed attempt
+	MarkFailed(ctx context.Context, sequence uint64, reason string) error
+
+	// DeletePublished removes the messages published before the given time
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RelayResult is what a relay of a batch did. Deferred are the messages not
+// published because an older message with the same key failed
+type RelayResult struct {
+	Published int
+	Failed    int
+	Deferred  int
+}
+
+type config struct {
+	batchSize    int
+	pollInterval time.Duration
+}
+
+type Option func(*config)
+
+// BatchSize is the maximum number of messages published in a relay lock
+func BatchSize(size int) Option {
+	return func(c *config) {
+		c.batchSize = size
+	}
+}
+
+// PollInterval is how often the relay looks for new messages when the last
+// batch was not full
+func PollInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.pollInterval = interval
+	}
+}
+
+// Relay publishes the messages of the outbox. A message is marked as published
+// only after the publisher accepts it, so it is published at least once: after
+// a crash or a failed commit it is published again.
+//
+// The messages are published in the order they were written. When a message
+// can not be published, the next ones with the same key wait for it, while the
+// other keys go on, so the events of a customer never arrive out of order
+type Relay struct {
+	store     Store
+	publisher Publisher
+	cfg       *config
+}
+
+func NewRelay(store Store, publisher Publisher, opts ...Option) *Relay {
+	cfg := &config{
+		batchSize:    _defaultBatchSize,
+		pollInterval: _defaultPollInterval,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		cfg:       cfg,
+	}
+}
+
+// Run relays the messages until ctx is canceled. A full batch is followed
+// right away by the next one, so a backlog is drained without waiting for
+// the poll interval
+func (relay *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(relay.cfg.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		result, err := relay.RelayOnce(ctx)
+
+		if err != nil {
+			slog.ErrorContext(ctx, "relay outbox", slog.String("error", err.Error()))
+		}
+
+		if err == nil && result.Published == relay.cfg.batchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes a batch of pending messages. It does nothing while
+// another relay holds the lock. The messages published before ctx is canceled
+// are still marked, so a stopping relay does not publish them twice
+func (relay *Relay) RelayOnce(ctx context.Context) (RelayResult, error) {
+	var result RelayResult
+
+	_, err := relay.store.Lock(context.WithoutCancel(ctx), func(storeCtx context.Context) error {
+		messages, err := relay.store.Pending(storeCtx, relay.cfg.batchSize)
+
+		if err != nil {
+			return err
+		}
+
+		failedKeys := map[string]bool{}
+		published := make([]uint64, 0, len(messages))
+
+		for _, message := range messages {
+			if ctx.Err() != nil {
+				break
+			}
+
+			if failedKeys[message.Key] {
+				result.Deferred++
+				metrics.ObserveOutbox(message.Type, "deferred", 0)
+				continue
+			}
+
+			err = relay.publisher.Publish(ctx, message)
+
+			if err != nil && ctx.Err() != nil {
+				break
+			}
+
+			if err != nil {
+				failedKeys[message.Key] = true
+				result.Failed++
+				metrics.ObserveOutbox(message.Type, "failed", 0)
+
+				slog.WarnContext(ctx, "publish outbox message",
+					slog.String("id", message.ID),
+					slog.String("type", message.Type),
+					slog.Int("attempts", message.Attempts+1),
+					slog.String("error", err.Error()),
+				)
+
+				err = relay.store.MarkFailed(storeCtx, message.Sequence, truncate(err.Error(), _maxFailureLength))
+
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			result.Published++
+			published = append(published, message.Sequence)
+			metrics.ObserveOutbox(message.Type, "published", time.Since(message.OccurredAt))
+		}
+
+		if len(published) == 0 {
+			return nil
+		}
+
+		return relay.store.MarkPublished(storeCtx, published, time.Now())
+	})
+
+	if err != nil {
+		return RelayResult{}, err
+	}
+
+	return result, nil
+}
+
+// Purge removes the messages published before the retention, every interval,
+// until ctx is canceled
+func Purge(ctx context.Context, store Store, interval time.Duration, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			deleted, err := store.DeletePublished(ctx, now.Add(-retention))
+
+			if err != nil {
+				slog.ErrorContext(ctx, "purge outbox messages", slog.String("error", err.Error()))
+				continue
+			}
+
+			slog.DebugContext(ctx, "purge outbox messages", slog.Int64("deleted", deleted))
+		}
+	}
+}
+
+// newID returns a random UUID (version 4)
+func newID() (string, error) {
+	var id [16]byte
+
+	_, err := rand.Read(id[:])
+
+	if err != nil {
+		return "", err
+	}
+
+	id[6] = (id[6] & 0x0f) | 0x40
+	id[8] = (id[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]), nil
+}
+
+func truncate(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+
+	return value[:length]
+}
@@ -0,0 +1,334 @@
+package outbox_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/outbox"
+)
+
+// failingPublisher fails the messages of the keys in fail, and publishes the
+// others to the memory publisher
+type failingPublisher struct {
+	*outbox.MemoryPublisher
+	mu   sync.Mutex
+	fail map[string]bool
+}
+
+func (p *failingPublisher) Publish(ctx context.Context, message outbox.Message) error {
+	p.mu.Lock()
+	fail := p.fail[message.Key]
+	p.mu.Unlock()
+
+	if fail {
+		return errors.New("broker unavailable")
+	}
+
+	return p.MemoryPublisher.Publish(ctx, message)
+}
+
+func (p *failingPublisher) recover(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.fail, key)
+}
+
+// purgeStore counts the messages deleted by DeletePublished
+type purgeStore struct {
+	*outbox.MemoryStore
+	deleted atomic.Int64
+}
+
+func (s *purgeStore) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := s.MemoryStore.DeletePublished(ctx, before)
+	s.deleted.Add(deleted)
+
+	return deleted, err
+}
+
+type fakeSNS struct {
+	snsiface.SNSAPI
+	inputs []*sns.PublishInput
+}
+
+func (client *fakeSNS) PublishWithContext(ctx aws.Context, input *sns.PublishInput, opts ...request.Option) (*sns.PublishOutput, error) {
+	client.inputs = append(client.inputs, input)
+
+	return &sns.PublishOutput{MessageId: aws.String("mock-id")}, nil
+}
+
+func newMessage(t *testing.T, eventType string, key string) outbox.Message {
+	message, err := outbox.NewMessage(eventType, 1, key, map[string]string{"key": key})
+	assert.NoError(t, err)
+
+	return message
+}
+
+func keysOf(messages []outbox.Message) []string {
+	keys := make([]string, 0, len(messages))
+
+	for _, message := range messages {
+		keys = append(keys, message.Key+"/"+message.Type)
+	}
+
+	return keys
+}
+
+func TestRelay(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got messages published in order when calling RelayOnce", func(t *testing.T) {
+		t.Parallel()
+
+		store := outbox.NewMemoryStore()
+		publisher := outbox.NewMemoryPublisher()
+		relay := outbox.NewRelay(store, publisher)
+
+		store.Append(
+			newMessage(t, "customer.created", "customer:1"),
+			newMessage(t, "customer.created", "customer:2"),
+			newMessage(t, "customer.updated", "customer:1"),
+		)
+
+		result, err := relay.RelayOnce(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.RelayResult{Published: 3}, result)
+		assert.Equal(t, []string{"customer:1/customer.created", "customer:2/customer.created", "customer:1/customer.updated"}, keysOf(publisher.Messages()))
+
+		pending, err := store.Pending(context.TODO(), 10)
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+
+	t.Run("got next messages of failed key deferred when calling RelayOnce with publisher error", func(t *testing.T) {
+		t.Parallel()
+
+		store := outbox.NewMemoryStore()
+		publisher := &failingPublisher{MemoryPublisher: outbox.NewMemoryPublisher(), fail: map[string]bool{"customer:1": true}}
+		relay := outbox.NewRelay(store, publisher)
+
+		store.Append(
+			newMessage(t, "customer.created", "customer:1"),
+			newMessage(t, "customer.created", "customer:2"),
+			newMessage(t, "customer.updated", "customer:1"),
+		)
+
+		result, err := relay.RelayOnce(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.RelayResult{Published: 1, Failed: 1, Deferred: 1}, result)
+		assert.Equal(t, []string{"customer:2/customer.created"}, keysOf(publisher.Messages()))
+
+		pending, err := store.Pending(context.TODO(), 10)
+		assert.NoError(t, err)
+		assert.Len(t, pending, 1)
+		assert.Equal(t, 1, pending[0].Attempts)
+
+		publisher.recover("customer:1")
+
+		result, err = relay.RelayOnce(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.RelayResult{Published: 1}, result)
+
+		result, err = relay.RelayOnce(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.RelayResult{Published: 1}, result)
+		assert.Equal(t, []string{"customer:2/customer.created", "customer:1/customer.created", "customer:1/customer.updated"}, keysOf(publisher.Messages()))
+	})
+
+	t.Run("got other keys published when calling RelayOnce with failed key filling batch", func(t *testing.T) {
+		t.Parallel()
+
+		store := outbox.NewMemoryStore()
+		publisher := &failingPublisher{MemoryPublisher: outbox.NewMemoryPublisher(), fail: map[string]bool{"customer:1": true}}
+		relay := outbox.NewRelay(store, publisher, outbox.BatchSize(3))
+
+		store.Append(
+			newMessage(t, "customer.created", "customer:1"),
+			newMessage(t, "customer.updated", "customer:1"),
+			newMessage(t, "customer.updated", "customer:1"),
+			newMessage(t, "customer.deleted", "customer:1"),
+			newMessage(t, "customer.created", "customer:2"),
+		)
+
+		result, err := relay.RelayOnce(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.RelayResult{Failed: 1, Deferred: 2}, result)
+
+		result, err = relay.RelayOnce(context.TODO())
+
+		assert.NoError(t, err)
+		assert.Equal(t, outbox.RelayResult{Published: 1, Failed: 1}, result)
+		assert.Equal(t, []string{"customer:2/customer.created"}, keysOf(publisher.Messages()))
+	})
+
+	t.Run("got nothing published when calling RelayOnce while lock is held", func(t *testing.T) {
+		t.Parallel()
+
+		store := outbox.NewMemoryStore()
+		publisher := outbox.NewMemoryPublisher()
+		relay := outbox.NewRelay(store, publisher)
+
+		store.Append(newMessage(t, "customer.created", "customer:1"))
+
+		acquired, err := store.Lock(context.TODO(), func(ctx context.Context) error {
+			result, err := relay.RelayOnce(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, outbox.RelayResult{}, result)
+
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.True(t, acquired)
+		assert.Empty(t, publisher.Messages())
+	})
+
+	t.Run("got backlog drained in batches when calling Run", func(t *testing.T) {
+		t.Parallel()
+
+		store := outbox.NewMemoryStore()
+		publisher := outbox.NewMemoryPublisher()
+		relay := outbox.NewRelay(store, publisher, outbox.BatchSize(2), outbox.PollInterval(time.Hour))
+
+		for range 5 {
+			store.Append(newMessage(t, "customer.created", "customer:1"))
+		}
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		done := make(chan error)
+
+		go func() {
+			done <- relay.Run(ctx)
+		}()
+
+		assert.Eventually(t, func() bool {
+			return len(publisher.Messages()) == 5
+		}, time.Second, 10*time.Millisecond)
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("got published messages removed when calling Purge", func(t *testing.T) {
+		t.Parallel()
+
+		store := &purgeStore{MemoryStore: outbox.NewMemoryStore()}
+		relay := outbox.NewRelay(store, outbox.NewMemoryPublisher())
+
+		store.Append(newMessage(t, "customer.created", "customer:1"))
+
+		_, err := relay.RelayOnce(context.TODO())
+		assert.NoError(t, err)
+
+		store.Append(newMessage(t, "customer.created", "customer:2"))
+
+		ctx, cancel := context.WithCancel(context.TODO())
+		done := make(chan struct{})
+
+		go func() {
+			outbox.Purge(ctx, store, 10*time.Millisecond, 0)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool {
+			return store.deleted.Load() == 1
+		}, time.Second, 10*time.Millisecond)
+
+		cancel()
+		<-done
+
+		pending, err := store.Pending(context.TODO(), 10)
+		assert.NoError(t, err)
+		assert.Len(t, pending, 1)
+	})
+}
+
+func TestPublishers(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got message envelope as JSON line when calling FilePublisher", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "events.jsonl")
+
+		publisher, err := outbox.NewFilePublisher(path)
+		assert.NoError(t, err)
+
+		message := newMessage(t, "customer.created", "customer:1")
+
+		assert.NoError(t, publisher.Publish(context.TODO(), message))
+		assert.NoError(t, publisher.Publish(context.TODO(), newMessage(t, "customer.deleted", "customer:1")))
+		assert.NoError(t, publisher.Close(context.TODO()))
+
+		file, err := os.Open(path)
+		assert.NoError(t, err)
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		lines := []map[string]any{}
+
+		for scanner.Scan() {
+			var line map[string]any
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+			lines = append(lines, line)
+		}
+
+		assert.Len(t, lines, 2)
+		assert.Equal(t, message.ID, lines[0]["id"])
+		assert.Equal(t, "customer.created", lines[0]["type"])
+		assert.Equal(t, float64(1), lines[0]["schemaVersion"])
+		assert.Equal(t, "customer:1", lines[0]["key"])
+		assert.Equal(t, map[string]any{"key": "customer:1"}, lines[0]["data"])
+		assert.NotContains(t, lines[0], "Sequence")
+	})
+
+	t.Run("got message group and deduplication ID when calling SNSPublisher with FIFO topic", func(t *testing.T) {
+		t.Parallel()
+
+		client := &fakeSNS{}
+		publisher := outbox.NewSNSPublisher(client, "arn:aws:sns:us-east-1:000000000000:customer-events.fifo")
+		message := newMessage(t, "customer.created", "customer:1")
+
+		assert.NoError(t, publisher.Publish(context.TODO(), message))
+
+		assert.Len(t, client.inputs, 1)
+		assert.Equal(t, "customer:1", aws.StringValue(client.inputs[0].MessageGroupId))
+		assert.Equal(t, message.ID, aws.StringValue(client.inputs[0].MessageDeduplicationId))
+		assert.Equal(t, "customer.created", aws.StringValue(client.inputs[0].MessageAttributes["type"].StringValue))
+		assert.Equal(t, "1", aws.StringValue(client.inputs[0].MessageAttributes["schemaVersion"].StringValue))
+		assert.Contains(t, aws.StringValue(client.inputs[0].Message), message.ID)
+	})
+
+	t.Run("got no message group when calling SNSPublisher with standard topic", func(t *testing.T) {
+		t.Parallel()
+
+		client := &fakeSNS{}
+		publisher := outbox.NewSNSPublisher(client, "arn:aws:sns:us-east-1:000000000000:customer-events")
+
+		assert.NoError(t, publisher.Publish(context.TODO(), newMessage(t, "customer.created", "customer:1")))
+
+		assert.Len(t, client.inputs, 1)
+		assert.Nil(t, client.inputs[0].MessageGroupId)
+		assert.Nil(t, client.inputs[0].MessageDeduplicationId)
+	})
+}
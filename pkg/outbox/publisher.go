@@ -0,0 +1,87 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+)
+
+// Publisher sends the messages to the other services. An error means the
+// message may not have been sent, and it is published again later, so the
+// same message may be sent more than once
+type Publisher interface {
+	Publish(ctx context.Context, message Message) error
+}
+
+// MemoryPublisher keeps the published messages, for tests
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, message Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, message)
+
+	return nil
+}
+
+// Messages returns the published messages, in the order they were published
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Message(nil), p.messages...)
+}
+
+// FilePublisher appends each message to a file as a line of JSON. It is meant
+// for local development, where the events are read from the file
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePublisher{
+		file: file,
+	}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, message Message) error {
+	line, err := json.Marshal(message)
+
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.file.Write(append(line, '\n'))
+
+	if err != nil {
+		return err
+	}
+
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.file.Close()
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+)
+
+// SNSPublisher publishes the messages to an AWS SNS topic. With a FIFO topic
+// (the ARN ends with .fifo) the message group is the message key, so the
+// events of a customer are delivered in order, and SNS drops the duplicates
+// by the message ID. The type and the schema version are also sent as message
+// attributes, so the subscriptions can filter on them
+type SNSPublisher struct {
+	client   snsiface.SNSAPI
+	topicARN string
+	fifo     bool
+}
+
+func NewSNSPublisher(client snsiface.SNSAPI, topicARN string) *SNSPublisher {
+	return &SNSPublisher{
+		client:   client,
+		topicARN: topicARN,
+		fifo:     strings.HasSuffix(topicARN, ".fifo"),
+	}
+}
+
+func (publisher *SNSPublisher) Publish(ctx context.Context, message Message) error {
+	body, err := json.Marshal(message)
+
+	if err != nil {
+		return err
+	}
+
+	input := &sns.PublishInput{
+		TopicArn: aws.String(publisher.topicARN),
+		Message:  aws.String(string(body)),
+		MessageAttributes: map[string]*sns.MessageAttributeValue{
+			"type": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(message.Type),
+			},
+			"schemaVersion": {
+				DataType:    aws.String("Number"),
+				StringValue: aws.String(strconv.Itoa(message.SchemaVersion)),
+			},
+		},
+	}
+
+	if publisher.fifo {
+		input.MessageGroupId = aws.String(message.Key)
+		input.MessageDeduplicationId = aws.String(message.ID)
+	}
+
+	_, err = publisher.client.PublishWithContext(ctx, input)
+
+	return err
+}
@@ -6,9 +6,18 @@ import (
 	"net/http"
 )
 
+// BusinessResponse is the error returned by the use cases. Constraint and
+// Column come from the LocalError of a database error, when it reports them.
+// Internal is true when Message has the text of a network or database error,
+// so it is only logged and never sent to the client
 type BusinessResponse struct {
-	StatusCode int    `json:"statusCode"`
-	Message    string `json:"msgError"`
+	StatusCode int          `json:"statusCode"`
+	Message    string       `json:"msgError"`
+	Code       ErrorCode    `json:"-"`
+	Errors     []FieldError `json:"-"`
+	Constraint string       `json:"-"`
+	Column     string       `json:"-"`
+	Internal   bool         `json:"-"`
 }
 
 func (br BusinessResponse) Error() string {
@@ -37,6 +46,10 @@ func (br BusinessResponse) Error() string {
 		Caso não seja NetworkError ou LocalError, retornará um statuso code 500
 		para o usuário
 
+	5) Código do erro (ErrorCode)
+		A mensagem montada aqui é interna e deve ir apenas para os logs. O usuário
+		recebe somente o código do catálogo e a mensagem segura (ver problem.go)
+
 *
 */
 func GetResponseError(err error, service string) error {
@@ -46,6 +59,11 @@ func GetResponseError(err error, service string) error {
 
 	statusCode := http.StatusInternalServerError
 	message := "Unexpected internal error"
+	code := ErrorCode("")
+	constraint := ""
+	column := ""
+	internal := true
+	detail := err.Error()
 
 	if errors.As(err, &networkError) {
 		statusCode = networkError.Code
@@ -53,14 +71,33 @@ func GetResponseError(err error, service string) error {
 	} else if errors.As(err, &databaseError) {
 		message = databaseError.Message
 		statusCode = getBusinessStatusCode(*databaseError)
+		constraint = databaseError.Constraint
+		column = databaseError.Column
 	} else if errors.As(err, &businessError) {
 		statusCode = businessError.StatusCode
 		message = businessError.Message
+		code = businessError.Code
+		constraint = businessError.Constraint
+		column = businessError.Column
+		internal = businessError.Internal
+		detail = ""
+	}
+
+	if detail != "" {
+		message = fmt.Sprintf("%v - %v", message, detail)
+	}
+
+	if code == "" {
+		code = getBusinessErrorCode(err, statusCode, service)
 	}
 
 	businessResponse := &BusinessResponse{
 		StatusCode: statusCode,
-		Message:    fmt.Sprintf("%v - %v", message, err.Error()),
+		Message:    message,
+		Code:       code,
+		Constraint: constraint,
+		Column:     column,
+		Internal:   internal,
 	}
 
 	return businessResponse
@@ -80,6 +117,8 @@ func getBusinessMessageError(statusCode int, service string) string {
 		message = fmt.Sprintf("Not found trying to execute %v", service)
 	case http.StatusConflict:
 		message = fmt.Sprintf("Conflit with some data using the service %v", service)
+	case http.StatusPreconditionFailed:
+		message = fmt.Sprintf("Precondition failed trying to execute %v", service)
 	case http.StatusUnprocessableEntity:
 		message = fmt.Sprintf("Logic error found in service %v", service)
 	default:
@@ -90,14 +129,28 @@ func getBusinessMessageError(statusCode int, service string) string {
 }
 
 func getBusinessStatusCode(localError LocalError) int {
-	if localError.Code == DATABASE_CONFLICT_ERROR {
+	if localError.Code == DATABASE_CONFLICT_ERROR ||
+		localError.Code == DATABASE_REFERENCE_ERROR ||
+		localError.Code == DATABASE_RETRYABLE_ERROR {
 		return http.StatusConflict
 	}
 
+	if localError.Code == DATABASE_CONSTRAINT_ERROR {
+		return http.StatusBadRequest
+	}
+
+	if localError.Code == DATABASE_TIMEOUT_ERROR {
+		return http.StatusGatewayTimeout
+	}
+
 	if localError.Code == NOT_FOUND_ERROR {
 		return http.StatusNotFound
 	}
 
+	if localError.Code == PRECONDITION_FAILED_ERROR {
+		return http.StatusPreconditionFailed
+	}
+
 	if localError.Code == DATABASE_ERROR {
 		return http.StatusServiceUnavailable
 	}
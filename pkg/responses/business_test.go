@@ -1,6 +1,7 @@
 package responses_test
 
 import (
+	"fmt"
 	"net/http"
 	"testing"
 
@@ -141,6 +142,20 @@ func TestBusinessResponse(t *testing.T) {
 		assert.Equal(t, http.StatusConflict, businessError.(*responses.BusinessResponse).StatusCode)
 	})
 
+	t.Run("got StatusPreconditionFailed error with Local Error when calling GetResponseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &responses.LocalError{
+			Code:    responses.PRECONDITION_FAILED_ERROR,
+			Message: "version mismatch",
+		}
+
+		businessError := responses.GetResponseError(err, "MOCK")
+
+		assert.Equal(t, http.StatusPreconditionFailed, businessError.(*responses.BusinessResponse).StatusCode)
+		assert.Equal(t, responses.CodePreconditionFailed, businessError.(*responses.BusinessResponse).Code)
+	})
+
 	t.Run("got StatusServiceUnavailable error with Local Error when calling GetResponseError", func(t *testing.T) {
 		t.Parallel()
 
@@ -158,7 +173,7 @@ func TestBusinessResponse(t *testing.T) {
 		t.Parallel()
 
 		err := &responses.LocalError{
-			Code:    responses.DATABASE_CONSTRAINT_ERROR,
+			Code:    responses.LOGIC_ERROR,
 			Message: "StatusUnprocessableEntity",
 		}
 
@@ -179,4 +194,18 @@ func TestBusinessResponse(t *testing.T) {
 
 		assert.Equal(t, http.StatusUnprocessableEntity, businessError.(*responses.BusinessResponse).StatusCode)
 	})
+
+	t.Run("got BusinessResponse message once when calling GetResponseError with wrapped BusinessResponse", func(t *testing.T) {
+		t.Parallel()
+
+		err := fmt.Errorf("update customer: %w", &responses.BusinessResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "Customer already exists",
+		})
+
+		businessError := responses.GetResponseError(err, "MOCK")
+
+		assert.Equal(t, http.StatusConflict, businessError.(*responses.BusinessResponse).StatusCode)
+		assert.Equal(t, "Customer already exists", businessError.Error())
+	})
 }
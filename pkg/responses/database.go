@@ -1,10 +1,10 @@
 package responses
 
 import (
+	"context"
+	"database/sql/driver"
 	"errors"
-	"strconv"
-
-	"github.com/jackc/pgx/v5/pgconn"
+	"net"
 )
 
 const (
@@ -13,48 +13,83 @@ const (
 	DATABASE_CONFLICT_ERROR   = 3
 	NOT_FOUND_ERROR           = 4
 	LOGIC_ERROR               = 5
+	PRECONDITION_FAILED_ERROR = 6
+	DATABASE_REFERENCE_ERROR  = 7
+	DATABASE_RETRYABLE_ERROR  = 8
+	DATABASE_TIMEOUT_ERROR    = 9
 )
 
+// unavailableMessage is the message of the errors of an unreachable database
+const unavailableMessage = "service unavailable"
+
+// LocalError is a classified database or business error. Constraint and
+// Column are the violated constraint and column, when the database reports them
 type LocalError struct {
-	Code    int
-	Message string
+	Code       int
+	Message    string
+	Constraint string
+	Column     string
 }
 
 func (er LocalError) Error() string {
 	return er.Message
 }
 
-func GetDatabaseError(err error) *LocalError {
-	var localError *pgconn.PgError
-	var connError *pgconn.ConnectError
+// databaseErrorClassifier returns the LocalError of an error of its dialect,
+// with ok false when the error is not of its driver
+type databaseErrorClassifier func(err error) (localError *LocalError, ok bool)
 
-	code := DATABASE_ERROR
-	message := err.Error()
+// databaseErrorClassifiers are the dialects known by GetDatabaseError
+var databaseErrorClassifiers = []databaseErrorClassifier{
+	getPostgresError,
+	getSQLiteError,
+}
 
-	if errors.As(err, &localError) {
-		iCode, err := strconv.Atoi(localError.Code)
-		if err != nil {
-			iCode = DATABASE_ERROR
+// GetDatabaseError classifies a database error of any supported dialect:
+//   - a unique violation is a DATABASE_CONFLICT_ERROR, with the violated constraint
+//   - a foreign key violation is a DATABASE_REFERENCE_ERROR
+//   - a not null, check or invalid data error is a DATABASE_CONSTRAINT_ERROR
+//   - a serialization failure or deadlock is a DATABASE_RETRYABLE_ERROR, since
+//     the same transaction may succeed when run again
+//   - a canceled or timed out query is a DATABASE_TIMEOUT_ERROR
+//   - a missing record is a NOT_FOUND_ERROR
+//   - an unreachable or overloaded database, and any other error, is a
+//     DATABASE_ERROR
+func GetDatabaseError(err error) *LocalError {
+	if err.Error() == "record not found" {
+		return &LocalError{
+			Message: err.Error(),
+			Code:    NOT_FOUND_ERROR,
 		}
-
-		code = iCode
-		message = localError.Message
 	}
 
-	if errors.As(err, &connError) {
-		message = "service unavailable"
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return &LocalError{
+			Message: err.Error(),
+			Code:    DATABASE_TIMEOUT_ERROR,
+		}
 	}
 
-	if message == "record not found" {
-		code = NOT_FOUND_ERROR
+	for _, classify := range databaseErrorClassifiers {
+		if localError, ok := classify(err); ok {
+			return localError
+		}
 	}
 
-	if code == 23505 {
-		code = DATABASE_CONFLICT_ERROR
+	message := err.Error()
+
+	if isConnectionError(err) {
+		message = unavailableMessage
 	}
 
 	return &LocalError{
 		Message: message,
-		Code:    code,
+		Code:    DATABASE_ERROR,
 	}
 }
+
+func isConnectionError(err error) bool {
+	var netError net.Error
+
+	return errors.Is(err, driver.ErrBadConn) || errors.As(err, &netError)
+}
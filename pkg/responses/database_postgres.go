@@ -0,0 +1,83 @@
+package responses
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// The SQLSTATEs of the Postgres errors with their own classification
+const (
+	_pgNotNullViolation     = "23502"
+	_pgForeignKeyViolation  = "23503"
+	_pgUniqueViolation      = "23505"
+	_pgCheckViolation       = "23514"
+	_pgExclusionViolation   = "23P01"
+	_pgSerializationFailure = "40001"
+	_pgDeadlockDetected     = "40P01"
+	_pgTooManyConnections   = "53300"
+	_pgQueryCanceled        = "57014"
+	_pgAdminShutdown        = "57P01"
+	_pgCrashShutdown        = "57P02"
+	_pgCannotConnectNow     = "57P03"
+)
+
+// The SQLSTATE classes, the first two characters of the code, of the other
+// errors
+const (
+	_pgConnectionExceptionClass   = "08"
+	_pgDataExceptionClass         = "22"
+	_pgIntegrityClass             = "23"
+	_pgTransactionRollbackClass   = "40"
+	_pgInsufficientResourcesClass = "53"
+)
+
+func getPostgresError(err error) (*LocalError, bool) {
+	var pgError *pgconn.PgError
+	var connError *pgconn.ConnectError
+
+	if errors.As(err, &connError) {
+		return &LocalError{
+			Message: unavailableMessage,
+			Code:    DATABASE_ERROR,
+		}, true
+	}
+
+	if !errors.As(err, &pgError) {
+		return nil, false
+	}
+
+	localError := &LocalError{
+		Message:    pgError.Message,
+		Code:       DATABASE_ERROR,
+		Constraint: pgError.ConstraintName,
+		Column:     pgError.ColumnName,
+	}
+
+	switch pgError.Code {
+	case _pgUniqueViolation, _pgExclusionViolation:
+		localError.Code = DATABASE_CONFLICT_ERROR
+	case _pgForeignKeyViolation:
+		localError.Code = DATABASE_REFERENCE_ERROR
+	case _pgNotNullViolation, _pgCheckViolation:
+		localError.Code = DATABASE_CONSTRAINT_ERROR
+	case _pgSerializationFailure, _pgDeadlockDetected:
+		localError.Code = DATABASE_RETRYABLE_ERROR
+	case _pgQueryCanceled:
+		localError.Code = DATABASE_TIMEOUT_ERROR
+	case _pgTooManyConnections, _pgAdminShutdown, _pgCrashShutdown, _pgCannotConnectNow:
+		localError.Message = unavailableMessage
+	default:
+		switch {
+		case strings.HasPrefix(pgError.Code, _pgIntegrityClass), strings.HasPrefix(pgError.Code, _pgDataExceptionClass):
+			localError.Code = DATABASE_CONSTRAINT_ERROR
+		case strings.HasPrefix(pgError.Code, _pgTransactionRollbackClass):
+			localError.Code = DATABASE_RETRYABLE_ERROR
+		case strings.HasPrefix(pgError.Code, _pgConnectionExceptionClass), strings.HasPrefix(pgError.Code, _pgInsufficientResourcesClass):
+			localError.Message = unavailableMessage
+		}
+	}
+
+	return localError, true
+}
@@ -0,0 +1,91 @@
+package responses
+
+import (
+	"errors"
+	"regexp"
+)
+
+// The SQLite result codes. The extended ones, like the unique violation, have
+// the primary code in the low byte
+const (
+	_sqliteBusy                 = 5
+	_sqliteLocked               = 6
+	_sqliteInterrupt            = 9
+	_sqliteIOErr                = 10
+	_sqliteCantOpen             = 14
+	_sqliteConstraint           = 19
+	_sqliteConstraintCheck      = 275
+	_sqliteConstraintForeignKey = 787
+	_sqliteConstraintNotNull    = 1299
+	_sqliteConstraintPrimaryKey = 1555
+	_sqliteConstraintUnique     = 2067
+)
+
+// SQLite reports the columns of a unique or not null violation and the name of
+// a check constraint only in the message
+var (
+	sqliteConstraintRegex = regexp.MustCompile(`UNIQUE constraint failed: ([\w.]+(?:, [\w.]+)*)`)
+	sqliteNotNullRegex    = regexp.MustCompile(`NOT NULL constraint failed: (?:\w+\.)?(\w+)`)
+	sqliteCheckRegex      = regexp.MustCompile(`CHECK constraint failed: (\w+)`)
+)
+
+// sqliteError is the error of the SQLite driver, with the extended result code.
+// The driver is not imported, so this package does not depend on it
+type sqliteError interface {
+	error
+	Code() int
+}
+
+func getSQLiteError(err error) (*LocalError, bool) {
+	var liteError sqliteError
+
+	if !errors.As(err, &liteError) {
+		return nil, false
+	}
+
+	localError := &LocalError{
+		Message: liteError.Error(),
+		Code:    DATABASE_ERROR,
+	}
+
+	switch liteError.Code() {
+	case _sqliteConstraintUnique, _sqliteConstraintPrimaryKey:
+		localError.Code = DATABASE_CONFLICT_ERROR
+
+		if match := sqliteConstraintRegex.FindStringSubmatch(liteError.Error()); match != nil {
+			localError.Constraint = match[1]
+		}
+
+		return localError, true
+	case _sqliteConstraintForeignKey:
+		localError.Code = DATABASE_REFERENCE_ERROR
+		return localError, true
+	case _sqliteConstraintNotNull:
+		localError.Code = DATABASE_CONSTRAINT_ERROR
+
+		if match := sqliteNotNullRegex.FindStringSubmatch(liteError.Error()); match != nil {
+			localError.Column = match[1]
+		}
+
+		return localError, true
+	case _sqliteConstraintCheck:
+		localError.Code = DATABASE_CONSTRAINT_ERROR
+
+		if match := sqliteCheckRegex.FindStringSubmatch(liteError.Error()); match != nil {
+			localError.Constraint = match[1]
+		}
+
+		return localError, true
+	}
+
+	switch liteError.Code() & 0xff {
+	case _sqliteBusy, _sqliteLocked, _sqliteIOErr, _sqliteCantOpen:
+		localError.Message = unavailableMessage
+	case _sqliteInterrupt:
+		localError.Code = DATABASE_TIMEOUT_ERROR
+	case _sqliteConstraint:
+		localError.Code = DATABASE_CONSTRAINT_ERROR
+	}
+
+	return localError, true
+}
@@ -1,7 +1,11 @@
 package responses_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net"
+	"net/http"
 	"testing"
 
 	"github.com/jackc/pgx/v5/pgconn"
@@ -12,16 +16,16 @@ import (
 func TestDatabaseResponse(t *testing.T) {
 	t.Parallel()
 
-	t.Run("got Specific error with Database Error when calling GetDatabaseError", func(t *testing.T) {
+	t.Run("got Generic error with unknown SQLSTATE with Database Error when calling GetDatabaseError", func(t *testing.T) {
 		t.Parallel()
 
 		err := &pgconn.PgError{
-			Code: "99",
+			Code: "42601",
 		}
 
 		localError := responses.GetDatabaseError(err)
 
-		assert.Equal(t, 99, localError.Code)
+		assert.Equal(t, responses.DATABASE_ERROR, localError.Code)
 	})
 
 	t.Run("got Generic error with Database Error when calling GetDatabaseError", func(t *testing.T) {
@@ -75,4 +79,237 @@ func TestDatabaseResponse(t *testing.T) {
 
 		assert.Equal(t, responses.DATABASE_CONFLICT_ERROR, localError.Code)
 	})
+	t.Run("got Constraint error with column with not null violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &pgconn.PgError{
+			Code:       "23502",
+			Message:    `null value in column "name" of relation "customers" violates not-null constraint`,
+			ColumnName: "name",
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_CONSTRAINT_ERROR, localError.Code)
+		assert.Equal(t, "name", localError.Column)
+
+		response := responses.GetResponseError(localError, "CustomerService").(*responses.BusinessResponse)
+
+		assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		assert.Equal(t, responses.CodeConstraintViolation, response.Code)
+	})
+
+	t.Run("got Constraint error with constraint with check violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &pgconn.PgError{
+			Code:           "23514",
+			ConstraintName: "chk_customers_name",
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_CONSTRAINT_ERROR, localError.Code)
+		assert.Equal(t, "chk_customers_name", localError.Constraint)
+	})
+
+	t.Run("got Constraint error with invalid data when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &pgconn.PgError{
+			Code: "22001",
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_CONSTRAINT_ERROR, localError.Code)
+	})
+
+	t.Run("got Reference error with foreign key violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &pgconn.PgError{
+			Code:           "23503",
+			ConstraintName: "fk_customer_history_customer",
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_REFERENCE_ERROR, localError.Code)
+		assert.Equal(t, "fk_customer_history_customer", localError.Constraint)
+
+		response := responses.GetResponseError(localError, "CustomerService").(*responses.BusinessResponse)
+
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+		assert.Equal(t, responses.CodeReferenceConflict, response.Code)
+	})
+
+	t.Run("got Retryable error with serialization failure and deadlock when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		for _, code := range []string{"40001", "40P01", "40002"} {
+			localError := responses.GetDatabaseError(&pgconn.PgError{Code: code})
+
+			assert.Equal(t, responses.DATABASE_RETRYABLE_ERROR, localError.Code, code)
+
+			response := responses.GetResponseError(localError, "CustomerService").(*responses.BusinessResponse)
+
+			assert.Equal(t, http.StatusConflict, response.StatusCode)
+			assert.Equal(t, responses.CodeTransactionConflict, response.Code)
+		}
+	})
+
+	t.Run("got Timeout error with canceled query when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &pgconn.PgError{
+			Code:    "57014",
+			Message: "canceling statement due to statement timeout",
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_TIMEOUT_ERROR, localError.Code)
+
+		response := responses.GetResponseError(localError, "CustomerService").(*responses.BusinessResponse)
+
+		assert.Equal(t, http.StatusGatewayTimeout, response.StatusCode)
+		assert.Equal(t, responses.CodeGatewayTimeout, response.Code)
+	})
+
+	t.Run("got Timeout error with context deadline when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		for _, err := range []error{
+			fmt.Errorf("timeout: %w", context.DeadlineExceeded),
+			context.Canceled,
+		} {
+			localError := responses.GetDatabaseError(err)
+
+			assert.Equal(t, responses.DATABASE_TIMEOUT_ERROR, localError.Code)
+		}
+	})
+
+	t.Run("got Unavailable error with too many connections when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		for _, code := range []string{"53300", "57P03", "08006"} {
+			localError := responses.GetDatabaseError(&pgconn.PgError{Code: code})
+
+			assert.Equal(t, responses.DATABASE_ERROR, localError.Code, code)
+			assert.Equal(t, "service unavailable", localError.Message, code)
+
+			response := responses.GetResponseError(localError, "CustomerService").(*responses.BusinessResponse)
+
+			assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		}
+	})
+
+	t.Run("got Conflict error with constraint with SQLite unique violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &sqliteError{
+			message: "constraint failed: UNIQUE constraint failed: customers.cpf_index (2067)",
+			code:    2067,
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_CONFLICT_ERROR, localError.Code)
+		assert.Equal(t, "customers.cpf_index", localError.Constraint)
+
+		response := responses.GetResponseError(localError, "CustomerService").(*responses.BusinessResponse)
+
+		assert.Equal(t, http.StatusConflict, response.StatusCode)
+		assert.Equal(t, responses.CodeCPFTaken, response.Code)
+	})
+
+	t.Run("got Unavailable error with SQLite busy database when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := fmt.Errorf("creating customer: %w", &sqliteError{
+			message: "database is locked (5) (SQLITE_BUSY)",
+			code:    5,
+		})
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_ERROR, localError.Code)
+		assert.Equal(t, "service unavailable", localError.Message)
+	})
+
+	t.Run("got Constraint error with SQLite not null violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &sqliteError{
+			message: "constraint failed: NOT NULL constraint failed: audit_logs.actor (1299)",
+			code:    1299,
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_CONSTRAINT_ERROR, localError.Code)
+		assert.Equal(t, "actor", localError.Column)
+	})
+
+	t.Run("got Reference error with SQLite foreign key violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &sqliteError{
+			message: "constraint failed: FOREIGN KEY constraint failed (787)",
+			code:    787,
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_REFERENCE_ERROR, localError.Code)
+	})
+
+	t.Run("got Constraint error with SQLite check violation when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &sqliteError{
+			message: "constraint failed: CHECK constraint failed: chk_customers_name (275)",
+			code:    275,
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_CONSTRAINT_ERROR, localError.Code)
+		assert.Equal(t, "chk_customers_name", localError.Constraint)
+	})
+
+	t.Run("got Timeout error with SQLite interrupted query when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &sqliteError{
+			message: "interrupted (9)",
+			code:    9,
+		}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_TIMEOUT_ERROR, localError.Code)
+	})
+
+	t.Run("got Unavailable error with network error when calling GetDatabaseError", func(t *testing.T) {
+		t.Parallel()
+
+		err := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+		localError := responses.GetDatabaseError(err)
+
+		assert.Equal(t, responses.DATABASE_ERROR, localError.Code)
+		assert.Equal(t, "service unavailable", localError.Message)
+	})
 }
+
+// sqliteError has the shape of the error of the SQLite driver
+type sqliteError struct {
+	message string
+	code    int
+}
+
+func (e *sqliteError) Error() string { return e.message }
+
+func (e *sqliteError) Code() int { return e.code }
@@ -1,6 +1,8 @@
 package responses
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
@@ -23,21 +25,33 @@ func GetCognitoError(err error) *NetworkError {
 		code = http.StatusConflict
 	}
 
+	if strings.Contains(err.Error(), "NotAuthorizedException") {
+		code = http.StatusUnauthorized
+	}
+
 	return &NetworkError{
 		Code:    code,
 		Message: message,
 	}
 }
 
-func GetNetworkError(urlError *url.Error) *NetworkError {
+// GetNetworkError maps an error of http.Client.Do. Timeouts are 504 and
+// temporary errors, like an open circuit breaker, are 503
+func GetNetworkError(err error) *NetworkError {
 	code := http.StatusInternalServerError
-	message := urlError.Unwrap().Error()
+	message := err.Error()
 
-	if urlError.Timeout() {
-		code = http.StatusGatewayTimeout
+	var urlError *url.Error
+	if errors.As(err, &urlError) && urlError.Err != nil {
+		message = urlError.Err.Error()
 	}
 
-	if urlError.Temporary() {
+	var timeoutError interface{ Timeout() bool }
+	var temporaryError interface{ Temporary() bool }
+
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &timeoutError) && timeoutError.Timeout() {
+		code = http.StatusGatewayTimeout
+	} else if errors.As(err, &temporaryError) && temporaryError.Temporary() {
 		code = http.StatusServiceUnavailable
 	}
 
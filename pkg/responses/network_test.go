@@ -1,7 +1,9 @@
 package responses_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"testing"
@@ -10,6 +12,11 @@ import (
 	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
 )
 
+type mockTemporaryError struct{}
+
+func (mockTemporaryError) Error() string   { return "temporary" }
+func (mockTemporaryError) Temporary() bool { return true }
+
 func TestNetworkResponse(t *testing.T) {
 	t.Parallel()
 
@@ -25,6 +32,24 @@ func TestNetworkResponse(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, localError.Code)
 	})
 
+	t.Run("got error mapped without panic when calling GetNetworkError with other error types", func(t *testing.T) {
+		t.Parallel()
+
+		localError := responses.GetNetworkError(errors.New("mock error"))
+
+		assert.Equal(t, http.StatusInternalServerError, localError.Code)
+		assert.Equal(t, "mock error", localError.Message)
+
+		localError = responses.GetNetworkError(&url.Error{Op: "Get", URL: "http://partner", Err: context.DeadlineExceeded})
+
+		assert.Equal(t, http.StatusGatewayTimeout, localError.Code)
+		assert.Equal(t, context.DeadlineExceeded.Error(), localError.Message)
+
+		localError = responses.GetNetworkError(fmt.Errorf("wrapped: %w", mockTemporaryError{}))
+
+		assert.Equal(t, http.StatusServiceUnavailable, localError.Code)
+	})
+
 	t.Run("got StatusConflict error with Cognito Error when calling GetCognitoError", func(t *testing.T) {
 		t.Parallel()
 
@@ -35,6 +60,16 @@ func TestNetworkResponse(t *testing.T) {
 		assert.Equal(t, http.StatusConflict, localError.Code)
 	})
 
+	t.Run("got StatusUnauthorized error with Cognito Error when calling GetCognitoError", func(t *testing.T) {
+		t.Parallel()
+
+		err := errors.New("NotAuthorizedException: Access Token has expired")
+
+		localError := responses.GetCognitoError(err)
+
+		assert.Equal(t, http.StatusUnauthorized, localError.Code)
+	})
+
 	t.Run("got StatusInternalServerError error with Cognito Error when calling GetCognitoError", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,249 @@
+package responses
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const ProblemContentType = "application/problem+json"
+
+// ErrorCode is the machine-readable identifier sent to the clients inside the
+// `code` member of a Problem Details response. Clients must branch on this value,
+// never on the human message. The full catalog is documented in the README.
+type ErrorCode string
+
+const (
+	CodeInternalError        ErrorCode = "INTERNAL_ERROR"
+	CodeBadRequest           ErrorCode = "BAD_REQUEST"
+	CodeMalformedBody        ErrorCode = "MALFORMED_BODY"
+	CodeUnsupportedMediaType ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodePayloadTooLarge      ErrorCode = "PAYLOAD_TOO_LARGE"
+	CodeValidationFailed     ErrorCode = "VALIDATION_FAILED"
+	CodeUnauthorized         ErrorCode = "UNAUTHORIZED"
+	CodeForbidden            ErrorCode = "FORBIDDEN"
+	CodeNotFound             ErrorCode = "NOT_FOUND"
+	CodeCustomerNotFound     ErrorCode = "CUSTOMER_NOT_FOUND"
+	CodeUserNotFound         ErrorCode = "USER_NOT_FOUND"
+	CodeConflict             ErrorCode = "CONFLICT"
+	CodeConstraintViolation  ErrorCode = "CONSTRAINT_VIOLATION"
+	CodeReferenceConflict    ErrorCode = "REFERENCE_CONFLICT"
+	CodeTransactionConflict  ErrorCode = "TRANSACTION_CONFLICT"
+	CodePreconditionFailed   ErrorCode = "PRECONDITION_FAILED"
+	CodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	CodeIdempotencyKeyReused ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInFlight  ErrorCode = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeCPFInvalid           ErrorCode = "CPF_INVALID"
+	CodeCPFTaken             ErrorCode = "CPF_TAKEN"
+	CodeEmailTaken           ErrorCode = "EMAIL_TAKEN"
+	CodeUnprocessable        ErrorCode = "UNPROCESSABLE"
+	CodeServiceUnavailable   ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeGatewayTimeout       ErrorCode = "GATEWAY_TIMEOUT"
+)
+
+type catalogEntry struct {
+	status int
+	title  string
+	detail string
+	// public is true when the message of the BusinessResponse is written by this
+	// service (e.g. JSON decoding) and is safe to be sent to the client as is
+	public bool
+}
+
+var catalog = map[ErrorCode]catalogEntry{
+	CodeInternalError:        {http.StatusInternalServerError, "Internal error", "An unexpected error happened. Try again later", false},
+	CodeBadRequest:           {http.StatusBadRequest, "Bad request", "The request is invalid", true},
+	CodeMalformedBody:        {http.StatusBadRequest, "Malformed body", "The request body could not be parsed", true},
+	CodeUnsupportedMediaType: {http.StatusUnsupportedMediaType, "Unsupported media type", "Content-Type header is not application/json", true},
+	CodePayloadTooLarge:      {http.StatusRequestEntityTooLarge, "Payload too large", "Request body must not be larger than 1MB", true},
+	CodeValidationFailed:     {http.StatusBadRequest, "Validation failed", "One or more fields are invalid. Check the errors list", false},
+	CodeUnauthorized:         {http.StatusUnauthorized, "Unauthorized", "The credentials are missing or invalid", false},
+	CodeForbidden:            {http.StatusForbidden, "Forbidden", "The operation is not allowed for this user", false},
+	CodeNotFound:             {http.StatusNotFound, "Not found", "The requested resource was not found", false},
+	CodeCustomerNotFound:     {http.StatusNotFound, "Customer not found", "The requested customer was not found", false},
+	CodeUserNotFound:         {http.StatusNotFound, "User not found", "The requested user was not found", false},
+	CodeConflict:             {http.StatusConflict, "Conflict", "The request conflicts with the current state of the resource", false},
+	CodeConstraintViolation:  {http.StatusBadRequest, "Constraint violation", "A required value is missing or a value is not allowed", false},
+	CodeReferenceConflict:    {http.StatusConflict, "Reference conflict", "The request references a missing resource or one that is still in use", false},
+	CodeTransactionConflict:  {http.StatusConflict, "Concurrent request", "The request conflicted with a concurrent request. Retry it", false},
+	CodePreconditionFailed:   {http.StatusPreconditionFailed, "Precondition failed", "The resource was changed by another request. Get it again and retry with the new ETag", false},
+	CodePreconditionRequired: {http.StatusPreconditionRequired, "Precondition required", "The If-Match header with the resource ETag is required", true},
+	CodeIdempotencyKeyReused: {http.StatusUnprocessableEntity, "Idempotency key reused", "The Idempotency-Key was already used with a different request", false},
+	CodeIdempotencyInFlight:  {http.StatusConflict, "Request in progress", "A request with the same Idempotency-Key is still being processed. Retry later", false},
+	CodeCPFInvalid:           {http.StatusBadRequest, "Invalid CPF", "The given CPF is not valid", false},
+	CodeCPFTaken:             {http.StatusConflict, "CPF already registered", "There is already an account with this CPF", false},
+	CodeEmailTaken:           {http.StatusConflict, "Email already registered", "There is already an account with this email", false},
+	CodeUnprocessable:        {http.StatusUnprocessableEntity, "Unprocessable request", "The request could not be processed", false},
+	CodeServiceUnavailable:   {http.StatusServiceUnavailable, "Service unavailable", "The service is temporarily unavailable. Try again later", false},
+	CodeGatewayTimeout:       {http.StatusGatewayTimeout, "Gateway timeout", "A dependency took too long to answer. Try again later", false},
+}
+
+// serviceNotFoundCodes maps the service names used in GetResponseError to its
+// specific not found codes
+var serviceNotFoundCodes = map[string]ErrorCode{
+	"CustomerService": CodeCustomerNotFound,
+	"UserService":     CodeUserNotFound,
+}
+
+// databaseErrorCodes maps the LocalError codes with a specific catalog code
+var databaseErrorCodes = map[int]ErrorCode{
+	DATABASE_CONSTRAINT_ERROR: CodeConstraintViolation,
+	DATABASE_REFERENCE_ERROR:  CodeReferenceConflict,
+	DATABASE_RETRYABLE_ERROR:  CodeTransactionConflict,
+}
+
+// FieldError describes one invalid input field. Pointer is a RFC 6901 JSON
+// pointer to the field in the request body and Rule is the failed validation tag
+type FieldError struct {
+	Pointer string `json:"pointer"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ProblemDetails is the RFC 7807 body sent on every error response. Constraint
+// and Column are the violated database constraint and column, so a client can
+// tell which value of a CONSTRAINT_VIOLATION or a REFERENCE_CONFLICT to fix
+type ProblemDetails struct {
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Status     int          `json:"status"`
+	Detail     string       `json:"detail,omitempty"`
+	Instance   string       `json:"instance,omitempty"`
+	Code       ErrorCode    `json:"code"`
+	RequestID  string       `json:"requestId,omitempty"`
+	Constraint string       `json:"constraint,omitempty"`
+	Column     string       `json:"column,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+}
+
+// NewProblemDetails builds the client safe representation of err. Only messages
+// produced by this service are exposed. Raw database and identity provider
+// messages are replaced by the catalog detail and must be logged by the caller.
+func NewProblemDetails(err error, instance, requestID string) *ProblemDetails {
+	status := http.StatusInternalServerError
+	code := CodeInternalError
+	message := ""
+	internal := false
+	constraint := ""
+	column := ""
+	var fieldErrors []FieldError
+
+	var br *BusinessResponse
+	if errors.As(err, &br) {
+		status = br.StatusCode
+		code = br.Code
+		message = br.Message
+		internal = br.Internal
+		fieldErrors = br.Errors
+		constraint = br.Constraint
+		column = br.Column
+
+		if code == "" {
+			code = CodeForStatus(status)
+		}
+	}
+
+	entry, ok := catalog[code]
+
+	if !ok {
+		entry = catalog[CodeForStatus(status)]
+	}
+
+	if status == 0 {
+		status = entry.status
+	}
+
+	detail := entry.detail
+
+	if entry.public && !internal && message != "" {
+		detail = message
+	}
+
+	return &ProblemDetails{
+		Type:       ProblemType(code),
+		Title:      entry.title,
+		Status:     status,
+		Detail:     detail,
+		Instance:   instance,
+		Code:       code,
+		RequestID:  requestID,
+		Constraint: constraint,
+		Column:     column,
+		Errors:     fieldErrors,
+	}
+}
+
+// ProblemType returns the stable `type` URI of a catalog code
+func ProblemType(code ErrorCode) string {
+	return fmt.Sprintf("urn:tech1-customer:problem:%v", strings.ReplaceAll(strings.ToLower(string(code)), "_", "-"))
+}
+
+// CodeForStatus returns the generic catalog code for a HTTP status code
+func CodeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFailed
+	case http.StatusPreconditionRequired:
+		return CodePreconditionRequired
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnprocessableEntity:
+		return CodeUnprocessable
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeGatewayTimeout
+	default:
+		return CodeInternalError
+	}
+}
+
+func getBusinessErrorCode(err error, statusCode int, service string) ErrorCode {
+	var databaseError *LocalError
+	var networkError *NetworkError
+
+	if errors.As(err, &databaseError) {
+		if code, ok := databaseErrorCodes[databaseError.Code]; ok {
+			return code
+		}
+	}
+
+	if statusCode == http.StatusNotFound {
+		if code, ok := serviceNotFoundCodes[service]; ok {
+			return code
+		}
+	}
+
+	if statusCode == http.StatusConflict {
+		if errors.As(err, &databaseError) {
+			constraint := strings.ToLower(databaseError.Constraint)
+
+			switch {
+			case strings.Contains(constraint, "email"):
+				return CodeEmailTaken
+			case strings.Contains(constraint, "cpf"):
+				return CodeCPFTaken
+			}
+		}
+
+		// The identity provider uses the CPF as the username
+		if errors.As(err, &networkError) && strings.Contains(networkError.Message, "UsernameExistsException") {
+			return CodeCPFTaken
+		}
+	}
+
+	return CodeForStatus(statusCode)
+}
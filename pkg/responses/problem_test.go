@@ -0,0 +1,168 @@
+package responses_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+)
+
+func TestProblemDetails(t *testing.T) {
+	t.Parallel()
+
+	t.Run("got InternalError problem with unknown error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		problem := responses.NewProblemDetails(errors.New("pq: connection refused"), "/api/customers/1", "REQ")
+
+		assert.Equal(t, http.StatusInternalServerError, problem.Status)
+		assert.Equal(t, responses.CodeInternalError, problem.Code)
+		assert.Equal(t, "urn:tech1-customer:problem:internal-error", problem.Type)
+		assert.Equal(t, "/api/customers/1", problem.Instance)
+		assert.Equal(t, "REQ", problem.RequestID)
+		assert.NotContains(t, problem.Detail, "connection refused")
+	})
+
+	t.Run("got CustomerNotFound problem with Local Error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(&responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		}, "CustomerService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, http.StatusNotFound, problem.Status)
+		assert.Equal(t, responses.CodeCustomerNotFound, problem.Code)
+		assert.NotContains(t, problem.Detail, "record not found")
+	})
+
+	t.Run("got UserNotFound problem with Local Error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(&responses.LocalError{
+			Code:    responses.NOT_FOUND_ERROR,
+			Message: "record not found",
+		}, "UserService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, responses.CodeUserNotFound, problem.Code)
+	})
+
+	t.Run("got CPFTaken problem with Local Error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(&responses.LocalError{
+			Code:       responses.DATABASE_CONFLICT_ERROR,
+			Message:    "duplicate key",
+			Constraint: "uni_customers_cpf",
+		}, "CustomerService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, http.StatusConflict, problem.Status)
+		assert.Equal(t, responses.CodeCPFTaken, problem.Code)
+	})
+
+	t.Run("got CPFTaken problem with Cognito Error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(responses.GetCognitoError(errors.New("UsernameExistsException: User account already exists")), "CustomerService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, http.StatusConflict, problem.Status)
+		assert.Equal(t, responses.CodeCPFTaken, problem.Code)
+		assert.NotContains(t, problem.Detail, "UsernameExistsException")
+	})
+
+	t.Run("got generic Conflict problem with unknown constraint when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(&responses.LocalError{
+			Code:    responses.DATABASE_CONFLICT_ERROR,
+			Message: "duplicate key",
+		}, "CustomerService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, responses.CodeConflict, problem.Code)
+	})
+
+	t.Run("got catalog detail with Network Error bad request when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(&responses.NetworkError{
+			Code:    http.StatusBadRequest,
+			Message: "InvalidParameterException: 1 validation error detected",
+		}, "CustomerService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.Equal(t, responses.CodeBadRequest, problem.Code)
+		assert.Equal(t, "The request is invalid", problem.Detail)
+		assert.Contains(t, err.Error(), "InvalidParameterException")
+	})
+
+	t.Run("got catalog detail with wrapped Network Error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(responses.GetResponseError(&responses.NetworkError{
+			Code:    http.StatusBadRequest,
+			Message: "InvalidParameterException",
+		}, "CustomerService"), "UserService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, "The request is invalid", problem.Detail)
+	})
+
+	t.Run("got CPFInvalid problem with Business Error when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := responses.GetResponseError(&responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Invalid CPF",
+			Code:       responses.CodeCPFInvalid,
+		}, "CustomerService")
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, http.StatusBadRequest, problem.Status)
+		assert.Equal(t, responses.CodeCPFInvalid, problem.Code)
+	})
+
+	t.Run("got public message with MalformedBody problem when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := &responses.BusinessResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Request body must not be empty",
+			Code:       responses.CodeMalformedBody,
+		}
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, "Request body must not be empty", problem.Detail)
+	})
+
+	t.Run("got code from status with Business Error without code when calling NewProblemDetails", func(t *testing.T) {
+		t.Parallel()
+
+		err := &responses.BusinessResponse{
+			StatusCode: http.StatusServiceUnavailable,
+			Message:    "dial tcp 10.0.0.1:5432: connect: connection refused",
+		}
+
+		problem := responses.NewProblemDetails(err, "", "")
+
+		assert.Equal(t, http.StatusServiceUnavailable, problem.Status)
+		assert.Equal(t, responses.CodeServiceUnavailable, problem.Code)
+		assert.NotContains(t, problem.Detail, "connection refused")
+	})
+}
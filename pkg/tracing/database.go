@@ -0,0 +1,134 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin creates one span per gorm operation as child of the span in the
+// statement context. The statement is recorded with the placeholders only, the
+// values are never added to the span
+type GormPlugin struct{}
+
+func NewGormPlugin() gorm.Plugin {
+	return &GormPlugin{}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+
+	if err := callback.Create().Before("gorm:create").Register("tracing:before_create", before("create")); err != nil {
+		return err
+	}
+
+	if err := callback.Create().After("gorm:create").Register("tracing:after_create", after); err != nil {
+		return err
+	}
+
+	if err := callback.Query().Before("gorm:query").Register("tracing:before_query", before("query")); err != nil {
+		return err
+	}
+
+	if err := callback.Query().After("gorm:query").Register("tracing:after_query", after); err != nil {
+		return err
+	}
+
+	if err := callback.Update().Before("gorm:update").Register("tracing:before_update", before("update")); err != nil {
+		return err
+	}
+
+	if err := callback.Update().After("gorm:update").Register("tracing:after_update", after); err != nil {
+		return err
+	}
+
+	if err := callback.Delete().Before("gorm:delete").Register("tracing:before_delete", before("delete")); err != nil {
+		return err
+	}
+
+	if err := callback.Delete().After("gorm:delete").Register("tracing:after_delete", after); err != nil {
+		return err
+	}
+
+	if err := callback.Row().Before("gorm:row").Register("tracing:before_row", before("row")); err != nil {
+		return err
+	}
+
+	if err := callback.Row().After("gorm:row").Register("tracing:after_row", after); err != nil {
+		return err
+	}
+
+	if err := callback.Raw().Before("gorm:raw").Register("tracing:before_raw", before("raw")); err != nil {
+		return err
+	}
+
+	return callback.Raw().After("gorm:raw").Register("tracing:after_raw", after)
+}
+
+func before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		name := "gorm." + operation
+
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+
+		ctx, span := Tracer().Start(db.Statement.Context, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				dbSystem(db.Dialector.Name()),
+				semconv.DBOperation(operation),
+				semconv.DBSQLTable(db.Statement.Table),
+			),
+		)
+
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+// dbSystem returns the db.system attribute of a gorm dialect
+func dbSystem(dialect string) attribute.KeyValue {
+	switch dialect {
+	case "postgres":
+		return semconv.DBSystemPostgreSQL
+	case "sqlite":
+		return semconv.DBSystemSqlite
+	case "mysql":
+		return semconv.DBSystemMySQL
+	default:
+		return semconv.DBSystemKey.String(dialect)
+	}
+}
+
+func after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+
+	if !ok {
+		return
+	}
+
+	span, ok := value.(trace.Span)
+
+	if !ok {
+		return
+	}
+
+	defer span.End()
+
+	span.SetAttributes(semconv.DBStatement(db.Statement.SQL.String()))
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.SetStatus(codes.Error, errorType(db.Error))
+	}
+}
@@ -0,0 +1,197 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	instrumentationName = "github.com/thiagoluis88git/tech1-customer"
+
+	_defaultServiceName = "tech1-customer"
+	_defaultSampleRatio = 1.0
+)
+
+type config struct {
+	exporter     string
+	serviceName  string
+	sampleRatio  float64
+	spanExporter sdktrace.SpanExporter
+}
+
+type Option func(*config)
+
+// Exporter selects the exporter by name: none, stdout or otlp. The OTLP
+// exporter is configured by the standard OTEL_EXPORTER_OTLP_* variables
+func Exporter(name string) Option {
+	return func(c *config) {
+		c.exporter = name
+	}
+}
+
+// ServiceName -.
+func ServiceName(name string) Option {
+	return func(c *config) {
+		c.serviceName = name
+	}
+}
+
+// SampleRatio is the ratio of the new traces that are sampled. The incoming
+// sampling decision is always respected
+func SampleRatio(ratio float64) Option {
+	return func(c *config) {
+		c.sampleRatio = ratio
+	}
+}
+
+// SpanExporter uses the given exporter synchronously instead of the one selected
+// by name. Used by the tests with the tracetest.InMemoryExporter
+func SpanExporter(exporter sdktrace.SpanExporter) Option {
+	return func(c *config) {
+		c.spanExporter = exporter
+	}
+}
+
+// Setup configures the global tracer provider and the W3C propagators. The
+// returned function flushes the pending spans and must be called on shutdown
+func Setup(ctx context.Context, opts ...Option) (func(context.Context) error, error) {
+	c := &config{
+		exporter:    ExporterNone,
+		serviceName: _defaultServiceName,
+		sampleRatio: _defaultSampleRatio,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if c.sampleRatio < 0 || c.sampleRatio > 1 {
+		return nil, fmt.Errorf("invalid tracing sample ratio %v: it must be between 0 and 1", c.sampleRatio)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(c.serviceName),
+	))
+
+	if err != nil {
+		return nil, err
+	}
+
+	providerOpts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.sampleRatio))),
+	}
+
+	switch {
+	case c.spanExporter != nil:
+		providerOpts = append(providerOpts, sdktrace.WithSyncer(c.spanExporter))
+	case c.exporter == ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case c.exporter == ExporterStdout:
+		exporter, err := stdouttrace.New()
+
+		if err != nil {
+			return nil, err
+		}
+
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	case c.exporter == ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+
+		if err != nil {
+			return nil, err
+		}
+
+		providerOpts = append(providerOpts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("invalid tracing exporter %q: it must be %v, %v or %v", c.exporter, ExporterNone, ExporterStdout, ExporterOTLP)
+	}
+
+	provider := sdktrace.NewTracerProvider(providerOpts...)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the application tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start creates a span as child of the span in the context. When the span is not
+// recording (tracing disabled or trace not sampled) the context is returned as is,
+// so nothing is allocated for the callers down the stack
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	spanCtx, span := Tracer().Start(ctx, name, opts...)
+
+	if !span.IsRecording() {
+		return ctx, span
+	}
+
+	return spanCtx, span
+}
+
+// End marks the span as failed when there is an error and ends it. Only the
+// error type and the error code are recorded, never the message, because some
+// messages have the CPF or the email of the user. It is meant to be deferred
+// with a pointer to the named error result:
+//
+//	ctx, span := tracing.Start(ctx, "CreateCustomerUseCase")
+//	defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.SetAttributes(
+			semconv.ErrorTypeKey.String(errorType(*err)),
+			attribute.String("error.code", string(responses.NewProblemDetails(*err, "", "").Code)),
+		)
+		span.SetStatus(codes.Error, errorType(*err))
+	}
+
+	span.End()
+}
+
+// TraceID returns the trace ID of the span in the context or an empty string
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
+
+// errorType returns the type of the innermost error, like *pgconn.PgError
+func errorType(err error) string {
+	for {
+		unwrapped := errors.Unwrap(err)
+
+		if unwrapped == nil {
+			return fmt.Sprintf("%T", err)
+		}
+
+		err = unwrapped
+	}
+}
@@ -0,0 +1,211 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/thiagoluis88git/tech1-customer/internal/core/domain/dto"
+	"github.com/thiagoluis88git/tech1-customer/pkg/httpserver"
+	"github.com/thiagoluis88git/tech1-customer/pkg/responses"
+	"github.com/thiagoluis88git/tech1-customer/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+func setupExporter(t *testing.T, opts ...tracing.Option) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+
+	shutdown, err := tracing.Setup(context.Background(), append(opts, tracing.SpanExporter(exporter))...)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		shutdown(context.Background())
+	})
+
+	return exporter
+}
+
+func attributeValue(attributes []attribute.KeyValue, key string) string {
+	for _, attr := range attributes {
+		if string(attr.Key) == key {
+			return attr.Value.Emit()
+		}
+	}
+
+	return ""
+}
+
+// The tests are not parallel because the tracer provider is global
+func TestTracing(t *testing.T) {
+	t.Run("got server span with incoming trace when calling Middleware", func(t *testing.T) {
+		exporter := setupExporter(t)
+
+		router := chi.NewRouter()
+		router.Use(tracing.Middleware)
+		router.Get("/mock/customers/{cpf}", func(w http.ResponseWriter, r *http.Request) {
+			_, span := tracing.Start(r.Context(), "MockUseCase")
+			span.End()
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/mock/customers/83212446293", nil)
+		req.Header.Set("traceparent", "00-"+incomingTraceID+"-00f067aa0ba902b7-01")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.GetSpans()
+
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "MockUseCase", spans[0].Name)
+		assert.Equal(t, "GET /mock/customers/{cpf}", spans[1].Name)
+		assert.Equal(t, incomingTraceID, spans[1].SpanContext.TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[1].Parent.SpanID().String())
+		assert.Equal(t, spans[1].SpanContext.SpanID(), spans[0].Parent.SpanID())
+		assert.Equal(t, "/mock/customers/{cpf}", attributeValue(spans[1].Attributes, "http.route"))
+		assert.Equal(t, "200", attributeValue(spans[1].Attributes, "http.response.status_code"))
+	})
+
+	t.Run("got error status without message when calling End with error", func(t *testing.T) {
+		exporter := setupExporter(t)
+
+		err := responses.GetResponseError(&responses.LocalError{
+			Code:    responses.DATABASE_CONFLICT_ERROR,
+			Message: "Key (cpf)=(83212446293) already exists",
+		}, "CustomerService")
+
+		_, span := tracing.Start(context.Background(), "CreateCustomerUseCase")
+		tracing.End(span, &err)
+
+		spans := exporter.GetSpans()
+
+		assert.Len(t, spans, 1)
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Equal(t, "*responses.BusinessResponse", spans[0].Status.Description)
+		assert.Equal(t, "CONFLICT", attributeValue(spans[0].Attributes, "error.code"))
+		assert.Empty(t, spans[0].Events)
+	})
+
+	t.Run("got same context when calling Start with trace not sampled", func(t *testing.T) {
+		exporter := setupExporter(t, tracing.SampleRatio(0))
+
+		ctx := context.Background()
+		spanCtx, span := tracing.Start(ctx, "MockUseCase")
+		span.End()
+
+		assert.Equal(t, ctx, spanCtx)
+		assert.Empty(t, exporter.GetSpans())
+	})
+
+	t.Run("got query span when using GormPlugin", func(t *testing.T) {
+		exporter := setupExporter(t)
+
+		conn, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+
+		db, err := gorm.Open(postgres.New(postgres.Config{
+			DSN:                  "sqlmock_tracing",
+			DriverName:           "postgres",
+			Conn:                 conn,
+			PreferSimpleProtocol: true,
+		}), &gorm.Config{DisableAutomaticPing: true})
+		assert.NoError(t, err)
+
+		err = db.Use(tracing.NewGormPlugin())
+		assert.NoError(t, err)
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		ctx, parent := tracing.Start(context.Background(), "MockRepository")
+
+		var ids []int
+		err = db.WithContext(ctx).Table("mock_tracing_table").Select("id").Where("cpf = ?", "83212446293").Find(&ids).Error
+		assert.NoError(t, err)
+
+		parent.End()
+
+		spans := exporter.GetSpans()
+
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "gorm.query mock_tracing_table", spans[0].Name)
+		assert.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent.SpanID())
+		assert.Equal(t, "postgresql", attributeValue(spans[0].Attributes, "db.system"))
+		assert.NotContains(t, attributeValue(spans[0].Attributes, "db.statement"), "83212446293")
+	})
+
+	t.Run("got sqlite system when using GormPlugin with SQLite", func(t *testing.T) {
+		exporter := setupExporter(t)
+
+		db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+		assert.NoError(t, err)
+
+		err = db.Use(tracing.NewGormPlugin())
+		assert.NoError(t, err)
+
+		var one int
+		err = db.WithContext(context.Background()).Raw("SELECT 1").Scan(&one).Error
+		assert.NoError(t, err)
+
+		spans := exporter.GetSpans()
+
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "gorm.row", spans[0].Name)
+		assert.Equal(t, "sqlite", attributeValue(spans[0].Attributes, "db.system"))
+	})
+
+	t.Run("got traceparent header when calling DoRequest", func(t *testing.T) {
+		exporter := setupExporter(t)
+
+		var traceparent string
+
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			traceparent = r.Header.Get("traceparent")
+			w.Write([]byte(`{"accessToken": "token"}`))
+		}))
+		defer ts.Close()
+
+		ctx, parent := tracing.Start(context.Background(), "MockUseCase")
+
+		_, err := httpserver.DoRequest(ctx, ts.Client(), ts.URL, nil, nil, http.MethodGet, dto.Token{})
+		assert.NoError(t, err)
+
+		parent.End()
+
+		spans := exporter.GetSpans()
+
+		assert.Len(t, spans, 2)
+		assert.Equal(t, "HTTP GET", spans[0].Name)
+		assert.Equal(t, "00-"+spans[0].SpanContext.TraceID().String()+"-"+spans[0].SpanContext.SpanID().String()+"-01", traceparent)
+	})
+
+	t.Run("got error when calling Setup with unknown exporter", func(t *testing.T) {
+		_, err := tracing.Setup(context.Background(), tracing.Exporter("jaeger"))
+
+		assert.Error(t, err)
+	})
+
+	t.Run("got trace id when calling TraceID", func(t *testing.T) {
+		setupExporter(t)
+
+		ctx, span := tracing.Start(context.Background(), "MockUseCase")
+		defer span.End()
+
+		assert.Equal(t, span.SpanContext().TraceID().String(), tracing.TraceID(ctx))
+		assert.Empty(t, tracing.TraceID(context.Background()))
+	})
+
+	t.Run("got no error when calling Setup with none exporter", func(t *testing.T) {
+		shutdown, err := tracing.Setup(context.Background())
+
+		assert.NoError(t, err)
+		assert.NoError(t, shutdown(context.Background()))
+	})
+}